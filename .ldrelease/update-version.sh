@@ -7,3 +7,12 @@ SOURCE_FILE=./internal/version.go
 TEMP_FILE=${SOURCE_FILE}.tmp
 sed "s/const SDKVersion =.*/const SDKVersion = \"${LD_RELEASE_VERSION}\"/g" ${SOURCE_FILE} > ${TEMP_FILE}
 mv ${TEMP_FILE} ${SOURCE_FILE}
+
+# The modules in subdirectories use SDK APIs that may have been added in this release, so they are released
+# along with the SDK and require the SDK version that is being released.
+NESTED_MODULE_FILES="./ldfilestore/go.mod ./ldsqlstore/go.mod ./cmd/go.mod \
+  ./ldmetrics/ldprometheus/go.mod ./ldmetrics/ldotel/go.mod"
+for MODULE_FILE in ${NESTED_MODULE_FILES}; do
+  sed "s#^\(\tgithub.com/launchdarkly/go-server-sdk/v6\) v.*#\1 v${LD_RELEASE_VERSION}#" ${MODULE_FILE} > ${MODULE_FILE}.tmp
+  mv ${MODULE_FILE}.tmp ${MODULE_FILE}
+done
@@ -0,0 +1,42 @@
+module github.com/launchdarkly/go-server-sdk/cmd
+
+go 1.18
+
+require (
+	github.com/launchdarkly/go-sdk-common/v3 v3.0.1
+	github.com/launchdarkly/go-server-sdk/ldfilestore v1.0.0
+	github.com/launchdarkly/go-server-sdk/ldsqlstore v1.0.0
+	github.com/launchdarkly/go-server-sdk/v6 v6.2.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/stretchr/testify v1.7.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/uuid v1.1.1 // indirect
+	github.com/gregjones/httpcache v0.0.0-20171119193500-2bcd89a1743f // indirect
+	github.com/josharian/intern v1.0.0 // indirect
+	github.com/launchdarkly/ccache v1.1.0 // indirect
+	github.com/launchdarkly/eventsource v1.6.2 // indirect
+	github.com/launchdarkly/go-jsonstream/v3 v3.0.0 // indirect
+	github.com/launchdarkly/go-sdk-events/v2 v2.0.1 // indirect
+	github.com/launchdarkly/go-semver v1.0.2 // indirect
+	github.com/launchdarkly/go-server-sdk-evaluation/v2 v2.0.2 // indirect
+	github.com/mailru/easyjson v0.7.6 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.etcd.io/bbolt v1.3.6 // indirect
+	golang.org/x/exp v0.0.0-20220823124025-807a23277127 // indirect
+	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e // indirect
+	golang.org/x/sys v0.1.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+// The tools can use SDK APIs that are added in the same release, so this module is released along with the
+// SDK, ldfilestore, and ldsqlstore, and .ldrelease/update-version.sh sets the SDK version above to the
+// version that is being released. The replace directives are only for building and testing these tools in
+// the SDK repository; Go ignores them when the module is used as a dependency.
+replace (
+	github.com/launchdarkly/go-server-sdk/ldfilestore => ../ldfilestore
+	github.com/launchdarkly/go-server-sdk/ldsqlstore => ../ldsqlstore
+	github.com/launchdarkly/go-server-sdk/v6 => ../
+)
@@ -0,0 +1,168 @@
+// Command ldbigsegmentsync populates a LaunchDarkly Big Segment store from a stream of segment membership
+// updates, using the ldbigsegmentsync package. It supports the Big Segment store implementations in this
+// repository that can be updated and do not require an external database driver: currently, the SQL Big
+// Segment store (ldsqlstore) with SQLite. For other databases, write a similar program with the
+// ldbigsegmentsync package.
+//
+// Usage:
+//
+//	ldbigsegmentsync sync -to STORE [-to-prefix PREFIX] [-in FILE | -url URL]
+//	ldbigsegmentsync serve -in FILE [-addr ADDRESS]
+//
+// STORE is "sqlite:DSN" for a SQLite database, and PREFIX is the SQL table prefix; if it is omitted, the
+// default is used. The sync command reads updates from a file, from standard input if neither -in nor -url
+// is specified, or from an HTTP server. It records its position in the store, so if it is run again, it
+// only applies updates that it has not already applied.
+//
+// The serve command starts an HTTP server that serves the updates in a file, as a local stand-in for a
+// real source of updates. It listens on localhost:8080 unless another address is specified.
+//
+// See the ldbigsegmentsync package for the format of the updates.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/ldsqlstore"
+	"github.com/launchdarkly/go-server-sdk/v6/ldbigsegmentsync"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const usage = `usage:
+  ldbigsegmentsync sync -to STORE [-to-prefix PREFIX] [-in FILE | -url URL]
+  ldbigsegmentsync serve -in FILE [-addr ADDRESS]
+
+STORE is "sqlite:DSN" for a SQLite database.
+`
+
+const defaultServeAddress = "localhost:8080"
+
+var errUsage = errors.New("invalid arguments") //nolint:gochecknoglobals
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "ldbigsegmentsync: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run executes a command. Errors in the arguments are reported to stderr along with the usage text, and
+// returned as errUsage; other errors are returned to the caller.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	command := args[0]
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	to := flags.String("to", "", "destination Big Segment store")
+	toPrefix := flags.String("to-prefix", "", "destination Big Segment store prefix")
+	in := flags.String("in", "", "file to read updates from (default for sync: standard input)")
+	streamURL := flags.String("url", "", "URL to request updates from")
+	addr := flags.String("addr", defaultServeAddress, "address for the server to listen on")
+	verbose := flags.Bool("v", false, "show informational log output from the Big Segment store")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+
+	loggers := ldlog.NewDefaultLoggers()
+	loggers.SetMinLevel(ldlog.Warn)
+	if *verbose {
+		loggers.SetMinLevel(ldlog.Info)
+	}
+
+	switch command {
+	case "sync":
+		if *to == "" || (*in != "" && *streamURL != "") {
+			fmt.Fprint(stderr, usage)
+			return errUsage
+		}
+		return withWriter(*to, *toPrefix, loggers, func(writer subsystems.BigSegmentStoreWriter) error {
+			var result ldbigsegmentsync.Result
+			var err error
+			switch {
+			case *streamURL != "":
+				result, err = ldbigsegmentsync.SyncFromURL(nil, *streamURL, writer)
+			case *in != "":
+				f, openErr := os.Open(*in) //nolint:gosec // G304: ok to read file into variable
+				if openErr != nil {
+					return openErr
+				}
+				defer f.Close() //nolint:errcheck
+				result, err = ldbigsegmentsync.Sync(f, writer)
+			default:
+				result, err = ldbigsegmentsync.Sync(stdin, writer)
+			}
+			fmt.Fprintf(stdout, "applied %d updates, skipped %d; cursor is %q\n",
+				result.Applied, result.Skipped, result.Cursor)
+			return err
+		})
+
+	case "serve":
+		if *in == "" || *to != "" || *streamURL != "" {
+			fmt.Fprint(stderr, usage)
+			return errUsage
+		}
+		fmt.Fprintf(stdout, "serving updates from %s at http://%s/\n", *in, *addr)
+		server := &http.Server{ //nolint:gosec // G112: this is only a local stand-in server
+			Addr:    *addr,
+			Handler: ldbigsegmentsync.NewUpdateFileHandler(*in),
+		}
+		return server.ListenAndServe()
+
+	default:
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+}
+
+// withWriter opens the Big Segment store described by spec, calls action, and then closes the store.
+func withWriter(
+	spec, prefix string,
+	loggers ldlog.Loggers,
+	action func(subsystems.BigSegmentStoreWriter) error,
+) error {
+	configurer, err := parseStoreSpec(spec, prefix)
+	if err != nil {
+		return err
+	}
+	writer, err := ldbigsegmentsync.OpenWriter(configurer, loggers)
+	if err != nil {
+		return err
+	}
+	err = action(writer)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func parseStoreSpec(spec, prefix string) (subsystems.ComponentConfigurer[subsystems.BigSegmentStore], error) {
+	storeType, location, ok := strings.Cut(spec, ":")
+	if !ok || location == "" {
+		return nil, fmt.Errorf("invalid Big Segment store %q: must be sqlite:DSN", spec)
+	}
+	switch storeType {
+	case "sqlite":
+		return ldsqlstore.BigSegmentStore(ldsqlstore.DialectSQLite).Open("sqlite3", location).TablePrefix(prefix), nil
+	default:
+		return nil, fmt.Errorf("unsupported Big Segment store type %q: must be sqlite", storeType)
+	}
+}
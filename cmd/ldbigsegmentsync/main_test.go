@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/ldbigsegmentsync"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUpdates = `{"cursor": "1", "segmentRef": "seg.g1", "replace": true, "included": ["h1", "h2"]}
+{"cursor": "2", "segmentRef": "seg.g1", "addExcluded": ["h3"], "removeIncluded": ["h2"]}
+`
+
+func assertStoreHasTestData(t *testing.T, spec, prefix string) {
+	configurer, err := parseStoreSpec(spec, prefix)
+	require.NoError(t, err)
+	writer, err := ldbigsegmentsync.OpenWriter(configurer, ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	defer writer.Close()
+
+	membership, err := writer.GetMembership("h1")
+	require.NoError(t, err)
+	require.NotNil(t, membership)
+	assert.True(t, membership.CheckMembership("seg.g1").BoolValue())
+	membership, err = writer.GetMembership("h3")
+	require.NoError(t, err)
+	require.NotNil(t, membership)
+	assert.False(t, membership.CheckMembership("seg.g1").BoolValue())
+	cursor, err := writer.GetSyncCursor()
+	require.NoError(t, err)
+	assert.Equal(t, "2", cursor)
+}
+
+func TestSyncFromFileAndStandardInput(t *testing.T) {
+	dir := t.TempDir()
+	sqliteStore := "sqlite:" + filepath.Join(dir, "segments.sqlite")
+	updatesFile := filepath.Join(dir, "updates.ndjson")
+	require.NoError(t, os.WriteFile(updatesFile, []byte(testUpdates), 0600))
+	var stdout, stderr bytes.Buffer
+
+	require.NoError(t, run([]string{"sync", "-to", sqliteStore, "-in", updatesFile}, nil, &stdout, &stderr))
+	assertStoreHasTestData(t, sqliteStore, "")
+	assert.Equal(t, "applied 2 updates, skipped 0; cursor is \"2\"\n", stdout.String())
+
+	stdout.Reset()
+	require.NoError(t, run([]string{"sync", "-to", sqliteStore}, strings.NewReader(testUpdates), &stdout, &stderr))
+	assert.Equal(t, "applied 0 updates, skipped 2; cursor is \"2\"\n", stdout.String())
+
+	require.NoError(t, run([]string{"sync", "-to", sqliteStore, "-to-prefix", "other_"},
+		strings.NewReader(testUpdates), &stdout, &stderr))
+	assertStoreHasTestData(t, sqliteStore, "other_")
+	assert.Equal(t, "", stderr.String())
+}
+
+func TestSyncFromURL(t *testing.T) {
+	dir := t.TempDir()
+	sqliteStore := "sqlite:" + filepath.Join(dir, "segments.sqlite")
+	updatesFile := filepath.Join(dir, "updates.ndjson")
+	require.NoError(t, os.WriteFile(updatesFile, []byte(testUpdates), 0600))
+	server := httptest.NewServer(ldbigsegmentsync.NewUpdateFileHandler(updatesFile))
+	defer server.Close()
+	var stdout, stderr bytes.Buffer
+
+	require.NoError(t, run([]string{"sync", "-to", sqliteStore, "-url", server.URL}, nil, &stdout, &stderr))
+	assertStoreHasTestData(t, sqliteStore, "")
+}
+
+func TestSyncFailsForMissingFile(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"sync", "-to", "sqlite:" + filepath.Join(t.TempDir(), "segments.sqlite"),
+		"-in", filepath.Join(t.TempDir(), "nope")}, nil, &stdout, &stderr)
+	assert.Error(t, err)
+	assert.NotEqual(t, errUsage, err)
+}
+
+func TestServeFailsForInvalidAddress(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"serve", "-in", "updates.ndjson", "-addr", "not a valid address"}, nil, &stdout, &stderr)
+	assert.Error(t, err)
+	assert.NotEqual(t, errUsage, err)
+}
+
+func TestInvalidArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"sync"},
+		{"sync", "-to", "sqlite:x", "-in", "x", "-url", "http://localhost"},
+		{"sync", "-to", "sqlite:x", "extra"},
+		{"serve"},
+		{"serve", "-in", "x", "-to", "sqlite:x"},
+		{"sync", "-bad-flag"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, errUsage, run(args, nil, &stdout, &stderr))
+			assert.Contains(t, stderr.String(), "usage:")
+		})
+	}
+}
+
+func TestParseStoreSpec(t *testing.T) {
+	configurer, err := parseStoreSpec("sqlite:./segments.sqlite", "")
+	assert.NoError(t, err)
+	assert.NotNil(t, configurer)
+	for _, spec := range []string{"", "sqlite", "sqlite:", "file:./segments.csv", "redis:localhost"} {
+		_, err := parseStoreSpec(spec, "")
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,180 @@
+// Command ldstoremigrate exports, imports, or copies the flag and segment data in a LaunchDarkly
+// persistent data store. It supports the data store implementations in this repository that do not require
+// an external database driver: the file data store (ldfilestore) and the SQL data store (ldsqlstore)
+// with SQLite. For other databases, write a similar program with the ldstoremigrate package.
+//
+// The command-line tools are in their own module, github.com/launchdarkly/go-server-sdk/cmd, since the
+// SQLite driver requires cgo and should not be a dependency of the SDK.
+//
+// Usage:
+//
+//	ldstoremigrate export -from STORE [-from-prefix PREFIX] [-out FILE]
+//	ldstoremigrate import -to STORE [-to-prefix PREFIX] [-in FILE]
+//	ldstoremigrate copy -from STORE [-from-prefix PREFIX] -to STORE [-to-prefix PREFIX]
+//
+// STORE is "file:PATH" for a file data store, or "sqlite:DSN" for a SQLite database. PREFIX is the file
+// data store prefix or the SQL table prefix; if it is omitted, the default for that store is used. The
+// export and import commands use standard output and standard input if no file is specified.
+//
+// The data is in the same JSON format that the LaunchDarkly polling service uses.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/ldfilestore"
+	"github.com/launchdarkly/go-server-sdk/ldsqlstore"
+	"github.com/launchdarkly/go-server-sdk/v6/ldstoremigrate"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const usage = `usage:
+  ldstoremigrate export -from STORE [-from-prefix PREFIX] [-out FILE]
+  ldstoremigrate import -to STORE [-to-prefix PREFIX] [-in FILE]
+  ldstoremigrate copy -from STORE [-from-prefix PREFIX] -to STORE [-to-prefix PREFIX]
+
+STORE is "file:PATH" for a file data store, or "sqlite:DSN" for a SQLite database.
+`
+
+var errUsage = errors.New("invalid arguments") //nolint:gochecknoglobals
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "ldstoremigrate: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run executes a command. Errors in the arguments are reported to stderr along with the usage text, and
+// returned as errUsage; other errors are returned to the caller.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+	command := args[0]
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+	from := flags.String("from", "", "source data store")
+	fromPrefix := flags.String("from-prefix", "", "source data store prefix")
+	to := flags.String("to", "", "destination data store")
+	toPrefix := flags.String("to-prefix", "", "destination data store prefix")
+	in := flags.String("in", "", "file to import from (default: standard input)")
+	out := flags.String("out", "", "file to export to (default: standard output)")
+	verbose := flags.Bool("v", false, "show informational log output from the data stores")
+	if err := flags.Parse(args[1:]); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+
+	loggers := ldlog.NewDefaultLoggers()
+	loggers.SetMinLevel(ldlog.Warn)
+	if *verbose {
+		loggers.SetMinLevel(ldlog.Info)
+	}
+
+	switch command {
+	case "export":
+		if *from == "" || *to != "" || *in != "" {
+			fmt.Fprint(stderr, usage)
+			return errUsage
+		}
+		return withStore(*from, *fromPrefix, loggers, func(source subsystems.PersistentDataStore) error {
+			if *out == "" {
+				return ldstoremigrate.Export(source, stdout)
+			}
+			f, err := os.Create(*out)
+			if err != nil {
+				return err
+			}
+			if err := ldstoremigrate.Export(source, f); err != nil {
+				_ = f.Close()
+				return err
+			}
+			return f.Close()
+		})
+
+	case "import":
+		if *to == "" || *from != "" || *out != "" {
+			fmt.Fprint(stderr, usage)
+			return errUsage
+		}
+		r := stdin
+		if *in != "" {
+			f, err := os.Open(*in) //nolint:gosec // G304: ok to read file into variable
+			if err != nil {
+				return err
+			}
+			defer f.Close() //nolint:errcheck
+			r = f
+		}
+		return withStore(*to, *toPrefix, loggers, func(dest subsystems.PersistentDataStore) error {
+			return ldstoremigrate.Import(r, dest)
+		})
+
+	case "copy":
+		if *from == "" || *to == "" || *in != "" || *out != "" {
+			fmt.Fprint(stderr, usage)
+			return errUsage
+		}
+		return withStore(*from, *fromPrefix, loggers, func(source subsystems.PersistentDataStore) error {
+			return withStore(*to, *toPrefix, loggers, func(dest subsystems.PersistentDataStore) error {
+				return ldstoremigrate.Copy(source, dest)
+			})
+		})
+
+	default:
+		fmt.Fprint(stderr, usage)
+		return errUsage
+	}
+}
+
+// withStore opens the data store described by spec, calls action, and then closes the store.
+func withStore(
+	spec, prefix string,
+	loggers ldlog.Loggers,
+	action func(subsystems.PersistentDataStore) error,
+) error {
+	configurer, err := parseStoreSpec(spec, prefix)
+	if err != nil {
+		return err
+	}
+	store, err := ldstoremigrate.OpenStore(configurer, loggers)
+	if err != nil {
+		return err
+	}
+	err = action(store)
+	if closeErr := store.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func parseStoreSpec(spec, prefix string) (subsystems.ComponentConfigurer[subsystems.PersistentDataStore], error) {
+	storeType, location, ok := strings.Cut(spec, ":")
+	if !ok || location == "" {
+		return nil, fmt.Errorf("invalid data store %q: must be file:PATH or sqlite:DSN", spec)
+	}
+	switch storeType {
+	case "file":
+		return ldfilestore.DataStore(location).Prefix(prefix), nil
+	case "sqlite":
+		return ldsqlstore.DataStore(ldsqlstore.DialectSQLite).Open("sqlite3", location).TablePrefix(prefix), nil
+	default:
+		return nil, fmt.Errorf("unsupported data store type %q: must be file or sqlite", storeType)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/ldstoremigrate"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testData = `{"flags": {"flag1": {"key": "flag1", "version": 10}, "flag2": {"key": "flag2", "version": 20,
+	"deleted": true}}, "segments": {"segment1": {"key": "segment1", "version": 30}}}`
+
+func assertStoreHasTestData(t *testing.T, spec, prefix string) {
+	configurer, err := parseStoreSpec(spec, prefix)
+	require.NoError(t, err)
+	store, err := ldstoremigrate.OpenStore(configurer, ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	defer store.Close()
+
+	flag1, err := store.Get(ldstoreimpl.Features(), "flag1")
+	require.NoError(t, err)
+	assert.Equal(t, 10, flag1.Version)
+	flag2, err := store.Get(ldstoreimpl.Features(), "flag2")
+	require.NoError(t, err)
+	assert.Equal(t, 20, flag2.Version)
+	assert.True(t, flag2.Deleted)
+	segment1, err := store.Get(ldstoreimpl.Segments(), "segment1")
+	require.NoError(t, err)
+	assert.Equal(t, 30, segment1.Version)
+}
+
+func TestImportExportAndCopy(t *testing.T) {
+	dir := t.TempDir()
+	fileStore := "file:" + filepath.Join(dir, "flags.db")
+	sqliteStore := "sqlite:" + filepath.Join(dir, "flags.sqlite")
+	var stdout, stderr bytes.Buffer
+
+	require.NoError(t, run([]string{"import", "-to", fileStore, "-to-prefix", "old"},
+		strings.NewReader(testData), &stdout, &stderr))
+	assertStoreHasTestData(t, fileStore, "old")
+
+	require.NoError(t, run([]string{"copy", "-from", fileStore, "-from-prefix", "old", "-to", fileStore,
+		"-to-prefix", "new"}, nil, &stdout, &stderr))
+	assertStoreHasTestData(t, fileStore, "new")
+
+	require.NoError(t, run([]string{"copy", "-from", fileStore, "-from-prefix", "new", "-to", sqliteStore},
+		nil, &stdout, &stderr))
+	assertStoreHasTestData(t, sqliteStore, "")
+
+	exportFile := filepath.Join(dir, "export.json")
+	require.NoError(t, run([]string{"export", "-from", sqliteStore, "-out", exportFile}, nil, &stdout, &stderr))
+	require.NoError(t, run([]string{"import", "-to", fileStore, "-in", exportFile}, nil, &stdout, &stderr))
+	assertStoreHasTestData(t, fileStore, "")
+
+	require.NoError(t, run([]string{"export", "-from", fileStore}, nil, &stdout, &stderr))
+	exported, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(exported), stdout.String())
+	assert.Equal(t, "", stderr.String())
+}
+
+func TestExportFailsForUninitializedStore(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"export", "-from", "file:" + filepath.Join(t.TempDir(), "flags.db")},
+		nil, &stdout, &stderr)
+	assert.Error(t, err)
+	assert.NotEqual(t, errUsage, err)
+	assert.Equal(t, "", stdout.String())
+}
+
+func TestInvalidArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"export"},
+		{"export", "-from", "file:x", "-to", "file:y"},
+		{"import", "-from", "file:x"},
+		{"copy", "-from", "file:x"},
+		{"copy", "-from", "file:x", "-to", "file:y", "extra"},
+		{"export", "-bad-flag"},
+	} {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			assert.Equal(t, errUsage, run(args, nil, &stdout, &stderr))
+			assert.Contains(t, stderr.String(), "usage:")
+		})
+	}
+}
+
+func TestParseStoreSpec(t *testing.T) {
+	for _, spec := range []string{"file:./flags.db", "sqlite:./flags.sqlite"} {
+		configurer, err := parseStoreSpec(spec, "")
+		assert.NoError(t, err)
+		assert.NotNil(t, configurer)
+	}
+	for _, spec := range []string{"", "file", "file:", "redis:localhost"} {
+		_, err := parseStoreSpec(spec, "")
+		assert.Error(t, err, spec)
+	}
+}
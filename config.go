@@ -94,6 +94,21 @@ type Config struct {
 	// as dropped events.
 	DiagnosticOptOut bool
 
+	// Sets a component that receives information about every flag evaluation, for metrics in your own
+	// monitoring system.
+	//
+	// The ldmetrics.EvaluationMetrics type keeps counters and latency histograms by flag key, variation, and
+	// evaluation reason, which can be exported to Prometheus or OpenTelemetry with the adapters in the
+	// ldmetrics package documentation. You could also provide your own implementation of
+	// EvaluationMetricsRecorder.
+	//
+	// If nil, no metrics are recorded.
+	//
+	//     // example: keep evaluation metrics for up to 200 flag keys
+	//     metrics := ldmetrics.NewEvaluationMetrics(ldmetrics.Options{MaxFlagKeys: 200})
+	//     config.EvaluationMetrics = metrics
+	EvaluationMetrics subsystems.EvaluationMetricsRecorder
+
 	// Sets the SDK's behavior regarding analytics events.
 	//
 	// The interface type for this field allows you to set it to either:
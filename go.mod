@@ -4,6 +4,7 @@ go 1.18
 
 require (
 	github.com/fsnotify/fsnotify v1.4.7
+	github.com/google/uuid v1.1.1
 	github.com/gregjones/httpcache v0.0.0-20171119193500-2bcd89a1743f
 	github.com/launchdarkly/ccache v1.1.0
 	github.com/launchdarkly/eventsource v1.6.2
@@ -13,7 +14,6 @@ require (
 	github.com/launchdarkly/go-sdk-events/v2 v2.0.1
 	github.com/launchdarkly/go-server-sdk-evaluation/v2 v2.0.2
 	github.com/launchdarkly/go-test-helpers/v3 v3.0.2
-	github.com/patrickmn/go-cache v2.1.0+incompatible
 	github.com/stretchr/testify v1.7.0
 	golang.org/x/exp v0.0.0-20220823124025-807a23277127
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
@@ -22,7 +22,6 @@ require (
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/google/uuid v1.1.1 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/launchdarkly/go-ntlmssp v1.0.1 // indirect
 	github.com/launchdarkly/go-semver v1.0.2 // indirect
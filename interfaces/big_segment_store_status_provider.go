@@ -1,5 +1,9 @@
 package interfaces
 
+import (
+	"time"
+)
+
 // BigSegmentStoreStatusProvider is an interface for querying the status of a Big Segment store.
 // The Big Segment store is the component that receives information about Big Segments, normally
 // from a database populated by the LaunchDarkly Relay Proxy.
@@ -51,6 +55,36 @@ type BigSegmentStoreStatusProvider interface {
 	RemoveStatusListener(<-chan BigSegmentStoreStatus)
 }
 
+// BigSegmentCacheController is an optional interface for inspecting and controlling the SDK's cache of
+// Big Segment memberships. The BigSegmentStoreStatusProvider returned by
+// [github.com/launchdarkly/go-server-sdk/v6.LDClient.GetBigSegmentStoreStatusProvider] implements it:
+//
+//	if c, ok := client.GetBigSegmentStoreStatusProvider().(interfaces.BigSegmentCacheController); ok {
+//	    stats := c.GetCacheStats()
+//	    log.Printf("Big Segment cache hits: %d, misses: %d", stats.Hits, stats.Misses)
+//	}
+//
+// It is a separate interface so that other implementations of BigSegmentStoreStatusProvider, such as
+// mocks in application tests, do not need to implement it.
+type BigSegmentCacheController interface {
+	// GetCacheStats returns statistics about the SDK's cache of Big Segment memberships, and about the
+	// queries that it has made to the Big Segment store.
+	//
+	// This can be used to choose appropriate values for the cache settings in
+	// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.BigSegmentsConfigurationBuilder]. If Big
+	// Segments are not configured, the Enabled property of the result is false.
+	GetCacheStats() BigSegmentCacheStats
+
+	// InvalidateContext removes the cached Big Segment memberships for a context key, so that the next
+	// evaluation that references a Big Segment for a context with that key will query the store.
+	//
+	// Cached memberships normally expire after the time set by
+	// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.BigSegmentsConfigurationBuilder.ContextCacheTime].
+	// If the application knows that a context's memberships have changed, it can call this method so
+	// that the change takes effect immediately. If Big Segments are not configured, it has no effect.
+	InvalidateContext(contextKey string)
+}
+
 // BigSegmentStoreStatus contains information about the status of a Big Segment store, provided by
 // [BigSegmentStoreStatusProvider].
 //
@@ -74,3 +108,50 @@ type BigSegmentStoreStatus struct {
 	// Segment will be using the last known data, which may be out of date.
 	Stale bool
 }
+
+// BigSegmentCacheStats contains statistics about the SDK's cache of Big Segment memberships, provided by
+// [BigSegmentCacheController.GetCacheStats].
+//
+// The counters are cumulative over the lifetime of the SDK client.
+type BigSegmentCacheStats struct {
+	// Enabled is true if Big Segments are configured. If it is false, all other properties are zero.
+	Enabled bool
+
+	// Hits is the number of membership lookups for a context key that were answered from the cache.
+	Hits int64
+
+	// NotFoundHits is the number of Hits for which the cached result was that the context has no
+	// memberships; these depend on the cache time for "not found" results, which can be set separately.
+	NotFoundHits int64
+
+	// Misses is the number of membership lookups that required a query to the Big Segment store, because
+	// the context key was not in the cache, or its entry had expired or been invalidated.
+	Misses int64
+
+	// Evictions is the number of cache entries that were removed before they expired, to stay within the
+	// configured maximum number of contexts.
+	Evictions int64
+
+	// Items is the current number of cache entries. This can include expired entries that have not yet
+	// been removed.
+	Items int
+
+	// StoreQueries is the number of queries that the SDK has made to the Big Segment store for context
+	// memberships. A query for the memberships of several context keys at once counts as one query.
+	StoreQueries int64
+
+	// StoreQueryErrors is the number of StoreQueries that failed.
+	StoreQueryErrors int64
+
+	// StoreQueryTime is the total time spent in StoreQueries, including ones that failed.
+	StoreQueryTime time.Duration
+}
+
+// AverageStoreQueryTime returns the average time that a membership query to the Big Segment store has
+// taken, or zero if there have been no queries.
+func (s BigSegmentCacheStats) AverageStoreQueryTime() time.Duration {
+	if s.StoreQueries == 0 {
+		return 0
+	}
+	return s.StoreQueryTime / time.Duration(s.StoreQueries)
+}
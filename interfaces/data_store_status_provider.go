@@ -62,6 +62,26 @@ type DataStoreStatusProvider interface {
 	RemoveStatusListener(<-chan DataStoreStatus)
 }
 
+// DataStoreCacheStatsProvider is an optional interface for getting statistics about the in-memory cache of
+// a persistent data store. The DataStoreStatusProvider returned by
+// [github.com/launchdarkly/go-server-sdk/v6.LDClient.GetDataStoreStatusProvider] implements it:
+//
+//	if p, ok := client.GetDataStoreStatusProvider().(interfaces.DataStoreCacheStatsProvider); ok {
+//	    stats := p.GetCacheStats()
+//	    log.Printf("data store cache hits: %d, misses: %d", stats.Hits, stats.Misses)
+//	}
+//
+// It is a separate interface so that other implementations of DataStoreStatusProvider, such as mocks in
+// application tests, do not need to implement it.
+type DataStoreCacheStatsProvider interface {
+	// GetCacheStats returns statistics about the in-memory cache of a persistent data store.
+	//
+	// This can be used to choose appropriate values for the cache limits in
+	// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStoreBuilder]. If the data store
+	// is not a persistent store, or caching is disabled, the Enabled property of the result is false.
+	GetCacheStats() DataStoreCacheStats
+}
+
 // DataStoreStatus contains information about the status of a data store, provided by [DataStoreStatusProvider].
 type DataStoreStatus struct {
 	// Available is true if the SDK believes the data store is now available.
@@ -77,4 +97,44 @@ type DataStoreStatus struct {
 	//
 	// This property is not meaningful to application code.
 	NeedsRefresh bool
+
+	// JournalDepth is the number of updates that were received while the store was unavailable, and are
+	// being held in the write-behind journal until they can be written to the store. It is always zero
+	// unless the journal is enabled with
+	// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStoreBuilder.WriteBehindJournal].
+	JournalDepth int
+}
+
+// DataStoreCacheStats contains statistics about the in-memory cache of a persistent data store, provided by
+// [DataStoreCacheStatsProvider.GetCacheStats].
+//
+// The counters are cumulative over the lifetime of the SDK client.
+type DataStoreCacheStats struct {
+	// Enabled is true if the data store has an in-memory cache. If it is false, all other properties are zero.
+	Enabled bool
+
+	// Hits is the number of queries for a flag, a segment, or all flags or segments that were answered from
+	// the cache.
+	Hits int64
+
+	// Misses is the number of queries that had to be sent to the persistent store because the data was not
+	// in the cache, or had expired.
+	Misses int64
+
+	// StaleHits is the number of hits, included in Hits, that were answered with expired data while the data
+	// was being refreshed in the background. This only happens if stale-while-revalidate caching is enabled
+	// with [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStoreBuilder.CacheStaleWhileRevalidate].
+	StaleHits int64
+
+	// Evictions is the number of cache entries that were removed before they expired, to stay within the
+	// configured maximum number of entries or maximum size.
+	Evictions int64
+
+	// Items is the current number of cache entries. Each flag or segment is one entry, and the full set of
+	// flags or segments, if it has been queried, is also one entry.
+	Items int
+
+	// Bytes is the current estimated size of the cache entries, based on the size of the serialized data.
+	// This is only computed if a maximum size has been configured; otherwise it is zero.
+	Bytes int64
 }
@@ -0,0 +1,127 @@
+package interfaces
+
+import (
+	"encoding/json"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+)
+
+// EventListener receives copies of the analytics events that the SDK generates, for debugging or testing.
+//
+// An implementation of this interface is returned by
+// [github.com/launchdarkly/go-server-sdk/v6.LDClient.AddEventListener]. Application code should not
+// implement this interface.
+//
+// The SDK never blocks while delivering values to a listener: if a channel's buffer is full, the value is
+// discarded and counted by GetDroppedCount. This makes it safe to attach a listener to a production
+// service for a short time, but the listener should be removed with
+// [github.com/launchdarkly/go-server-sdk/v6.LDClient.RemoveEventListener] when it is no longer needed.
+type EventListener interface {
+	// Events returns a channel that receives a copy of every evaluation, identify, and custom event as it
+	// is recorded, and every summary event as it is sent. The channel is closed when the listener is
+	// removed.
+	Events() <-chan AnalyticsEvent
+
+	// Payloads returns a channel that receives a copy of every payload that the SDK delivers, in the JSON
+	// format that is sent to LaunchDarkly. The channel is closed when the listener is removed.
+	Payloads() <-chan EventListenerPayload
+
+	// GetDroppedCount returns the number of events and payloads that were discarded because the
+	// listener's channels were full.
+	GetDroppedCount() int
+}
+
+// AnalyticsEventKind is a parameter type for [AnalyticsEvent], indicating the type of the event.
+type AnalyticsEventKind string
+
+const (
+	// EvaluationAnalyticsEvent denotes a flag evaluation. The SDK records one of these for every
+	// evaluation, whether or not it produces a full feature event in the output.
+	EvaluationAnalyticsEvent AnalyticsEventKind = "evaluation"
+	// IdentifyAnalyticsEvent denotes an identify event.
+	IdentifyAnalyticsEvent AnalyticsEventKind = "identify"
+	// CustomAnalyticsEvent denotes a custom event.
+	CustomAnalyticsEvent AnalyticsEventKind = "custom"
+	// SummaryAnalyticsEvent denotes a summary event, which contains the evaluation counts for a flush
+	// interval.
+	SummaryAnalyticsEvent AnalyticsEventKind = "summary"
+)
+
+// AnalyticsEvent is a copy of an analytics event that is delivered to an [EventListener].
+//
+// Exactly one of the Evaluation, Identify, Custom, or Summary fields is set, depending on the Kind.
+// Evaluation, identify, and custom events are the SDK's inputs to the event processor. Their contexts have
+// had the same processing as the contexts that are sent to LaunchDarkly: any redaction policy has been
+// applied, and private attributes have been removed.
+type AnalyticsEvent struct {
+	// Kind is the type of the event.
+	Kind AnalyticsEventKind
+
+	// Evaluation contains the properties of an evaluation event.
+	Evaluation *ldevents.EvaluationData
+
+	// Identify contains the properties of an identify event.
+	Identify *ldevents.IdentifyEventData
+
+	// Custom contains the properties of a custom event.
+	Custom *ldevents.CustomEventData
+
+	// Summary contains the properties of a summary event.
+	Summary *SummaryEventData
+}
+
+// SummaryEventData contains the properties of a summary event.
+type SummaryEventData struct {
+	// StartDate is the time of the first evaluation that was counted.
+	StartDate ldtime.UnixMillisecondTime
+
+	// EndDate is the time of the last evaluation that was counted.
+	EndDate ldtime.UnixMillisecondTime
+
+	// Features contains the evaluation counts for each flag, by flag key.
+	Features map[string]FlagSummaryData
+}
+
+// FlagSummaryData contains the evaluation counts for one flag in a [SummaryEventData].
+type FlagSummaryData struct {
+	// Default is the default value that the application passed when evaluating the flag.
+	Default ldvalue.Value
+
+	// ContextKinds contains the kinds of all contexts that the flag was evaluated for.
+	ContextKinds []string
+
+	// Counters contains a count for each distinct result of evaluating the flag.
+	Counters []FlagSummaryCounter
+}
+
+// FlagSummaryCounter contains the number of times that a flag evaluation produced a particular result.
+type FlagSummaryCounter struct {
+	// Variation is the variation index, or undefined if the default value was returned.
+	Variation ldvalue.OptionalInt
+
+	// Version is the flag version, or undefined if the flag was not found.
+	Version ldvalue.OptionalInt
+
+	// Value is the result of the evaluation.
+	Value ldvalue.Value
+
+	// Count is the number of evaluations that produced this result.
+	Count int
+
+	// Unknown is true if the flag was not found.
+	Unknown bool
+}
+
+// EventListenerPayload is a copy of an event payload that is delivered to an [EventListener].
+type EventListenerPayload struct {
+	// Kind is "analytics" for a payload of analytics events, or "diagnostic" for a diagnostic event.
+	Kind string
+
+	// EventCount is the number of events in the payload.
+	EventCount int
+
+	// Data is the JSON representation of the payload, exactly as the SDK sends it.
+	Data json.RawMessage
+}
@@ -23,9 +23,10 @@ type FlagTracker interface {
 	// value than it previously returned for some context. If you want to track flag value changes, use
 	// AddFlagValueChangeListener instead.
 	//
-	// Change events only work if the SDK is actually connecting to LaunchDarkly (or using the file data source).
-	// If the SDK is only reading flags from a database (ldcomponents.ExternalUpdatesOnly) then it cannot
-	// know when there is a change, because flags are read on an as-needed basis.
+	// If the SDK is only reading flags from a database (ldcomponents.ExternalUpdatesOnly), it detects changes
+	// either by receiving notifications from the database, if the database integration supports this, or by
+	// querying the database at intervals; in the latter case, events may be delayed by the polling interval
+	// and by the database cache TTL.
 	//
 	// It is the caller's responsibility to consume values from the channel. Allowing values to accumulate in
 	// the channel can cause an SDK goroutine to be blocked.
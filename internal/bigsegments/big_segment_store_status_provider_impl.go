@@ -5,6 +5,14 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v6/internal"
 )
 
+// BigSegmentStoreManager is the interface of the component that provides access to the Big Segment store,
+// which is ldstoreimpl.BigSegmentStoreWrapper. It is defined here because that package imports this one.
+type BigSegmentStoreManager interface {
+	GetStatus() interfaces.BigSegmentStoreStatus
+	GetCacheStats() interfaces.BigSegmentCacheStats
+	InvalidateContext(contextKey string)
+}
+
 // This is the standard implementation of BigSegmentStoreStatusProvider. Most of the work is done by
 // BigSegmentStoreManager, which exposes the methods that other SDK components need to access the store.
 //
@@ -13,27 +21,27 @@ import (
 // any status updates, but this API object still exists so your app won't crash if you try to use
 // GetStatus or AddStatusListener.
 type bigSegmentStoreStatusProviderImpl struct {
-	getStatusFn func() interfaces.BigSegmentStoreStatus
+	manager     BigSegmentStoreManager
 	broadcaster *internal.Broadcaster[interfaces.BigSegmentStoreStatus]
 }
 
 // NewBigSegmentStoreStatusProviderImpl creates the internal implementation of
 // BigSegmentStoreStatusProvider. The manager parameter can be nil if there is no Big Segment store.
 func NewBigSegmentStoreStatusProviderImpl(
-	getStatusFn func() interfaces.BigSegmentStoreStatus,
+	manager BigSegmentStoreManager,
 	broadcaster *internal.Broadcaster[interfaces.BigSegmentStoreStatus],
 ) interfaces.BigSegmentStoreStatusProvider {
 	return &bigSegmentStoreStatusProviderImpl{
-		getStatusFn: getStatusFn,
+		manager:     manager,
 		broadcaster: broadcaster,
 	}
 }
 
 func (b *bigSegmentStoreStatusProviderImpl) GetStatus() interfaces.BigSegmentStoreStatus {
-	if b.getStatusFn == nil {
+	if b.manager == nil {
 		return interfaces.BigSegmentStoreStatus{Available: false}
 	}
-	return b.getStatusFn()
+	return b.manager.GetStatus()
 }
 
 func (b *bigSegmentStoreStatusProviderImpl) AddStatusListener() <-chan interfaces.BigSegmentStoreStatus {
@@ -45,3 +53,18 @@ func (b *bigSegmentStoreStatusProviderImpl) RemoveStatusListener(
 ) {
 	b.broadcaster.RemoveListener(ch)
 }
+
+// GetCacheStats implements interfaces.BigSegmentCacheController.
+func (b *bigSegmentStoreStatusProviderImpl) GetCacheStats() interfaces.BigSegmentCacheStats {
+	if b.manager == nil {
+		return interfaces.BigSegmentCacheStats{}
+	}
+	return b.manager.GetCacheStats()
+}
+
+// InvalidateContext implements interfaces.BigSegmentCacheController.
+func (b *bigSegmentStoreStatusProviderImpl) InvalidateContext(contextKey string) {
+	if b.manager != nil {
+		b.manager.InvalidateContext(contextKey)
+	}
+}
@@ -19,6 +19,45 @@ func TestGetStatusWhenStatusFunctionIsUndefined(t *testing.T) {
 	assert.False(t, status.Stale)
 }
 
+func TestGetCacheStatsWhenThereIsNoStore(t *testing.T) {
+	provider := NewBigSegmentStoreStatusProviderImpl(nil, nil).(interfaces.BigSegmentCacheController)
+
+	assert.Equal(t, interfaces.BigSegmentCacheStats{}, provider.GetCacheStats())
+	provider.InvalidateContext("key") // does not panic
+}
+
+type mockBigSegmentStoreManager struct {
+	status      interfaces.BigSegmentStoreStatus
+	stats       interfaces.BigSegmentCacheStats
+	invalidated []string
+}
+
+func (m *mockBigSegmentStoreManager) GetStatus() interfaces.BigSegmentStoreStatus {
+	return m.status
+}
+
+func (m *mockBigSegmentStoreManager) GetCacheStats() interfaces.BigSegmentCacheStats {
+	return m.stats
+}
+
+func (m *mockBigSegmentStoreManager) InvalidateContext(contextKey string) {
+	m.invalidated = append(m.invalidated, contextKey)
+}
+
+func TestMethodsAreDelegatedToManager(t *testing.T) {
+	manager := &mockBigSegmentStoreManager{
+		status: interfaces.BigSegmentStoreStatus{Available: true, Stale: true},
+		stats:  interfaces.BigSegmentCacheStats{Enabled: true, Hits: 1, Misses: 2, Items: 3},
+	}
+	provider := NewBigSegmentStoreStatusProviderImpl(manager, nil)
+
+	assert.Equal(t, manager.status, provider.GetStatus())
+	controller := provider.(interfaces.BigSegmentCacheController)
+	assert.Equal(t, manager.stats, controller.GetCacheStats())
+	controller.InvalidateContext("key")
+	assert.Equal(t, []string{"key"}, manager.invalidated)
+}
+
 func TestStatusListener(t *testing.T) {
 	broadcaster := internal.NewBroadcaster[interfaces.BigSegmentStoreStatus]()
 	defer broadcaster.Close()
@@ -0,0 +1,116 @@
+package bigsegments
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	ldeval "github.com/launchdarkly/go-server-sdk-evaluation/v2"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+)
+
+// ContextKeysForFlags returns the keys of the individual contexts within a context whose Big Segment
+// membership state might be queried when evaluating the specified flags. It follows prerequisites and
+// segment references in the same way that the evaluator does, but it does not evaluate any clauses, so
+// the result can include keys that an evaluation turns out not to need.
+//
+// The SDK uses this to prefetch all of the memberships for a multi-kind context with a single query.
+func ContextKeysForFlags(
+	context ldcontext.Context,
+	flags []*ldmodel.FeatureFlag,
+	dataProvider ldeval.DataProvider,
+) []string {
+	f := bigSegmentKindFinder{dataProvider: dataProvider}
+	for _, flag := range flags {
+		f.visitFlag(flag)
+	}
+	var keys []string
+	for _, kind := range f.kinds {
+		if c := context.IndividualContextByKind(kind); c.IsDefined() && !containsString(keys, c.Key()) {
+			keys = append(keys, c.Key())
+		}
+	}
+	return keys
+}
+
+// bigSegmentKindFinder collects the context kinds of the Big Segments that a flag can reference. The maps
+// of visited items, which also prevent infinite recursion if there is a circular reference, are only
+// created if the flag has prerequisites or segment references.
+type bigSegmentKindFinder struct {
+	dataProvider    ldeval.DataProvider
+	visitedFlags    map[string]struct{}
+	visitedSegments map[string]struct{}
+	kinds           []ldcontext.Kind
+}
+
+func (f *bigSegmentKindFinder) visitFlag(flag *ldmodel.FeatureFlag) {
+	if flag == nil || !markVisited(&f.visitedFlags, flag.Key) {
+		return
+	}
+	for _, prereq := range flag.Prerequisites {
+		f.visitFlag(f.dataProvider.GetFeatureFlag(prereq.Key))
+	}
+	for _, rule := range flag.Rules {
+		f.visitClauses(rule.Clauses)
+	}
+}
+
+func (f *bigSegmentKindFinder) visitClauses(clauses []ldmodel.Clause) {
+	for _, clause := range clauses {
+		if clause.Op != ldmodel.OperatorSegmentMatch {
+			continue
+		}
+		for _, value := range clause.Values {
+			if value.IsString() {
+				f.visitSegment(f.dataProvider.GetSegment(value.StringValue()))
+			}
+		}
+	}
+}
+
+func (f *bigSegmentKindFinder) visitSegment(segment *ldmodel.Segment) {
+	if segment == nil || !markVisited(&f.visitedSegments, segment.Key) {
+		return
+	}
+	// The evaluator does not query a Big Segment whose generation is unknown
+	if segment.Unbounded && segment.Generation.IsDefined() {
+		kind := segment.UnboundedContextKind
+		if kind == "" {
+			kind = ldcontext.DefaultKind
+		}
+		if !containsKind(f.kinds, kind) {
+			f.kinds = append(f.kinds, kind)
+		}
+	}
+	for _, rule := range segment.Rules {
+		f.visitClauses(rule.Clauses)
+	}
+}
+
+// markVisited adds a key to a set, creating the set if necessary, and returns false if it was already
+// in the set.
+func markVisited(visited *map[string]struct{}, key string) bool {
+	if _, ok := (*visited)[key]; ok {
+		return false
+	}
+	if *visited == nil {
+		*visited = make(map[string]struct{})
+	}
+	(*visited)[key] = struct{}{}
+	return true
+}
+
+func containsKind(kinds []ldcontext.Kind, kind ldcontext.Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
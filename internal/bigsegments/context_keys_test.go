@@ -0,0 +1,114 @@
+package bigsegments
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type simpleDataProvider struct {
+	flags    map[string]*ldmodel.FeatureFlag
+	segments map[string]*ldmodel.Segment
+}
+
+func (p simpleDataProvider) GetFeatureFlag(key string) *ldmodel.FeatureFlag { return p.flags[key] }
+
+func (p simpleDataProvider) GetSegment(key string) *ldmodel.Segment { return p.segments[key] }
+
+func segmentMatchRule(segmentKeys ...string) *ldbuilders.RuleBuilder {
+	values := make([]ldvalue.Value, 0, len(segmentKeys))
+	for _, key := range segmentKeys {
+		values = append(values, ldvalue.String(key))
+	}
+	return ldbuilders.NewRuleBuilder().Variation(0).Clauses(
+		ldbuilders.Clause("", ldmodel.OperatorSegmentMatch, values...))
+}
+
+func TestContextKeysForFlags(t *testing.T) {
+	userSegment := ldbuilders.NewSegmentBuilder("user-segment").Unbounded(true).Generation(1).Build()
+	orgSegment := ldbuilders.NewSegmentBuilder("org-segment").Unbounded(true).UnboundedContextKind("org").
+		Generation(1).Build()
+	deviceSegment := ldbuilders.NewSegmentBuilder("device-segment").Unbounded(true).
+		UnboundedContextKind("device").Generation(1).Build()
+	noGenerationSegment := ldbuilders.NewSegmentBuilder("no-generation-segment").Unbounded(true).
+		UnboundedContextKind("org").Build()
+	regularSegment := ldbuilders.NewSegmentBuilder("regular-segment").Build()
+	nestingSegment := ldbuilders.NewSegmentBuilder("nesting-segment").
+		AddRule(ldbuilders.NewSegmentRuleBuilder().Clauses(
+			ldbuilders.Clause("", ldmodel.OperatorSegmentMatch, ldvalue.String(orgSegment.Key)),
+		)).Build()
+	circularSegment := ldbuilders.NewSegmentBuilder("circular-segment").
+		AddRule(ldbuilders.NewSegmentRuleBuilder().Clauses(
+			ldbuilders.Clause("", ldmodel.OperatorSegmentMatch, ldvalue.String("circular-segment")),
+		)).Build()
+	prereqFlag := ldbuilders.NewFlagBuilder("prereq").AddRule(segmentMatchRule(orgSegment.Key)).Build()
+	circularFlag := ldbuilders.NewFlagBuilder("circular").AddPrerequisite("circular", 0).Build()
+
+	dataProvider := simpleDataProvider{
+		flags:    map[string]*ldmodel.FeatureFlag{prereqFlag.Key: &prereqFlag, circularFlag.Key: &circularFlag},
+		segments: map[string]*ldmodel.Segment{},
+	}
+	for _, s := range []ldmodel.Segment{userSegment, orgSegment, deviceSegment, noGenerationSegment,
+		regularSegment, nestingSegment, circularSegment} {
+		segment := s
+		dataProvider.segments[segment.Key] = &segment
+	}
+
+	context := ldcontext.NewMulti(ldcontext.New("user-key"), ldcontext.NewWithKind("org", "org-key"))
+
+	for _, p := range []struct {
+		name     string
+		flag     ldmodel.FeatureFlag
+		expected []string
+	}{
+		{"no rules", ldbuilders.NewFlagBuilder("f").Build(), nil},
+		{"regular segment", ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule(regularSegment.Key)).Build(), nil},
+		{"unknown segment", ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule("unknown")).Build(), nil},
+		{"Big Segment without generation",
+			ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule(noGenerationSegment.Key)).Build(), nil},
+		{"Big Segment for kind that the context does not have",
+			ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule(deviceSegment.Key)).Build(), nil},
+		{"Big Segments in one clause",
+			ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule(userSegment.Key, orgSegment.Key)).Build(),
+			[]string{"user-key", "org-key"}},
+		{"Big Segments in several rules",
+			ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule(orgSegment.Key)).
+				AddRule(segmentMatchRule(userSegment.Key)).AddRule(segmentMatchRule(orgSegment.Key)).Build(),
+			[]string{"org-key", "user-key"}},
+		{"Big Segment in prerequisite",
+			ldbuilders.NewFlagBuilder("f").AddPrerequisite(prereqFlag.Key, 0).
+				AddRule(segmentMatchRule(userSegment.Key)).Build(),
+			[]string{"org-key", "user-key"}},
+		{"Big Segment in nested segment",
+			ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule(nestingSegment.Key)).Build(),
+			[]string{"org-key"}},
+		{"circular references",
+			ldbuilders.NewFlagBuilder("f").AddPrerequisite(circularFlag.Key, 0).
+				AddRule(segmentMatchRule(circularSegment.Key)).Build(),
+			nil},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			flag := p.flag
+			assert.Equal(t, p.expected, ContextKeysForFlags(context, []*ldmodel.FeatureFlag{&flag}, dataProvider))
+		})
+	}
+
+	t.Run("several flags", func(t *testing.T) {
+		flag1 := ldbuilders.NewFlagBuilder("f1").AddRule(segmentMatchRule(userSegment.Key)).Build()
+		flag2 := ldbuilders.NewFlagBuilder("f2").AddRule(segmentMatchRule(orgSegment.Key)).Build()
+		assert.Equal(t, []string{"user-key", "org-key"},
+			ContextKeysForFlags(context, []*ldmodel.FeatureFlag{&flag1, nil, &flag2}, dataProvider))
+	})
+
+	t.Run("contexts with the same key", func(t *testing.T) {
+		sameKeyContext := ldcontext.NewMulti(ldcontext.New("key"), ldcontext.NewWithKind("org", "key"))
+		flag := ldbuilders.NewFlagBuilder("f").AddRule(segmentMatchRule(userSegment.Key, orgSegment.Key)).Build()
+		assert.Equal(t, []string{"key"},
+			ContextKeysForFlags(sameKeyContext, []*ldmodel.FeatureFlag{&flag}, dataProvider))
+	})
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/events"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 )
 
@@ -10,4 +11,6 @@ type ClientContextImpl struct {
 	subsystems.BasicClientContext
 	// Used internally to share a diagnosticsManager instance between components.
 	DiagnosticsManager *ldevents.DiagnosticsManager
+	// Used internally to deliver copies of analytics events to listeners added with LDClient.AddEventListener.
+	EventTap *events.EventTap
 }
@@ -70,7 +70,10 @@ func computeDependenciesFrom(kind st.DataKind, fromItem st.ItemDescriptor) kindA
 	return ret
 }
 
-func sortCollectionsForDataStoreInit(allData []st.Collection) []st.Collection {
+// SortCollectionsForDataStoreInit puts the data in the order in which it should be written to a data store:
+// segments before flags, and each flag after any flags that it has as prerequisites. This is so that a data
+// store that is not updated atomically will never contain a flag whose dependencies are missing.
+func SortCollectionsForDataStoreInit(allData []st.Collection) []st.Collection {
 	colls := make([]st.Collection, 0, len(allData))
 	for _, coll := range allData {
 		if doesDataKindSupportDependencies(coll.Kind) {
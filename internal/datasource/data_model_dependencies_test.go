@@ -112,7 +112,7 @@ func TestComputeDependenciesFromNullItem(t *testing.T) {
 
 func TestSortCollectionsForDataStoreInit(t *testing.T) {
 	inputData := makeDependencyOrderingDataSourceTestData()
-	sortedData := sortCollectionsForDataStoreInit(inputData)
+	sortedData := SortCollectionsForDataStoreInit(inputData)
 	verifySortedData(t, sortedData, inputData)
 }
 
@@ -132,7 +132,7 @@ func TestSortCollectionsLeavesItemsOfUnknownDataKindUnchanged(t *testing.T) {
 			}},
 		{Kind: datakinds.Segments, Items: nil},
 	}
-	sortedData := sortCollectionsForDataStoreInit(inputData)
+	sortedData := SortCollectionsForDataStoreInit(inputData)
 
 	// the unknown data kind appears last, and the ordering of its items is unchanged
 	assert.Len(t, sortedData, 3)
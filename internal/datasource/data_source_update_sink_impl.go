@@ -71,7 +71,7 @@ func (d *DataSourceUpdateSinkImpl) Init(allData []st.Collection) bool {
 		}
 	}
 
-	err := d.store.Init(sortCollectionsForDataStoreInit(allData))
+	err := d.store.Init(SortCollectionsForDataStoreInit(allData))
 	updated := d.maybeUpdateError(err)
 
 	if updated {
@@ -0,0 +1,197 @@
+package datasource
+
+import (
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// externalChangeSubscriber is implemented by the persistent data store wrapper if the underlying store
+// implementation supports subsystems.PersistentDataStoreChangeNotifier.
+type externalChangeSubscriber interface {
+	SubscribeToExternalChanges(onChange func(kind st.DataKind, key string)) error
+}
+
+// externalChangeWatcher detects changes that another process has made to the data store, when the SDK is
+// in daemon mode and so is not receiving updates from LaunchDarkly itself. It keeps track of the version
+// of every item, and generates flag change events for any items whose versions have changed (and for any
+// flags that depend on them), just as DataSourceUpdateSinkImpl does for updates from a data source.
+//
+// If the store supports change notifications, it tells us which items to check. Otherwise, if polling is
+// enabled, we query all of the data periodically. Since these queries go through the data store wrapper, a
+// change will not be seen until the cached copy of the data (if any) has expired.
+//
+// Since no other data source is active in daemon mode, the watcher is the only thing that updates the
+// DataSourceUpdateSinkImpl's dependency graph.
+type externalChangeWatcher struct {
+	updates      *DataSourceUpdateSinkImpl
+	pollInterval time.Duration
+	versions     map[kindAndKey]int
+	knownKinds   map[st.DataKind]bool
+	closer       chan struct{}
+	closeOnce    sync.Once
+	lock         sync.Mutex
+
+	initialReadWaitGroup sync.WaitGroup // lets tests wait for the initial read
+}
+
+func newExternalChangeWatcher(updates *DataSourceUpdateSinkImpl, pollInterval time.Duration) *externalChangeWatcher {
+	return &externalChangeWatcher{
+		updates:      updates,
+		pollInterval: pollInterval,
+		versions:     make(map[kindAndKey]int),
+		knownKinds:   make(map[st.DataKind]bool),
+		closer:       make(chan struct{}),
+	}
+}
+
+// start begins watching for changes. It first reads the current state of the data, which does not
+// generate any events, so that it has something to compare later versions with.
+//
+// That initial read is done on another goroutine, so that starting the SDK does not wait for it; a change
+// made before the read has completed does not generate an event, just as a change made before start was
+// called does not. If neither change notifications nor polling are available, the store is not queried
+// at all.
+func (w *externalChangeWatcher) start() {
+	loggers := w.updates.loggers
+	if s, ok := w.updates.store.(externalChangeSubscriber); ok {
+		err := s.SubscribeToExternalChanges(w.handleChange)
+		if err == nil {
+			loggers.Info("Using change notifications from data store to detect flag changes")
+			w.initialReadWaitGroup.Add(1)
+			go func() {
+				defer w.initialReadWaitGroup.Done()
+				w.readInitialData()
+			}()
+			return
+		}
+		loggers.Debugf("Data store change notifications are not available: %s", err)
+	}
+	if w.pollInterval <= 0 {
+		loggers.Debug("Data store change polling is not enabled; flag change events will not be generated")
+		return
+	}
+	loggers.Infof("Will query data store every %s to detect flag changes", w.pollInterval)
+	w.initialReadWaitGroup.Add(1)
+	go w.poll()
+}
+
+// readInitialData records the current versions of all items of any kinds that we have not yet read.
+func (w *externalChangeWatcher) readInitialData() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for _, kind := range datakinds.AllDataKinds() {
+		if !w.knownKinds[kind] {
+			w.checkAllItems(kind, nil)
+		}
+	}
+}
+
+func (w *externalChangeWatcher) close() {
+	w.closeOnce.Do(func() { close(w.closer) })
+}
+
+func (w *externalChangeWatcher) poll() {
+	w.readInitialData()
+	w.initialReadWaitGroup.Done()
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.closer:
+			return
+		case <-ticker.C:
+			for _, kind := range datakinds.AllDataKinds() {
+				w.handleChange(kind, "")
+			}
+		}
+	}
+}
+
+// handleChange checks whether the specified item has changed, or all items of that kind if key is empty,
+// and sends flag change events if so.
+func (w *externalChangeWatcher) handleChange(kind st.DataKind, key string) {
+	select {
+	case <-w.closer:
+		return
+	default:
+	}
+	affectedItems := make(kindAndKeySet)
+	w.lock.Lock()
+	if key == "" || !w.knownKinds[kind] {
+		w.checkAllItems(kind, affectedItems)
+	} else {
+		w.checkItem(kind, key, affectedItems)
+	}
+	w.lock.Unlock()
+	w.updates.sendChangeEvents(affectedItems)
+}
+
+// checkAllItems compares all items of the specified kind with the versions we previously saw. If this
+// is the first time we have successfully read this kind, we only record the versions.
+func (w *externalChangeWatcher) checkAllItems(kind st.DataKind, affectedItems kindAndKeySet) {
+	items, err := w.updates.store.GetAll(kind)
+	if err != nil {
+		w.updates.loggers.Debugf("Unable to query data store for %s: %s", kind.GetName(), err)
+		return
+	}
+	if !w.knownKinds[kind] {
+		affectedItems = nil
+		w.knownKinds[kind] = true
+	}
+	keys := make(map[string]bool, len(items))
+	for _, item := range items {
+		keys[item.Key] = true
+		w.itemReceived(kind, item.Key, item.Item, affectedItems)
+	}
+	for k := range w.versions {
+		if k.kind == kind && !keys[k.key] {
+			w.itemRemoved(k, affectedItems)
+		}
+	}
+}
+
+func (w *externalChangeWatcher) checkItem(kind st.DataKind, key string, affectedItems kindAndKeySet) {
+	item, err := w.updates.store.Get(kind, key)
+	if err != nil {
+		w.updates.loggers.Debugf("Unable to query data store for %s key %q: %s", kind.GetName(), key, err)
+		return
+	}
+	if item.Version < 0 { // not found
+		if _, ok := w.versions[kindAndKey{kind, key}]; ok {
+			w.itemRemoved(kindAndKey{kind, key}, affectedItems)
+		}
+		return
+	}
+	w.itemReceived(kind, key, item, affectedItems)
+}
+
+// itemReceived records the current version of an item; a deleted item placeholder is treated like any
+// other version. If affectedItems is non-nil and the version is new, the item and everything that
+// depends on it are added to affectedItems.
+func (w *externalChangeWatcher) itemReceived(
+	kind st.DataKind,
+	key string,
+	item st.ItemDescriptor,
+	affectedItems kindAndKeySet,
+) {
+	k := kindAndKey{kind, key}
+	if oldVersion, ok := w.versions[k]; ok && oldVersion >= item.Version {
+		return
+	}
+	w.versions[k] = item.Version
+	w.updates.dependencyTracker.updateDependenciesFrom(kind, key, item)
+	if affectedItems != nil {
+		w.updates.dependencyTracker.addAffectedItems(affectedItems, k)
+	}
+}
+
+func (w *externalChangeWatcher) itemRemoved(k kindAndKey, affectedItems kindAndKeySet) {
+	delete(w.versions, k)
+	w.updates.dependencyTracker.updateDependenciesFrom(k.kind, k.key, st.ItemDescriptor{}.NotFound())
+	if affectedItems != nil {
+		w.updates.dependencyTracker.addAffectedItems(affectedItems, k)
+	}
+}
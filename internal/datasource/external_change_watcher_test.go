@@ -0,0 +1,240 @@
+package datasource
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subscribableDataStore simulates the persistent data store wrapper for a store that supports change
+// notifications.
+type subscribableDataStore struct {
+	subsystems.DataStore
+	onChange func(st.DataKind, string)
+}
+
+func (s *subscribableDataStore) SubscribeToExternalChanges(onChange func(st.DataKind, string)) error {
+	s.onChange = onChange
+	return nil
+}
+
+// queryCountingDataStore counts the number of times that the watcher has queried a data store.
+type queryCountingDataStore struct {
+	subsystems.DataStore
+	queries int64
+}
+
+func (s *queryCountingDataStore) GetAll(kind st.DataKind) ([]st.KeyedItemDescriptor, error) {
+	atomic.AddInt64(&s.queries, 1)
+	return s.DataStore.GetAll(kind)
+}
+
+func (s *queryCountingDataStore) Get(kind st.DataKind, key string) (st.ItemDescriptor, error) {
+	atomic.AddInt64(&s.queries, 1)
+	return s.DataStore.Get(kind, key)
+}
+
+type externalChangeWatcherTestParams struct {
+	store                 subsystems.DataStore
+	flagChangeBroadcaster *internal.Broadcaster[interfaces.FlagChangeEvent]
+	watcher               *externalChangeWatcher
+	mockLog               *ldlogtest.MockLog
+}
+
+func externalChangeWatcherTest(
+	t *testing.T,
+	store subsystems.DataStore,
+	pollInterval time.Duration,
+	action func(externalChangeWatcherTestParams),
+) {
+	p := externalChangeWatcherTestParams{store: store, mockLog: ldlogtest.NewMockLog()}
+	dataSourceStatusBroadcaster := internal.NewBroadcaster[interfaces.DataSourceStatus]()
+	defer dataSourceStatusBroadcaster.Close()
+	p.flagChangeBroadcaster = internal.NewBroadcaster[interfaces.FlagChangeEvent]()
+	defer p.flagChangeBroadcaster.Close()
+	updates := NewDataSourceUpdateSinkImpl(
+		store,
+		datastore.NewDataStoreStatusProviderImpl(store, datastore.NewDataStoreUpdateSinkImpl(nil)),
+		dataSourceStatusBroadcaster,
+		p.flagChangeBroadcaster,
+		testDataSourceOutageTimeout,
+		p.mockLog.Loggers,
+	)
+	p.watcher = newExternalChangeWatcher(updates, pollInterval)
+	defer p.watcher.close()
+	action(p)
+}
+
+func upsertFlag(t *testing.T, store subsystems.DataStore, flag ldmodel.FeatureFlag) {
+	_, err := store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+	require.NoError(t, err)
+}
+
+func upsertSegment(t *testing.T, store subsystems.DataStore, segment ldmodel.Segment) {
+	_, err := store.Upsert(datakinds.Segments, segment.Key, sharedtest.SegmentDescriptor(segment))
+	require.NoError(t, err)
+}
+
+func TestExternalChangeWatcherWithPolling(t *testing.T) {
+	pollInterval := time.Millisecond * 10
+
+	t.Run("sends events for added, updated, and deleted flags", func(t *testing.T) {
+		store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+		require.NoError(t, store.Init(nil))
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag2").Version(1).Build())
+
+		externalChangeWatcherTest(t, store, pollInterval, func(p externalChangeWatcherTestParams) {
+			ch := p.flagChangeBroadcaster.AddListener()
+			p.watcher.start()
+			p.watcher.initialReadWaitGroup.Wait()
+			th.AssertNoMoreValues(t, ch, pollInterval*5) // the initial state does not generate events
+
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(2).Build())
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag3").Version(1).Build())
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag1", "flag3")
+
+			_, err := store.Upsert(datakinds.Features, "flag2", st.ItemDescriptor{Version: 2})
+			require.NoError(t, err)
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag2")
+		})
+	})
+
+	t.Run("sends events for flags that depend on a changed flag or segment", func(t *testing.T) {
+		store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+		require.NoError(t, store.Init(nil))
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag2").Version(1).AddPrerequisite("flag1", 0).Build())
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag3").Version(1).
+			AddRule(ldbuilders.NewRuleBuilder().Clauses(ldbuilders.SegmentMatchClause("segment1"))).Build())
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag4").Version(1).Build())
+		upsertSegment(t, store, ldbuilders.NewSegmentBuilder("segment1").Version(1).Build())
+
+		externalChangeWatcherTest(t, store, pollInterval, func(p externalChangeWatcherTestParams) {
+			ch := p.flagChangeBroadcaster.AddListener()
+			p.watcher.start()
+			p.watcher.initialReadWaitGroup.Wait()
+
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(2).Build())
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag1", "flag2")
+
+			upsertSegment(t, store, ldbuilders.NewSegmentBuilder("segment1").Version(2).Build())
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag3")
+		})
+	})
+
+	t.Run("store error does not cause spurious events", func(t *testing.T) {
+		store := mocks.NewCapturingDataStore(datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers()))
+		require.NoError(t, store.Init(nil))
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+
+		externalChangeWatcherTest(t, store, time.Hour, func(p externalChangeWatcherTestParams) {
+			ch := p.flagChangeBroadcaster.AddListener()
+			store.SetFakeError(errors.New("sorry"))
+			p.watcher.start() // can't read the initial state
+			p.watcher.initialReadWaitGroup.Wait()
+
+			store.SetFakeError(nil)
+			p.watcher.handleChange(datakinds.Features, "flag1")
+			th.AssertNoMoreValues(t, ch, time.Millisecond*50) // the first successful query is treated as the initial state
+
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(2).Build())
+			p.watcher.handleChange(datakinds.Features, "flag1")
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag1")
+		})
+	})
+
+	t.Run("does not query store if interval is zero", func(t *testing.T) {
+		store := &queryCountingDataStore{DataStore: datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())}
+		require.NoError(t, store.Init(nil))
+
+		externalChangeWatcherTest(t, store, 0, func(p externalChangeWatcherTestParams) {
+			ch := p.flagChangeBroadcaster.AddListener()
+			p.watcher.start()
+
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+			th.AssertNoMoreValues(t, ch, time.Millisecond*100)
+			assert.Equal(t, int64(0), atomic.LoadInt64(&store.queries))
+		})
+	})
+}
+
+func TestExternalChangeWatcherWithNotifications(t *testing.T) {
+	t.Run("checks item that store reports as changed", func(t *testing.T) {
+		store := &subscribableDataStore{DataStore: datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())}
+		require.NoError(t, store.Init(nil))
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag2").Version(1).Build())
+
+		externalChangeWatcherTest(t, store, time.Hour, func(p externalChangeWatcherTestParams) {
+			ch := p.flagChangeBroadcaster.AddListener()
+			p.watcher.start()
+			p.watcher.initialReadWaitGroup.Wait()
+			require.NotNil(t, store.onChange)
+			p.mockLog.AssertMessageMatch(t, true, ldlog.Info, "Using change notifications")
+
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(2).Build())
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag2").Version(2).Build())
+			store.onChange(datakinds.Features, "flag1")
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag1")
+
+			store.onChange(datakinds.Features, "flag1") // no change since last time
+			th.AssertNoMoreValues(t, ch, time.Millisecond*50)
+
+			store.onChange(datakinds.Features, "")
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag2")
+		})
+	})
+
+	t.Run("sends event if item was removed", func(t *testing.T) {
+		core := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+		store := &subscribableDataStore{DataStore: core}
+		require.NoError(t, store.Init(nil))
+		upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+
+		externalChangeWatcherTest(t, store, time.Hour, func(p externalChangeWatcherTestParams) {
+			ch := p.flagChangeBroadcaster.AddListener()
+			p.watcher.start()
+			p.watcher.initialReadWaitGroup.Wait()
+
+			require.NoError(t, store.Init(nil))
+			store.onChange(datakinds.Features, "flag1")
+			sharedtest.ExpectFlagChangeEvents(t, ch, "flag1")
+		})
+	})
+
+	t.Run("ignores notifications after close", func(t *testing.T) {
+		store := &subscribableDataStore{DataStore: datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())}
+		require.NoError(t, store.Init(nil))
+
+		externalChangeWatcherTest(t, store, time.Hour, func(p externalChangeWatcherTestParams) {
+			ch := p.flagChangeBroadcaster.AddListener()
+			p.watcher.start()
+			p.watcher.close()
+
+			upsertFlag(t, store, ldbuilders.NewFlagBuilder("flag1").Version(1).Build())
+			store.onChange(datakinds.Features, "flag1")
+			th.AssertNoMoreValues(t, ch, time.Millisecond*50)
+		})
+	})
+}
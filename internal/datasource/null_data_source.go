@@ -1,12 +1,45 @@
 package datasource
 
-import "github.com/launchdarkly/go-server-sdk/v6/subsystems"
+import (
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
 
 // NewNullDataSource returns a stub implementation of DataSource.
 func NewNullDataSource() subsystems.DataSource {
 	return nullDataSource{}
 }
 
+// NewExternalUpdatesDataSource returns the implementation of DataSource that is used in daemon mode. It
+// does not receive any data, but it watches the data store for changes made by another process so that
+// it can generate flag change events. If changePollInterval is zero or negative, it only does this if the
+// data store supports change notifications.
+//
+// If updates is not the SDK's own DataSourceUpdateSink implementation, this is the same as
+// NewNullDataSource.
+func NewExternalUpdatesDataSource(
+	updates subsystems.DataSourceUpdateSink,
+	changePollInterval time.Duration,
+) subsystems.DataSource {
+	sink, ok := updates.(*DataSourceUpdateSinkImpl)
+	if !ok {
+		return NewNullDataSource()
+	}
+	return &externalUpdatesDataSource{watcher: newExternalChangeWatcher(sink, changePollInterval)}
+}
+
+// IsNullDataSource returns true if the DataSource was created by NewNullDataSource or
+// NewExternalUpdatesDataSource, meaning that there is no need to wait for it to initialize.
+func IsNullDataSource(ds subsystems.DataSource) bool {
+	switch ds.(type) {
+	case nullDataSource, *externalUpdatesDataSource:
+		return true
+	default:
+		return false
+	}
+}
+
 type nullDataSource struct{}
 
 func (n nullDataSource) IsInitialized() bool {
@@ -20,3 +53,18 @@ func (n nullDataSource) Close() error {
 func (n nullDataSource) Start(closeWhenReady chan<- struct{}) {
 	close(closeWhenReady)
 }
+
+type externalUpdatesDataSource struct {
+	nullDataSource
+	watcher *externalChangeWatcher
+}
+
+func (e *externalUpdatesDataSource) Close() error {
+	e.watcher.close()
+	return nil
+}
+
+func (e *externalUpdatesDataSource) Start(closeWhenReady chan<- struct{}) {
+	e.watcher.start()
+	close(closeWhenReady)
+}
@@ -2,8 +2,22 @@ package datasource
 
 import (
 	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNullDataSource(t *testing.T) {
@@ -17,3 +31,47 @@ func TestNullDataSource(t *testing.T) {
 
 	assert.Nil(t, d.Close())
 }
+
+func TestExternalUpdatesDataSource(t *testing.T) {
+	t.Run("is the same as null data source if update sink is not the SDK's implementation", func(t *testing.T) {
+		withMockDataSourceUpdates(func(updates *mocks.MockDataSourceUpdates) {
+			assert.Equal(t, NewNullDataSource(), NewExternalUpdatesDataSource(updates, time.Second))
+		})
+	})
+
+	t.Run("starts and stops watching for changes", func(t *testing.T) {
+		store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+		require.NoError(t, store.Init(nil))
+		statusBroadcaster := internal.NewBroadcaster[interfaces.DataSourceStatus]()
+		defer statusBroadcaster.Close()
+		flagChangeBroadcaster := internal.NewBroadcaster[interfaces.FlagChangeEvent]()
+		defer flagChangeBroadcaster.Close()
+		updates := NewDataSourceUpdateSinkImpl(store, nil, statusBroadcaster, flagChangeBroadcaster, 0,
+			ldlog.NewDisabledLoggers())
+		ch := flagChangeBroadcaster.AddListener()
+
+		d := NewExternalUpdatesDataSource(updates, time.Millisecond*10)
+		assert.True(t, IsNullDataSource(d))
+		assert.True(t, d.IsInitialized())
+
+		readyCh := make(chan struct{})
+		d.Start(readyCh)
+		_, ok := <-readyCh
+		assert.False(t, ok)
+		d.(*externalUpdatesDataSource).watcher.initialReadWaitGroup.Wait()
+
+		flag := ldbuilders.NewFlagBuilder("flag1").Version(1).Build()
+		_, _ = store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+		sharedtest.ExpectFlagChangeEvents(t, ch, "flag1")
+
+		assert.Nil(t, d.Close())
+		flag = ldbuilders.NewFlagBuilder("flag1").Version(2).Build()
+		_, _ = store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+		th.AssertNoMoreValues(t, ch, time.Millisecond*50)
+	})
+}
+
+func TestIsNullDataSource(t *testing.T) {
+	assert.True(t, IsNullDataSource(NewNullDataSource()))
+	assert.False(t, IsNullDataSource(&PollingProcessor{}))
+}
@@ -0,0 +1,186 @@
+package datastore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// compressedItemHeader is the prefix of every compressed item, followed by one byte that identifies the
+// compression algorithm. A zero byte can never be the start of an uncompressed item, since the SDK's data
+// kinds serialize items as JSON, so compressed and uncompressed items can be stored side by side.
+var compressedItemHeader = []byte{0, 'L', 'D', 'Z'} //nolint:gochecknoglobals
+
+const compressionAlgorithmGzip byte = 1
+
+var errUnsupportedCompressionFormat = errors.New("unsupported compression format") //nolint:gochecknoglobals
+
+// PersistentDataStoreCompressionConfig contains the compression options for a persistent data store, as
+// configured with ldcomponents.PersistentDataStoreBuilder.
+type PersistentDataStoreCompressionConfig struct {
+	// Enabled is true if items should be compressed when they are written. Compressed items are always
+	// decompressed when they are read, whether or not this is true.
+	Enabled bool
+
+	// MinSize is the serialized size in bytes below which items are not compressed.
+	MinSize int
+}
+
+// compressedPersistentDataStore is a PersistentDataStore decorator that compresses the serialized data of
+// large items with gzip. The version and deleted state are passed to the underlying store unchanged.
+//
+// Reading is always supported, whether or not the item was compressed and whether or not compression is
+// enabled for writing, so data written by SDK instances with and without compression can coexist.
+// However, since a compressed item is no longer JSON, a store implementation that finds the version of an
+// item by deserializing it will not be able to compare versions correctly for compressed items.
+type compressedPersistentDataStore struct {
+	core    subsystems.PersistentDataStore
+	enabled bool
+	minSize int
+	writers sync.Pool
+	readers sync.Pool
+}
+
+// NewCompressedPersistentDataStore creates a PersistentDataStore that compresses the data in another one.
+// This is not visible in the public API; ldcomponents.PersistentDataStoreBuilder always uses it, so that
+// compressed items can be read even if compression is not enabled for writing.
+func NewCompressedPersistentDataStore(
+	core subsystems.PersistentDataStore,
+	config PersistentDataStoreCompressionConfig,
+) subsystems.PersistentDataStore {
+	return &compressedPersistentDataStore{core: core, enabled: config.Enabled, minSize: config.MinSize}
+}
+
+func (s *compressedPersistentDataStore) Init(allData []st.SerializedCollection) error {
+	compressedData := make([]st.SerializedCollection, 0, len(allData))
+	for _, coll := range allData {
+		items := make([]st.KeyedSerializedItemDescriptor, 0, len(coll.Items))
+		for _, item := range coll.Items {
+			items = append(items, st.KeyedSerializedItemDescriptor{Key: item.Key, Item: s.compress(item.Item)})
+		}
+		compressedData = append(compressedData, st.SerializedCollection{Kind: coll.Kind, Items: items})
+	}
+	return s.core.Init(compressedData)
+}
+
+func (s *compressedPersistentDataStore) Get(kind st.DataKind, key string) (st.SerializedItemDescriptor, error) {
+	item, err := s.core.Get(kind, key)
+	if err != nil {
+		return item, err
+	}
+	decompressedItem, err := s.decompress(item)
+	if err != nil {
+		return st.SerializedItemDescriptor{}.NotFound(),
+			fmt.Errorf("unable to decompress %s key %q: %w", kind.GetName(), key, err)
+	}
+	return decompressedItem, nil
+}
+
+func (s *compressedPersistentDataStore) GetAll(kind st.DataKind) ([]st.KeyedSerializedItemDescriptor, error) {
+	items, err := s.core.GetAll(kind)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]st.KeyedSerializedItemDescriptor, 0, len(items))
+	for _, item := range items {
+		decompressedItem, err := s.decompress(item.Item)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decompress %s key %q: %w", kind.GetName(), item.Key, err)
+		}
+		ret = append(ret, st.KeyedSerializedItemDescriptor{Key: item.Key, Item: decompressedItem})
+	}
+	return ret, nil
+}
+
+func (s *compressedPersistentDataStore) Upsert(
+	kind st.DataKind,
+	key string,
+	item st.SerializedItemDescriptor,
+) (bool, error) {
+	return s.core.Upsert(kind, key, s.compress(item))
+}
+
+func (s *compressedPersistentDataStore) IsInitialized() bool {
+	return s.core.IsInitialized()
+}
+
+func (s *compressedPersistentDataStore) IsStoreAvailable() bool {
+	return s.core.IsStoreAvailable()
+}
+
+func (s *compressedPersistentDataStore) Close() error {
+	return s.core.Close()
+}
+
+// SubscribeToChanges passes change notifications through from the underlying store, if it supports them.
+func (s *compressedPersistentDataStore) SubscribeToChanges(onChange func(kind st.DataKind, key string)) error {
+	if notifier, ok := s.core.(subsystems.PersistentDataStoreChangeNotifier); ok {
+		return notifier.SubscribeToChanges(onChange)
+	}
+	return errChangeNotificationsNotSupported
+}
+
+// compress returns a compressed copy of the item, unless compression is disabled, or the item is smaller
+// than the minimum size or would not get any smaller.
+func (s *compressedPersistentDataStore) wrappedStore() subsystems.PersistentDataStore {
+	return s.core
+}
+
+func (s *compressedPersistentDataStore) compress(item st.SerializedItemDescriptor) st.SerializedItemDescriptor {
+	if !s.enabled || item.SerializedItem == nil || len(item.SerializedItem) < s.minSize {
+		return item
+	}
+	var buf bytes.Buffer
+	buf.Write(compressedItemHeader)
+	buf.WriteByte(compressionAlgorithmGzip)
+	w, _ := s.writers.Get().(*gzip.Writer)
+	if w == nil {
+		w = gzip.NewWriter(&buf)
+	} else {
+		w.Reset(&buf)
+	}
+	_, err := w.Write(item.SerializedItem)
+	if err == nil {
+		err = w.Close()
+	}
+	s.writers.Put(w)
+	if err != nil || buf.Len() >= len(item.SerializedItem) {
+		return item
+	}
+	return st.SerializedItemDescriptor{Version: item.Version, Deleted: item.Deleted, SerializedItem: buf.Bytes()}
+}
+
+func (s *compressedPersistentDataStore) decompress(
+	item st.SerializedItemDescriptor,
+) (st.SerializedItemDescriptor, error) {
+	data := item.SerializedItem
+	if !bytes.HasPrefix(data, compressedItemHeader) {
+		return item, nil
+	}
+	data = data[len(compressedItemHeader):]
+	if len(data) == 0 || data[0] != compressionAlgorithmGzip {
+		return item, errUnsupportedCompressionFormat
+	}
+	r, _ := s.readers.Get().(*gzip.Reader)
+	var err error
+	if r == nil {
+		r, err = gzip.NewReader(bytes.NewReader(data[1:]))
+	} else {
+		err = r.Reset(bytes.NewReader(data[1:]))
+	}
+	if err != nil {
+		return item, err
+	}
+	defer s.readers.Put(r)
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return item, err
+	}
+	return st.SerializedItemDescriptor{Version: item.Version, Deleted: item.Deleted, SerializedItem: decompressed}, nil
+}
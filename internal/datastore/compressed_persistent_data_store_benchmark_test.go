@@ -0,0 +1,104 @@
+package datastore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// These benchmarks compare reading and writing a large segment through the persistent data store wrapper,
+// with caching disabled, with and without compression. The "stored-bytes" metric is the size of the data
+// that the underlying store received.
+
+var compressedStoreBenchmarkResultItem ldstoretypes.ItemDescriptor
+
+type compressedStoreBenchmarkCase struct {
+	numIncludedKeys int
+	compress        bool
+}
+
+var compressedStoreBenchmarkCases = []compressedStoreBenchmarkCase{
+	{numIncludedKeys: 100, compress: false},
+	{numIncludedKeys: 100, compress: true},
+	{numIncludedKeys: 10000, compress: false},
+	{numIncludedKeys: 10000, compress: true},
+}
+
+func (bc compressedStoreBenchmarkCase) String() string {
+	mode := "uncompressed"
+	if bc.compress {
+		mode = "compressed"
+	}
+	return fmt.Sprintf("%d keys, %s", bc.numIncludedKeys, mode)
+}
+
+type compressedStoreBenchmarkEnv struct {
+	core    *mocks.MockPersistentDataStore
+	store   subsystems.DataStore
+	segment ldstoretypes.ItemDescriptor
+}
+
+func newCompressedStoreBenchmarkEnv(b *testing.B, bc compressedStoreBenchmarkCase) *compressedStoreBenchmarkEnv {
+	env := &compressedStoreBenchmarkEnv{core: mocks.NewMockPersistentDataStore()}
+	var core subsystems.PersistentDataStore = env.core
+	if bc.compress {
+		core = NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{Enabled: true, MinSize: 1024})
+	}
+	broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+	b.Cleanup(broadcaster.Close)
+	env.store = NewPersistentDataStoreWrapper(core, NewDataStoreUpdateSinkImpl(broadcaster),
+		PersistentDataStoreCacheConfig{}, PersistentDataStoreJournalConfig{}, ldlog.NewDisabledLoggers())
+	b.Cleanup(func() { _ = env.store.Close() })
+
+	keys := make([]string, bc.numIncludedKeys)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user-%08d@example.com", i)
+	}
+	segment := ldbuilders.NewSegmentBuilder("big-segment").Version(1).Included(keys...).Build()
+	env.segment = sharedtest.SegmentDescriptor(segment)
+	return env
+}
+
+func (env *compressedStoreBenchmarkEnv) reportStoredBytes(b *testing.B) {
+	stored := env.core.ForceGet(datakinds.Segments, "big-segment")
+	b.ReportMetric(float64(len(stored.SerializedItem)), "stored-bytes")
+}
+
+func BenchmarkPersistentDataStoreLargeSegmentUpsert(b *testing.B) {
+	for _, bc := range compressedStoreBenchmarkCases {
+		b.Run(bc.String(), func(b *testing.B) {
+			env := newCompressedStoreBenchmarkEnv(b, bc)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				env.segment.Version = i + 1
+				_, _ = env.store.Upsert(datakinds.Segments, "big-segment", env.segment)
+			}
+			b.StopTimer()
+			env.reportStoredBytes(b)
+		})
+	}
+}
+
+func BenchmarkPersistentDataStoreLargeSegmentGet(b *testing.B) {
+	for _, bc := range compressedStoreBenchmarkCases {
+		b.Run(bc.String(), func(b *testing.B) {
+			env := newCompressedStoreBenchmarkEnv(b, bc)
+			_, _ = env.store.Upsert(datakinds.Segments, "big-segment", env.segment)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				compressedStoreBenchmarkResultItem, _ = env.store.Get(datakinds.Segments, "big-segment")
+			}
+			b.StopTimer()
+			env.reportStoredBytes(b)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package datastore
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isCompressedItem(item st.SerializedItemDescriptor) bool {
+	return bytes.HasPrefix(item.SerializedItem, compressedItemHeader)
+}
+
+func TestCompressedPersistentDataStore(t *testing.T) {
+	largeItem := mocks.MockDataItem{Key: "large", Version: 1, Name: strings.Repeat("abcdefgh", 100)}
+	smallItem := mocks.MockDataItem{Key: "small", Version: 2, Name: "x"}
+
+	t.Run("compresses items that are at least the minimum size", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		store := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{Enabled: true, MinSize: 100})
+		require.NoError(t, store.Init(mocks.MakeSerializedMockDataSet(largeItem)))
+		_, err := store.Upsert(mocks.MockData, smallItem.Key, smallItem.ToSerializedItemDescriptor())
+		require.NoError(t, err)
+
+		raw := core.ForceGet(mocks.MockData, largeItem.Key)
+		assert.True(t, isCompressedItem(raw))
+		assert.Less(t, len(raw.SerializedItem), len(largeItem.ToSerializedItemDescriptor().SerializedItem))
+		assert.Equal(t, largeItem.Version, raw.Version)
+		assert.Equal(t, smallItem.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, smallItem.Key))
+
+		for _, item := range []mocks.MockDataItem{largeItem, smallItem} {
+			result, err := store.Get(mocks.MockData, item.Key)
+			require.NoError(t, err)
+			assert.Equal(t, item.ToSerializedItemDescriptor(), result)
+		}
+		items, err := store.GetAll(mocks.MockData)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []st.KeyedSerializedItemDescriptor{
+			{Key: largeItem.Key, Item: largeItem.ToSerializedItemDescriptor()},
+			{Key: smallItem.Key, Item: smallItem.ToSerializedItemDescriptor()},
+		}, items)
+	})
+
+	t.Run("does not compress item if it would not get smaller", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		store := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{Enabled: true})
+		_, err := store.Upsert(mocks.MockData, smallItem.Key, smallItem.ToSerializedItemDescriptor())
+		require.NoError(t, err)
+		assert.Equal(t, smallItem.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, smallItem.Key))
+	})
+
+	t.Run("deleted item without serialized data is passed through", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		store := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{Enabled: true})
+		deleted := st.SerializedItemDescriptor{Version: 3, Deleted: true}
+		_, err := store.Upsert(mocks.MockData, largeItem.Key, deleted)
+		require.NoError(t, err)
+		result, err := store.Get(mocks.MockData, largeItem.Key)
+		require.NoError(t, err)
+		assert.Equal(t, deleted, result)
+	})
+
+	t.Run("does not compress items if compression is not enabled", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		store := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{})
+		require.NoError(t, store.Init(mocks.MakeSerializedMockDataSet(largeItem)))
+		assert.Equal(t, largeItem.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, largeItem.Key))
+	})
+
+	t.Run("reads compressed data if compression is not enabled", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		writer := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{Enabled: true})
+		_, err := writer.Upsert(mocks.MockData, largeItem.Key, largeItem.ToSerializedItemDescriptor())
+		require.NoError(t, err)
+		require.True(t, isCompressedItem(core.ForceGet(mocks.MockData, largeItem.Key)))
+
+		store := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{})
+		result, err := store.Get(mocks.MockData, largeItem.Key)
+		require.NoError(t, err)
+		assert.Equal(t, largeItem.ToSerializedItemDescriptor(), result)
+	})
+
+	t.Run("reads uncompressed data written without compression", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		core.ForceSet(mocks.MockData, largeItem.Key, largeItem.ToSerializedItemDescriptor())
+		store := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{})
+		result, err := store.Get(mocks.MockData, largeItem.Key)
+		require.NoError(t, err)
+		assert.Equal(t, largeItem.ToSerializedItemDescriptor(), result)
+	})
+
+	t.Run("invalid compressed data", func(t *testing.T) {
+		for name, data := range map[string][]byte{
+			"unknown algorithm": append(append([]byte{}, compressedItemHeader...), 99),
+			"no algorithm":      compressedItemHeader,
+			"bad gzip data":     append(append([]byte{}, compressedItemHeader...), compressionAlgorithmGzip, 1, 2, 3),
+		} {
+			t.Run(name, func(t *testing.T) {
+				core := mocks.NewMockPersistentDataStore()
+				core.ForceSet(mocks.MockData, largeItem.Key, st.SerializedItemDescriptor{Version: 1, SerializedItem: data})
+				store := NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{})
+				_, err := store.Get(mocks.MockData, largeItem.Key)
+				assert.Error(t, err)
+				_, err = store.GetAll(mocks.MockData)
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("change notifications are passed through", func(t *testing.T) {
+		store := NewCompressedPersistentDataStore(mocks.NewMockPersistentDataStore(), PersistentDataStoreCompressionConfig{})
+		assert.Equal(t, errChangeNotificationsNotSupported,
+			store.(subsystems.PersistentDataStoreChangeNotifier).SubscribeToChanges(func(st.DataKind, string) {}))
+
+		core := mocks.NewMockPersistentDataStoreWithChangeNotifier()
+		store = NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{})
+		var received []string
+		require.NoError(t, store.(subsystems.PersistentDataStoreChangeNotifier).SubscribeToChanges(
+			func(kind st.DataKind, key string) { received = append(received, key) }))
+		core.NotifyChange(mocks.MockData, largeItem.Key)
+		assert.Equal(t, []string{largeItem.Key}, received)
+	})
+}
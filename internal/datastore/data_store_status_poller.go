@@ -12,49 +12,61 @@ import (
 // dataStoreStatusPoller maintains the "last known available" state for a persistent data store and
 // can poll the store for recovery. This is used only by persistentDataStoreWrapper.
 type dataStoreStatusPoller struct {
-	statusUpdater     func(interfaces.DataStoreStatus)
-	lock              sync.Mutex
-	lastAvailable     bool
-	pollFn            func() bool
-	refreshOnRecovery bool
-	pollCloser        chan struct{}
-	closeOnce         sync.Once
-	loggers           ldlog.Loggers
+	statusUpdater func(interfaces.DataStoreStatus)
+	lock          sync.Mutex
+	lastAvailable bool
+	pollFn        func() (available bool, needsRefresh bool)
+	pollCloser    chan struct{}
+	closed        bool
+	loggers       ldlog.Loggers
 }
 
 const statusPollInterval = time.Millisecond * 500
 
-// newDataStoreStatusPoller creates a new dataStoreStatusPoller. The pollFn should return
-// true if the store is available, false if not.
+// newDataStoreStatusPoller creates a new dataStoreStatusPoller. The pollFn should return true for
+// available if the store is available, false if not; if it is available, needsRefresh indicates whether
+// the store may be missing updates from the time when it was unavailable.
 func newDataStoreStatusPoller(
 	availableNow bool,
-	pollFn func() bool,
+	pollFn func() (available bool, needsRefresh bool),
 	statusUpdater func(interfaces.DataStoreStatus),
-	refreshOnRecovery bool,
 	loggers ldlog.Loggers,
 ) *dataStoreStatusPoller {
 	return &dataStoreStatusPoller{
-		lastAvailable:     availableNow,
-		pollFn:            pollFn,
-		statusUpdater:     statusUpdater,
-		refreshOnRecovery: refreshOnRecovery,
-		loggers:           loggers,
+		lastAvailable: availableNow,
+		pollFn:        pollFn,
+		statusUpdater: statusUpdater,
+		loggers:       loggers,
 	}
 }
 
 // UpdateAvailability signals that the store is now available or unavailable. If that is a change,
 // an update will be sent (and, if the new status is unavailable, it will start polling for recovery).
 func (m *dataStoreStatusPoller) UpdateAvailability(available bool) {
+	m.updateAvailability(available, false)
+}
+
+// RepublishStatus sends the current status again if the store is unavailable. This is used when other
+// information in the status, such as the journal depth, has changed.
+func (m *dataStoreStatusPoller) RepublishStatus() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if !m.lastAvailable && !m.closed {
+		m.statusUpdater(interfaces.DataStoreStatus{Available: false})
+	}
+}
+
+func (m *dataStoreStatusPoller) updateAvailability(available bool, needsRefresh bool) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
-	if available == m.lastAvailable {
+	if available == m.lastAvailable || m.closed {
 		return
 	}
 	m.lastAvailable = available
 	newStatus := interfaces.DataStoreStatus{Available: available}
 	if available {
 		m.loggers.Warn("Persistent store is available again")
-		newStatus.NeedsRefresh = m.refreshOnRecovery
+		newStatus.NeedsRefresh = needsRefresh
 	}
 	m.statusUpdater(newStatus)
 
@@ -66,14 +78,20 @@ func (m *dataStoreStatusPoller) UpdateAvailability(available bool) {
 	}
 }
 
-// Close shuts down all channels and goroutines used by the manager.
+// Close shuts down all channels and goroutines used by the manager. Since status updates are sent while
+// holding the lock, once Close has returned no more updates will be sent, so the caller can safely close
+// whatever the statusUpdater function sends to.
 func (m *dataStoreStatusPoller) Close() {
-	m.closeOnce.Do(func() {
-		if m.pollCloser != nil {
-			close(m.pollCloser)
-			m.pollCloser = nil
-		}
-	})
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.closed {
+		return
+	}
+	m.closed = true
+	if m.pollCloser != nil {
+		close(m.pollCloser)
+		m.pollCloser = nil
+	}
 }
 
 func (m *dataStoreStatusPoller) startStatusPoller() chan struct{} {
@@ -84,8 +102,8 @@ func (m *dataStoreStatusPoller) startStatusPoller() chan struct{} {
 		for {
 			select {
 			case <-ticker.C:
-				if m.pollFn() {
-					m.UpdateAvailability(true)
+				if available, needsRefresh := m.pollFn(); available {
+					m.updateAvailability(true, needsRefresh)
 					return
 				}
 			case <-closer:
@@ -0,0 +1,30 @@
+package datastore
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataStoreStatusPollerSendsNoUpdatesAfterClose(t *testing.T) {
+	var updates []interfaces.DataStoreStatus
+	poller := newDataStoreStatusPoller(
+		true,
+		func() (bool, bool) { return false, false },
+		func(status interfaces.DataStoreStatus) { updates = append(updates, status) },
+		ldlog.NewDisabledLoggers(),
+	)
+
+	poller.UpdateAvailability(false)
+	assert.Equal(t, []interfaces.DataStoreStatus{{Available: false}}, updates)
+
+	poller.Close()
+	poller.RepublishStatus()
+	poller.UpdateAvailability(true)
+	assert.Len(t, updates, 1)
+
+	poller.Close() // closing twice is harmless
+}
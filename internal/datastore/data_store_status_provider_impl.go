@@ -12,6 +12,12 @@ type dataStoreStatusProviderImpl struct {
 	dataStoreUpdates *DataStoreUpdateSinkImpl
 }
 
+// cacheStatsProvider is implemented by data store implementations that have an in-memory cache, such as
+// persistentDataStoreWrapper.
+type cacheStatsProvider interface {
+	getCacheStats() interfaces.DataStoreCacheStats
+}
+
 // NewDataStoreStatusProviderImpl creates the internal implementation of DataStoreStatusProvider.
 func NewDataStoreStatusProviderImpl(
 	store subsystems.DataStore,
@@ -38,3 +44,11 @@ func (d *dataStoreStatusProviderImpl) AddStatusListener() <-chan interfaces.Data
 func (d *dataStoreStatusProviderImpl) RemoveStatusListener(ch <-chan interfaces.DataStoreStatus) {
 	d.dataStoreUpdates.getBroadcaster().RemoveListener(ch)
 }
+
+// GetCacheStats implements interfaces.DataStoreCacheStatsProvider.
+func (d *dataStoreStatusProviderImpl) GetCacheStats() interfaces.DataStoreCacheStats {
+	if cs, ok := d.store.(cacheStatsProvider); ok {
+		return cs.getCacheStats()
+	}
+	return interfaces.DataStoreCacheStats{}
+}
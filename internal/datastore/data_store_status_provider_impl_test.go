@@ -56,6 +56,29 @@ func TestDataStoreStatusProviderImpl(t *testing.T) {
 		})
 	})
 
+	t.Run("GetCacheStats", func(t *testing.T) {
+		dataStoreStatusProviderTest(func(p dataStoreStatusProviderTestParams) {
+			statsProvider, ok := p.dataStoreStatusProvider.(interfaces.DataStoreCacheStatsProvider)
+			require.True(t, ok)
+			assert.Equal(t, interfaces.DataStoreCacheStats{}, statsProvider.GetCacheStats())
+		})
+
+		broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+		defer broadcaster.Close()
+		dataStoreUpdates := NewDataStoreUpdateSinkImpl(broadcaster)
+		store := NewPersistentDataStoreWrapper(mocks.NewMockPersistentDataStore(), dataStoreUpdates,
+			PersistentDataStoreCacheConfig{TTL: -1}, PersistentDataStoreJournalConfig{}, sharedtest.NewTestLoggers())
+		defer store.Close()
+		_, err := store.Get(mocks.MockData, "key")
+		require.NoError(t, err)
+		_, err = store.Get(mocks.MockData, "key")
+		require.NoError(t, err)
+
+		provider := NewDataStoreStatusProviderImpl(store, dataStoreUpdates).(interfaces.DataStoreCacheStatsProvider)
+		assert.Equal(t, interfaces.DataStoreCacheStats{Enabled: true, Hits: 1, Misses: 1, Items: 1},
+			provider.GetCacheStats())
+	})
+
 	t.Run("listeners", func(t *testing.T) {
 		dataStoreStatusProviderTest(func(p dataStoreStatusProviderTestParams) {
 			ch1 := p.dataStoreStatusProvider.AddStatusListener()
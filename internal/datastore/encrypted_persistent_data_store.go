@@ -0,0 +1,274 @@
+package datastore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+var errEncryptionKeyIDRequired = errors.New("encryption key ID must not be empty") //nolint:gochecknoglobals
+
+// EncryptionKey is one of the keys used by NewEncryptedPersistentDataStore. The ID is stored along with
+// each encrypted item, so that the right key can be found to decrypt it.
+type EncryptionKey struct {
+	ID  string
+	Key []byte
+}
+
+// EncryptedPersistentDataStoreConfig contains the options for NewEncryptedPersistentDataStore, as
+// configured with ldcomponents.EncryptedPersistentDataStore.
+type EncryptedPersistentDataStoreConfig struct {
+	// PrimaryKey is the key used to encrypt all items that are written to the store. It is also used for
+	// decryption.
+	PrimaryKey EncryptionKey
+
+	// DecryptionKeys are other keys that items in the store may have been encrypted with, such as the
+	// previous primary key after a key rotation.
+	DecryptionKeys []EncryptionKey
+
+	// AllowUnencrypted means that items in the store that are not encrypted are returned as they are,
+	// rather than causing an error. This is used when encryption is enabled for a store that already
+	// contains data.
+	AllowUnencrypted bool
+}
+
+// encryptedPersistentDataStore is a PersistentDataStore decorator that encrypts the serialized data of
+// each item with AES-GCM. The version and deleted state are not encrypted: they are passed to the
+// underlying store as usual, and are also included as plain JSON properties alongside the encrypted data,
+// so that a store implementation that can only find the version of an item by deserializing it with the
+// data kind will still work (this works with the SDK's own data kinds, which use JSON). The kind, key,
+// version, and deleted state are used as additional authenticated data, so an encrypted item cannot be
+// moved to a different key or given a different version without detection.
+//
+// Items are always encrypted with the primary key. Items that were encrypted with an older key are not
+// rewritten when they are read, because the store would not accept an update with the same version;
+// instead, they are re-encrypted with the primary key the next time they are written, which for all items
+// is no later than the next time the SDK receives a full data set from LaunchDarkly. ldstoremigrate.Rewrite
+// can be used to re-encrypt all items at once.
+type encryptedPersistentDataStore struct {
+	core             subsystems.PersistentDataStore
+	primaryKeyID     string
+	ciphers          map[string]cipher.AEAD
+	allowUnencrypted bool
+}
+
+// encryptedItemEnvelope is the JSON representation of an encrypted item.
+type encryptedItemEnvelope struct {
+	Key       string             `json:"key"`
+	Version   int                `json:"version"`
+	Deleted   bool               `json:"deleted,omitempty"`
+	Encrypted *encryptedItemData `json:"ldEncrypted"`
+}
+
+type encryptedItemData struct {
+	KeyID string `json:"keyId"`
+	Data  []byte `json:"data"` // nonce followed by ciphertext; encoded in base64 by encoding/json
+}
+
+// persistentDataStoreDecorator is implemented by the SDK's PersistentDataStore decorators, so that
+// IsEncryptedPersistentDataStore can see through them.
+type persistentDataStoreDecorator interface {
+	wrappedStore() subsystems.PersistentDataStore
+}
+
+// IsEncryptedPersistentDataStore returns true if the store, or any store that it decorates, is one that was
+// created by NewEncryptedPersistentDataStore. This is determined from the built stores rather than from the
+// configuration, so it does not matter how the encryption builder was nested inside other builders.
+func IsEncryptedPersistentDataStore(store subsystems.PersistentDataStore) bool {
+	for store != nil {
+		if _, ok := store.(*encryptedPersistentDataStore); ok {
+			return true
+		}
+		decorator, ok := store.(persistentDataStoreDecorator)
+		if !ok {
+			return false
+		}
+		store = decorator.wrappedStore()
+	}
+	return false
+}
+
+// NewEncryptedPersistentDataStore creates a PersistentDataStore that encrypts the data in another one.
+// This is not visible in the public API; it is always called through
+// ldcomponents.EncryptedPersistentDataStore().
+func NewEncryptedPersistentDataStore(
+	core subsystems.PersistentDataStore,
+	config EncryptedPersistentDataStoreConfig,
+) (subsystems.PersistentDataStore, error) {
+	store := &encryptedPersistentDataStore{
+		core:             core,
+		primaryKeyID:     config.PrimaryKey.ID,
+		ciphers:          make(map[string]cipher.AEAD),
+		allowUnencrypted: config.AllowUnencrypted,
+	}
+	for _, key := range append([]EncryptionKey{config.PrimaryKey}, config.DecryptionKeys...) {
+		if key.ID == "" {
+			return nil, errEncryptionKeyIDRequired
+		}
+		if _, ok := store.ciphers[key.ID]; ok {
+			return nil, fmt.Errorf("encryption key ID %q was used more than once", key.ID)
+		}
+		block, err := aes.NewCipher(key.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", key.ID, err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		store.ciphers[key.ID] = gcm
+	}
+	return store, nil
+}
+
+func (s *encryptedPersistentDataStore) Init(allData []st.SerializedCollection) error {
+	encryptedData := make([]st.SerializedCollection, 0, len(allData))
+	for _, coll := range allData {
+		items := make([]st.KeyedSerializedItemDescriptor, 0, len(coll.Items))
+		for _, item := range coll.Items {
+			encryptedItem, err := s.encrypt(coll.Kind, item.Key, item.Item)
+			if err != nil {
+				return err
+			}
+			items = append(items, st.KeyedSerializedItemDescriptor{Key: item.Key, Item: encryptedItem})
+		}
+		encryptedData = append(encryptedData, st.SerializedCollection{Kind: coll.Kind, Items: items})
+	}
+	return s.core.Init(encryptedData)
+}
+
+func (s *encryptedPersistentDataStore) Get(kind st.DataKind, key string) (st.SerializedItemDescriptor, error) {
+	item, err := s.core.Get(kind, key)
+	if err != nil || item.SerializedItem == nil {
+		return item, err
+	}
+	decryptedItem, err := s.decrypt(kind, key, item)
+	if err != nil {
+		return st.SerializedItemDescriptor{}.NotFound(), err
+	}
+	return decryptedItem, nil
+}
+
+func (s *encryptedPersistentDataStore) GetAll(kind st.DataKind) ([]st.KeyedSerializedItemDescriptor, error) {
+	items, err := s.core.GetAll(kind)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]st.KeyedSerializedItemDescriptor, 0, len(items))
+	for _, item := range items {
+		if item.Item.SerializedItem != nil {
+			decryptedItem, err := s.decrypt(kind, item.Key, item.Item)
+			if err != nil {
+				return nil, err
+			}
+			item.Item = decryptedItem
+		}
+		ret = append(ret, item)
+	}
+	return ret, nil
+}
+
+func (s *encryptedPersistentDataStore) Upsert(
+	kind st.DataKind,
+	key string,
+	item st.SerializedItemDescriptor,
+) (bool, error) {
+	encryptedItem, err := s.encrypt(kind, key, item)
+	if err != nil {
+		return false, err
+	}
+	return s.core.Upsert(kind, key, encryptedItem)
+}
+
+func (s *encryptedPersistentDataStore) IsInitialized() bool {
+	return s.core.IsInitialized()
+}
+
+func (s *encryptedPersistentDataStore) IsStoreAvailable() bool {
+	return s.core.IsStoreAvailable()
+}
+
+func (s *encryptedPersistentDataStore) Close() error {
+	return s.core.Close()
+}
+
+// SubscribeToChanges passes change notifications through from the underlying store, if it supports them.
+func (s *encryptedPersistentDataStore) SubscribeToChanges(onChange func(kind st.DataKind, key string)) error {
+	if notifier, ok := s.core.(subsystems.PersistentDataStoreChangeNotifier); ok {
+		return notifier.SubscribeToChanges(onChange)
+	}
+	return errChangeNotificationsNotSupported
+}
+
+func (s *encryptedPersistentDataStore) wrappedStore() subsystems.PersistentDataStore {
+	return s.core
+}
+
+func (s *encryptedPersistentDataStore) encrypt(
+	kind st.DataKind,
+	key string,
+	item st.SerializedItemDescriptor,
+) (st.SerializedItemDescriptor, error) {
+	if item.SerializedItem == nil {
+		return item, nil
+	}
+	gcm := s.ciphers[s.primaryKeyID]
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(item.SerializedItem)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return item, err
+	}
+	envelope := encryptedItemEnvelope{
+		Key:     key,
+		Version: item.Version,
+		Deleted: item.Deleted,
+		Encrypted: &encryptedItemData{
+			KeyID: s.primaryKeyID,
+			Data:  gcm.Seal(nonce, nonce, item.SerializedItem, encryptionAdditionalData(kind, key, item)),
+		},
+	}
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return item, err
+	}
+	return st.SerializedItemDescriptor{Version: item.Version, Deleted: item.Deleted, SerializedItem: data}, nil
+}
+
+func (s *encryptedPersistentDataStore) decrypt(
+	kind st.DataKind,
+	key string,
+	item st.SerializedItemDescriptor,
+) (st.SerializedItemDescriptor, error) {
+	var envelope encryptedItemEnvelope
+	if err := json.Unmarshal(item.SerializedItem, &envelope); err != nil || envelope.Encrypted == nil {
+		if s.allowUnencrypted {
+			return item, nil
+		}
+		return item, fmt.Errorf("data store contains unencrypted data for %s key %q", kind.GetName(), key)
+	}
+	gcm, ok := s.ciphers[envelope.Encrypted.KeyID]
+	if !ok {
+		return item, fmt.Errorf("%s key %q was encrypted with unknown key ID %q", kind.GetName(), key,
+			envelope.Encrypted.KeyID)
+	}
+	data := envelope.Encrypted.Data
+	if len(data) < gcm.NonceSize() {
+		return item, fmt.Errorf("invalid encrypted data for %s key %q", kind.GetName(), key)
+	}
+	metadata := st.SerializedItemDescriptor{Version: envelope.Version, Deleted: envelope.Deleted}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():],
+		encryptionAdditionalData(kind, key, metadata))
+	if err != nil {
+		return item, fmt.Errorf("unable to decrypt %s key %q: %w", kind.GetName(), key, err)
+	}
+	return st.SerializedItemDescriptor{Version: item.Version, Deleted: item.Deleted, SerializedItem: plaintext}, nil
+}
+
+func encryptionAdditionalData(kind st.DataKind, key string, item st.SerializedItemDescriptor) []byte {
+	return []byte(fmt.Sprintf("%s\x00%s\x00%d\x00%t", kind.GetName(), key, item.Version, item.Deleted))
+}
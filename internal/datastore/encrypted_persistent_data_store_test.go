@@ -0,0 +1,211 @@
+package datastore
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testEncryptionKey1 = EncryptionKey{ID: "key1", Key: []byte("0123456789abcdef")}
+	testEncryptionKey2 = EncryptionKey{ID: "key2", Key: []byte("fedcba9876543210fedcba9876543210")}
+)
+
+func makeEncryptedStore(
+	t *testing.T,
+	core subsystems.PersistentDataStore,
+	config EncryptedPersistentDataStoreConfig,
+) subsystems.PersistentDataStore {
+	store, err := NewEncryptedPersistentDataStore(core, config)
+	require.NoError(t, err)
+	return store
+}
+
+func parseEncryptedItemEnvelope(t *testing.T, item st.SerializedItemDescriptor) encryptedItemEnvelope {
+	var envelope encryptedItemEnvelope
+	require.NoError(t, json.Unmarshal(item.SerializedItem, &envelope))
+	require.NotNil(t, envelope.Encrypted)
+	return envelope
+}
+
+func TestEncryptedPersistentDataStore(t *testing.T) {
+	item1 := mocks.MockDataItem{Key: "key1", Version: 1, Name: "secret-name"}
+	item2 := mocks.MockDataItem{Key: "key2", Version: 2, Name: "other-secret"}
+
+	t.Run("encrypts items but not metadata", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		store := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		require.NoError(t, store.Init(mocks.MakeSerializedMockDataSet(item1)))
+		_, err := store.Upsert(mocks.MockData, item2.Key, item2.ToSerializedItemDescriptor())
+		require.NoError(t, err)
+
+		for _, item := range []mocks.MockDataItem{item1, item2} {
+			raw := core.ForceGet(mocks.MockData, item.Key)
+			assert.Equal(t, item.Version, raw.Version)
+			assert.False(t, bytes.Contains(raw.SerializedItem, []byte(item.Name)))
+			envelope := parseEncryptedItemEnvelope(t, raw)
+			assert.Equal(t, item.Key, envelope.Key)
+			assert.Equal(t, item.Version, envelope.Version)
+			assert.Equal(t, testEncryptionKey1.ID, envelope.Encrypted.KeyID)
+
+			result, err := store.Get(mocks.MockData, item.Key)
+			require.NoError(t, err)
+			assert.Equal(t, item.ToSerializedItemDescriptor(), result)
+		}
+
+		items, err := store.GetAll(mocks.MockData)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []st.KeyedSerializedItemDescriptor{
+			{Key: item1.Key, Item: item1.ToSerializedItemDescriptor()},
+			{Key: item2.Key, Item: item2.ToSerializedItemDescriptor()},
+		}, items)
+	})
+
+	t.Run("SDK data kinds can read the version of an encrypted item", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		core.SetPersistOnlyAsString(true) // so the store must deserialize the item to compare versions
+		store := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		flag := ldbuilders.NewFlagBuilder("flagkey").Version(3).Build()
+		_, err := store.Upsert(datakinds.Features, flag.Key,
+			st.SerializedItemDescriptor{Version: 3, SerializedItem: datakinds.Features.Serialize(st.ItemDescriptor{
+				Version: 3, Item: &flag})})
+		require.NoError(t, err)
+		_, err = store.Upsert(datakinds.Features, "deleted", st.SerializedItemDescriptor{Version: 4, Deleted: true,
+			SerializedItem: datakinds.Features.Serialize(st.ItemDescriptor{Version: 4})})
+		require.NoError(t, err)
+
+		olderFlag := ldbuilders.NewFlagBuilder(flag.Key).Version(2).Build()
+		updated, err := store.Upsert(datakinds.Features, flag.Key,
+			st.SerializedItemDescriptor{Version: 2, SerializedItem: datakinds.Features.Serialize(st.ItemDescriptor{
+				Version: 2, Item: &olderFlag})})
+		require.NoError(t, err)
+		assert.False(t, updated)
+
+		item, err := datakinds.Features.Deserialize(core.ForceGet(datakinds.Features, flag.Key).SerializedItem)
+		require.NoError(t, err)
+		assert.Equal(t, 3, item.Version)
+		item, err = datakinds.Features.Deserialize(core.ForceGet(datakinds.Features, "deleted").SerializedItem)
+		require.NoError(t, err)
+		assert.Equal(t, st.ItemDescriptor{Version: 4}, item)
+	})
+
+	t.Run("deleted item without serialized data is passed through", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		store := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		deleted := st.SerializedItemDescriptor{Version: 2, Deleted: true}
+		_, err := store.Upsert(mocks.MockData, item1.Key, deleted)
+		require.NoError(t, err)
+
+		result, err := store.Get(mocks.MockData, item1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, deleted, result)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		oldStore := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		require.NoError(t, oldStore.Init(mocks.MakeSerializedMockDataSet(item1, item2)))
+
+		newStore := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey2,
+			DecryptionKeys: []EncryptionKey{testEncryptionKey1}})
+		result, err := newStore.Get(mocks.MockData, item1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, item1.ToSerializedItemDescriptor(), result)
+
+		item1v2 := mocks.MockDataItem{Key: item1.Key, Version: 2, Name: item1.Name}
+		_, err = newStore.Upsert(mocks.MockData, item1.Key, item1v2.ToSerializedItemDescriptor())
+		require.NoError(t, err)
+		assert.Equal(t, testEncryptionKey2.ID,
+			parseEncryptedItemEnvelope(t, core.ForceGet(mocks.MockData, item1.Key)).Encrypted.KeyID)
+		assert.Equal(t, testEncryptionKey1.ID,
+			parseEncryptedItemEnvelope(t, core.ForceGet(mocks.MockData, item2.Key)).Encrypted.KeyID)
+
+		_, err = oldStore.Get(mocks.MockData, item1.Key) // old store doesn't know the new key
+		assert.Error(t, err)
+	})
+
+	t.Run("metadata cannot be altered", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		store := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		_, err := store.Upsert(mocks.MockData, item1.Key, item1.ToSerializedItemDescriptor())
+		require.NoError(t, err)
+
+		raw := core.ForceGet(mocks.MockData, item1.Key)
+		envelope := parseEncryptedItemEnvelope(t, raw)
+		envelope.Version = 99
+		data, _ := json.Marshal(envelope)
+		core.ForceSet(mocks.MockData, item1.Key, st.SerializedItemDescriptor{Version: 99, SerializedItem: data})
+		core.ForceSet(mocks.MockData, item2.Key, raw) // same encrypted data under a different key
+
+		_, err = store.Get(mocks.MockData, item1.Key)
+		assert.Error(t, err)
+		_, err = store.Get(mocks.MockData, item2.Key)
+		assert.Error(t, err)
+		_, err = store.GetAll(mocks.MockData)
+		assert.Error(t, err)
+	})
+
+	t.Run("unencrypted data", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		core.ForceSet(mocks.MockData, item1.Key, item1.ToSerializedItemDescriptor())
+
+		store := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		_, err := store.Get(mocks.MockData, item1.Key)
+		assert.Error(t, err)
+
+		store = makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1,
+			AllowUnencrypted: true})
+		result, err := store.Get(mocks.MockData, item1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, item1.ToSerializedItemDescriptor(), result)
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		for _, config := range []EncryptedPersistentDataStoreConfig{
+			{PrimaryKey: EncryptionKey{ID: "", Key: testEncryptionKey1.Key}},
+			{PrimaryKey: EncryptionKey{ID: "bad", Key: []byte("too short")}},
+			{PrimaryKey: testEncryptionKey1, DecryptionKeys: []EncryptionKey{{ID: testEncryptionKey1.ID, Key: testEncryptionKey2.Key}}},
+		} {
+			_, err := NewEncryptedPersistentDataStore(core, config)
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("change notifications are passed through", func(t *testing.T) {
+		store := makeEncryptedStore(t, mocks.NewMockPersistentDataStore(),
+			EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		assert.Equal(t, errChangeNotificationsNotSupported,
+			store.(subsystems.PersistentDataStoreChangeNotifier).SubscribeToChanges(func(st.DataKind, string) {}))
+
+		core := mocks.NewMockPersistentDataStoreWithChangeNotifier()
+		store = makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+		var received []string
+		require.NoError(t, store.(subsystems.PersistentDataStoreChangeNotifier).SubscribeToChanges(
+			func(kind st.DataKind, key string) { received = append(received, key) }))
+		core.NotifyChange(mocks.MockData, item1.Key)
+		assert.Equal(t, []string{item1.Key}, received)
+	})
+}
+
+func TestIsEncryptedPersistentDataStore(t *testing.T) {
+	core := mocks.NewMockPersistentDataStore()
+	encrypted := makeEncryptedStore(t, core, EncryptedPersistentDataStoreConfig{PrimaryKey: testEncryptionKey1})
+
+	assert.False(t, IsEncryptedPersistentDataStore(core))
+	assert.True(t, IsEncryptedPersistentDataStore(encrypted))
+	assert.True(t, IsEncryptedPersistentDataStore(
+		NewCompressedPersistentDataStore(encrypted, PersistentDataStoreCompressionConfig{})))
+	assert.False(t, IsEncryptedPersistentDataStore(
+		NewCompressedPersistentDataStore(core, PersistentDataStoreCompressionConfig{})))
+}
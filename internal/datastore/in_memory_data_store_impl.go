@@ -2,71 +2,98 @@ package datastore
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
 )
 
-// inMemoryDataStore is a memory based DataStore implementation, backed by a lock-striped map.
+// inMemoryDataStoreShardCount is the number of separate maps that the items of each data kind are divided
+// into. An update copies only one of these maps, so the cost of an update is proportional to the number of
+// items divided by this number.
+const inMemoryDataStoreShardCount = 32
+
+// inMemoryDataStore is a memory based DataStore implementation that readers can use without any locking.
 //
 // Implementation notes:
 //
+// The data is held in an immutable snapshot, which is published with an atomic pointer swap. Get, GetAll,
+// and IsInitialized just load the current snapshot, so they never wait for each other or for a writer, no
+// matter how often the data source applies updates. Writers are serialized by writeLock.
+//
+// Init builds an entirely new snapshot. Upsert builds a new snapshot that shares almost everything with
+// the old one: it copies only the top-level map of data kinds, the array of shards for the affected kind,
+// and the one shard that contains the key. The items of each kind are divided among shards by a hash of
+// the key, so an update copies on average 1/inMemoryDataStoreShardCount of the items of that kind.
+//
 // We deliberately do not use a defer pattern to manage the lock in these methods. Using defer adds a small but
-// consistent overhead, and these store methods may be called with very high frequency (at least in the case of
-// Get and IsInitialized). To make it safe to hold a lock without deferring the unlock, we must ensure that
+// consistent overhead. To make it safe to hold a lock without deferring the unlock, we must ensure that
 // there is only one return point from each method, and that there is no operation that could possibly cause a
 // panic after the lock has been acquired. See notes on performance in CONTRIBUTING.md.
 type inMemoryDataStore struct {
-	allData       map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor
+	snapshot  atomic.Value // always holds an *inMemoryDataSnapshot
+	writeLock sync.Mutex
+	loggers   ldlog.Loggers
+}
+
+// inMemoryDataSnapshot is the complete state of an inMemoryDataStore at some point in time. Once it has
+// been published, it is never modified.
+type inMemoryDataSnapshot struct {
+	allData       map[ldstoretypes.DataKind]*inMemoryKindData
 	isInitialized bool
-	sync.RWMutex
-	loggers ldlog.Loggers
+}
+
+// inMemoryKindData contains all of the items of one data kind. A nil shard is equivalent to an empty one.
+type inMemoryKindData struct {
+	shards [inMemoryDataStoreShardCount]map[string]ldstoretypes.ItemDescriptor
+	count  int
 }
 
 // NewInMemoryDataStore creates an instance of the in-memory data store. This is not part of the public API; it is
 // always called through ldcomponents.inMemoryDataStore().
 func NewInMemoryDataStore(loggers ldlog.Loggers) subsystems.DataStore {
-	return &inMemoryDataStore{
-		allData:       make(map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor),
-		isInitialized: false,
-		loggers:       loggers,
-	}
+	store := &inMemoryDataStore{loggers: loggers}
+	store.snapshot.Store(&inMemoryDataSnapshot{
+		allData: make(map[ldstoretypes.DataKind]*inMemoryKindData),
+	})
+	return store
 }
 
 func (store *inMemoryDataStore) Init(allData []ldstoretypes.Collection) error {
-	store.Lock()
-
-	store.allData = make(map[ldstoretypes.DataKind]map[string]ldstoretypes.ItemDescriptor)
-
+	newSnapshot := &inMemoryDataSnapshot{
+		allData:       make(map[ldstoretypes.DataKind]*inMemoryKindData, len(allData)),
+		isInitialized: true,
+	}
 	for _, coll := range allData {
-		items := make(map[string]ldstoretypes.ItemDescriptor)
+		kindData := &inMemoryKindData{}
 		for _, item := range coll.Items {
-			items[item.Key] = item.Item
+			shard := &kindData.shards[inMemoryDataStoreShardIndex(item.Key)]
+			if *shard == nil {
+				*shard = make(map[string]ldstoretypes.ItemDescriptor)
+			}
+			if _, exists := (*shard)[item.Key]; !exists {
+				kindData.count++
+			}
+			(*shard)[item.Key] = item.Item
 		}
-		store.allData[coll.Kind] = items
+		newSnapshot.allData[coll.Kind] = kindData
 	}
 
-	store.isInitialized = true
-
-	store.Unlock()
+	store.writeLock.Lock()
+	store.snapshot.Store(newSnapshot)
+	store.writeLock.Unlock()
 
 	return nil
 }
 
 func (store *inMemoryDataStore) Get(kind ldstoretypes.DataKind, key string) (ldstoretypes.ItemDescriptor, error) {
-	store.RLock()
-
-	var coll map[string]ldstoretypes.ItemDescriptor
 	var item ldstoretypes.ItemDescriptor
 	var ok bool
-	coll, ok = store.allData[kind]
-	if ok {
-		item, ok = coll[key]
+	if kindData := store.currentSnapshot().allData[kind]; kindData != nil {
+		item, ok = kindData.shards[inMemoryDataStoreShardIndex(key)][key]
 	}
 
-	store.RUnlock()
-
 	if ok {
 		return item, nil
 	}
@@ -77,20 +104,16 @@ func (store *inMemoryDataStore) Get(kind ldstoretypes.DataKind, key string) (lds
 }
 
 func (store *inMemoryDataStore) GetAll(kind ldstoretypes.DataKind) ([]ldstoretypes.KeyedItemDescriptor, error) {
-	store.RLock()
-
 	var itemsOut []ldstoretypes.KeyedItemDescriptor
-	if itemsMap, ok := store.allData[kind]; ok {
-		if len(itemsMap) > 0 {
-			itemsOut = make([]ldstoretypes.KeyedItemDescriptor, 0, len(itemsMap))
-			for key, item := range itemsMap {
+	if kindData := store.currentSnapshot().allData[kind]; kindData != nil && kindData.count > 0 {
+		itemsOut = make([]ldstoretypes.KeyedItemDescriptor, 0, kindData.count)
+		for _, shard := range kindData.shards {
+			for key, item := range shard {
 				itemsOut = append(itemsOut, ldstoretypes.KeyedItemDescriptor{Key: key, Item: item})
 			}
 		}
 	}
 
-	store.RUnlock()
-
 	return itemsOut, nil
 }
 
@@ -99,38 +122,52 @@ func (store *inMemoryDataStore) Upsert(
 	key string,
 	newItem ldstoretypes.ItemDescriptor,
 ) (bool, error) {
-	store.Lock()
+	shardIndex := inMemoryDataStoreShardIndex(key)
 
-	var coll map[string]ldstoretypes.ItemDescriptor
-	var ok bool
-	shouldUpdate := true
-	updated := false
-	if coll, ok = store.allData[kind]; ok {
-		if item, ok := coll[key]; ok {
-			if item.Version >= newItem.Version {
-				shouldUpdate = false
-			}
+	store.writeLock.Lock()
+
+	oldSnapshot := store.currentSnapshot()
+	oldKindData := oldSnapshot.allData[kind]
+	updated := true
+	if oldKindData != nil {
+		if item, ok := oldKindData.shards[shardIndex][key]; ok && item.Version >= newItem.Version {
+			updated = false
 		}
-	} else {
-		store.allData[kind] = map[string]ldstoretypes.ItemDescriptor{key: newItem}
-		shouldUpdate = false // because we already initialized the map with the new item
-		updated = true
 	}
-	if shouldUpdate {
-		coll[key] = newItem
-		updated = true
+	if updated {
+		newKindData := &inMemoryKindData{}
+		if oldKindData != nil {
+			*newKindData = *oldKindData // copies the array of shards, but not the shards themselves
+		}
+		oldShard := newKindData.shards[shardIndex]
+		newShard := make(map[string]ldstoretypes.ItemDescriptor, len(oldShard)+1)
+		for k, v := range oldShard {
+			newShard[k] = v
+		}
+		if _, exists := oldShard[key]; !exists {
+			newKindData.count++
+		}
+		newShard[key] = newItem
+		newKindData.shards[shardIndex] = newShard
+
+		newSnapshot := &inMemoryDataSnapshot{
+			allData:       make(map[ldstoretypes.DataKind]*inMemoryKindData, len(oldSnapshot.allData)+1),
+			isInitialized: oldSnapshot.isInitialized,
+		}
+		for k, v := range oldSnapshot.allData {
+			newSnapshot.allData[k] = v
+		}
+		newSnapshot.allData[kind] = newKindData
+		store.snapshot.Store(newSnapshot)
 	}
 
-	store.Unlock()
+	store.writeLock.Unlock()
 
 	return updated, nil
 }
 
 func (store *inMemoryDataStore) IsInitialized() bool {
-	store.RLock()
-	ret := store.isInitialized
-	store.RUnlock()
-	return ret
+	return store.currentSnapshot().isInitialized
 }
 
 func (store *inMemoryDataStore) IsStatusMonitoringEnabled() bool {
@@ -140,3 +177,18 @@ func (store *inMemoryDataStore) IsStatusMonitoringEnabled() bool {
 func (store *inMemoryDataStore) Close() error {
 	return nil
 }
+
+func (store *inMemoryDataStore) currentSnapshot() *inMemoryDataSnapshot {
+	return store.snapshot.Load().(*inMemoryDataSnapshot)
+}
+
+// inMemoryDataStoreShardIndex computes the shard for a key with the 32-bit FNV-1a hash, which we implement
+// here rather than using hash/fnv so that Get does not allocate.
+func inMemoryDataStoreShardIndex(key string) int {
+	hash := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= 16777619
+	}
+	return int(hash % inMemoryDataStoreShardCount)
+}
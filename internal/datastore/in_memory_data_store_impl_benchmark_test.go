@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -235,3 +236,109 @@ func BenchmarkInMemoryStoreUpsertNewSegment(b *testing.B) {
 			sharedtest.SegmentDescriptor(*env.targetSegmentCopy))
 	})
 }
+
+// The following benchmarks measure reads from many goroutines at once, which is how the store is used when
+// an application evaluates flags under heavy load. The "WhileUpserting" variants also run a goroutine that
+// continuously updates a flag and a segment, as a data source would when it is receiving many patches, so
+// that they show whether readers are slowed down by writers.
+
+func benchmarkInMemoryStoreParallel(
+	b *testing.B,
+	cases []inMemoryStoreBenchmarkCase,
+	withConcurrentUpserts bool,
+	benchmarkAction func(*inMemoryStoreBenchmarkEnv, inMemoryStoreBenchmarkCase),
+) {
+	env := newInMemoryStoreBenchmarkEnv()
+	for _, bc := range cases {
+		env.setUp(bc)
+
+		b.Run(fmt.Sprintf("%+v", bc), func(b *testing.B) {
+			var stopWriter chan struct{}
+			var writerDone sync.WaitGroup
+			if withConcurrentUpserts {
+				stopWriter = make(chan struct{})
+				writerDone.Add(1)
+				go runInMemoryStoreBenchmarkWriter(env, stopWriter, &writerDone)
+			}
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					benchmarkAction(env, bc)
+				}
+			})
+			b.StopTimer()
+			if withConcurrentUpserts {
+				close(stopWriter)
+				writerDone.Wait()
+			}
+		})
+		env.tearDown()
+	}
+}
+
+// runInMemoryStoreBenchmarkWriter updates the target flag and segment until stopped. It uses its own copies
+// of the items, since the benchmark actions may read the ones in env.
+func runInMemoryStoreBenchmarkWriter(env *inMemoryStoreBenchmarkEnv, stop <-chan struct{}, done *sync.WaitGroup) {
+	defer done.Done()
+	flag := *env.targetFlagCopy
+	segment := *env.targetSegmentCopy
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		flag.Version++
+		_, _ = env.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+		segment.Version++
+		_, _ = env.store.Upsert(datakinds.Segments, segment.Key, sharedtest.SegmentDescriptor(segment))
+	}
+}
+
+func BenchmarkInMemoryStoreParallelGetFlag(b *testing.B) {
+	dataKind := datakinds.Features
+	benchmarkInMemoryStoreParallel(b, inMemoryStoreBenchmarkCases, false, func(env *inMemoryStoreBenchmarkEnv, bc inMemoryStoreBenchmarkCase) {
+		_, _ = env.store.Get(dataKind, env.targetFlagKey)
+	})
+}
+
+func BenchmarkInMemoryStoreParallelGetFlagWhileUpserting(b *testing.B) {
+	dataKind := datakinds.Features
+	benchmarkInMemoryStoreParallel(b, inMemoryStoreBenchmarkCases, true, func(env *inMemoryStoreBenchmarkEnv, bc inMemoryStoreBenchmarkCase) {
+		_, _ = env.store.Get(dataKind, env.targetFlagKey)
+	})
+}
+
+func BenchmarkInMemoryStoreParallelGetSegmentWhileUpserting(b *testing.B) {
+	dataKind := datakinds.Segments
+	benchmarkInMemoryStoreParallel(b, inMemoryStoreBenchmarkCases, true, func(env *inMemoryStoreBenchmarkEnv, bc inMemoryStoreBenchmarkCase) {
+		_, _ = env.store.Get(dataKind, env.targetSegmentKey)
+	})
+}
+
+func BenchmarkInMemoryStoreParallelGetUnknownFlagWhileUpserting(b *testing.B) {
+	dataKind := datakinds.Features
+	benchmarkInMemoryStoreParallel(b, inMemoryStoreBenchmarkCases, true, func(env *inMemoryStoreBenchmarkEnv, bc inMemoryStoreBenchmarkCase) {
+		_, _ = env.store.Get(dataKind, env.unknownKey)
+	})
+}
+
+func BenchmarkInMemoryStoreParallelGetAllFlags(b *testing.B) {
+	dataKind := datakinds.Features
+	benchmarkInMemoryStoreParallel(b, inMemoryStoreBenchmarkCases, false, func(env *inMemoryStoreBenchmarkEnv, bc inMemoryStoreBenchmarkCase) {
+		_, _ = env.store.GetAll(dataKind)
+	})
+}
+
+func BenchmarkInMemoryStoreParallelGetAllFlagsWhileUpserting(b *testing.B) {
+	dataKind := datakinds.Features
+	benchmarkInMemoryStoreParallel(b, inMemoryStoreBenchmarkCases, true, func(env *inMemoryStoreBenchmarkEnv, bc inMemoryStoreBenchmarkCase) {
+		_, _ = env.store.GetAll(dataKind)
+	})
+}
+
+func BenchmarkInMemoryStoreParallelIsInitializedWhileUpserting(b *testing.B) {
+	benchmarkInMemoryStoreParallel(b, inMemoryStoreBenchmarkCases, true, func(env *inMemoryStoreBenchmarkEnv, bc inMemoryStoreBenchmarkCase) {
+		_ = env.store.IsInitialized()
+	})
+}
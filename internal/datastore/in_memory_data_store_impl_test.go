@@ -3,11 +3,13 @@ package datastore
 import (
 	"fmt"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
@@ -23,6 +25,7 @@ func TestInMemoryDataStore(t *testing.T) {
 	t.Run("GetAll", testInMemoryDataStoreGetAll)
 	t.Run("Upsert", testInMemoryDataStoreUpsert)
 	t.Run("Delete", testInMemoryDataStoreDelete)
+	t.Run("concurrent reads and writes", testInMemoryDataStoreConcurrentReadsAndWrites)
 
 	t.Run("IsStatusMonitoringEnabled", func(t *testing.T) {
 		assert.False(t, makeInMemoryStore().IsStatusMonitoringEnabled())
@@ -305,3 +308,57 @@ func testInMemoryDataStoreDelete(t *testing.T) {
 		})
 	})
 }
+
+func testInMemoryDataStoreConcurrentReadsAndWrites(t *testing.T) {
+	// This test is mainly meaningful when run with -race. Readers must always see a consistent set of items,
+	// and concurrent upserts must neither be lost nor allowed to replace a newer version.
+	const numKeys, numWriters, numVersions = 200, 4, 20
+	store := makeInMemoryStore()
+	require.NoError(t, store.Init(sharedtest.NewDataSetBuilder().Build()))
+
+	var writers, readers sync.WaitGroup
+	stopReaders := make(chan struct{})
+	for i := 0; i < numWriters; i++ {
+		writers.Add(1)
+		go func(writer int) {
+			defer writers.Done()
+			for version := 1; version <= numVersions; version++ {
+				for k := 0; k < numKeys; k++ {
+					key := fmt.Sprintf("flag%d", k)
+					flag := ldbuilders.NewFlagBuilder(key).Version(version*numWriters + writer).Build()
+					_, _ = store.Upsert(datakinds.Features, key, sharedtest.FlagDescriptor(flag))
+				}
+			}
+		}(i)
+	}
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		previousCount := 0
+		for {
+			select {
+			case <-stopReaders:
+				return
+			default:
+			}
+			items, _ := store.GetAll(datakinds.Features)
+			assert.GreaterOrEqual(t, len(items), previousCount)
+			previousCount = len(items)
+			for _, item := range items {
+				assert.Equal(t, item.Key, item.Item.Item.(*ldmodel.FeatureFlag).Key)
+			}
+		}
+	}()
+	writers.Wait()
+	close(stopReaders)
+	readers.Wait()
+
+	items, err := store.GetAll(datakinds.Features)
+	require.NoError(t, err)
+	assert.Len(t, items, numKeys)
+	for k := 0; k < numKeys; k++ {
+		result, err := store.Get(datakinds.Features, fmt.Sprintf("flag%d", k))
+		require.NoError(t, err)
+		assert.Equal(t, numVersions*numWriters+numWriters-1, result.Version)
+	}
+}
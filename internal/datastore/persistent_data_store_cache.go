@@ -0,0 +1,220 @@
+package datastore
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+)
+
+// persistentDataStoreCache is the in-memory cache used by persistentDataStoreWrapper.
+//
+// Every entry expires after the same TTL, unless the TTL is negative, in which case entries never expire.
+// If maxStaleness is nonzero, an expired entry is kept for that much longer so that it can still be served
+// while the wrapper refreshes it in the background. If refreshAhead is nonzero, the wrapper is told that an
+// entry should be refreshed when it is that close to expiring. Entries that are past their expiration time
+// plus maxStaleness are removed lazily, when they are read or when they reach the end of the LRU list.
+//
+// If maxItems or maxBytes is nonzero, the least recently used entries are evicted whenever necessary to
+// stay within those limits. The size of an entry is provided by the caller; the wrapper uses the size of
+// the serialized data as an estimate. An entry that is bigger than maxBytes by itself is not cached at all.
+type persistentDataStoreCache struct {
+	ttl          time.Duration
+	maxStaleness time.Duration
+	refreshAhead time.Duration
+	maxItems     int
+	maxBytes     int64
+	entries      map[string]*list.Element
+	lru          *list.List // front is most recently used
+	totalBytes   int64
+	hits         int64
+	misses       int64
+	staleHits    int64
+	evictions    int64
+	timeNow      func() time.Time // can be changed by tests
+	lock         sync.Mutex
+}
+
+type persistentDataStoreCacheEntry struct {
+	key       string
+	value     interface{}
+	size      int64
+	refreshAt time.Time // zero if the entry never expires or refresh-ahead is disabled
+	expiresAt time.Time // zero if the entry never expires
+	removeAt  time.Time // zero if the entry never expires
+}
+
+// cacheEntryState describes the result of a cache lookup.
+type cacheEntryState int
+
+const (
+	// cacheEntryMissing means there was no entry, or it was too stale to use.
+	cacheEntryMissing cacheEntryState = iota
+	// cacheEntryFresh means the entry has not expired.
+	cacheEntryFresh
+	// cacheEntryRefreshDue means the entry has not expired, but is close enough to expiring that it
+	// should be refreshed in the background.
+	cacheEntryRefreshDue
+	// cacheEntryStale means the entry has expired, but is within the maximum staleness, so it can be
+	// used while it is refreshed in the background.
+	cacheEntryStale
+)
+
+func newPersistentDataStoreCache(config PersistentDataStoreCacheConfig) *persistentDataStoreCache {
+	c := &persistentDataStoreCache{
+		ttl:      config.TTL,
+		maxItems: config.MaxItems,
+		maxBytes: config.MaxBytes,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		timeNow:  time.Now,
+	}
+	if c.ttl > 0 {
+		// Staleness and refresh-ahead only make sense if entries expire. A refresh-ahead window that is
+		// as long as the TTL would mean refreshing on every read, so we don't allow that.
+		c.maxStaleness = config.MaxStaleness
+		if config.RefreshAhead < c.ttl {
+			c.refreshAhead = config.RefreshAhead
+		}
+	}
+	return c
+}
+
+// isBounded returns true if the cache may evict entries before they expire.
+func (c *persistentDataStoreCache) isBounded() bool {
+	return c.maxItems > 0 || c.maxBytes > 0
+}
+
+// get returns the cached value for a key, marking it as recently used, and counts a hit or a miss. The
+// value is only valid if the state is not cacheEntryMissing.
+func (c *persistentDataStoreCache) get(key string) (interface{}, cacheEntryState) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	value, state := c.getInternal(key, true)
+	switch state {
+	case cacheEntryMissing:
+		c.misses++
+	case cacheEntryStale:
+		c.hits++
+		c.staleHits++
+	default:
+		c.hits++
+	}
+	return value, state
+}
+
+// peek returns the cached value for a key without affecting the LRU order or the statistics. This is for
+// the wrapper's own bookkeeping, as opposed to queries from the SDK.
+func (c *persistentDataStoreCache) peek(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	value, state := c.getInternal(key, false)
+	return value, state != cacheEntryMissing
+}
+
+func (c *persistentDataStoreCache) getInternal(key string, markUsed bool) (interface{}, cacheEntryState) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, cacheEntryMissing
+	}
+	entry := elem.Value.(*persistentDataStoreCacheEntry)
+	now := c.timeNow()
+	if c.isRemovable(entry, now) {
+		c.removeElement(elem)
+		return nil, cacheEntryMissing
+	}
+	if markUsed {
+		c.lru.MoveToFront(elem)
+	}
+	switch {
+	case !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt):
+		return entry.value, cacheEntryStale
+	case !entry.refreshAt.IsZero() && !now.Before(entry.refreshAt):
+		return entry.value, cacheEntryRefreshDue
+	default:
+		return entry.value, cacheEntryFresh
+	}
+}
+
+// set adds or replaces an entry, resetting its expiration time.
+func (c *persistentDataStoreCache) set(key string, value interface{}, size int64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+	entry := &persistentDataStoreCacheEntry{key: key, value: value, size: size}
+	if c.ttl > 0 {
+		entry.expiresAt = c.timeNow().Add(c.ttl)
+		entry.removeAt = entry.expiresAt.Add(c.maxStaleness)
+		if c.refreshAhead > 0 {
+			entry.refreshAt = entry.expiresAt.Add(-c.refreshAhead)
+		}
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.totalBytes += size
+	c.removeExpiredAndEvict()
+}
+
+func (c *persistentDataStoreCache) delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// flush removes all entries. It does not reset the statistics.
+func (c *persistentDataStoreCache) flush() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.totalBytes = 0
+}
+
+func (c *persistentDataStoreCache) getStats() interfaces.DataStoreCacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return interfaces.DataStoreCacheStats{
+		Enabled:   true,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		StaleHits: c.staleHits,
+		Evictions: c.evictions,
+		Items:     len(c.entries),
+		Bytes:     c.totalBytes,
+	}
+}
+
+// removeExpiredAndEvict is called after adding an entry. Removable entries at the end of the LRU list are
+// removed first, since that costs nothing; then, if we are still over a limit, the least recently used
+// entries are evicted.
+func (c *persistentDataStoreCache) removeExpiredAndEvict() {
+	now := c.timeNow()
+	for elem := c.lru.Back(); elem != nil; elem = c.lru.Back() {
+		if !c.isRemovable(elem.Value.(*persistentDataStoreCacheEntry), now) {
+			break
+		}
+		c.removeElement(elem)
+	}
+	for (c.maxItems > 0 && c.lru.Len() > c.maxItems) || (c.maxBytes > 0 && c.totalBytes > c.maxBytes) {
+		c.removeElement(c.lru.Back())
+		c.evictions++
+	}
+}
+
+func (c *persistentDataStoreCache) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*persistentDataStoreCacheEntry)
+	delete(c.entries, entry.key)
+	c.totalBytes -= entry.size
+}
+
+// isRemovable returns true if the entry has expired and is also past the maximum staleness.
+func (c *persistentDataStoreCache) isRemovable(entry *persistentDataStoreCacheEntry, now time.Time) bool {
+	return !entry.removeAt.IsZero() && !now.Before(entry.removeAt)
+}
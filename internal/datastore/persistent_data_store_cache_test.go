@@ -0,0 +1,236 @@
+package datastore
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testClock is a clock for cache tests that only moves when the test advances it.
+type testClock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Now()}
+}
+
+func (c *testClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	c.now = c.now.Add(d)
+	c.lock.Unlock()
+}
+
+func TestPersistentDataStoreCacheGetAndSet(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1})
+
+	_, state := c.get("a")
+	assert.Equal(t, cacheEntryMissing, state)
+
+	c.set("a", "value-a", 10)
+	value, state := c.get("a")
+	assert.Equal(t, cacheEntryFresh, state)
+	assert.Equal(t, "value-a", value)
+
+	c.set("a", "value-a2", 20)
+	value, state = c.get("a")
+	assert.Equal(t, cacheEntryFresh, state)
+	assert.Equal(t, "value-a2", value)
+
+	assert.Equal(t, interfaces.DataStoreCacheStats{Enabled: true, Hits: 2, Misses: 1, Items: 1, Bytes: 20}, c.getStats())
+}
+
+func TestPersistentDataStoreCachePeekDoesNotAffectStatsOrOrder(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1, MaxItems: 2})
+	c.set("a", "value-a", 1)
+	c.set("b", "value-b", 1)
+
+	value, ok := c.peek("a")
+	assert.True(t, ok)
+	assert.Equal(t, "value-a", value)
+	_, ok = c.peek("c")
+	assert.False(t, ok)
+	assert.Equal(t, int64(0), c.getStats().Hits)
+	assert.Equal(t, int64(0), c.getStats().Misses)
+
+	c.set("c", "value-c", 1) // "a" is still the least recently used, since peek didn't count as a use
+	_, ok = c.peek("a")
+	assert.False(t, ok)
+	_, ok = c.peek("b")
+	assert.True(t, ok)
+}
+
+func TestPersistentDataStoreCacheDelete(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1})
+	c.set("a", "value-a", 5)
+	c.set("b", "value-b", 7)
+
+	c.delete("a")
+	c.delete("unknown")
+	_, ok := c.peek("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.getStats().Items)
+	assert.Equal(t, int64(7), c.getStats().Bytes)
+}
+
+func TestPersistentDataStoreCacheFlush(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1})
+	c.set("a", "value-a", 5)
+	_, _ = c.get("a")
+
+	c.flush()
+	_, ok := c.peek("a")
+	assert.False(t, ok)
+	assert.Equal(t, interfaces.DataStoreCacheStats{Enabled: true, Hits: 1}, c.getStats())
+}
+
+func TestPersistentDataStoreCacheEvictsLeastRecentlyUsedItemsOverMaxItems(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1, MaxItems: 2})
+	assert.True(t, c.isBounded())
+
+	c.set("a", "value-a", 1)
+	c.set("b", "value-b", 1)
+	_, _ = c.get("a") // now "b" is the least recently used
+	c.set("c", "value-c", 1)
+
+	_, ok := c.peek("b")
+	assert.False(t, ok)
+	_, ok = c.peek("a")
+	assert.True(t, ok)
+	_, ok = c.peek("c")
+	assert.True(t, ok)
+
+	stats := c.getStats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Items)
+}
+
+func TestPersistentDataStoreCacheEvictsLeastRecentlyUsedItemsOverMaxBytes(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1, MaxBytes: 100})
+	assert.True(t, c.isBounded())
+
+	c.set("a", "value-a", 40)
+	c.set("b", "value-b", 40)
+	c.set("c", "value-c", 40)
+
+	_, ok := c.peek("a")
+	assert.False(t, ok)
+
+	stats := c.getStats()
+	assert.Equal(t, int64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Items)
+	assert.Equal(t, int64(80), stats.Bytes)
+}
+
+func TestPersistentDataStoreCacheDoesNotStoreItemLargerThanMaxBytes(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1, MaxBytes: 100})
+	c.set("a", "value-a", 40)
+	c.set("a", "value-a2", 101) // replaces the old value, but the new one is not cached either
+
+	_, ok := c.peek("a")
+	assert.False(t, ok)
+
+	stats := c.getStats()
+	assert.Equal(t, int64(0), stats.Evictions)
+	assert.Equal(t, 0, stats.Items)
+	assert.Equal(t, int64(0), stats.Bytes)
+}
+
+func TestPersistentDataStoreCacheExpiresItemsAfterTTL(t *testing.T) {
+	ttl := time.Minute
+	clock := newTestClock()
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: ttl})
+	c.timeNow = clock.Now
+	assert.False(t, c.isBounded())
+
+	c.set("a", "value-a", 1)
+	_, state := c.get("a")
+	assert.Equal(t, cacheEntryFresh, state)
+
+	clock.Advance(ttl)
+	_, state = c.get("a")
+	assert.Equal(t, cacheEntryMissing, state)
+
+	stats := c.getStats()
+	assert.Equal(t, int64(0), stats.Evictions) // expiration is not counted as an eviction
+	assert.Equal(t, 0, stats.Items)
+}
+
+func TestPersistentDataStoreCacheRemovesExpiredItemsBeforeEvicting(t *testing.T) {
+	ttl := time.Minute
+	clock := newTestClock()
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: ttl, MaxItems: 2})
+	c.timeNow = clock.Now
+
+	c.set("a", "value-a", 1)
+	c.set("b", "value-b", 1)
+	clock.Advance(ttl)
+	c.set("c", "value-c", 1)
+
+	stats := c.getStats()
+	assert.Equal(t, int64(0), stats.Evictions)
+	assert.Equal(t, 1, stats.Items)
+}
+
+func TestPersistentDataStoreCacheReturnsStaleItemsWithinMaxStaleness(t *testing.T) {
+	ttl := time.Minute
+	clock := newTestClock()
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: ttl, MaxStaleness: ttl * 5})
+	c.timeNow = clock.Now
+
+	c.set("a", "value-a", 1)
+	clock.Advance(ttl)
+	value, state := c.get("a")
+	assert.Equal(t, cacheEntryStale, state)
+	assert.Equal(t, "value-a", value)
+
+	clock.Advance(ttl * 5)
+	_, state = c.get("a")
+	assert.Equal(t, cacheEntryMissing, state)
+
+	stats := c.getStats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.StaleHits)
+	assert.Equal(t, int64(1), stats.Misses)
+}
+
+func TestPersistentDataStoreCacheReportsRefreshDueWithinRefreshAheadWindow(t *testing.T) {
+	ttl := time.Minute
+	clock := newTestClock()
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: ttl, RefreshAhead: ttl / 2})
+	c.timeNow = clock.Now
+
+	c.set("a", "value-a", 1)
+	clock.Advance(ttl/2 - time.Millisecond)
+	_, state := c.get("a")
+	assert.Equal(t, cacheEntryFresh, state)
+
+	clock.Advance(time.Millisecond)
+	value, state := c.get("a")
+	assert.Equal(t, cacheEntryRefreshDue, state)
+	assert.Equal(t, "value-a", value)
+
+	c.set("a", "value-a2", 1)
+	_, state = c.get("a")
+	assert.Equal(t, cacheEntryFresh, state)
+}
+
+func TestPersistentDataStoreCacheIgnoresStalenessOptionsIfEntriesDoNotExpire(t *testing.T) {
+	c := newPersistentDataStoreCache(PersistentDataStoreCacheConfig{TTL: -1, MaxStaleness: time.Hour,
+		RefreshAhead: time.Hour})
+
+	c.set("a", "value-a", 1)
+	_, state := c.get("a")
+	assert.Equal(t, cacheEntryFresh, state)
+}
@@ -0,0 +1,281 @@
+package datastore
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// maxJournalLineSize is the largest line that we will accept in a journal file. Each line contains one
+// serialized flag or segment, which could be large.
+const maxJournalLineSize = 64 * 1024 * 1024
+
+// PersistentDataStoreJournalConfig contains the write-behind journal options for a persistent data store,
+// as configured with ldcomponents.PersistentDataStoreBuilder.
+type PersistentDataStoreJournalConfig struct {
+	// MaxItems is the maximum number of distinct items that the journal can hold. Zero means there is no
+	// journal.
+	MaxItems int
+
+	// FilePath is the path of a file where the journal is also saved, so that it survives a restart. An
+	// empty string means the journal is only kept in memory. The file contains the same serialized data
+	// that the wrapper passes to the store, so it is never encrypted; ldcomponents does not allow a file
+	// to be used with an encrypted store.
+	FilePath string
+}
+
+// persistentDataStoreJournal records updates that could not be written to a persistent store because it
+// was unavailable, so that persistentDataStoreWrapper can write them once the store is available again.
+//
+// Only the latest version of each item is kept, since an older version would be overwritten anyway. If the
+// journal is full, updates for items that it does not already contain are dropped, and the journal is
+// marked as incomplete so that the wrapper knows it must ask the data source for a full refresh instead.
+type persistentDataStoreJournal struct {
+	maxItems   int
+	filePath   string
+	entries    map[string]persistentDataStoreJournalEntry
+	incomplete bool
+	loggers    ldlog.Loggers
+	lock       sync.Mutex
+}
+
+type persistentDataStoreJournalEntry struct {
+	kind st.DataKind
+	key  string
+	item st.SerializedItemDescriptor
+}
+
+// journalFileLine is the JSON representation of one entry in the journal file.
+type journalFileLine struct {
+	Kind    string `json:"kind"`
+	Key     string `json:"key"`
+	Version int    `json:"version"`
+	Deleted bool   `json:"deleted,omitempty"`
+	Item    string `json:"item,omitempty"`
+}
+
+// newPersistentDataStoreJournal creates a journal. If there is a journal file, any entries already in it
+// are loaded; kinds is used to find the data kinds of those entries by name.
+func newPersistentDataStoreJournal(
+	config PersistentDataStoreJournalConfig,
+	kinds []st.DataKind,
+	loggers ldlog.Loggers,
+) *persistentDataStoreJournal {
+	j := &persistentDataStoreJournal{
+		maxItems: config.MaxItems,
+		filePath: config.FilePath,
+		entries:  make(map[string]persistentDataStoreJournalEntry),
+		loggers:  loggers,
+	}
+	if j.filePath != "" {
+		j.load(kinds)
+	}
+	return j
+}
+
+// add records an update. If there is already a newer version of the item in the journal, it is ignored.
+func (j *persistentDataStoreJournal) add(kind st.DataKind, key string, item st.SerializedItemDescriptor) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	entryKey := dataStoreCacheKey(kind, key)
+	if old, ok := j.entries[entryKey]; ok {
+		if old.item.Version >= item.Version {
+			return
+		}
+	} else if len(j.entries) >= j.maxItems {
+		if !j.incomplete {
+			j.loggers.Warnf("Write-behind journal is full (%d items); further updates will be lost until the"+
+				" data is refreshed from LaunchDarkly", j.maxItems)
+		}
+		j.incomplete = true
+		return
+	}
+	entry := persistentDataStoreJournalEntry{kind: kind, key: key, item: item}
+	j.entries[entryKey] = entry
+	if j.filePath != "" {
+		j.appendToFile(entry)
+	}
+}
+
+// markIncomplete records that an update was lost without being added to the journal.
+func (j *persistentDataStoreJournal) markIncomplete() {
+	j.lock.Lock()
+	j.incomplete = true
+	j.lock.Unlock()
+}
+
+// isIncomplete returns true if any updates were lost since the journal was last reset.
+func (j *persistentDataStoreJournal) isIncomplete() bool {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.incomplete
+}
+
+// depth returns the number of items in the journal.
+func (j *persistentDataStoreJournal) depth() int {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return len(j.entries)
+}
+
+// getEntries returns a snapshot of the journal in ascending version order, which is the order in which
+// the updates should be replayed.
+func (j *persistentDataStoreJournal) getEntries() []persistentDataStoreJournalEntry {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	ret := make([]persistentDataStoreJournalEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		ret = append(ret, e)
+	}
+	sort.Slice(ret, func(i, k int) bool {
+		if ret[i].item.Version != ret[k].item.Version {
+			return ret[i].item.Version < ret[k].item.Version
+		}
+		return dataStoreCacheKey(ret[i].kind, ret[i].key) < dataStoreCacheKey(ret[k].kind, ret[k].key)
+	})
+	return ret
+}
+
+// remove deletes entries that have been written to the store. An entry is kept if a newer version of
+// the item was added since the snapshot was taken.
+func (j *persistentDataStoreJournal) remove(written []persistentDataStoreJournalEntry) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	for _, e := range written {
+		entryKey := dataStoreCacheKey(e.kind, e.key)
+		if current, ok := j.entries[entryKey]; ok && current.item.Version <= e.item.Version {
+			delete(j.entries, entryKey)
+		}
+	}
+	if j.filePath != "" {
+		j.rewriteFile()
+	}
+}
+
+// reset discards all entries and clears the incomplete state. This is done when the entire data set has
+// been written to the store, or when a full refresh has been requested from the data source.
+func (j *persistentDataStoreJournal) reset() {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	hadEntries := len(j.entries) != 0
+	j.entries = make(map[string]persistentDataStoreJournalEntry)
+	j.incomplete = false
+	if j.filePath != "" && hadEntries {
+		j.rewriteFile()
+	}
+}
+
+func (j *persistentDataStoreJournal) load(kinds []st.DataKind) {
+	file, err := os.Open(j.filePath) //nolint:gosec // G304: ok to read file into variable
+	if err != nil {
+		if !os.IsNotExist(err) {
+			j.loggers.Errorf("Unable to read write-behind journal file %q: %s", j.filePath, err)
+		}
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	kindsByName := make(map[string]st.DataKind, len(kinds))
+	for _, kind := range kinds {
+		kindsByName[kind.GetName()] = kind
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxJournalLineSize)
+	for scanner.Scan() {
+		var line journalFileLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			// A partial line at the end of the file could be left by a crash while writing; skip it
+			j.loggers.Warnf("Ignoring invalid data in write-behind journal file %q: %s", j.filePath, err)
+			continue
+		}
+		kind, ok := kindsByName[line.Kind]
+		if !ok {
+			j.loggers.Warnf("Ignoring unknown data kind %q in write-behind journal file %q", line.Kind, j.filePath)
+			continue
+		}
+		item := st.SerializedItemDescriptor{Version: line.Version, Deleted: line.Deleted}
+		if line.Item != "" {
+			item.SerializedItem = []byte(line.Item)
+		}
+		entryKey := dataStoreCacheKey(kind, line.Key)
+		if old, ok := j.entries[entryKey]; !ok || old.item.Version < item.Version {
+			j.entries[entryKey] = persistentDataStoreJournalEntry{kind: kind, key: line.Key, item: item}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		j.loggers.Errorf("Unable to read write-behind journal file %q: %s", j.filePath, err)
+	}
+	if len(j.entries) != 0 {
+		j.loggers.Infof("Loaded %d updates from write-behind journal file", len(j.entries))
+	}
+}
+
+// appendToFile adds one entry to the journal file. The file can contain several versions of the same item;
+// the latest one wins when it is loaded, and the file is compacted whenever entries are removed.
+func (j *persistentDataStoreJournal) appendToFile(entry persistentDataStoreJournalEntry) {
+	file, err := os.OpenFile(j.filePath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		j.loggers.Errorf("Unable to write to write-behind journal file %q: %s", j.filePath, err)
+		return
+	}
+	_, err = file.Write(makeJournalFileLine(entry))
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		j.loggers.Errorf("Unable to write to write-behind journal file %q: %s", j.filePath, err)
+	}
+}
+
+// rewriteFile replaces the journal file with the current entries. It writes a temporary file first, so
+// that a crash cannot leave a partially written journal.
+func (j *persistentDataStoreJournal) rewriteFile() {
+	if len(j.entries) == 0 {
+		if err := os.Remove(j.filePath); err != nil && !os.IsNotExist(err) {
+			j.loggers.Errorf("Unable to remove write-behind journal file %q: %s", j.filePath, err)
+		}
+		return
+	}
+	tempPath := j.filePath + ".tmp"
+	file, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		j.loggers.Errorf("Unable to write to write-behind journal file %q: %s", tempPath, err)
+		return
+	}
+	writer := bufio.NewWriter(file)
+	for _, entry := range j.entries {
+		_, _ = writer.Write(makeJournalFileLine(entry))
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tempPath, j.filePath)
+	}
+	if err != nil {
+		j.loggers.Errorf("Unable to write to write-behind journal file %q: %s", j.filePath, err)
+	}
+}
+
+func makeJournalFileLine(entry persistentDataStoreJournalEntry) []byte {
+	line, _ := json.Marshal(journalFileLine{
+		Kind:    entry.kind.GetName(),
+		Key:     entry.key,
+		Version: entry.item.Version,
+		Deleted: entry.item.Deleted,
+		Item:    string(entry.item.SerializedItem),
+	})
+	return append(line, '\n')
+}
@@ -0,0 +1,135 @@
+package datastore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var journalTestKinds = []st.DataKind{mocks.MockData, mocks.MockOtherData}
+
+func makeJournalTestItem(key string, version int) st.SerializedItemDescriptor {
+	return mocks.MockDataItem{Key: key, Version: version}.ToSerializedItemDescriptor()
+}
+
+func journalEntryKeysAndVersions(entries []persistentDataStoreJournalEntry) []string {
+	ret := make([]string, 0, len(entries))
+	for _, e := range entries {
+		ret = append(ret, fmt.Sprintf("%s:%d", dataStoreCacheKey(e.kind, e.key), e.item.Version))
+	}
+	return ret
+}
+
+func TestPersistentDataStoreJournalKeepsLatestVersionOfEachItem(t *testing.T) {
+	j := newPersistentDataStoreJournal(PersistentDataStoreJournalConfig{MaxItems: 10}, journalTestKinds,
+		ldlogtest.NewMockLog().Loggers)
+
+	j.add(mocks.MockData, "a", makeJournalTestItem("a", 2))
+	j.add(mocks.MockData, "a", makeJournalTestItem("a", 1))
+	j.add(mocks.MockData, "a", makeJournalTestItem("a", 3))
+
+	assert.Equal(t, 1, j.depth())
+	assert.Equal(t, []string{"mock1:a:3"}, journalEntryKeysAndVersions(j.getEntries()))
+	assert.False(t, j.isIncomplete())
+}
+
+func TestPersistentDataStoreJournalReturnsEntriesInVersionOrder(t *testing.T) {
+	j := newPersistentDataStoreJournal(PersistentDataStoreJournalConfig{MaxItems: 10}, journalTestKinds,
+		ldlogtest.NewMockLog().Loggers)
+
+	j.add(mocks.MockData, "a", makeJournalTestItem("a", 5))
+	j.add(mocks.MockOtherData, "b", makeJournalTestItem("b", 1))
+	j.add(mocks.MockData, "c", makeJournalTestItem("c", 3))
+	j.add(mocks.MockData, "b", makeJournalTestItem("b", 3))
+
+	assert.Equal(t, []string{"mock2:b:1", "mock1:b:3", "mock1:c:3", "mock1:a:5"}, journalEntryKeysAndVersions(j.getEntries()))
+}
+
+func TestPersistentDataStoreJournalIsIncompleteWhenFull(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	j := newPersistentDataStoreJournal(PersistentDataStoreJournalConfig{MaxItems: 2}, journalTestKinds,
+		mockLog.Loggers)
+
+	j.add(mocks.MockData, "a", makeJournalTestItem("a", 1))
+	j.add(mocks.MockData, "b", makeJournalTestItem("b", 1))
+	assert.False(t, j.isIncomplete())
+
+	j.add(mocks.MockData, "c", makeJournalTestItem("c", 1))
+	assert.True(t, j.isIncomplete())
+	assert.Equal(t, 2, j.depth())
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "journal is full")
+
+	j.add(mocks.MockData, "a", makeJournalTestItem("a", 2)) // an item that is already in the journal can be updated
+	assert.Equal(t, []string{"mock1:b:1", "mock1:a:2"}, journalEntryKeysAndVersions(j.getEntries()))
+
+	j.reset()
+	assert.False(t, j.isIncomplete())
+	assert.Equal(t, 0, j.depth())
+}
+
+func TestPersistentDataStoreJournalRemoveKeepsNewerVersions(t *testing.T) {
+	j := newPersistentDataStoreJournal(PersistentDataStoreJournalConfig{MaxItems: 10}, journalTestKinds,
+		ldlogtest.NewMockLog().Loggers)
+
+	j.add(mocks.MockData, "a", makeJournalTestItem("a", 1))
+	j.add(mocks.MockData, "b", makeJournalTestItem("b", 1))
+	written := j.getEntries()
+	j.add(mocks.MockData, "b", makeJournalTestItem("b", 2))
+
+	j.remove(written)
+	assert.Equal(t, []string{"mock1:b:2"}, journalEntryKeysAndVersions(j.getEntries()))
+}
+
+func TestPersistentDataStoreJournalFile(t *testing.T) {
+	th.WithTempDir(func(dir string) {
+		filePath := filepath.Join(dir, "journal")
+		config := PersistentDataStoreJournalConfig{MaxItems: 10, FilePath: filePath}
+
+		j1 := newPersistentDataStoreJournal(config, journalTestKinds, ldlogtest.NewMockLog().Loggers)
+		assert.Equal(t, 0, j1.depth())
+		j1.add(mocks.MockData, "a", makeJournalTestItem("a", 1))
+		j1.add(mocks.MockData, "a", makeJournalTestItem("a", 2))
+		j1.add(mocks.MockOtherData, "b", st.SerializedItemDescriptor{Version: 3, Deleted: true})
+
+		j2 := newPersistentDataStoreJournal(config, journalTestKinds, ldlogtest.NewMockLog().Loggers)
+		entries := j2.getEntries()
+		require.Len(t, entries, 2)
+		assert.Equal(t, makeJournalTestItem("a", 2), entries[0].item)
+		assert.Equal(t, mocks.MockOtherData, entries[1].kind)
+		assert.Equal(t, st.SerializedItemDescriptor{Version: 3, Deleted: true}, entries[1].item)
+
+		j2.remove(entries[:1])
+		j3 := newPersistentDataStoreJournal(config, journalTestKinds, ldlogtest.NewMockLog().Loggers)
+		assert.Equal(t, []string{"mock2:b:3"}, journalEntryKeysAndVersions(j3.getEntries()))
+
+		j3.reset()
+		_, err := os.Stat(filePath)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestPersistentDataStoreJournalFileWithInvalidData(t *testing.T) {
+	data := `{"kind":"mock1","key":"a","version":1,"item":"a,1"}
+{"kind":"unknown","key":"b","version":1}
+{"kind":"mock1","key":"c","vers`
+	th.WithTempFileData([]byte(data), func(filePath string) {
+		mockLog := ldlogtest.NewMockLog()
+		config := PersistentDataStoreJournalConfig{MaxItems: 10, FilePath: filePath}
+		j := newPersistentDataStoreJournal(config, journalTestKinds, mockLog.Loggers)
+
+		assert.Equal(t, []string{"mock1:a:1"}, journalEntryKeysAndVersions(j.getEntries()))
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, "unknown data kind")
+		mockLog.AssertMessageMatch(t, true, ldlog.Warn, "invalid data")
+	})
+}
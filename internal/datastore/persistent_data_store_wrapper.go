@@ -1,73 +1,117 @@
 package datastore
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
 
-	"github.com/patrickmn/go-cache"
 	"golang.org/x/exp/slices"
 	"golang.org/x/sync/singleflight"
 )
 
+var errChangeNotificationsNotSupported = errors.New( //nolint:gochecknoglobals
+	"persistent data store does not support change notifications")
+
+// PersistentDataStoreCacheConfig contains the caching options for a persistent data store, as configured
+// with ldcomponents.PersistentDataStoreBuilder.
+type PersistentDataStoreCacheConfig struct {
+	// TTL is the cache TTL. Zero means there is no cache; a negative value means entries never expire.
+	TTL time.Duration
+
+	// MaxItems is the maximum number of cache entries, or zero for no limit.
+	MaxItems int
+
+	// MaxBytes is the maximum estimated size of the cache entries, or zero for no limit.
+	MaxBytes int64
+
+	// MaxStaleness is how long an expired entry can still be used while it is refreshed in the background.
+	// Zero means that expired entries are never used.
+	MaxStaleness time.Duration
+
+	// RefreshAhead is how long before expiration a background refresh of an entry is started, if the entry
+	// is read during that time. Zero means that entries are never refreshed before they expire.
+	RefreshAhead time.Duration
+}
+
 // persistentDataStoreWrapper is the implementation of DataStore that we use for all persistent data stores.
 type persistentDataStoreWrapper struct {
 	core             subsystems.PersistentDataStore
 	dataStoreUpdates subsystems.DataStoreUpdateSink
 	statusPoller     *dataStoreStatusPoller
-	cache            *cache.Cache
+	cache            *persistentDataStoreCache
 	cacheTTL         time.Duration
+	journal          *persistentDataStoreJournal
 	requests         singleflight.Group
+	refreshing       map[string]struct{}
+	refreshWaitGroup sync.WaitGroup
+	refreshLock      sync.Mutex
+	closed           bool
 	loggers          ldlog.Loggers
 	inited           bool
+	initCheckedTime  time.Time
 	initLock         sync.RWMutex
 }
 
-const initCheckedKey = "$initChecked"
-
 // NewPersistentDataStoreWrapper creates the implementation of DataStore that we use for all persistent data
 // stores. This is not visible in the public API; it is always called through ldcomponents.PersistentDataStore().
 func NewPersistentDataStoreWrapper(
 	core subsystems.PersistentDataStore,
 	dataStoreUpdates subsystems.DataStoreUpdateSink,
-	cacheTTL time.Duration,
+	cacheConfig PersistentDataStoreCacheConfig,
+	journalConfig PersistentDataStoreJournalConfig,
 	loggers ldlog.Loggers,
 ) subsystems.DataStore {
-	var myCache *cache.Cache
-	if cacheTTL != 0 {
-		myCache = cache.New(cacheTTL, 5*time.Minute)
-		// Note that the documented behavior of go-cache is that if cacheTTL is negative, the
-		// cache never expires. That is consistent with we've defined the parameter.
+	var myCache *persistentDataStoreCache
+	if cacheConfig.TTL != 0 {
+		myCache = newPersistentDataStoreCache(cacheConfig)
+	}
+	var journal *persistentDataStoreJournal
+	if journalConfig.MaxItems > 0 {
+		journal = newPersistentDataStoreJournal(journalConfig, datakinds.AllDataKinds(), loggers)
 	}
 
 	w := &persistentDataStoreWrapper{
 		core:             core,
 		dataStoreUpdates: dataStoreUpdates,
 		cache:            myCache,
-		cacheTTL:         cacheTTL,
+		cacheTTL:         cacheConfig.TTL,
+		journal:          journal,
+		refreshing:       make(map[string]struct{}),
 		loggers:          loggers,
 	}
 
 	w.statusPoller = newDataStoreStatusPoller(
 		true,
 		w.pollAvailabilityAfterOutage,
-		dataStoreUpdates.UpdateStatus,
-		myCache == nil || cacheTTL > 0, // needsRefresh=true unless we're in infinite cache mode
+		w.updateStatus,
 		loggers,
 	)
 
+	// If the journal file contained updates from a previous run that were never written to the store,
+	// write them now. If that fails, the store is now considered unavailable, and we'll try again when
+	// it recovers.
+	if journal != nil && journal.depth() != 0 {
+		w.replayJournal()
+	}
+
 	return w
 }
 
 func (w *persistentDataStoreWrapper) Init(allData []st.Collection) error {
 	err := w.initCore(allData)
+	if err != nil && w.journal != nil {
+		// The journal only holds individual updates, so it can't help us recover from this
+		w.journal.markIncomplete()
+	}
 	if w.cache != nil {
-		w.cache.Flush()
+		w.cache.flush()
 	}
 	if err != nil && !w.hasInfiniteCache() {
 		// If the underlying store failed to do the update, and we've got an expiring cache, then:
@@ -97,23 +141,27 @@ func (w *persistentDataStoreWrapper) Get(kind st.DataKind, key string) (st.ItemD
 		return item, err
 	}
 	cacheKey := dataStoreCacheKey(kind, key)
-	if data, present := w.cache.Get(cacheKey); present {
-		if item, ok := data.(st.ItemDescriptor); ok {
-			return item, nil
-		}
-	}
-	// Item was not cached or cached value was not valid. Use singleflight to ensure that we'll only
-	// do this core query once even if multiple goroutines are requesting it
 	reqKey := fmt.Sprintf("get:%s:%s", kind.GetName(), key)
-	itemIntf, err, _ := w.requests.Do(reqKey, func() (interface{}, error) {
+	query := func() (interface{}, error) {
 		item, err := w.getAndDeserializeItem(kind, key)
 		w.processError(err)
 		if err == nil {
-			w.cache.Set(cacheKey, item, cache.DefaultExpiration)
+			w.cache.set(cacheKey, item, w.itemSize(kind, key, item))
 			return item, nil
 		}
 		return nil, err
-	})
+	}
+	if data, state := w.cache.get(cacheKey); state != cacheEntryMissing {
+		if item, ok := data.(st.ItemDescriptor); ok {
+			if state != cacheEntryFresh {
+				w.refreshInBackground(reqKey, query)
+			}
+			return item, nil
+		}
+	}
+	// Item was not cached or cached value was not valid. Use singleflight to ensure that we'll only
+	// do this core query once even if multiple goroutines are requesting it
+	itemIntf, err, _ := w.requests.Do(reqKey, query)
 	if err != nil || itemIntf == nil {
 		return st.ItemDescriptor{}.NotFound(), err
 	}
@@ -131,25 +179,29 @@ func (w *persistentDataStoreWrapper) GetAll(kind st.DataKind) ([]st.KeyedItemDes
 		w.processError(err)
 		return items, err
 	}
-	// Check whether we have a cache item for the entire data set
 	cacheKey := dataStoreAllItemsCacheKey(kind)
-	if data, present := w.cache.Get(cacheKey); present {
-		if items, ok := data.([]st.KeyedItemDescriptor); ok {
-			return items, nil
-		}
-	}
-	// Data set was not cached or cached value was not valid. Use singleflight to ensure that we'll only
-	// do this core query once even if multiple goroutines are requesting it
 	reqKey := fmt.Sprintf("all:%s", kind.GetName())
-	itemsIntf, err, _ := w.requests.Do(reqKey, func() (interface{}, error) {
+	query := func() (interface{}, error) {
 		items, err := w.getAllAndDeserialize(kind)
 		w.processError(err)
 		if err == nil {
-			w.cache.Set(cacheKey, items, cache.DefaultExpiration)
+			w.cache.set(cacheKey, items, w.itemsSize(kind, items))
 			return items, nil
 		}
 		return nil, err
-	})
+	}
+	// Check whether we have a cache item for the entire data set
+	if data, state := w.cache.get(cacheKey); state != cacheEntryMissing {
+		if items, ok := data.([]st.KeyedItemDescriptor); ok {
+			if state != cacheEntryFresh {
+				w.refreshInBackground(reqKey, query)
+			}
+			return items, nil
+		}
+	}
+	// Data set was not cached or cached value was not valid. Use singleflight to ensure that we'll only
+	// do this core query once even if multiple goroutines are requesting it
+	itemsIntf, err, _ := w.requests.Do(reqKey, query)
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +221,16 @@ func (w *persistentDataStoreWrapper) Upsert(
 	serializedItem := w.serialize(kind, newItem)
 	updated, err := w.core.Upsert(kind, key, serializedItem)
 	w.processError(err)
+	if err != nil && w.journal != nil {
+		if w.core.IsStoreAvailable() {
+			// The store rejected this update for some reason other than an outage, so trying it again
+			// later probably won't help
+			w.journal.markIncomplete()
+		} else {
+			w.journal.add(kind, key, serializedItem)
+			w.statusPoller.RepublishStatus()
+		}
+	}
 	// Normally, if the underlying store failed to do the update, we do not want to update the cache -
 	// the idea being that it's better to stay in a consistent state of having old data than to act
 	// like we have new data but then suddenly fall back to old data when the cache expires. However,
@@ -183,48 +245,34 @@ func (w *persistentDataStoreWrapper) Upsert(
 		allCacheKey := dataStoreAllItemsCacheKey(kind)
 		if err == nil {
 			if updated {
-				w.cache.Set(cacheKey, newItem, cache.DefaultExpiration)
+				w.cache.set(cacheKey, newItem, w.itemSize(kind, key, newItem))
 				// If the cache has a finite TTL, then we should remove the "all items" cache entry to force
 				// a reread the next time All is called. However, if it's an infinite TTL, we need to just
 				// update the item within the existing "all items" entry (since we want things to still work
 				// even if the underlying store is unavailable).
 				if w.hasInfiniteCache() {
-					if data, present := w.cache.Get(allCacheKey); present {
-						if items, ok := data.([]st.KeyedItemDescriptor); ok {
-							w.cache.Set(allCacheKey, updateSingleItem(items, key, newItem), cache.DefaultExpiration)
-						}
-					}
+					w.updateSingleItemInAllItems(kind, key, newItem)
 				} else {
-					w.cache.Delete(allCacheKey)
+					w.cache.delete(allCacheKey)
 				}
 			} else {
 				// there was a concurrent modification elsewhere - update the cache to get the new state
-				if w.hasInfiniteCache() {
-					if data, present := w.cache.Get(allCacheKey); present {
-						if items, ok := data.([]st.KeyedItemDescriptor); ok {
-							w.cache.Set(allCacheKey, updateSingleItem(items, key, newItem), cache.DefaultExpiration)
-						}
-					}
-				} else {
-					w.cache.Delete(cacheKey)
-					w.cache.Delete(allCacheKey)
-					_, _ = w.Get(kind, key) // doing this query repopulates the cache
-				}
+				w.cache.delete(cacheKey)
+				w.cache.delete(allCacheKey)
+				_, _ = w.Get(kind, key) // doing this query repopulates the cache
 			}
 		} else {
 			// The underlying store returned an error. If the cache has an infinite TTL, then we should go
 			// ahead and update the cache so that it always has the latest data; we may be able to use the
 			// cached data to repopulate the store later if it starts working again.
-			if w.hasInfiniteCache() {
-				w.cache.Set(cacheKey, newItem, cache.DefaultExpiration)
-				cachedItems := []st.KeyedItemDescriptor{}
-				if data, present := w.cache.Get(allCacheKey); present {
-					if items, ok := data.([]st.KeyedItemDescriptor); ok {
-						cachedItems = items
-					}
-				}
-				w.loggers.Error(cachedItems)
-				w.cache.Set(allCacheKey, updateSingleItem(cachedItems, key, newItem), cache.DefaultExpiration)
+			w.cache.set(cacheKey, newItem, w.itemSize(kind, key, newItem))
+			if _, present := w.cache.peek(allCacheKey); present || w.cache.isBounded() {
+				w.updateSingleItemInAllItems(kind, key, newItem)
+			} else {
+				// Since entries in an unbounded cache are never evicted in this mode, the absence of an "all
+				// items" entry means that we never had any items of this kind.
+				items := []st.KeyedItemDescriptor{{Key: key, Item: newItem}}
+				w.cache.set(allCacheKey, items, w.itemsSize(kind, items))
 			}
 		}
 	}
@@ -234,27 +282,28 @@ func (w *persistentDataStoreWrapper) Upsert(
 func (w *persistentDataStoreWrapper) IsInitialized() bool {
 	w.initLock.RLock()
 	previousValue := w.inited
+	initCheckedTime := w.initCheckedTime
 	w.initLock.RUnlock()
 	if previousValue {
 		return true
 	}
 
-	if w.cache != nil {
-		if _, found := w.cache.Get(initCheckedKey); found {
-			return false
-		}
+	// If we recently checked the underlying store and it was not initialized, we don't check again until
+	// the cache TTL has elapsed. This is tracked separately from the cache entries, so it is not affected
+	// by evictions.
+	if w.cache != nil && !initCheckedTime.IsZero() &&
+		(w.cacheTTL < 0 || time.Since(initCheckedTime) < w.cacheTTL) {
+		return false
 	}
 
 	newValue := w.core.IsInitialized()
+	w.initLock.Lock()
+	defer w.initLock.Unlock()
 	if newValue {
-		w.initLock.Lock()
-		defer w.initLock.Unlock()
 		w.inited = true
-		if w.cache != nil {
-			w.cache.Delete(initCheckedKey)
-		}
+		w.initCheckedTime = time.Time{}
 	} else if w.cache != nil {
-		w.cache.Set(initCheckedKey, "", cache.DefaultExpiration)
+		w.initCheckedTime = time.Now()
 	}
 	return newValue
 }
@@ -265,45 +314,169 @@ func (w *persistentDataStoreWrapper) IsStatusMonitoringEnabled() bool {
 
 func (w *persistentDataStoreWrapper) Close() error {
 	w.statusPoller.Close()
+	w.refreshLock.Lock()
+	w.closed = true
+	w.refreshLock.Unlock()
+	w.refreshWaitGroup.Wait() // don't close the store while a background refresh might still be using it
 	return w.core.Close()
 }
 
-func (w *persistentDataStoreWrapper) pollAvailabilityAfterOutage() bool {
+func (w *persistentDataStoreWrapper) pollAvailabilityAfterOutage() (available bool, needsRefresh bool) {
 	if !w.core.IsStoreAvailable() {
-		return false
+		return false, false
 	}
-	if w.hasInfiniteCache() {
-		// If we're in infinite cache mode, then we can assume the cache has a full set of current
-		// flag data (since presumably the data source has still been running) and we can just
-		// write the contents of the cache to the underlying data store.
-		kinds := datakinds.AllDataKinds()
-		allData := make([]st.Collection, 0, len(kinds))
-		for _, kind := range kinds {
-			allCacheKey := dataStoreAllItemsCacheKey(kind)
-			if data, present := w.cache.Get(allCacheKey); present {
-				if items, ok := data.([]st.KeyedItemDescriptor); ok {
-					allData = append(allData, st.Collection{Kind: kind, Items: items})
-				}
+	if w.hasInfiniteCache() && w.writeCachedDataToStore() {
+		if !w.cache.isBounded() {
+			// The cache had a full set of current data, so the store is now up to date
+			return true, false
+		}
+	} else if w.journal != nil && !w.replayJournal() {
+		return false, false
+	}
+	// Unless everything that happened during the outage was in the journal, we can't be sure that the
+	// store is up to date, so the data source should refresh all of the data.
+	needsRefresh = w.journal == nil || w.journal.isIncomplete()
+	if w.journal != nil && needsRefresh {
+		w.journal.reset()
+	}
+	return true, needsRefresh
+}
+
+// writeCachedDataToStore is called when the store has recovered from an outage in infinite cache mode. It
+// returns false if it could not try to write the data, because the cache does not have all of it.
+func (w *persistentDataStoreWrapper) writeCachedDataToStore() bool {
+	// If we're in infinite cache mode, then we can assume the cache has a full set of current
+	// flag data (since presumably the data source has still been running) and we can just
+	// write the contents of the cache to the underlying data store.
+	kinds := datakinds.AllDataKinds()
+	allData := make([]st.Collection, 0, len(kinds))
+	for _, kind := range kinds {
+		allCacheKey := dataStoreAllItemsCacheKey(kind)
+		if data, present := w.cache.peek(allCacheKey); present {
+			if items, ok := data.([]st.KeyedItemDescriptor); ok {
+				allData = append(allData, st.Collection{Kind: kind, Items: items})
 			}
 		}
-		err := w.initCore(allData)
-		if err != nil {
-			// We failed to write the cached data to the underlying store. In this case,
-			// w.initCore() has already put us back into the failed state. The only further
-			// thing we can do is to log a note about what just happened.
-			w.loggers.Errorf("Tried to write cached data to persistent store after a store outage, but failed: %s", err)
-		} else {
-			w.loggers.Warn("Successfully updated persistent store from cached data")
-			// Note that w.inited should have already been set when InitInternal was originally called -
-			// in infinite cache mode, we set it even if the database update failed.
+	}
+	if w.cache.isBounded() && len(allData) < len(kinds) {
+		// Some of the data was evicted from the cache, so writing what is left would delete the rest
+		// of it from the store.
+		w.loggers.Warn("Not updating persistent store from cached data after a store outage, because" +
+			" the cache no longer contains all of the data")
+		return false
+	}
+	err := w.initCore(allData)
+	if err != nil {
+		// We failed to write the cached data to the underlying store. In this case,
+		// w.initCore() has already put us back into the failed state. The only further
+		// thing we can do is to log a note about what just happened.
+		w.loggers.Errorf("Tried to write cached data to persistent store after a store outage, but failed: %s", err)
+	} else {
+		w.loggers.Warn("Successfully updated persistent store from cached data")
+		// Note that w.inited should have already been set when InitInternal was originally called -
+		// in infinite cache mode, we set it even if the database update failed.
+	}
+	return true
+}
+
+// replayJournal writes the updates from the write-behind journal to the store, in version order. It
+// returns false if the store failed again before all of them were written; the ones that were written
+// are removed from the journal either way.
+func (w *persistentDataStoreWrapper) replayJournal() bool {
+	entries := w.journal.getEntries()
+	if len(entries) == 0 {
+		return true
+	}
+	for i, e := range entries {
+		if _, err := w.core.Upsert(e.kind, e.key, e.item); err != nil {
+			w.loggers.Errorf("Tried to write updates from write-behind journal to persistent store, but failed: %s", err)
+			w.processError(err)
+			w.journal.remove(entries[:i])
+			w.statusPoller.RepublishStatus()
+			return false
+		}
+		if w.cache != nil && !w.hasInfiniteCache() {
+			// We didn't update an expiring cache when the update originally failed, so make sure that the
+			// next query gets the new data from the store
+			w.cache.delete(dataStoreCacheKey(e.kind, e.key))
+			w.cache.delete(dataStoreAllItemsCacheKey(e.kind))
 		}
 	}
+	w.journal.remove(entries)
+	w.loggers.Warnf("Wrote %d updates from write-behind journal to persistent store", len(entries))
 	return true
 }
 
+// updateStatus adds the journal depth to a status update from the status poller.
+func (w *persistentDataStoreWrapper) updateStatus(status interfaces.DataStoreStatus) {
+	if w.journal != nil {
+		status.JournalDepth = w.journal.depth()
+	}
+	w.dataStoreUpdates.UpdateStatus(status)
+}
+
+// SubscribeToExternalChanges is used by the SDK's data source in daemon mode to find out when another
+// process has changed the data in the store, if the store implementation supports this. Before calling
+// onChange, we remove any affected items from the cache, so that the next query will see the new data.
+func (w *persistentDataStoreWrapper) SubscribeToExternalChanges(
+	onChange func(kind st.DataKind, key string),
+) error {
+	notifier, ok := w.core.(subsystems.PersistentDataStoreChangeNotifier)
+	if !ok {
+		return errChangeNotificationsNotSupported
+	}
+	return notifier.SubscribeToChanges(func(kind st.DataKind, key string) {
+		w.refreshLock.Lock()
+		closed := w.closed
+		w.refreshLock.Unlock()
+		if closed {
+			return
+		}
+		if w.cache != nil {
+			if key == "" {
+				w.cache.flush()
+			} else {
+				w.cache.delete(dataStoreCacheKey(kind, key))
+				w.cache.delete(dataStoreAllItemsCacheKey(kind))
+			}
+		}
+		onChange(kind, key)
+	})
+}
+
+// refreshInBackground starts a query to refresh a cache entry, unless one is already in progress for the
+// same key. The query goes through the same singleflight group as synchronous reads, so a synchronous
+// read that happens at the same time will wait for it rather than doing its own query. The cached value
+// continues to be used until the query finishes; if the query fails, the value is used until it is past
+// the maximum staleness.
+func (w *persistentDataStoreWrapper) refreshInBackground(reqKey string, query func() (interface{}, error)) {
+	w.refreshLock.Lock()
+	defer w.refreshLock.Unlock()
+	if _, inProgress := w.refreshing[reqKey]; inProgress || w.closed {
+		return
+	}
+	w.refreshing[reqKey] = struct{}{}
+	w.refreshWaitGroup.Add(1)
+	go func() {
+		defer w.refreshWaitGroup.Done()
+		_, _, _ = w.requests.Do(reqKey, query)
+		w.refreshLock.Lock()
+		delete(w.refreshing, reqKey)
+		w.refreshLock.Unlock()
+	}()
+}
+
 func (w *persistentDataStoreWrapper) hasInfiniteCache() bool {
 	return w.cache != nil && w.cacheTTL < 0
 }
+
+func (w *persistentDataStoreWrapper) getCacheStats() interfaces.DataStoreCacheStats {
+	if w.cache == nil {
+		return interfaces.DataStoreCacheStats{}
+	}
+	return w.cache.getStats()
+}
+
 func dataStoreCacheKey(kind st.DataKind, key string) string {
 	return kind.GetName() + ":" + key
 }
@@ -322,6 +495,10 @@ func (w *persistentDataStoreWrapper) initCore(allData []st.Collection) error {
 	}
 	err := w.core.Init(serializedAllData)
 	w.processError(err)
+	if err == nil && w.journal != nil {
+		// The store now has a complete data set, which is newer than anything in the journal
+		w.journal.reset()
+	}
 	return err
 }
 
@@ -360,14 +537,51 @@ func (w *persistentDataStoreWrapper) cacheItems(
 ) {
 	if w.cache != nil {
 		copyOfItems := slices.Clone(items)
-		w.cache.Set(dataStoreAllItemsCacheKey(kind), copyOfItems, cache.DefaultExpiration)
+		w.cache.set(dataStoreAllItemsCacheKey(kind), copyOfItems, w.itemsSize(kind, items))
 
 		for _, item := range items {
-			w.cache.Set(dataStoreCacheKey(kind, item.Key), item.Item, cache.DefaultExpiration)
+			w.cache.set(dataStoreCacheKey(kind, item.Key), item.Item, w.itemSize(kind, item.Key, item.Item))
+		}
+	}
+}
+
+// updateSingleItemInAllItems updates an item within the cached "all items" entry for its kind, if that
+// entry is present.
+func (w *persistentDataStoreWrapper) updateSingleItemInAllItems(
+	kind st.DataKind,
+	key string,
+	newItem st.ItemDescriptor,
+) {
+	allCacheKey := dataStoreAllItemsCacheKey(kind)
+	if data, present := w.cache.peek(allCacheKey); present {
+		if items, ok := data.([]st.KeyedItemDescriptor); ok {
+			newItems := updateSingleItem(items, key, newItem)
+			w.cache.set(allCacheKey, newItems, w.itemsSize(kind, newItems))
 		}
 	}
 }
 
+// itemSize returns the estimated size of a cache entry for a single item, if the cache has a size limit.
+// Otherwise it returns zero, since the size is not needed.
+func (w *persistentDataStoreWrapper) itemSize(kind st.DataKind, key string, item st.ItemDescriptor) int64 {
+	if w.cache.maxBytes <= 0 {
+		return 0
+	}
+	return int64(len(key) + len(kind.Serialize(item)))
+}
+
+// itemsSize returns the estimated size of a cache entry for a list of items, if the cache has a size limit.
+func (w *persistentDataStoreWrapper) itemsSize(kind st.DataKind, items []st.KeyedItemDescriptor) int64 {
+	if w.cache.maxBytes <= 0 {
+		return 0
+	}
+	var total int64
+	for _, item := range items {
+		total += w.itemSize(kind, item.Key, item.Item)
+	}
+	return total
+}
+
 func (w *persistentDataStoreWrapper) serialize(
 	kind st.DataKind,
 	item st.ItemDescriptor,
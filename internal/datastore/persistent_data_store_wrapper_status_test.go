@@ -30,12 +30,21 @@ type dataStoreStatusTestParams struct {
 }
 
 func withDataStoreStatusTestParams(mode testCacheMode, action func(dataStoreStatusTestParams)) {
+	withDataStoreStatusTestParamsForConfig(mode.cacheConfig(), PersistentDataStoreJournalConfig{}, action)
+}
+
+func withDataStoreStatusTestParamsForConfig(
+	cacheConfig PersistentDataStoreCacheConfig,
+	journalConfig PersistentDataStoreJournalConfig,
+	action func(dataStoreStatusTestParams),
+) {
 	params := dataStoreStatusTestParams{}
 	params.broadcaster = internal.NewBroadcaster[interfaces.DataStoreStatus]()
 	defer params.broadcaster.Close()
 	params.dataStoreUpdates = NewDataStoreUpdateSinkImpl(params.broadcaster)
 	params.core = mocks.NewMockPersistentDataStore()
-	params.store = NewPersistentDataStoreWrapper(params.core, params.dataStoreUpdates, mode.ttl(), sharedtest.NewTestLoggers())
+	params.store = NewPersistentDataStoreWrapper(params.core, params.dataStoreUpdates, cacheConfig, journalConfig,
+		sharedtest.NewTestLoggers())
 	defer params.store.Close()
 	action(params)
 }
@@ -147,4 +156,131 @@ func TestDataStoreWrapperStatus(t *testing.T) {
 			assert.Equal(t, flag.Version, p.core.ForceGet(datakinds.Features, flag.Key).Version)
 		})
 	})
+	t.Run("Cache is not written to store after recovery if it is missing evicted data", func(t *testing.T) {
+		cacheConfig := PersistentDataStoreCacheConfig{TTL: -1, MaxItems: 1}
+		withDataStoreStatusTestParamsForConfig(cacheConfig, PersistentDataStoreJournalConfig{}, func(p dataStoreStatusTestParams) {
+			statusCh := p.broadcaster.AddListener()
+
+			flag := ldbuilders.NewFlagBuilder("flag").Version(1).Build()
+			require.NoError(t, p.store.Init([]ldstoretypes.Collection{
+				{Kind: datakinds.Features, Items: []ldstoretypes.KeyedItemDescriptor{
+					{Key: flag.Key, Item: sharedtest.FlagDescriptor(flag)},
+				}},
+				{Kind: datakinds.Segments, Items: nil},
+			}))
+
+			myError := errors.New("sorry")
+			p.core.SetFakeError(myError)
+			p.core.SetAvailable(false)
+			flagv2 := ldbuilders.NewFlagBuilder(flag.Key).Version(2).Build()
+			_, err := p.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flagv2))
+			require.Equal(t, myError, err)
+
+			updatedStatus := th.RequireValue(t, statusCh, statusUpdateTimeout)
+			require.Equal(t, intf.DataStoreStatus{Available: false}, updatedStatus)
+
+			p.core.SetFakeError(nil)
+			p.core.SetAvailable(true)
+
+			// Since the cache can't hold all of the data, the wrapper asks for a refresh from the data source
+			// instead of overwriting the store with an incomplete data set
+			updatedStatus = th.RequireValue(t, statusCh, statusUpdateTimeout)
+			assert.Equal(t, intf.DataStoreStatus{Available: true, NeedsRefresh: true}, updatedStatus)
+			assert.Equal(t, flag.Version, p.core.ForceGet(datakinds.Features, flag.Key).Version)
+		})
+	})
+	t.Run("Journaled updates are written to store after recovery", func(t *testing.T) {
+		journalConfig := PersistentDataStoreJournalConfig{MaxItems: 10}
+		withDataStoreStatusTestParamsForConfig(testCached.cacheConfig(), journalConfig, func(p dataStoreStatusTestParams) {
+			statusCh := p.broadcaster.AddListener()
+
+			flag1v1 := ldbuilders.NewFlagBuilder("flag1").Version(1).Build()
+			require.NoError(t, p.store.Init([]ldstoretypes.Collection{
+				{Kind: datakinds.Features, Items: []ldstoretypes.KeyedItemDescriptor{
+					{Key: flag1v1.Key, Item: sharedtest.FlagDescriptor(flag1v1)},
+				}},
+				{Kind: datakinds.Segments, Items: nil},
+			}))
+
+			myError := errors.New("sorry")
+			p.core.SetFakeError(myError)
+			p.core.SetAvailable(false)
+
+			flag1v3 := ldbuilders.NewFlagBuilder(flag1v1.Key).Version(3).Build()
+			flag2v2 := ldbuilders.NewFlagBuilder("flag2").Version(2).Build()
+			_, err := p.store.Upsert(datakinds.Features, flag1v3.Key, sharedtest.FlagDescriptor(flag1v3))
+			require.Equal(t, myError, err)
+			_, err = p.store.Upsert(datakinds.Features, flag2v2.Key, sharedtest.FlagDescriptor(flag2v2))
+			require.Equal(t, myError, err)
+
+			assert.Equal(t, intf.DataStoreStatus{Available: false}, th.RequireValue(t, statusCh, statusUpdateTimeout))
+			assert.Equal(t, intf.DataStoreStatus{Available: false, JournalDepth: 1},
+				th.RequireValue(t, statusCh, statusUpdateTimeout))
+			assert.Equal(t, intf.DataStoreStatus{Available: false, JournalDepth: 2},
+				th.RequireValue(t, statusCh, statusUpdateTimeout))
+
+			p.core.SetFakeError(nil)
+			p.core.SetAvailable(true)
+
+			// The journal contained all of the updates, so there's no need for the data source to refresh
+			updatedStatus := th.RequireValue(t, statusCh, statusUpdateTimeout)
+			assert.Equal(t, intf.DataStoreStatus{Available: true}, updatedStatus)
+			assert.Equal(t, flag1v3.Version, p.core.ForceGet(datakinds.Features, flag1v3.Key).Version)
+			assert.Equal(t, flag2v2.Version, p.core.ForceGet(datakinds.Features, flag2v2.Key).Version)
+
+			// The cache entry for the flag that was in the journal was invalidated
+			item, err := p.store.Get(datakinds.Features, flag1v3.Key)
+			require.NoError(t, err)
+			assert.Equal(t, flag1v3.Version, item.Version)
+		})
+	})
+
+	t.Run("Store needs refresh after recovery if journal was full", func(t *testing.T) {
+		journalConfig := PersistentDataStoreJournalConfig{MaxItems: 1}
+		withDataStoreStatusTestParamsForConfig(testCached.cacheConfig(), journalConfig, func(p dataStoreStatusTestParams) {
+			statusCh := p.broadcaster.AddListener()
+
+			myError := errors.New("sorry")
+			p.core.SetFakeError(myError)
+			p.core.SetAvailable(false)
+
+			flag1 := ldbuilders.NewFlagBuilder("flag1").Version(1).Build()
+			flag2 := ldbuilders.NewFlagBuilder("flag2").Version(1).Build()
+			_, err := p.store.Upsert(datakinds.Features, flag1.Key, sharedtest.FlagDescriptor(flag1))
+			require.Equal(t, myError, err)
+			_, err = p.store.Upsert(datakinds.Features, flag2.Key, sharedtest.FlagDescriptor(flag2))
+			require.Equal(t, myError, err)
+
+			assert.Equal(t, intf.DataStoreStatus{Available: false}, th.RequireValue(t, statusCh, statusUpdateTimeout))
+			assert.Equal(t, intf.DataStoreStatus{Available: false, JournalDepth: 1},
+				th.RequireValue(t, statusCh, statusUpdateTimeout))
+
+			p.core.SetFakeError(nil)
+			p.core.SetAvailable(true)
+
+			updatedStatus := th.RequireValue(t, statusCh, statusUpdateTimeout)
+			assert.Equal(t, intf.DataStoreStatus{Available: true, NeedsRefresh: true}, updatedStatus)
+			assert.Equal(t, flag1.Version, p.core.ForceGet(datakinds.Features, flag1.Key).Version)
+			assert.Equal(t, ldstoretypes.SerializedItemDescriptor{}.NotFound(), p.core.ForceGet(datakinds.Features, flag2.Key))
+		})
+	})
+
+	t.Run("Journaled updates from a previous run are written to store at startup", func(t *testing.T) {
+		th.WithTempFile(func(journalFilePath string) {
+			journalConfig := PersistentDataStoreJournalConfig{MaxItems: 10, FilePath: journalFilePath}
+			flag := ldbuilders.NewFlagBuilder("flag").Version(1).Build()
+
+			withDataStoreStatusTestParamsForConfig(testCached.cacheConfig(), journalConfig, func(p dataStoreStatusTestParams) {
+				p.core.SetFakeError(errors.New("sorry"))
+				p.core.SetAvailable(false)
+				_, _ = p.store.Upsert(datakinds.Features, flag.Key, sharedtest.FlagDescriptor(flag))
+				assert.Equal(t, 1, p.dataStoreUpdates.getStatus().JournalDepth)
+			})
+
+			withDataStoreStatusTestParamsForConfig(testCached.cacheConfig(), journalConfig, func(p dataStoreStatusTestParams) {
+				assert.Equal(t, flag.Version, p.core.ForceGet(datakinds.Features, flag.Key).Version)
+				assert.Equal(t, intf.DataStoreStatus{Available: true}, p.dataStoreUpdates.getStatus())
+			})
+		})
+	})
 }
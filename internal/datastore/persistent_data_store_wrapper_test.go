@@ -2,6 +2,7 @@ package datastore
 
 import (
 	"errors"
+	"fmt"
 	"sort"
 	"testing"
 	"time"
@@ -14,6 +15,8 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
 
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -41,6 +44,10 @@ func (m testCacheMode) ttl() time.Duration {
 	}
 }
 
+func (m testCacheMode) cacheConfig() PersistentDataStoreCacheConfig {
+	return PersistentDataStoreCacheConfig{TTL: m.ttl()}
+}
+
 func (m testCacheMode) isInfiniteTTL() bool {
 	return m.ttl() < 0
 }
@@ -52,7 +59,7 @@ func makePersistentDataStoreWrapper(
 ) subsystems.DataStore {
 	broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
 	dataStoreUpdates := NewDataStoreUpdateSinkImpl(broadcaster)
-	return NewPersistentDataStoreWrapper(core, dataStoreUpdates, mode.ttl(), s.NewTestLoggers())
+	return NewPersistentDataStoreWrapper(core, dataStoreUpdates, mode.cacheConfig(), PersistentDataStoreJournalConfig{}, s.NewTestLoggers())
 }
 
 func TestPersistentDataStoreWrapper(t *testing.T) {
@@ -558,3 +565,268 @@ func testPersistentDataStoreWrapperUpdateFailuresWithCache(t *testing.T, mode te
 		})
 	}
 }
+
+func TestPersistentDataStoreWrapperWithCacheLimits(t *testing.T) {
+	makeWrapper := func(cacheConfig PersistentDataStoreCacheConfig) (*mocks.MockPersistentDataStore, *persistentDataStoreWrapper) {
+		core := mocks.NewMockPersistentDataStore()
+		broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+		t.Cleanup(broadcaster.Close)
+		w := NewPersistentDataStoreWrapper(core, NewDataStoreUpdateSinkImpl(broadcaster), cacheConfig, PersistentDataStoreJournalConfig{},
+			s.NewTestLoggers()).(*persistentDataStoreWrapper)
+		t.Cleanup(func() { _ = w.Close() })
+		return core, w
+	}
+
+	for _, ttl := range []time.Duration{time.Minute, -1} {
+		t.Run(fmt.Sprintf("TTL %s", ttl), func(t *testing.T) {
+			t.Run("evicted item is read from store", func(t *testing.T) {
+				core, w := makeWrapper(PersistentDataStoreCacheConfig{TTL: ttl, MaxItems: 2})
+				item1 := mocks.MockDataItem{Key: "key1", Version: 1}
+				item2 := mocks.MockDataItem{Key: "key2", Version: 1}
+				item3 := mocks.MockDataItem{Key: "key3", Version: 1}
+				require.NoError(t, w.Init(mocks.MakeMockDataSet(item1, item2, item3)))
+
+				// Only the last two cache entries that Init created (the last item, and the all-items entry for
+				// the other data kind) are still cached, so the store is queried for the first item but not the last.
+				core.ForceSet(mocks.MockData, item1.Key, mocks.MockDataItem{Key: item1.Key, Version: 2}.ToSerializedItemDescriptor())
+				core.ForceSet(mocks.MockData, item3.Key, mocks.MockDataItem{Key: item3.Key, Version: 2}.ToSerializedItemDescriptor())
+
+				item, err := w.Get(mocks.MockData, item3.Key)
+				require.NoError(t, err)
+				assert.Equal(t, 1, item.Version)
+
+				item, err = w.Get(mocks.MockData, item1.Key)
+				require.NoError(t, err)
+				assert.Equal(t, 2, item.Version)
+
+				stats := w.getCacheStats()
+				assert.True(t, stats.Enabled)
+				assert.Equal(t, int64(1), stats.Hits)
+				assert.Equal(t, int64(1), stats.Misses)
+				assert.Equal(t, int64(4), stats.Evictions) // three during Init, one when key1 was cached again
+				assert.Equal(t, 2, stats.Items)
+			})
+
+			t.Run("evicted all-items entry is read from store", func(t *testing.T) {
+				core, w := makeWrapper(PersistentDataStoreCacheConfig{TTL: ttl, MaxItems: 1})
+				item1 := mocks.MockDataItem{Key: "key1", Version: 1}
+				require.NoError(t, w.Init(mocks.MakeMockDataSet(item1)))
+
+				item2 := mocks.MockDataItem{Key: "key2", Version: 1}
+				core.ForceSet(mocks.MockData, item2.Key, item2.ToSerializedItemDescriptor())
+
+				items, err := w.GetAll(mocks.MockData)
+				require.NoError(t, err)
+				assert.Len(t, items, 2)
+			})
+
+			t.Run("size limit", func(t *testing.T) {
+				_, w := makeWrapper(PersistentDataStoreCacheConfig{TTL: ttl, MaxBytes: 1000})
+				item1 := mocks.MockDataItem{Key: "key1", Version: 1}
+				require.NoError(t, w.Init(mocks.MakeMockDataSet(item1)))
+
+				stats := w.getCacheStats()
+				assert.Equal(t, 3, stats.Items) // the all-items entries for both kinds, and the one item
+				assert.Greater(t, stats.Bytes, int64(0))
+				assert.LessOrEqual(t, stats.Bytes, int64(1000))
+			})
+		})
+	}
+
+	t.Run("stats are disabled if there is no cache", func(t *testing.T) {
+		_, w := makeWrapper(PersistentDataStoreCacheConfig{})
+		assert.Equal(t, interfaces.DataStoreCacheStats{}, w.getCacheStats())
+	})
+}
+
+func TestPersistentDataStoreWrapperWithStaleWhileRevalidate(t *testing.T) {
+	// These tests use a test clock for the cache, so that no test depends on how long anything takes. Since
+	// the test clock doesn't move during a background refresh, the refreshed entry is always fresh.
+	makeWrapper := func(
+		core subsystems.PersistentDataStore,
+		cacheConfig PersistentDataStoreCacheConfig,
+	) (*persistentDataStoreWrapper, *testClock) {
+		broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+		t.Cleanup(broadcaster.Close)
+		w := NewPersistentDataStoreWrapper(core, NewDataStoreUpdateSinkImpl(broadcaster), cacheConfig,
+			PersistentDataStoreJournalConfig{}, s.NewTestLoggers()).(*persistentDataStoreWrapper)
+		t.Cleanup(func() { _ = w.Close() })
+		clock := newTestClock()
+		w.cache.timeNow = clock.Now
+		return w, clock
+	}
+	ttl := time.Minute
+	itemv1 := mocks.MockDataItem{Key: "key", Version: 1}
+	itemv2 := mocks.MockDataItem{Key: itemv1.Key, Version: 2}
+
+	t.Run("expired item is returned while it is refreshed in the background", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		w, clock := makeWrapper(core, PersistentDataStoreCacheConfig{TTL: ttl, MaxStaleness: time.Hour})
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(itemv1)))
+		core.ForceSet(mocks.MockData, itemv1.Key, itemv2.ToSerializedItemDescriptor())
+		clock.Advance(ttl)
+
+		item, err := w.Get(mocks.MockData, itemv1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, itemv1.ToItemDescriptor(), item)
+
+		w.refreshWaitGroup.Wait()
+		item, err = w.Get(mocks.MockData, itemv1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, itemv2.ToItemDescriptor(), item)
+		assert.Equal(t, int64(1), w.getCacheStats().StaleHits)
+	})
+
+	t.Run("expired data set is returned while it is refreshed in the background", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		w, clock := makeWrapper(core, PersistentDataStoreCacheConfig{TTL: ttl, MaxStaleness: time.Hour})
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(itemv1)))
+		core.ForceSet(mocks.MockData, itemv1.Key, itemv2.ToSerializedItemDescriptor())
+		clock.Advance(ttl)
+
+		items, err := w.GetAll(mocks.MockData)
+		require.NoError(t, err)
+		assert.Equal(t, []st.KeyedItemDescriptor{{Key: itemv1.Key, Item: itemv1.ToItemDescriptor()}}, items)
+
+		w.refreshWaitGroup.Wait()
+		items, err = w.GetAll(mocks.MockData)
+		require.NoError(t, err)
+		assert.Equal(t, []st.KeyedItemDescriptor{{Key: itemv1.Key, Item: itemv2.ToItemDescriptor()}}, items)
+	})
+
+	t.Run("only one background refresh is done at a time", func(t *testing.T) {
+		core := newBlockingQueryStore()
+		w, clock := makeWrapper(core, PersistentDataStoreCacheConfig{TTL: ttl, MaxStaleness: time.Hour})
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(itemv1)))
+		clock.Advance(ttl)
+
+		for i := 0; i < 3; i++ {
+			item, err := w.Get(mocks.MockData, itemv1.Key)
+			require.NoError(t, err)
+			assert.Equal(t, itemv1.ToItemDescriptor(), item)
+		}
+		th.RequireValue(t, core.queryStartedCh, time.Second)
+		close(core.releaseCh)
+		w.refreshWaitGroup.Wait()
+		assert.Len(t, core.queryStartedCh, 0)
+	})
+
+	t.Run("expired item is still returned if the background refresh fails", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		w, clock := makeWrapper(core, PersistentDataStoreCacheConfig{TTL: ttl, MaxStaleness: time.Hour})
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(itemv1)))
+		core.SetFakeError(errors.New("sorry"))
+		clock.Advance(ttl)
+
+		for i := 0; i < 3; i++ {
+			item, err := w.Get(mocks.MockData, itemv1.Key)
+			require.NoError(t, err)
+			assert.Equal(t, itemv1.ToItemDescriptor(), item)
+			w.refreshWaitGroup.Wait()
+		}
+	})
+
+	t.Run("item that is past the maximum staleness is read synchronously", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		w, clock := makeWrapper(core, PersistentDataStoreCacheConfig{TTL: ttl, MaxStaleness: ttl})
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(itemv1)))
+		core.ForceSet(mocks.MockData, itemv1.Key, itemv2.ToSerializedItemDescriptor())
+		clock.Advance(ttl * 2)
+
+		item, err := w.Get(mocks.MockData, itemv1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, itemv2.ToItemDescriptor(), item)
+	})
+
+	t.Run("item is refreshed in the background before it expires", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		w, clock := makeWrapper(core, PersistentDataStoreCacheConfig{TTL: ttl, RefreshAhead: ttl / 2})
+		require.NoError(t, w.Init(mocks.MakeMockDataSet(itemv1)))
+		core.ForceSet(mocks.MockData, itemv1.Key, itemv2.ToSerializedItemDescriptor())
+		clock.Advance(ttl / 2)
+
+		item, err := w.Get(mocks.MockData, itemv1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, itemv1.ToItemDescriptor(), item)
+
+		w.refreshWaitGroup.Wait()
+		item, err = w.Get(mocks.MockData, itemv1.Key)
+		require.NoError(t, err)
+		assert.Equal(t, itemv2.ToItemDescriptor(), item)
+		assert.Equal(t, int64(0), w.getCacheStats().StaleHits)
+	})
+}
+
+// blockingQueryStore is a MockPersistentDataStore whose Get method signals that it has been called, and
+// then waits until releaseCh is closed.
+type blockingQueryStore struct {
+	*mocks.MockPersistentDataStore
+	queryStartedCh chan struct{}
+	releaseCh      chan struct{}
+}
+
+func newBlockingQueryStore() *blockingQueryStore {
+	return &blockingQueryStore{
+		MockPersistentDataStore: mocks.NewMockPersistentDataStore(),
+		queryStartedCh:          make(chan struct{}, 10),
+		releaseCh:               make(chan struct{}),
+	}
+}
+
+func (b *blockingQueryStore) Get(kind st.DataKind, key string) (st.SerializedItemDescriptor, error) {
+	b.queryStartedCh <- struct{}{}
+	<-b.releaseCh
+	return b.MockPersistentDataStore.Get(kind, key)
+}
+
+func TestPersistentDataStoreWrapperSubscribeToExternalChanges(t *testing.T) {
+	makeWrapper := func(core subsystems.PersistentDataStore) *persistentDataStoreWrapper {
+		broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+		t.Cleanup(broadcaster.Close)
+		w := NewPersistentDataStoreWrapper(core, NewDataStoreUpdateSinkImpl(broadcaster),
+			PersistentDataStoreCacheConfig{TTL: -1}, PersistentDataStoreJournalConfig{}, s.NewTestLoggers())
+		return w.(*persistentDataStoreWrapper)
+	}
+	itemv1 := mocks.MockDataItem{Key: "key", Version: 1}
+	itemv2 := mocks.MockDataItem{Key: itemv1.Key, Version: 2}
+
+	t.Run("returns error if store does not support notifications", func(t *testing.T) {
+		w := makeWrapper(mocks.NewMockPersistentDataStore())
+		defer w.Close()
+		assert.Equal(t, errChangeNotificationsNotSupported,
+			w.SubscribeToExternalChanges(func(st.DataKind, string) {}))
+	})
+
+	for _, notifyKey := range []string{itemv1.Key, ""} {
+		t.Run(fmt.Sprintf("notification invalidates cache (key %q)", notifyKey), func(t *testing.T) {
+			core := mocks.NewMockPersistentDataStoreWithChangeNotifier()
+			w := makeWrapper(core)
+			defer w.Close()
+			require.NoError(t, w.Init(mocks.MakeMockDataSet(itemv1)))
+
+			var received []string
+			require.NoError(t, w.SubscribeToExternalChanges(func(kind st.DataKind, key string) {
+				item, err := w.Get(kind, itemv1.Key) // the new data should already be visible
+				require.NoError(t, err)
+				items, err := w.GetAll(kind)
+				require.NoError(t, err)
+				require.Len(t, items, 1)
+				received = append(received, fmt.Sprintf("%s:%d:%d", key, item.Version, items[0].Item.Version))
+			}))
+
+			core.ForceSet(mocks.MockData, itemv1.Key, itemv2.ToSerializedItemDescriptor())
+			core.NotifyChange(mocks.MockData, notifyKey)
+			assert.Equal(t, []string{notifyKey + ":2:2"}, received)
+		})
+	}
+
+	t.Run("notifications are ignored after close", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStoreWithChangeNotifier()
+		w := makeWrapper(core)
+		received := 0
+		require.NoError(t, w.SubscribeToExternalChanges(func(st.DataKind, string) { received++ }))
+		require.NoError(t, w.Close())
+		core.NotifyChange(mocks.MockData, itemv1.Key)
+		assert.Equal(t, 0, received)
+	})
+}
@@ -0,0 +1,256 @@
+package events
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+)
+
+// ContextEventRecorder is an optional interface for an ldevents.EventProcessor whose methods for recording
+// events also receive the evaluation context that each event was created from. If the event processor
+// implements it, LDClient calls these methods instead of the corresponding EventProcessor methods.
+//
+// This is needed because go-sdk-events does not expose the context in its input types, so an
+// EventProcessor decorator cannot otherwise see or transform it. The EventInputContext in the event is
+// always equivalent to ldevents.Context(context).
+type ContextEventRecorder interface {
+	RecordEvaluationForContext(e ldevents.EvaluationData, context ldcontext.Context)
+	RecordIdentifyEventForContext(e ldevents.IdentifyEventData, context ldcontext.Context)
+	RecordCustomEventForContext(e ldevents.CustomEventData, context ldcontext.Context)
+}
+
+// ContextEventProcessorConfig contains the parameters for NewContextEventProcessor.
+type ContextEventProcessorConfig struct {
+	// RedactContext is applied to the context of every event before the event is passed on, so that the
+	// event processor formats the redacted context. If nil, contexts are not changed.
+	RedactContext func(context ldcontext.Context) ldcontext.Context
+
+	// Tap, if not nil, receives a copy of every event. The context in the copy has had RedactContext
+	// applied, and also has all private attributes removed, so listeners never see any context properties
+	// that would not be sent to LaunchDarkly.
+	Tap *EventTap
+
+	// Sampling, if not nil, determines which full feature events and custom events are sent. An evaluation
+	// that is not sampled is still counted in the summary event.
+	Sampling *EventSamplingConfig
+
+	// AllAttributesPrivate and PrivateAttributes must be the same as in the EventsConfiguration of the
+	// wrapped EventProcessor. They are used for the copies of events that are delivered to Tap, and for
+	// the contexts of sampled custom events.
+	AllAttributesPrivate bool
+	PrivateAttributes    []ldattr.Ref
+}
+
+// contextEventProcessor is a decorator for an EventProcessor that implements ContextEventRecorder, and
+// processes the contexts of events before passing them to the wrapped EventProcessor.
+//
+// Events that are recorded with the plain EventProcessor methods are passed through unchanged: they are not
+// delivered to the EventTap or sampled, since their contexts cannot be inspected.
+type contextEventProcessor struct {
+	ldevents.EventProcessor
+	config ContextEventProcessorConfig
+}
+
+// NewContextEventProcessor returns an EventProcessor that implements ContextEventRecorder.
+func NewContextEventProcessor(
+	ep ldevents.EventProcessor,
+	config ContextEventProcessorConfig,
+) ldevents.EventProcessor {
+	return contextEventProcessor{EventProcessor: ep, config: config}
+}
+
+func (p contextEventProcessor) RecordEvaluationForContext(e ldevents.EvaluationData, context ldcontext.Context) {
+	ratio := 1
+	if e.RequireFullEvent && p.config.Sampling != nil {
+		ratio = p.config.Sampling.featureEventRatio(e.Key)
+	}
+	sampled := isSampled(ratio, e.Key, context)
+	context = p.redact(context)
+	e.Context = ldevents.Context(context)
+	if p.config.Tap.HasListeners() {
+		eventCopy := e
+		eventCopy.Context = p.listenerContext(context)
+		p.config.Tap.PublishEvent(interfaces.AnalyticsEvent{
+			Kind:       interfaces.EvaluationAnalyticsEvent,
+			Evaluation: &eventCopy,
+		})
+	}
+	if ratio == 1 {
+		p.EventProcessor.RecordEvaluation(e)
+		return
+	}
+	// The event processor still counts the evaluation in the summary, and adds an index event if necessary,
+	// but the full feature event is added here so that it can have a samplingRatio property.
+	e.RequireFullEvent = false
+	p.EventProcessor.RecordEvaluation(e)
+	if sampled {
+		p.EventProcessor.RecordRawEvent(makeSampledFeatureEvent(e, context, ratio))
+	}
+}
+
+func (p contextEventProcessor) RecordIdentifyEventForContext(
+	e ldevents.IdentifyEventData,
+	context ldcontext.Context,
+) {
+	context = p.redact(context)
+	e.Context = ldevents.Context(context)
+	if p.config.Tap.HasListeners() {
+		eventCopy := e
+		eventCopy.Context = p.listenerContext(context)
+		p.config.Tap.PublishEvent(interfaces.AnalyticsEvent{Kind: interfaces.IdentifyAnalyticsEvent, Identify: &eventCopy})
+	}
+	p.EventProcessor.RecordIdentifyEvent(e)
+}
+
+func (p contextEventProcessor) RecordCustomEventForContext(e ldevents.CustomEventData, context ldcontext.Context) {
+	ratio := 1
+	if p.config.Sampling != nil {
+		ratio = p.config.Sampling.CustomEventRatio
+	}
+	sampled := isSampled(ratio, e.Key, context)
+	context = p.redact(context)
+	e.Context = ldevents.Context(context)
+	if p.config.Tap.HasListeners() {
+		eventCopy := e
+		eventCopy.Context = p.listenerContext(context)
+		p.config.Tap.PublishEvent(interfaces.AnalyticsEvent{Kind: interfaces.CustomAnalyticsEvent, Custom: &eventCopy})
+	}
+	switch {
+	case ratio == 1:
+		p.EventProcessor.RecordCustomEvent(e)
+	case sampled:
+		// An index event is always added, since the event processor cannot tell whether it has already
+		// seen the context of a raw event.
+		p.EventProcessor.RecordRawEvent(makeIndexEvent(e.CreationDate, context, p.config.AllAttributesPrivate,
+			p.config.PrivateAttributes))
+		p.EventProcessor.RecordRawEvent(makeSampledCustomEvent(e, context, ratio))
+	}
+}
+
+func (p contextEventProcessor) redact(context ldcontext.Context) ldcontext.Context {
+	if p.config.RedactContext != nil {
+		return p.config.RedactContext(context)
+	}
+	return context
+}
+
+func (p contextEventProcessor) listenerContext(context ldcontext.Context) ldevents.EventInputContext {
+	return ldevents.Context(removePrivateAttributes(context, p.config.AllAttributesPrivate, p.config.PrivateAttributes))
+}
+
+// removePrivateAttributes returns a copy of the context without any of the attributes that the event
+// processor would omit from its output, as determined by the same rules: attributes that are private in
+// the context itself, or in the configuration, or all optional attributes if allAttributesPrivate is true.
+// The kind, key, and anonymous attributes are never private.
+func removePrivateAttributes(
+	context ldcontext.Context,
+	allAttributesPrivate bool,
+	privateAttributes []ldattr.Ref,
+) ldcontext.Context {
+	if context.Err() != nil {
+		return context
+	}
+	if !context.Multiple() {
+		return removePrivateAttributesFromSingleContext(context, allAttributesPrivate, privateAttributes, nil)
+	}
+	builder := ldcontext.NewMultiBuilder()
+	for i := 0; i < context.IndividualContextCount(); i++ {
+		builder.Add(removePrivateAttributesFromSingleContext(
+			context.IndividualContextByIndex(i), allAttributesPrivate, privateAttributes, nil))
+	}
+	return builder.Build()
+}
+
+// removePrivateAttributesFromSingleContext is the implementation of removePrivateAttributes for a context
+// that is not a multi-kind context. If redacted is not nil, the attribute reference strings of the removed
+// attributes are added to it, in the same form as in the redactedAttributes metadata of an event.
+func removePrivateAttributesFromSingleContext(
+	context ldcontext.Context,
+	allAttributesPrivate bool,
+	privateAttributes []ldattr.Ref,
+	redacted *[]string,
+) ldcontext.Context {
+	refs := privateAttributes
+	if count := context.PrivateAttributeCount(); count != 0 {
+		refs = make([]ldattr.Ref, 0, len(privateAttributes)+count)
+		refs = append(refs, privateAttributes...)
+		for i := 0; i < count; i++ {
+			ref, _ := context.PrivateAttributeByIndex(i)
+			refs = append(refs, ref)
+		}
+	}
+	var builder *ldcontext.Builder // only created if something changes
+	for _, name := range context.GetOptionalAttributeNames(nil) {
+		newValue, changed := ldvalue.Null(), true
+		if allAttributesPrivate {
+			if redacted != nil {
+				*redacted = append(*redacted, ldattr.NewLiteralRef(name).String())
+			}
+		} else {
+			newValue, changed = removePrivateValues([]string{name}, context.GetValue(name), refs, redacted)
+		}
+		if changed {
+			if builder == nil {
+				builder = ldcontext.NewBuilderFromContext(context)
+			}
+			builder.SetValue(name, newValue) // setting a null value removes the attribute
+		}
+	}
+	if builder == nil {
+		return context
+	}
+	return builder.Build()
+}
+
+// removePrivateValues returns the value with any private properties removed and true, or the original
+// value and false if nothing was removed. It returns a null value if the value itself is private.
+func removePrivateValues(
+	path []string,
+	value ldvalue.Value,
+	refs []ldattr.Ref,
+	redacted *[]string,
+) (ldvalue.Value, bool) {
+	for _, ref := range refs {
+		if refMatchesPath(ref, path) {
+			if redacted != nil {
+				*redacted = append(*redacted, ref.String())
+			}
+			return ldvalue.Null(), true
+		}
+	}
+	if value.Type() != ldvalue.ObjectType {
+		return value, false
+	}
+	var props map[string]ldvalue.Value // only copied if a property is removed
+	for _, name := range value.Keys(nil) {
+		propPath := append(path[:len(path):len(path)], name)
+		if newValue, changed := removePrivateValues(propPath, value.GetByKey(name), refs, redacted); changed {
+			if props == nil {
+				props = value.AsValueMap().AsMap()
+			}
+			if newValue.IsNull() {
+				delete(props, name)
+			} else {
+				props[name] = newValue
+			}
+		}
+	}
+	if props == nil {
+		return value, false
+	}
+	return ldvalue.CopyObject(props), true
+}
+
+func refMatchesPath(ref ldattr.Ref, path []string) bool {
+	if ref.Err() != nil || ref.Depth() != len(path) {
+		return false
+	}
+	for i, component := range path {
+		if ref.Component(i) != component {
+			return false
+		}
+	}
+	return true
+}
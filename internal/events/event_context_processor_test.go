@@ -0,0 +1,131 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextEventProcessorAppliesRedactContext(t *testing.T) {
+	capturing := &mocks.CapturingEventProcessor{}
+	redacted := ldcontext.New("redacted")
+	ep := NewContextEventProcessor(capturing, ContextEventProcessorConfig{
+		RedactContext: func(ldcontext.Context) ldcontext.Context { return redacted },
+	})
+	recorder, ok := ep.(ContextEventRecorder)
+	require.True(t, ok)
+
+	context := ldcontext.New("user-key")
+	eval := ldevents.EvaluationData{BaseEvent: ldevents.BaseEvent{Context: ldevents.Context(context)}, Key: "flag-key"}
+	identify := ldevents.IdentifyEventData{BaseEvent: ldevents.BaseEvent{Context: ldevents.Context(context)}}
+	custom := makeTestCustomEvent("event-key")
+	recorder.RecordEvaluationForContext(eval, context)
+	recorder.RecordIdentifyEventForContext(identify, context)
+	recorder.RecordCustomEventForContext(custom, context)
+
+	eval.Context = ldevents.Context(redacted)
+	identify.Context = ldevents.Context(redacted)
+	custom.Context = ldevents.Context(redacted)
+	assert.Equal(t, []interface{}{eval, identify, custom}, capturing.Events)
+}
+
+func TestContextEventProcessorPassesPlainEventsThroughUnchanged(t *testing.T) {
+	capturing := &mocks.CapturingEventProcessor{}
+	ep := NewContextEventProcessor(capturing, ContextEventProcessorConfig{
+		RedactContext: func(ldcontext.Context) ldcontext.Context { return ldcontext.New("redacted") },
+	})
+
+	custom := makeTestCustomEvent("event-key")
+	ep.RecordCustomEvent(custom)
+
+	assert.Equal(t, []interface{}{custom}, capturing.Events)
+}
+
+func TestContextEventProcessorPublishesEventsWithRedactedContextsToTap(t *testing.T) {
+	tap := NewEventTap()
+	listener := tap.AddListener(10)
+	capturing := &mocks.CapturingEventProcessor{}
+	ep := NewContextEventProcessor(capturing, ContextEventProcessorConfig{
+		RedactContext: func(c ldcontext.Context) ldcontext.Context {
+			return ldcontext.NewBuilderFromContext(c).Private("email").Build()
+		},
+		Tap:               tap,
+		PrivateAttributes: []ldattr.Ref{ldattr.NewRef("/address/street")},
+	})
+	recorder := ep.(ContextEventRecorder)
+
+	context := ldcontext.NewBuilder("user-key").
+		Name("a").
+		SetString("email", "b").
+		SetString("phone", "c").
+		SetValue("address", ldvalue.ObjectBuild().SetString("street", "d").SetString("city", "e").Build()).
+		Private("phone").
+		Build()
+	redacted := ldcontext.NewBuilderFromContext(context).Private("email").Build()
+	expectedListenerContext := ldevents.Context(ldcontext.NewBuilder("user-key").
+		Name("a").
+		SetValue("address", ldvalue.ObjectBuild().SetString("city", "e").Build()).
+		Private("phone", "email").
+		Build())
+
+	eval := ldevents.EvaluationData{BaseEvent: ldevents.BaseEvent{Context: ldevents.Context(context)}, Key: "flag-key"}
+	identify := ldevents.IdentifyEventData{BaseEvent: ldevents.BaseEvent{Context: ldevents.Context(context)}}
+	custom := ldevents.CustomEventData{BaseEvent: ldevents.BaseEvent{Context: ldevents.Context(context)}, Key: "key"}
+	recorder.RecordEvaluationForContext(eval, context)
+	recorder.RecordIdentifyEventForContext(identify, context)
+	recorder.RecordCustomEventForContext(custom, context)
+
+	eval.Context, identify.Context, custom.Context = ldevents.Context(redacted), ldevents.Context(redacted),
+		ldevents.Context(redacted)
+	assert.Equal(t, []interface{}{eval, identify, custom}, capturing.Events)
+
+	eval.Context, identify.Context, custom.Context = expectedListenerContext, expectedListenerContext,
+		expectedListenerContext
+	e1 := th.RequireValue(t, listener.Events(), time.Second)
+	assert.Equal(t, interfaces.AnalyticsEvent{Kind: interfaces.EvaluationAnalyticsEvent, Evaluation: &eval}, e1)
+	e2 := th.RequireValue(t, listener.Events(), time.Second)
+	assert.Equal(t, interfaces.AnalyticsEvent{Kind: interfaces.IdentifyAnalyticsEvent, Identify: &identify}, e2)
+	e3 := th.RequireValue(t, listener.Events(), time.Second)
+	assert.Equal(t, interfaces.AnalyticsEvent{Kind: interfaces.CustomAnalyticsEvent, Custom: &custom}, e3)
+}
+
+func TestRemovePrivateAttributes(t *testing.T) {
+	context := ldcontext.NewMulti(
+		ldcontext.NewBuilder("u").Name("a").SetString("email", "b").Anonymous(true).Build(),
+		ldcontext.NewBuilder("o").Kind("org").Name("c").SetString("email", "d").Private("name").Build(),
+	)
+
+	t.Run("no private attributes", func(t *testing.T) {
+		c := ldcontext.NewBuilder("u").Name("a").Build()
+		assert.Equal(t, c, removePrivateAttributes(c, false, nil))
+	})
+
+	t.Run("private attributes in config and context", func(t *testing.T) {
+		expected := ldcontext.NewMulti(
+			ldcontext.NewBuilder("u").Name("a").Anonymous(true).Build(),
+			ldcontext.NewBuilder("o").Kind("org").Private("name").Build(),
+		)
+		actual := removePrivateAttributes(context, false, []ldattr.Ref{ldattr.NewRef("email")})
+		assert.True(t, expected.Equal(actual), "expected: %s\nactual: %s", expected, actual)
+	})
+
+	t.Run("all attributes private", func(t *testing.T) {
+		expected := ldcontext.NewMulti(
+			ldcontext.NewBuilder("u").Anonymous(true).Build(),
+			ldcontext.NewBuilder("o").Kind("org").Private("name").Build(),
+		)
+		actual := removePrivateAttributes(context, true, nil)
+		assert.True(t, expected.Equal(actual), "expected: %s\nactual: %s", expected, actual)
+	})
+}
@@ -0,0 +1,197 @@
+package events
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+)
+
+const samplingRatioProperty = "samplingRatio"
+
+// EventSamplingConfig contains the sampling ratios for ContextEventProcessorConfig. Each ratio N means
+// that 1 out of every N events of that kind is sent; 1 means that all are sent, and 0 means that none are
+// sent.
+type EventSamplingConfig struct {
+	// FeatureEventRatio is the sampling ratio for full feature events.
+	FeatureEventRatio int
+	// FlagFeatureEventRatios contains sampling ratios for the feature events of specific flags, which
+	// override FeatureEventRatio.
+	FlagFeatureEventRatios map[string]int
+	// CustomEventRatio is the sampling ratio for custom events.
+	CustomEventRatio int
+}
+
+// IsEnabled returns true if any of the ratios would cause events to be discarded.
+func (c EventSamplingConfig) IsEnabled() bool {
+	if c.FeatureEventRatio != 1 || c.CustomEventRatio != 1 {
+		return true
+	}
+	for _, ratio := range c.FlagFeatureEventRatios {
+		if ratio != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (c EventSamplingConfig) featureEventRatio(flagKey string) int {
+	if ratio, ok := c.FlagFeatureEventRatios[flagKey]; ok {
+		return ratio
+	}
+	return c.FeatureEventRatio
+}
+
+// isSampled returns true if an event should be kept, given a sampling ratio of 1 in N. The decision is
+// based only on the flag key or event key and the context keys, so that it is the same every time.
+func isSampled(ratio int, key string, context ldcontext.Context) bool {
+	if ratio <= 0 {
+		return false
+	}
+	if ratio == 1 {
+		return true
+	}
+	kindKeys := make([]string, 0, context.IndividualContextCount())
+	for i := 0; i < context.IndividualContextCount(); i++ {
+		ic := context.IndividualContextByIndex(i)
+		kindKeys = append(kindKeys, string(ic.Kind())+":"+ic.Key())
+	}
+	sort.Strings(kindKeys)
+	var b strings.Builder
+	b.WriteString(key)
+	for _, kindKey := range kindKeys {
+		b.WriteString(":")
+		b.WriteString(kindKey)
+	}
+	hash := sha256.Sum256([]byte(b.String()))
+	return binary.BigEndian.Uint64(hash[:8])%uint64(ratio) == 0
+}
+
+// The following functions produce the JSON for events that are kept by sampling, which the event processor
+// cannot produce itself because it has no way to add the samplingRatio property. They are passed to the
+// event processor as raw events, in the same format that it uses for its own output.
+
+func makeSampledFeatureEvent(e ldevents.EvaluationData, context ldcontext.Context, ratio int) json.RawMessage {
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	beginEventFields(&obj, ldevents.FeatureRequestEventKind, e.CreationDate)
+	obj.Name("key").String(e.Key)
+	obj.Maybe("version", e.Version.IsDefined()).Int(e.Version.IntValue())
+	writeContextKeys(&obj, context)
+	obj.Maybe("variation", e.Variation.IsDefined()).Int(e.Variation.IntValue())
+	e.Value.WriteToJSONWriter(obj.Name("value"))
+	e.Default.WriteToJSONWriter(obj.Name("default"))
+	obj.Maybe("prereqOf", e.PrereqOf.IsDefined()).String(e.PrereqOf.StringValue())
+	if e.Reason.GetKind() != "" {
+		e.Reason.WriteToJSONWriter(obj.Name("reason"))
+	}
+	obj.Name(samplingRatioProperty).Int(ratio)
+	obj.End()
+	return w.Bytes()
+}
+
+func makeSampledCustomEvent(e ldevents.CustomEventData, context ldcontext.Context, ratio int) json.RawMessage {
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	beginEventFields(&obj, ldevents.CustomEventKind, e.CreationDate)
+	obj.Name("key").String(e.Key)
+	if !e.Data.IsNull() {
+		e.Data.WriteToJSONWriter(obj.Name("data"))
+	}
+	writeContextKeys(&obj, context)
+	obj.Maybe("metricValue", e.HasMetric).Float64(e.MetricValue)
+	obj.Name(samplingRatioProperty).Int(ratio)
+	obj.End()
+	return w.Bytes()
+}
+
+// makeIndexEvent produces an index event for the context of a sampled custom event. Normally the event
+// processor adds one the first time it sees a context, but it does not see the contexts of raw events.
+func makeIndexEvent(
+	creationDate ldtime.UnixMillisecondTime,
+	context ldcontext.Context,
+	allAttributesPrivate bool,
+	privateAttributes []ldattr.Ref,
+) json.RawMessage {
+	w := jwriter.NewWriter()
+	obj := w.Object()
+	beginEventFields(&obj, ldevents.IndexEventKind, creationDate)
+	writeEventContext(obj.Name("context"), context, allAttributesPrivate, privateAttributes)
+	obj.End()
+	return w.Bytes()
+}
+
+func beginEventFields(obj *jwriter.ObjectState, kind string, creationDate ldtime.UnixMillisecondTime) {
+	obj.Name("kind").String(kind)
+	obj.Name("creationDate").Float64(float64(creationDate))
+}
+
+func writeContextKeys(obj *jwriter.ObjectState, context ldcontext.Context) {
+	keysObj := obj.Name("contextKeys").Object()
+	for i := 0; i < context.IndividualContextCount(); i++ {
+		ic := context.IndividualContextByIndex(i)
+		keysObj.Name(string(ic.Kind())).String(ic.Key())
+	}
+	keysObj.End()
+}
+
+// writeEventContext writes a context in the format that the event processor uses for contexts in events,
+// omitting private attributes and listing them in the redactedAttributes metadata.
+func writeEventContext(
+	w *jwriter.Writer,
+	context ldcontext.Context,
+	allAttributesPrivate bool,
+	privateAttributes []ldattr.Ref,
+) {
+	if !context.Multiple() {
+		writeSingleEventContext(w, context, true, allAttributesPrivate, privateAttributes)
+		return
+	}
+	obj := w.Object()
+	obj.Name(ldattr.KindAttr).String(string(ldcontext.MultiKind))
+	for i := 0; i < context.IndividualContextCount(); i++ {
+		ic := context.IndividualContextByIndex(i)
+		obj.Name(string(ic.Kind()))
+		writeSingleEventContext(w, ic, false, allAttributesPrivate, privateAttributes)
+	}
+	obj.End()
+}
+
+func writeSingleEventContext(
+	w *jwriter.Writer,
+	context ldcontext.Context,
+	includeKind bool,
+	allAttributesPrivate bool,
+	privateAttributes []ldattr.Ref,
+) {
+	var redacted []string
+	filtered := removePrivateAttributesFromSingleContext(context, allAttributesPrivate, privateAttributes, &redacted)
+	obj := w.Object()
+	if includeKind {
+		obj.Name(ldattr.KindAttr).String(string(filtered.Kind()))
+	}
+	obj.Name(ldattr.KeyAttr).String(filtered.Key())
+	for _, name := range filtered.GetOptionalAttributeNames(nil) {
+		filtered.GetValue(name).WriteToJSONWriter(obj.Name(name))
+	}
+	if filtered.Anonymous() {
+		obj.Name(ldattr.AnonymousAttr).Bool(true)
+	}
+	if len(redacted) != 0 {
+		metaObj := obj.Name("_meta").Object()
+		redactedArr := metaObj.Name("redactedAttributes").Array()
+		for _, a := range redacted {
+			redactedArr.String(a)
+		}
+		redactedArr.End()
+		metaObj.End()
+	}
+	obj.End()
+}
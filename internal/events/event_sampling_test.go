@@ -0,0 +1,288 @@
+package events
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-jsonstream/v3/jwriter"
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentPayload struct {
+	kind       ldevents.EventDataKind
+	data       []byte
+	eventCount int
+}
+
+type recordingLDEventSender struct {
+	payloads []sentPayload
+}
+
+func (s *recordingLDEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	s.payloads = append(s.payloads, sentPayload{kind, data, eventCount})
+	return ldevents.EventSenderResult{Success: true}
+}
+
+func makeSamplingTestProcessor(
+	sender ldevents.EventSender,
+	sampling EventSamplingConfig,
+	privateAttributes ...ldattr.Ref,
+) (ldevents.EventProcessor, ContextEventRecorder) {
+	ep := ldevents.NewDefaultEventProcessor(ldevents.EventsConfiguration{
+		Capacity:              1000,
+		EventSender:           sender,
+		FlushInterval:         time.Hour,
+		Loggers:               ldlog.NewDisabledLoggers(),
+		PrivateAttributes:     privateAttributes,
+		UserKeysCapacity:      1000,
+		UserKeysFlushInterval: time.Hour,
+	})
+	cep := NewContextEventProcessor(ep, ContextEventProcessorConfig{
+		Sampling:          &sampling,
+		PrivateAttributes: privateAttributes,
+	})
+	return cep, cep.(ContextEventRecorder)
+}
+
+func flushAndGetOutputEvents(t *testing.T, ep ldevents.EventProcessor, sender *recordingLDEventSender) []ldvalue.Value {
+	require.True(t, ep.FlushBlocking(time.Second))
+	require.Len(t, sender.payloads, 1)
+	return ldvalue.Parse(sender.payloads[0].data).AsValueArray().AsSlice()
+}
+
+func findSampledContext(ratio int, key string) ldcontext.Context {
+	for i := 0; ; i++ {
+		if context := ldcontext.New(fmt.Sprintf("user-%d", i)); isSampled(ratio, key, context) {
+			return context
+		}
+	}
+}
+
+func findUnsampledContext(ratio int, key string) ldcontext.Context {
+	for i := 0; ; i++ {
+		if context := ldcontext.New(fmt.Sprintf("user-%d", i)); !isSampled(ratio, key, context) {
+			return context
+		}
+	}
+}
+
+func TestEventSamplingConfigIsEnabled(t *testing.T) {
+	assert.False(t, EventSamplingConfig{FeatureEventRatio: 1, CustomEventRatio: 1}.IsEnabled())
+	assert.False(t, EventSamplingConfig{FeatureEventRatio: 1, CustomEventRatio: 1,
+		FlagFeatureEventRatios: map[string]int{"flag": 1}}.IsEnabled())
+	assert.True(t, EventSamplingConfig{FeatureEventRatio: 2, CustomEventRatio: 1}.IsEnabled())
+	assert.True(t, EventSamplingConfig{FeatureEventRatio: 1, CustomEventRatio: 0}.IsEnabled())
+	assert.True(t, EventSamplingConfig{FeatureEventRatio: 1, CustomEventRatio: 1,
+		FlagFeatureEventRatios: map[string]int{"flag": 5}}.IsEnabled())
+}
+
+func TestIsSampled(t *testing.T) {
+	t.Run("ratio of 1 or 0", func(t *testing.T) {
+		assert.True(t, isSampled(1, "flag", ldcontext.New("a")))
+		assert.False(t, isSampled(0, "flag", ldcontext.New("a")))
+	})
+
+	t.Run("is deterministic", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("user-%d", i)
+			assert.Equal(t, isSampled(3, "flag", ldcontext.New(key)), isSampled(3, "flag", ldcontext.New(key)))
+		}
+	})
+
+	t.Run("does not depend on order of context kinds", func(t *testing.T) {
+		for i := 0; i < 100; i++ {
+			user, org := ldcontext.New(fmt.Sprintf("u%d", i)), ldcontext.NewWithKind("org", "o")
+			assert.Equal(t, isSampled(2, "flag", ldcontext.NewMulti(user, org)),
+				isSampled(2, "flag", ldcontext.NewMulti(org, user)))
+		}
+	})
+
+	t.Run("keeps approximately 1 in N", func(t *testing.T) {
+		count := 0
+		for i := 0; i < 10000; i++ {
+			if isSampled(10, "flag", ldcontext.New(fmt.Sprintf("user-%d", i))) {
+				count++
+			}
+		}
+		assert.InDelta(t, 1000, count, 150)
+	})
+}
+
+func TestSampledFeatureEvents(t *testing.T) {
+	sampling := EventSamplingConfig{
+		FeatureEventRatio:      0,
+		FlagFeatureEventRatios: map[string]int{"sampled-flag": 2, "unsampled-flag": 1},
+		CustomEventRatio:       1,
+	}
+	context := findSampledContext(2, "sampled-flag")
+	makeEval := func(flagKey string, requireFullEvent bool) ldevents.EvaluationData {
+		return ldevents.EvaluationData{
+			BaseEvent:        ldevents.BaseEvent{CreationDate: 1000, Context: ldevents.Context(context)},
+			Key:              flagKey,
+			Version:          ldvalue.NewOptionalInt(11),
+			Variation:        ldvalue.NewOptionalInt(1),
+			Value:            ldvalue.Bool(true),
+			Default:          ldvalue.Bool(false),
+			Reason:           ldreason.NewEvalReasonFallthrough(),
+			RequireFullEvent: requireFullEvent,
+		}
+	}
+
+	t.Run("sampled event has ratio, and all evaluations are counted", func(t *testing.T) {
+		sender := &recordingLDEventSender{}
+		ep, recorder := makeSamplingTestProcessor(sender, sampling)
+		recorder.RecordEvaluationForContext(makeEval("sampled-flag", true), context)
+		recorder.RecordEvaluationForContext(makeEval("other-flag", true), context)
+		recorder.RecordEvaluationForContext(makeEval("untracked-flag", false), context)
+
+		output := flushAndGetOutputEvents(t, ep, sender)
+		require.Len(t, output, 3)
+		assert.Equal(t, "index", output[0].GetByKey("kind").StringValue())
+		assert.JSONEq(t, `{"kind":"feature","creationDate":1000,"key":"sampled-flag","version":11,
+			"contextKeys":{"user":"`+context.Key()+`"},"variation":1,"value":true,"default":false,
+			"reason":{"kind":"FALLTHROUGH"},"samplingRatio":2}`, output[1].JSONString())
+		assert.Equal(t, "summary", output[2].GetByKey("kind").StringValue())
+		assert.ElementsMatch(t, []string{"sampled-flag", "other-flag", "untracked-flag"},
+			output[2].GetByKey("features").Keys(nil))
+	})
+
+	t.Run("unsampled event is not sent", func(t *testing.T) {
+		sender := &recordingLDEventSender{}
+		ep, recorder := makeSamplingTestProcessor(sender, sampling)
+		unsampledContext := findUnsampledContext(2, "sampled-flag")
+		recorder.RecordEvaluationForContext(makeEval("sampled-flag", true), unsampledContext)
+
+		output := flushAndGetOutputEvents(t, ep, sender)
+		require.Len(t, output, 2)
+		assert.Equal(t, "index", output[0].GetByKey("kind").StringValue())
+		assert.Equal(t, "summary", output[1].GetByKey("kind").StringValue())
+	})
+
+	t.Run("event with ratio of 1 is sent by the event processor", func(t *testing.T) {
+		sender := &recordingLDEventSender{}
+		ep, recorder := makeSamplingTestProcessor(sender, sampling)
+		recorder.RecordEvaluationForContext(makeEval("unsampled-flag", true), context)
+
+		output := flushAndGetOutputEvents(t, ep, sender)
+		require.Len(t, output, 3)
+		assert.Equal(t, "feature", output[1].GetByKey("kind").StringValue())
+		assert.False(t, output[1].GetByKey("samplingRatio").IsDefined())
+	})
+}
+
+func TestSampledCustomEvents(t *testing.T) {
+	sampling := EventSamplingConfig{FeatureEventRatio: 1, CustomEventRatio: 2}
+	makeCustom := func(context ldcontext.Context) ldevents.CustomEventData {
+		return ldevents.CustomEventData{
+			BaseEvent:   ldevents.BaseEvent{CreationDate: 1000, Context: ldevents.Context(context)},
+			Key:         "event-key",
+			Data:        ldvalue.String("x"),
+			HasMetric:   true,
+			MetricValue: 1.5,
+		}
+	}
+
+	t.Run("sampled event has ratio and an index event", func(t *testing.T) {
+		sender := &recordingLDEventSender{}
+		ep, recorder := makeSamplingTestProcessor(sender, sampling, ldattr.NewRef("email"))
+		context := ldcontext.NewBuilderFromContext(findSampledContext(2, "event-key")).
+			Name("a").
+			SetString("email", "b").
+			Build()
+		recorder.RecordCustomEventForContext(makeCustom(context), context)
+
+		output := flushAndGetOutputEvents(t, ep, sender)
+		require.Len(t, output, 2)
+		assert.JSONEq(t, `{"kind":"index","creationDate":1000,"context":{"kind":"user","key":"`+context.Key()+
+			`","name":"a","_meta":{"redactedAttributes":["email"]}}}`, output[0].JSONString())
+		assert.JSONEq(t, `{"kind":"custom","creationDate":1000,"key":"event-key","data":"x",
+			"contextKeys":{"user":"`+context.Key()+`"},"metricValue":1.5,"samplingRatio":2}`, output[1].JSONString())
+	})
+
+	t.Run("unsampled event is not sent", func(t *testing.T) {
+		sender := &recordingLDEventSender{}
+		ep, recorder := makeSamplingTestProcessor(sender, sampling)
+		context := findUnsampledContext(2, "event-key")
+		recorder.RecordCustomEventForContext(makeCustom(context), context)
+
+		assert.True(t, ep.FlushBlocking(time.Second))
+		assert.Len(t, sender.payloads, 0)
+	})
+}
+
+func TestWriteEventContextMatchesEventProcessorFormat(t *testing.T) {
+	contexts := []ldcontext.Context{
+		ldcontext.New("a"),
+		ldcontext.NewBuilder("a").Name("b").Anonymous(true).Build(),
+		ldcontext.NewBuilder("a").
+			SetString("email", "b").
+			SetString("phone", "c").
+			SetValue("address", ldvalue.Parse([]byte(`{"street":"d","city":"e","geo":{"lat":1}}`))).
+			Private("phone", "/address/geo/lat").
+			Build(),
+		ldcontext.NewMulti(
+			ldcontext.NewBuilder("a").SetString("email", "b").Build(),
+			ldcontext.NewBuilder("c").Kind("org").Name("d").Private("name").Build(),
+		),
+	}
+	configs := []ldevents.EventsConfiguration{
+		{},
+		{AllAttributesPrivate: true},
+		{PrivateAttributes: []ldattr.Ref{ldattr.NewRef("email"), ldattr.NewRef("/address/street")}},
+	}
+	for _, config := range configs {
+		for _, context := range contexts {
+			sender := &recordingLDEventSender{}
+			config.EventSender = sender
+			config.Capacity = 1000
+			config.FlushInterval = time.Hour
+			config.Loggers = ldlog.NewDisabledLoggers()
+			ep := ldevents.NewDefaultEventProcessor(config)
+			ep.RecordIdentifyEvent(ldevents.IdentifyEventData{BaseEvent: ldevents.BaseEvent{Context: ldevents.Context(context)}})
+			output := flushAndGetOutputEvents(t, ep, sender)
+			_ = ep.Close()
+
+			w := jwriter.NewWriter()
+			writeEventContext(&w, context, config.AllAttributesPrivate, config.PrivateAttributes)
+			require.NoError(t, w.Error())
+			assert.Equal(t, sortRedactedAttributes(output[0].GetByKey("context")),
+				sortRedactedAttributes(ldvalue.Parse(w.Bytes())))
+		}
+	}
+}
+
+// sortRedactedAttributes sorts the redactedAttributes lists in the JSON of a context in an event, since
+// their order depends on the order of attributes in the context, which is not defined.
+func sortRedactedAttributes(contextJSON ldvalue.Value) ldvalue.Value {
+	props := contextJSON.AsValueMap().AsMap()
+	if redacted := contextJSON.GetByKey("_meta").GetByKey("redactedAttributes"); redacted.Count() != 0 {
+		var names []string
+		for _, a := range redacted.AsValueArray().AsSlice() {
+			names = append(names, a.StringValue())
+		}
+		sort.Strings(names)
+		props["_meta"] = ldvalue.ObjectBuild().Set("redactedAttributes", ldvalue.CopyArbitraryValue(names)).Build()
+	}
+	if contextJSON.GetByKey("kind").StringValue() == "multi" {
+		for name, value := range props {
+			if name != "kind" {
+				props[name] = sortRedactedAttributes(value)
+			}
+		}
+	}
+	return ldvalue.CopyObject(props)
+}
@@ -0,0 +1,399 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+const (
+	spoolFileSuffix               = ".json"
+	spoolTempFileSuffix           = ".tmp"
+	defaultSpoolInitialRetryDelay = time.Second
+	defaultSpoolMaxRetryDelay     = 5 * time.Minute
+)
+
+// EventSpoolConfig contains the parameters for NewEventSpool.
+type EventSpoolConfig struct {
+	// Directory is the directory where undelivered payloads are stored. It is created if necessary.
+	Directory string
+	// MaxSize is the maximum total size of the stored payloads, in bytes.
+	MaxSize int64
+	// Sender is the component that actually delivers the payloads.
+	Sender subsystems.EventSender
+	// MakeHeaders is called to get the headers for a payload that is being resent. Headers are not stored
+	// in the spool, since they include the SDK key.
+	MakeHeaders func(kind subsystems.EventPayloadKind, payloadID string) http.Header
+	// InitialRetryDelay is the delay before the first attempt to resend, or 0 for the default.
+	InitialRetryDelay time.Duration
+	// MaxRetryDelay is the maximum delay between attempts to resend, or 0 for the default.
+	MaxRetryDelay time.Duration
+	// Loggers is used for logging.
+	Loggers ldlog.Loggers
+}
+
+// EventSpoolStats contains counts that are reported in diagnostic events.
+type EventSpoolStats struct {
+	// SpooledEvents is the number of events that were stored in the spool since the last call to
+	// GetAndResetStats.
+	SpooledEvents int
+	// DroppedEvents is the number of events that were discarded from the spool since the last call to
+	// GetAndResetStats, either because the spool was full or because they could not be delivered.
+	DroppedEvents int
+	// PendingPayloads is the number of payloads that are currently stored in the spool.
+	PendingPayloads int
+}
+
+// EventSpool is an implementation of subsystems.EventSender that adds durable storage to another
+// EventSender. If a payload of analytics events cannot be delivered because of an error that might be
+// temporary, it is written to a file in the spool directory, and a background goroutine tries to resend
+// it, with an exponential backoff, until it succeeds; if LaunchDarkly rejects it with an error such as a
+// 401 that means no further events should be sent, resending stops and the files are kept. Payloads that
+// were left in the directory by an earlier process are resent at startup. A resent payload has the same
+// payload ID as the original, so LaunchDarkly can discard duplicates.
+//
+// The total size of the stored payloads is limited; if it would be exceeded, the oldest payloads are
+// discarded. Diagnostic payloads are never stored.
+type EventSpool struct {
+	config    EventSpoolConfig
+	entries   []spoolEntry // sorted from oldest to newest
+	totalSize int64
+	stats     EventSpoolStats
+	wakeCh    chan struct{}
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+	lock      sync.Mutex
+}
+
+type spoolEntry struct {
+	fileName   string
+	size       int64
+	eventCount int
+}
+
+// spoolRecord is the JSON representation of a payload in a spool file.
+type spoolRecord struct {
+	Kind       subsystems.EventPayloadKind `json:"kind"`
+	PayloadID  string                      `json:"payloadId"`
+	EventCount int                         `json:"eventCount"`
+	Data       json.RawMessage             `json:"data"`
+}
+
+// NewEventSpool creates an EventSpool, loads any payloads that are already in its directory, and starts
+// the goroutine that resends them.
+func NewEventSpool(config EventSpoolConfig) (*EventSpool, error) {
+	if config.InitialRetryDelay <= 0 {
+		config.InitialRetryDelay = defaultSpoolInitialRetryDelay
+	}
+	if config.MaxRetryDelay <= 0 {
+		config.MaxRetryDelay = defaultSpoolMaxRetryDelay
+	}
+	if err := os.MkdirAll(config.Directory, 0700); err != nil {
+		return nil, fmt.Errorf("unable to create event spool directory: %w", err)
+	}
+	s := &EventSpool{
+		config:  config,
+		wakeCh:  make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if len(s.entries) != 0 {
+		config.Loggers.Infof("Found %d undelivered event payload(s) in event spool; will resend", len(s.entries))
+	}
+	go s.run(len(s.entries) != 0)
+	return s, nil
+}
+
+// SendEventData tries to deliver the payload; if that fails with a retryable error, it stores the payload
+// to be resent later and returns nil.
+func (s *EventSpool) SendEventData(payload subsystems.EventPayload) error {
+	err := s.config.Sender.SendEventData(payload)
+	if err == nil || payload.Kind != subsystems.AnalyticsEventPayload ||
+		!errors.As(err, &subsystems.RetryableEventSenderError{}) {
+		return err
+	}
+	if spoolErr := s.add(payload); spoolErr != nil {
+		s.config.Loggers.Errorf("Unable to store undelivered events in event spool: %s", spoolErr)
+		return err
+	}
+	s.config.Loggers.Warnf("Error sending events, will resend from event spool: %s", err)
+	return nil
+}
+
+// GetAndResetStats returns the current statistics, and resets the counts to zero.
+func (s *EventSpool) GetAndResetStats() EventSpoolStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ret := s.stats
+	ret.PendingPayloads = len(s.entries)
+	s.stats = EventSpoolStats{}
+	return ret
+}
+
+// Close stops the resend goroutine. Any payloads that have not been delivered remain in the directory.
+func (s *EventSpool) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+		<-s.doneCh
+	})
+}
+
+func (s *EventSpool) load() error {
+	dirEntries, err := os.ReadDir(s.config.Directory)
+	if err != nil {
+		return fmt.Errorf("unable to read event spool directory: %w", err)
+	}
+	for _, de := range dirEntries { // ReadDir sorts by name, so this is from oldest to newest
+		name := de.Name()
+		if de.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(name, spoolTempFileSuffix) { // left over from a write that did not complete
+			_ = os.Remove(filepath.Join(s.config.Directory, name))
+			continue
+		}
+		if !strings.HasSuffix(name, spoolFileSuffix) {
+			continue
+		}
+		record, size, err := s.readRecord(name)
+		if err != nil {
+			s.config.Loggers.Warnf("Discarding invalid event spool file %s: %s", name, err)
+			_ = os.Remove(filepath.Join(s.config.Directory, name))
+			continue
+		}
+		s.entries = append(s.entries, spoolEntry{fileName: name, size: size, eventCount: record.EventCount})
+		s.totalSize += size
+	}
+	s.lock.Lock()
+	s.evictOldestUntilAvailable(0) // in case MaxSize has been reduced since the files were written
+	s.lock.Unlock()
+	return nil
+}
+
+func (s *EventSpool) add(payload subsystems.EventPayload) error {
+	data, err := json.Marshal(spoolRecord{
+		Kind:       payload.Kind,
+		PayloadID:  payload.PayloadID,
+		EventCount: payload.EventCount,
+		Data:       payload.Data,
+	})
+	if err != nil {
+		return err
+	}
+	size := int64(len(data))
+	if size > s.config.MaxSize {
+		s.lock.Lock()
+		s.stats.DroppedEvents += payload.EventCount
+		s.lock.Unlock()
+		s.config.Loggers.Warnf("Event payload of %d bytes is too large for event spool; events were dropped", size)
+		return nil
+	}
+
+	// The file is written before taking the lock, so that other senders and the resend goroutine are not
+	// blocked by disk I/O. The time prefix makes the files sort from oldest to newest, and the payload ID
+	// makes the name unique.
+	fileName := fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), payload.PayloadID, spoolFileSuffix)
+	filePath := filepath.Join(s.config.Directory, fileName)
+	if err := os.WriteFile(filePath+spoolTempFileSuffix, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(filePath+spoolTempFileSuffix, filePath); err != nil {
+		_ = os.Remove(filePath + spoolTempFileSuffix)
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.evictOldestUntilAvailable(size)
+	// Another payload may have been added while this one was being written, so keep the entries in order.
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].fileName > fileName })
+	s.entries = append(s.entries, spoolEntry{})
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = spoolEntry{fileName: fileName, size: size, eventCount: payload.EventCount}
+	s.totalSize += size
+	s.stats.SpooledEvents += payload.EventCount
+
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// evictOldestUntilAvailable must be called while holding the lock.
+func (s *EventSpool) evictOldestUntilAvailable(size int64) {
+	for len(s.entries) != 0 && s.totalSize+size > s.config.MaxSize {
+		oldest := s.entries[0]
+		s.entries = s.entries[1:]
+		s.totalSize -= oldest.size
+		s.stats.DroppedEvents += oldest.eventCount
+		_ = os.Remove(filepath.Join(s.config.Directory, oldest.fileName))
+		s.config.Loggers.Warnf("Event spool is full; dropped %d event(s)", oldest.eventCount)
+	}
+}
+
+func (s *EventSpool) readRecord(fileName string) (spoolRecord, int64, error) {
+	var record spoolRecord
+	filePath := filepath.Join(s.config.Directory, fileName)
+	data, err := os.ReadFile(filePath) //nolint:gosec // G304: ok to read file into variable
+	if err != nil {
+		return record, 0, err
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, 0, err
+	}
+	if record.Kind != subsystems.AnalyticsEventPayload || len(record.Data) == 0 {
+		return record, 0, errors.New("not an analytics event payload")
+	}
+	return record, int64(len(data)), nil
+}
+
+func (s *EventSpool) run(resendImmediately bool) {
+	defer close(s.doneCh)
+	delay := s.config.InitialRetryDelay
+	if resendImmediately {
+		delay = 0
+	}
+	for {
+		if !s.hasPending() {
+			select {
+			case <-s.wakeCh:
+			case <-s.closeCh:
+				return
+			}
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-s.closeCh:
+			timer.Stop()
+			return
+		}
+		switch s.resendPending() {
+		case resendCompleted:
+			delay = s.config.InitialRetryDelay
+			continue
+		case resendStopped:
+			return
+		}
+		delay *= 2
+		if delay < s.config.InitialRetryDelay {
+			delay = s.config.InitialRetryDelay
+		}
+		if delay > s.config.MaxRetryDelay {
+			delay = s.config.MaxRetryDelay
+		}
+		s.config.Loggers.Warnf("Unable to resend events from event spool; will retry after %s", delay)
+	}
+}
+
+func (s *EventSpool) hasPending() bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.entries) != 0
+}
+
+// resendOutcome is the result of resendPending.
+type resendOutcome int
+
+const (
+	resendCompleted resendOutcome = iota // there are no stored payloads left
+	resendWillRetry                      // a retryable error occurred
+	resendStopped                        // the spool was closed, or an error occurred that means we must stop
+)
+
+// resendPending tries to deliver stored payloads from oldest to newest, until there are none left or it
+// gets an error that means it should stop for now.
+//
+// If LaunchDarkly responds with an error that means no further events should be sent, such as a 401 for
+// an invalid SDK key, it stops resending altogether. The stored payloads are kept, so that they can be
+// delivered by a later process that has a valid configuration.
+func (s *EventSpool) resendPending() resendOutcome {
+	for {
+		select {
+		case <-s.closeCh:
+			return resendStopped
+		default:
+		}
+		s.lock.Lock()
+		if len(s.entries) == 0 {
+			s.lock.Unlock()
+			return resendCompleted
+		}
+		entry := s.entries[0]
+		s.lock.Unlock()
+		record, _, readErr := s.readRecord(entry.fileName)
+		if readErr != nil {
+			if !s.hasEntry(entry.fileName) {
+				continue // it was evicted while we were reading it
+			}
+			s.config.Loggers.Warnf("Discarding invalid event spool file %s: %s", entry.fileName, readErr)
+			s.remove(entry.fileName, true)
+			continue
+		}
+
+		err := s.config.Sender.SendEventData(subsystems.EventPayload{
+			Kind:       record.Kind,
+			Data:       record.Data,
+			EventCount: record.EventCount,
+			PayloadID:  record.PayloadID,
+			Headers:    s.config.MakeHeaders(record.Kind, record.PayloadID),
+		})
+		switch {
+		case err == nil:
+			s.config.Loggers.Debugf("Resent %d event(s) from event spool", record.EventCount)
+			s.remove(entry.fileName, false)
+		case errors.As(err, &subsystems.RetryableEventSenderError{}):
+			return resendWillRetry
+		case errors.As(err, &UnrecoverableHTTPError{}):
+			s.config.Loggers.Errorf("Error resending events from event spool (giving up permanently;"+
+				" undelivered events remain in the spool directory): %s", err)
+			return resendStopped
+		default:
+			s.config.Loggers.Warnf("Error resending events from event spool, some events were dropped: %s", err)
+			s.remove(entry.fileName, true)
+		}
+	}
+}
+
+func (s *EventSpool) hasEntry(fileName string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, e := range s.entries {
+		if e.fileName == fileName {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *EventSpool) remove(fileName string, dropped bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, e := range s.entries {
+		if e.fileName == fileName {
+			if dropped {
+				s.stats.DroppedEvents += e.eventCount
+			}
+			s.totalSize -= e.size
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			_ = os.Remove(filepath.Join(s.config.Directory, fileName))
+			return
+		}
+	}
+	// if we get here, the entry was already evicted
+}
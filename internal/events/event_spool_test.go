@@ -0,0 +1,299 @@
+package events
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSender records payloads and returns whatever error is currently set.
+type testSender struct {
+	payloadsCh chan subsystems.EventPayload
+	err        error
+	lock       sync.Mutex
+}
+
+func newTestSender() *testSender {
+	return &testSender{payloadsCh: make(chan subsystems.EventPayload, 100)}
+}
+
+func (s *testSender) SendEventData(payload subsystems.EventPayload) error {
+	s.lock.Lock()
+	err := s.err
+	s.lock.Unlock()
+	select {
+	case s.payloadsCh <- payload:
+	default:
+	}
+	return err
+}
+
+func (s *testSender) setError(err error) {
+	s.lock.Lock()
+	s.err = err
+	s.lock.Unlock()
+}
+
+func (s *testSender) drain() {
+	for len(s.payloadsCh) > 0 {
+		<-s.payloadsCh
+	}
+}
+
+var errRetryable = subsystems.RetryableEventSenderError{Err: errors.New("sorry")} //nolint:gochecknoglobals
+
+func makeTestSpool(t *testing.T, dir string, sender subsystems.EventSender, maxSize int64) *EventSpool {
+	mockLog := ldlogtest.NewMockLog()
+	spool, err := NewEventSpool(EventSpoolConfig{
+		Directory: dir,
+		MaxSize:   maxSize,
+		Sender:    sender,
+		MakeHeaders: func(kind subsystems.EventPayloadKind, payloadID string) http.Header {
+			return http.Header{"X-Test-Payload-Id": []string{payloadID}}
+		},
+		InitialRetryDelay: time.Millisecond * 10,
+		MaxRetryDelay:     time.Millisecond * 50,
+		Loggers:           mockLog.Loggers,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		spool.Close()
+		mockLog.DumpIfTestFailed(t)
+	})
+	return spool
+}
+
+func makeAnalyticsPayload(payloadID string, eventCount int) subsystems.EventPayload {
+	return subsystems.EventPayload{
+		Kind:       subsystems.AnalyticsEventPayload,
+		Data:       []byte(`[{"kind":"custom","key":"` + payloadID + `"}]`),
+		EventCount: eventCount,
+		PayloadID:  payloadID,
+	}
+}
+
+func spoolFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var ret []string
+	for _, e := range entries {
+		ret = append(ret, e.Name())
+	}
+	return ret
+}
+
+// waitForResends collects the payloads that the sender receives until the spool directory is empty. A
+// payload may be seen more than once, if the sender had failed an attempt that was already in progress.
+func waitForResends(t *testing.T, sender *testSender, dir string) []subsystems.EventPayload {
+	var ret []subsystems.EventPayload
+	deadline := time.After(time.Second)
+	for len(spoolFiles(t, dir)) != 0 {
+		select {
+		case p := <-sender.payloadsCh:
+			if len(ret) == 0 || ret[len(ret)-1].PayloadID != p.PayloadID {
+				ret = append(ret, p)
+			} else {
+				ret[len(ret)-1] = p
+			}
+		case <-deadline:
+			require.Fail(t, "timed out waiting for payloads to be resent")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	for len(sender.payloadsCh) > 0 {
+		p := <-sender.payloadsCh
+		if len(ret) == 0 || ret[len(ret)-1].PayloadID != p.PayloadID {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+func payloadIDs(payloads []subsystems.EventPayload) []string {
+	ret := make([]string, 0, len(payloads))
+	for _, p := range payloads {
+		ret = append(ret, p.PayloadID)
+	}
+	return ret
+}
+
+func TestEventSpoolDeliversPayloadDirectly(t *testing.T) {
+	dir := t.TempDir()
+	sender := newTestSender()
+	spool := makeTestSpool(t, dir, sender, 100000)
+
+	require.NoError(t, spool.SendEventData(makeAnalyticsPayload("payload-1", 1)))
+
+	assert.Equal(t, "payload-1", th.RequireValue(t, sender.payloadsCh, time.Second).PayloadID)
+	assert.Len(t, spoolFiles(t, dir), 0)
+	assert.Equal(t, EventSpoolStats{}, spool.GetAndResetStats())
+}
+
+func TestEventSpoolStoresAndResendsPayloadAfterRetryableError(t *testing.T) {
+	dir := t.TempDir()
+	sender := newTestSender()
+	sender.setError(errRetryable)
+	spool := makeTestSpool(t, dir, sender, 100000)
+
+	payload := makeAnalyticsPayload("payload-1", 2)
+	require.NoError(t, spool.SendEventData(payload))
+	th.RequireValue(t, sender.payloadsCh, time.Second) // the initial attempt
+	assert.Len(t, spoolFiles(t, dir), 1)
+	assert.Equal(t, EventSpoolStats{SpooledEvents: 2, PendingPayloads: 1}, spool.GetAndResetStats())
+
+	th.RequireValue(t, sender.payloadsCh, time.Second) // a resend attempt that fails
+	sender.drain()
+	sender.setError(nil)
+
+	resends := waitForResends(t, sender, dir)
+	require.Len(t, resends, 1)
+	resent := resends[0]
+	assert.Equal(t, payload.Kind, resent.Kind)
+	assert.Equal(t, payload.PayloadID, resent.PayloadID)
+	assert.Equal(t, payload.EventCount, resent.EventCount)
+	assert.JSONEq(t, string(payload.Data), string(resent.Data))
+	assert.Equal(t, "payload-1", resent.Headers.Get("X-Test-Payload-Id"))
+	assert.Equal(t, EventSpoolStats{}, spool.GetAndResetStats())
+}
+
+func TestEventSpoolDoesNotStorePayloadAfterOtherError(t *testing.T) {
+	dir := t.TempDir()
+	sender := newTestSender()
+	fakeError := errors.New("sorry")
+	sender.setError(fakeError)
+	spool := makeTestSpool(t, dir, sender, 100000)
+
+	assert.Equal(t, fakeError, spool.SendEventData(makeAnalyticsPayload("payload-1", 1)))
+	assert.Len(t, spoolFiles(t, dir), 0)
+}
+
+func TestEventSpoolDoesNotStoreDiagnosticPayload(t *testing.T) {
+	dir := t.TempDir()
+	sender := newTestSender()
+	sender.setError(errRetryable)
+	spool := makeTestSpool(t, dir, sender, 100000)
+
+	err := spool.SendEventData(subsystems.EventPayload{
+		Kind: subsystems.DiagnosticEventPayload,
+		Data: []byte(`{"kind":"diagnostic"}`),
+	})
+	assert.Equal(t, errRetryable, err)
+	assert.Len(t, spoolFiles(t, dir), 0)
+}
+
+func TestEventSpoolResendsPayloadsFromEarlierProcessAtStartup(t *testing.T) {
+	dir := t.TempDir()
+	failingSender := newTestSender()
+	failingSender.setError(errRetryable)
+	spool1 := makeTestSpool(t, dir, failingSender, 100000)
+	require.NoError(t, spool1.SendEventData(makeAnalyticsPayload("payload-1", 1)))
+	require.NoError(t, spool1.SendEventData(makeAnalyticsPayload("payload-2", 1)))
+	spool1.Close()
+	require.Len(t, spoolFiles(t, dir), 2)
+
+	sender := newTestSender()
+	spool2 := makeTestSpool(t, dir, sender, 100000)
+	assert.Equal(t, []string{"payload-1", "payload-2"}, payloadIDs(waitForResends(t, sender, dir)))
+	assert.Equal(t, EventSpoolStats{}, spool2.GetAndResetStats())
+}
+
+func TestEventSpoolDiscardsOldestPayloadsWhenFull(t *testing.T) {
+	dir := t.TempDir()
+	sender := newTestSender()
+	sender.setError(errRetryable)
+	spool := makeTestSpool(t, dir, sender, 250) // each of these payloads takes about 100 bytes
+
+	require.NoError(t, spool.SendEventData(makeAnalyticsPayload("payload-1", 1)))
+	require.NoError(t, spool.SendEventData(makeAnalyticsPayload("payload-2", 2)))
+	require.NoError(t, spool.SendEventData(makeAnalyticsPayload("payload-3", 3)))
+
+	assert.Len(t, spoolFiles(t, dir), 2)
+	assert.Equal(t, EventSpoolStats{SpooledEvents: 6, DroppedEvents: 1, PendingPayloads: 2}, spool.GetAndResetStats())
+
+	sender.drain()
+	sender.setError(nil)
+	assert.Equal(t, []string{"payload-2", "payload-3"}, payloadIDs(waitForResends(t, sender, dir)))
+}
+
+func TestEventSpoolDropsPayloadThatIsLargerThanMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	sender := newTestSender()
+	sender.setError(errRetryable)
+	spool := makeTestSpool(t, dir, sender, 10)
+
+	require.NoError(t, spool.SendEventData(makeAnalyticsPayload("payload-1", 3)))
+
+	assert.Len(t, spoolFiles(t, dir), 0)
+	assert.Equal(t, EventSpoolStats{DroppedEvents: 3}, spool.GetAndResetStats())
+}
+
+func TestEventSpoolDropsPayloadIfResendFailsWithOtherError(t *testing.T) {
+	dir := t.TempDir()
+	sender := newTestSender()
+	sender.setError(errRetryable)
+	spool := makeTestSpool(t, dir, sender, 100000)
+
+	require.NoError(t, spool.SendEventData(makeAnalyticsPayload("payload-1", 2)))
+	spool.GetAndResetStats()
+	sender.setError(errors.New("sorry"))
+
+	require.Eventually(t, func() bool { return len(spoolFiles(t, dir)) == 0 }, time.Second, time.Millisecond)
+	assert.Equal(t, EventSpoolStats{DroppedEvents: 2}, spool.GetAndResetStats())
+}
+
+func TestEventSpoolKeepsPayloadsAndStopsResendingAfterUnrecoverableError(t *testing.T) {
+	dir := t.TempDir()
+	failingSender := newTestSender()
+	failingSender.setError(errRetryable)
+	spool1 := makeTestSpool(t, dir, failingSender, 100000)
+	require.NoError(t, spool1.SendEventData(makeAnalyticsPayload("payload-1", 2)))
+	require.NoError(t, spool1.SendEventData(makeAnalyticsPayload("payload-2", 3)))
+	spool1.Close()
+
+	sender := newTestSender()
+	sender.setError(UnrecoverableHTTPError{StatusCode: 401})
+	spool2 := makeTestSpool(t, dir, sender, 100000)
+
+	p := th.RequireValue(t, sender.payloadsCh, time.Second)
+	assert.Equal(t, "payload-1", p.PayloadID)
+	th.AssertNoMoreValues(t, sender.payloadsCh, time.Millisecond*100)
+
+	assert.Len(t, spoolFiles(t, dir), 2)
+	assert.Equal(t, EventSpoolStats{PendingPayloads: 2}, spool2.GetAndResetStats())
+}
+
+func TestEventSpoolRemovesInvalidFilesAtStartup(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1-a.json"), []byte(`not JSON`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2-b.json"), []byte(`{"kind":"diagnostic","data":{}}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "3-c.json.tmp"), []byte(`{}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other-file"), []byte(`{}`), 0600))
+	sender := newTestSender()
+
+	makeTestSpool(t, dir, sender, 100000)
+
+	assert.Equal(t, []string{"other-file"}, spoolFiles(t, dir))
+	th.AssertNoMoreValues(t, sender.payloadsCh, time.Millisecond*50)
+}
+
+func TestEventSpoolCreatesDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "a", "b")
+	sender := newTestSender()
+	sender.setError(errRetryable)
+	spool := makeTestSpool(t, dir, sender, 100000)
+
+	require.NoError(t, spool.SendEventData(makeAnalyticsPayload("payload-1", 1)))
+	assert.Len(t, spoolFiles(t, dir), 1)
+}
@@ -0,0 +1,213 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+)
+
+// DefaultEventListenerBufferSize is the channel buffer size for an event listener if none is specified.
+const DefaultEventListenerBufferSize = 1000
+
+// EventTap delivers copies of analytics events and payloads to any number of listeners. Unlike
+// internal.Broadcaster, it never blocks: if a listener's channel is full, the value is discarded and
+// counted. When there are no listeners, the only cost of calling its methods is an atomic load.
+type EventTap struct {
+	listenerCount int32
+	listeners     []*eventTapListener
+	lock          sync.RWMutex
+}
+
+type eventTapListener struct {
+	dropped    int64
+	eventsCh   chan interfaces.AnalyticsEvent
+	payloadsCh chan interfaces.EventListenerPayload
+}
+
+// NewEventTap creates an EventTap.
+func NewEventTap() *EventTap {
+	return &EventTap{}
+}
+
+// AddListener creates a listener whose channels have the specified buffer size, or
+// DefaultEventListenerBufferSize if it is zero or negative.
+func (t *EventTap) AddListener(bufferSize int) interfaces.EventListener {
+	if bufferSize <= 0 {
+		bufferSize = DefaultEventListenerBufferSize
+	}
+	l := &eventTapListener{
+		eventsCh:   make(chan interfaces.AnalyticsEvent, bufferSize),
+		payloadsCh: make(chan interfaces.EventListenerPayload, bufferSize),
+	}
+	t.lock.Lock()
+	t.listeners = append(t.listeners, l)
+	atomic.StoreInt32(&t.listenerCount, int32(len(t.listeners)))
+	t.lock.Unlock()
+	return l
+}
+
+// RemoveListener removes a listener and closes its channels. It has no effect if the listener was not
+// created by this EventTap, or was already removed.
+func (t *EventTap) RemoveListener(listener interfaces.EventListener) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for i, l := range t.listeners {
+		if l == listener {
+			t.listeners = append(t.listeners[:i:i], t.listeners[i+1:]...)
+			atomic.StoreInt32(&t.listenerCount, int32(len(t.listeners)))
+			close(l.eventsCh)
+			close(l.payloadsCh)
+			return
+		}
+	}
+}
+
+// HasListeners returns true if there are any listeners.
+func (t *EventTap) HasListeners() bool {
+	return t != nil && atomic.LoadInt32(&t.listenerCount) != 0
+}
+
+// Close removes all listeners and closes their channels.
+func (t *EventTap) Close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, l := range t.listeners {
+		close(l.eventsCh)
+		close(l.payloadsCh)
+	}
+	t.listeners = nil
+	atomic.StoreInt32(&t.listenerCount, 0)
+}
+
+// PublishEvent delivers an event to all listeners.
+func (t *EventTap) PublishEvent(event interfaces.AnalyticsEvent) {
+	if !t.HasListeners() {
+		return
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	for _, l := range t.listeners {
+		select {
+		case l.eventsCh <- event:
+		default:
+			atomic.AddInt64(&l.dropped, 1)
+		}
+	}
+}
+
+// PublishPayload delivers a payload to all listeners, and also delivers any summary events that it
+// contains, since those are not otherwise visible as structured values.
+func (t *EventTap) PublishPayload(kind ldevents.EventDataKind, data []byte, eventCount int) {
+	if !t.HasListeners() {
+		return
+	}
+	payload := interfaces.EventListenerPayload{
+		Kind:       string(kind),
+		EventCount: eventCount,
+		Data:       append(json.RawMessage(nil), data...), // the caller might reuse the original buffer
+	}
+	var summaries []interfaces.AnalyticsEvent
+	if kind == ldevents.AnalyticsEventDataKind {
+		summaries = parseSummaryEvents(data)
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	for _, l := range t.listeners {
+		for _, s := range summaries {
+			select {
+			case l.eventsCh <- s:
+			default:
+				atomic.AddInt64(&l.dropped, 1)
+			}
+		}
+		select {
+		case l.payloadsCh <- payload:
+		default:
+			atomic.AddInt64(&l.dropped, 1)
+		}
+	}
+}
+
+func (l *eventTapListener) Events() <-chan interfaces.AnalyticsEvent {
+	return l.eventsCh
+}
+
+func (l *eventTapListener) Payloads() <-chan interfaces.EventListenerPayload {
+	return l.payloadsCh
+}
+
+func (l *eventTapListener) GetDroppedCount() int {
+	return int(atomic.LoadInt64(&l.dropped))
+}
+
+func parseSummaryEvents(data []byte) []interfaces.AnalyticsEvent {
+	var ret []interfaces.AnalyticsEvent
+	outputEvents := ldvalue.Parse(data)
+	for i := 0; i < outputEvents.Count(); i++ {
+		e := outputEvents.GetByIndex(i)
+		if e.GetByKey("kind").StringValue() != "summary" {
+			continue
+		}
+		summary := interfaces.SummaryEventData{
+			StartDate: ldtime.UnixMillisecondTime(e.GetByKey("startDate").Float64Value()),
+			EndDate:   ldtime.UnixMillisecondTime(e.GetByKey("endDate").Float64Value()),
+			Features:  make(map[string]interfaces.FlagSummaryData),
+		}
+		features := e.GetByKey("features")
+		for _, flagKey := range features.Keys(nil) {
+			f := features.GetByKey(flagKey)
+			flagSummary := interfaces.FlagSummaryData{Default: f.GetByKey("default")}
+			kinds := f.GetByKey("contextKinds")
+			for j := 0; j < kinds.Count(); j++ {
+				flagSummary.ContextKinds = append(flagSummary.ContextKinds, kinds.GetByIndex(j).StringValue())
+			}
+			counters := f.GetByKey("counters")
+			for j := 0; j < counters.Count(); j++ {
+				c := counters.GetByIndex(j)
+				flagSummary.Counters = append(flagSummary.Counters, interfaces.FlagSummaryCounter{
+					Variation: optionalIntFromValue(c.GetByKey("variation")),
+					Version:   optionalIntFromValue(c.GetByKey("version")),
+					Value:     c.GetByKey("value"),
+					Count:     c.GetByKey("count").IntValue(),
+					Unknown:   c.GetByKey("unknown").BoolValue(),
+				})
+			}
+			summary.Features[flagKey] = flagSummary
+		}
+		ret = append(ret, interfaces.AnalyticsEvent{Kind: interfaces.SummaryAnalyticsEvent, Summary: &summary})
+	}
+	return ret
+}
+
+func optionalIntFromValue(v ldvalue.Value) ldvalue.OptionalInt {
+	if v.IsNumber() {
+		return ldvalue.NewOptionalInt(v.IntValue())
+	}
+	return ldvalue.OptionalInt{}
+}
+
+// tappingEventSender is a decorator for an EventSender that publishes its payloads to an EventTap.
+type tappingEventSender struct {
+	sender ldevents.EventSender
+	tap    *EventTap
+}
+
+// NewTappingEventSender returns an EventSender that publishes copies of the payloads that it sends to
+// the EventTap, before passing them to the wrapped EventSender.
+func NewTappingEventSender(sender ldevents.EventSender, tap *EventTap) ldevents.EventSender {
+	return tappingEventSender{sender: sender, tap: tap}
+}
+
+func (s tappingEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	s.tap.PublishPayload(kind, data, eventCount)
+	return s.sender.SendEventData(kind, data, eventCount)
+}
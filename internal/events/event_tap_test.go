@@ -0,0 +1,162 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingLDEventSender struct {
+	kinds []ldevents.EventDataKind
+}
+
+func (s *capturingLDEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	s.kinds = append(s.kinds, kind)
+	return ldevents.EventSenderResult{Success: true}
+}
+
+func makeTestCustomEvent(key string) ldevents.CustomEventData {
+	return ldevents.CustomEventData{
+		BaseEvent: ldevents.BaseEvent{Context: ldevents.Context(ldcontext.New("user-key"))},
+		Key:       key,
+	}
+}
+
+func TestEventTapWithNoListeners(t *testing.T) {
+	tap := NewEventTap()
+	assert.False(t, tap.HasListeners())
+	tap.PublishEvent(interfaces.AnalyticsEvent{Kind: interfaces.CustomAnalyticsEvent})
+	tap.PublishPayload(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+
+	var nilTap *EventTap
+	assert.False(t, nilTap.HasListeners())
+}
+
+func TestEventTapDeliversEventsToAllListeners(t *testing.T) {
+	tap := NewEventTap()
+	listener1 := tap.AddListener(10)
+	listener2 := tap.AddListener(10)
+	assert.True(t, tap.HasListeners())
+
+	event := makeTestCustomEvent("event-key")
+	tap.PublishEvent(interfaces.AnalyticsEvent{Kind: interfaces.CustomAnalyticsEvent, Custom: &event})
+
+	for _, l := range []interfaces.EventListener{listener1, listener2} {
+		e := th.RequireValue(t, l.Events(), time.Second)
+		assert.Equal(t, interfaces.CustomAnalyticsEvent, e.Kind)
+		require.NotNil(t, e.Custom)
+		assert.Equal(t, "event-key", e.Custom.Key)
+	}
+}
+
+func TestEventTapDropsValuesWhenListenerIsFull(t *testing.T) {
+	tap := NewEventTap()
+	listener := tap.AddListener(2)
+
+	for i := 0; i < 5; i++ {
+		tap.PublishEvent(interfaces.AnalyticsEvent{Kind: interfaces.CustomAnalyticsEvent})
+	}
+	for i := 0; i < 3; i++ {
+		tap.PublishPayload(ldevents.DiagnosticEventDataKind, []byte(`{}`), 1)
+	}
+
+	assert.Len(t, listener.Events(), 2)
+	assert.Len(t, listener.Payloads(), 2)
+	assert.Equal(t, 4, listener.GetDroppedCount())
+}
+
+func TestEventTapUsesDefaultBufferSize(t *testing.T) {
+	tap := NewEventTap()
+	listener := tap.AddListener(0)
+	assert.Equal(t, DefaultEventListenerBufferSize, cap(listener.Events()))
+	assert.Equal(t, DefaultEventListenerBufferSize, cap(listener.Payloads()))
+}
+
+func TestEventTapRemoveListenerClosesChannels(t *testing.T) {
+	tap := NewEventTap()
+	listener1 := tap.AddListener(10)
+	listener2 := tap.AddListener(10)
+
+	tap.RemoveListener(listener1)
+	tap.RemoveListener(listener1) // no effect
+	th.AssertChannelClosed(t, listener1.Events(), time.Second)
+	th.AssertChannelClosed(t, listener1.Payloads(), time.Second)
+	assert.True(t, tap.HasListeners())
+
+	tap.PublishEvent(interfaces.AnalyticsEvent{Kind: interfaces.CustomAnalyticsEvent})
+	th.RequireValue(t, listener2.Events(), time.Second)
+
+	tap.Close()
+	assert.False(t, tap.HasListeners())
+	th.AssertChannelClosed(t, listener2.Events(), time.Second)
+	th.AssertChannelClosed(t, listener2.Payloads(), time.Second)
+}
+
+func TestTappingEventSenderPublishesPayloadsAndSummaryEvents(t *testing.T) {
+	tap := NewEventTap()
+	listener := tap.AddListener(10)
+	capturing := &capturingLDEventSender{}
+	sender := NewTappingEventSender(capturing, tap)
+
+	data := []byte(`[{"kind":"custom","key":"event-key"},{"kind":"summary","startDate":1000,"endDate":2000,
+"features":{"flag-key":{"default":false,"contextKinds":["user"],
+"counters":[{"variation":1,"version":11,"value":true,"count":2},{"unknown":true,"value":false,"count":1}]}}}]`)
+	result := sender.SendEventData(ldevents.AnalyticsEventDataKind, data, 2)
+	assert.True(t, result.Success)
+	assert.Equal(t, []ldevents.EventDataKind{ldevents.AnalyticsEventDataKind}, capturing.kinds)
+
+	payload := th.RequireValue(t, listener.Payloads(), time.Second)
+	assert.Equal(t, "analytics", payload.Kind)
+	assert.Equal(t, 2, payload.EventCount)
+	assert.JSONEq(t, string(data), string(payload.Data))
+
+	e := th.RequireValue(t, listener.Events(), time.Second)
+	assert.Equal(t, interfaces.SummaryAnalyticsEvent, e.Kind)
+	require.NotNil(t, e.Summary)
+	assert.Equal(t, interfaces.SummaryEventData{
+		StartDate: ldtime.UnixMillisecondTime(1000),
+		EndDate:   ldtime.UnixMillisecondTime(2000),
+		Features: map[string]interfaces.FlagSummaryData{
+			"flag-key": {
+				Default:      ldvalue.Bool(false),
+				ContextKinds: []string{"user"},
+				Counters: []interfaces.FlagSummaryCounter{
+					{
+						Variation: ldvalue.NewOptionalInt(1),
+						Version:   ldvalue.NewOptionalInt(11),
+						Value:     ldvalue.Bool(true),
+						Count:     2,
+					},
+					{Value: ldvalue.Bool(false), Count: 1, Unknown: true},
+				},
+			},
+		},
+	}, *e.Summary)
+	th.AssertNoMoreValues(t, listener.Events(), time.Millisecond*50)
+}
+
+func TestTappingEventSenderDoesNotParseDiagnosticPayloads(t *testing.T) {
+	tap := NewEventTap()
+	listener := tap.AddListener(10)
+	sender := NewTappingEventSender(&capturingLDEventSender{}, tap)
+
+	sender.SendEventData(ldevents.DiagnosticEventDataKind, []byte(`{"kind":"diagnostic"}`), 1)
+
+	payload := th.RequireValue(t, listener.Payloads(), time.Second)
+	assert.Equal(t, "diagnostic", payload.Kind)
+	assert.Len(t, listener.Events(), 0)
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// UnrecoverableHTTPError is returned by HTTPEventSender if LaunchDarkly responded with an HTTP error that
+// means no further events should be sent, such as a 401 for an invalid SDK key.
+type UnrecoverableHTTPError struct {
+	StatusCode int
+}
+
+// Error returns a description of the error.
+func (e UnrecoverableHTTPError) Error() string {
+	return httpErrorDescription(e.StatusCode)
+}
+
+// HTTPEventSender is an implementation of subsystems.EventSender that posts event data to LaunchDarkly.
+//
+// The SDK normally uses the EventSender implementation from go-sdk-events for this, which generates its
+// own payload IDs and does its own retrying. HTTPEventSender is used instead when the event spool is
+// enabled, since then a payload may be sent more than once, after arbitrarily long delays, and it must keep
+// the same payload ID each time. It does not retry; it returns subsystems.RetryableEventSenderError for any
+// error that might be temporary.
+type HTTPEventSender struct {
+	client  *http.Client
+	baseURI string
+	loggers ldlog.Loggers
+}
+
+// NewHTTPEventSender creates an HTTPEventSender.
+func NewHTTPEventSender(client *http.Client, baseURI string, loggers ldlog.Loggers) *HTTPEventSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPEventSender{
+		client:  client,
+		baseURI: strings.TrimRight(baseURI, "/"),
+		loggers: loggers,
+	}
+}
+
+// SendEventData posts a payload to the analytics or diagnostic event endpoint, using the headers in the
+// payload.
+func (s *HTTPEventSender) SendEventData(payload subsystems.EventPayload) error {
+	var path string
+	switch payload.Kind {
+	case subsystems.AnalyticsEventPayload:
+		path = "/bulk"
+	case subsystems.DiagnosticEventPayload:
+		path = "/diagnostic"
+	default:
+		return fmt.Errorf("unknown event payload kind %q", payload.Kind)
+	}
+	req, err := http.NewRequest("POST", s.baseURI+path, bytes.NewReader(payload.Data))
+	if err != nil { // COVERAGE: no way to simulate this condition in unit tests
+		return err
+	}
+	req.Header = payload.Headers.Clone()
+	s.loggers.Debugf("Sending %d event(s): %s", payload.EventCount, payload.Data)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return subsystems.RetryableEventSenderError{Err: err}
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == 400:
+		// LaunchDarkly rejected this payload, but that does not mean that it will reject others
+		return errors.New(httpErrorDescription(resp.StatusCode))
+	case isHTTPErrorRecoverable(resp.StatusCode):
+		return subsystems.RetryableEventSenderError{Err: errors.New(httpErrorDescription(resp.StatusCode))}
+	default:
+		return UnrecoverableHTTPError{StatusCode: resp.StatusCode}
+	}
+}
+
+// Tests whether an HTTP error status represents a condition that might resolve on its own if we retry,
+// or at least should not make us permanently stop sending requests.
+func isHTTPErrorRecoverable(statusCode int) bool {
+	if statusCode >= 400 && statusCode < 500 {
+		switch statusCode {
+		case 400: // bad request
+			return true
+		case 408: // request timeout
+			return true
+		case 429: // too many requests
+			return true
+		default:
+			return false // all other 4xx errors are unrecoverable
+		}
+	}
+	return true
+}
+
+func httpErrorDescription(statusCode int) string {
+	message := ""
+	if statusCode == 401 || statusCode == 403 {
+		message = " (invalid SDK key)"
+	}
+	return fmt.Sprintf("HTTP error %d%s", statusCode, message)
+}
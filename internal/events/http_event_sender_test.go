@@ -0,0 +1,97 @@
+package events
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPEventSenderPostsPayloadWithHeaders(t *testing.T) {
+	for _, p := range []struct {
+		kind subsystems.EventPayloadKind
+		path string
+	}{
+		{subsystems.AnalyticsEventPayload, "/bulk"},
+		{subsystems.DiagnosticEventPayload, "/diagnostic"},
+	} {
+		t.Run(string(p.kind), func(t *testing.T) {
+			handler, requestsCh := httphelpers.RecordingHandler(httphelpers.HandlerWithStatus(202))
+			httphelpers.WithServer(handler, func(server *httptest.Server) {
+				sender := NewHTTPEventSender(nil, server.URL+"/", ldlogtest.NewMockLog().Loggers)
+				headers := make(http.Header)
+				headers.Set("Authorization", "sdk-key")
+				headers.Set("X-LaunchDarkly-Payload-ID", "payload-1")
+
+				err := sender.SendEventData(subsystems.EventPayload{
+					Kind:    p.kind,
+					Data:    []byte(`[{"kind":"custom"}]`),
+					Headers: headers,
+				})
+				require.NoError(t, err)
+
+				r := th.RequireValue(t, requestsCh, time.Second)
+				assert.Equal(t, "POST", r.Request.Method)
+				assert.Equal(t, p.path, r.Request.URL.Path)
+				assert.Equal(t, "sdk-key", r.Request.Header.Get("Authorization"))
+				assert.Equal(t, "payload-1", r.Request.Header.Get("X-LaunchDarkly-Payload-ID"))
+				assert.Equal(t, `[{"kind":"custom"}]`, string(r.Body))
+			})
+		})
+	}
+}
+
+func TestHTTPEventSenderErrors(t *testing.T) {
+	for _, p := range []struct {
+		status        int
+		retryable     bool
+		unrecoverable bool
+	}{
+		{400, false, false},
+		{401, false, true},
+		{403, false, true},
+		{408, true, false},
+		{429, true, false},
+		{500, true, false},
+		{503, true, false},
+	} {
+		t.Run(http.StatusText(p.status), func(t *testing.T) {
+			httphelpers.WithServer(httphelpers.HandlerWithStatus(p.status), func(server *httptest.Server) {
+				sender := NewHTTPEventSender(nil, server.URL, ldlogtest.NewMockLog().Loggers)
+
+				err := sender.SendEventData(subsystems.EventPayload{Kind: subsystems.AnalyticsEventPayload})
+				require.Error(t, err)
+				assert.Equal(t, p.retryable, errors.As(err, &subsystems.RetryableEventSenderError{}))
+				assert.Equal(t, p.unrecoverable, errors.As(err, &UnrecoverableHTTPError{}))
+			})
+		})
+	}
+
+	t.Run("network error", func(t *testing.T) {
+		httphelpers.WithServer(httphelpers.BrokenConnectionHandler(), func(server *httptest.Server) {
+			sender := NewHTTPEventSender(nil, server.URL, ldlogtest.NewMockLog().Loggers)
+
+			err := sender.SendEventData(subsystems.EventPayload{Kind: subsystems.AnalyticsEventPayload})
+			require.Error(t, err)
+			assert.True(t, errors.As(err, &subsystems.RetryableEventSenderError{}))
+		})
+	})
+
+	t.Run("unknown payload kind", func(t *testing.T) {
+		sender := NewHTTPEventSender(nil, "http://localhost", ldlogtest.NewMockLog().Loggers)
+
+		err := sender.SendEventData(subsystems.EventPayload{Kind: "other"})
+		require.Error(t, err)
+		assert.False(t, errors.As(err, &subsystems.RetryableEventSenderError{}))
+	})
+}
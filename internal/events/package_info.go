@@ -0,0 +1,4 @@
+// Package events is an internal package containing implementation types for the SDK's analytics event
+// delivery that are not provided by go-sdk-events, such as the optional disk-backed event spool. These
+// types are not visible from outside of the SDK.
+package events
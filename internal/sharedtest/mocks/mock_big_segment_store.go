@@ -113,3 +113,34 @@ func ExpectBigSegmentStoreStatus(
 		require.Equal(t, newStatus, statusGetter())
 	}
 }
+
+// MockBigSegmentStoreBatch is a MockBigSegmentStore that also implements BigSegmentStoreBatch. Batch
+// queries are recorded separately from individual queries.
+type MockBigSegmentStoreBatch struct {
+	MockBigSegmentStore
+	batchQueries [][]string
+}
+
+func (m *MockBigSegmentStoreBatch) GetMemberships( //nolint:revive
+	contextHashes []string,
+) (map[string]subsystems.BigSegmentMembership, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.batchQueries = append(m.batchQueries, append([]string(nil), contextHashes...))
+	if m.membershipErr != nil {
+		return nil, m.membershipErr
+	}
+	ret := make(map[string]subsystems.BigSegmentMembership)
+	for _, hash := range contextHashes {
+		if membership, ok := m.memberships[hash]; ok {
+			ret[hash] = membership
+		}
+	}
+	return ret, nil
+}
+
+func (m *MockBigSegmentStoreBatch) TestGetBatchMembershipQueries() [][]string { //nolint:revive
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return append([][]string(nil), m.batchQueries...)
+}
@@ -0,0 +1,150 @@
+package mocks
+
+import (
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// MockBigSegmentStoreWriter is an in-memory implementation of BigSegmentStoreWriter. Close does nothing,
+// so the same instance can be reused to simulate reopening a store whose data persists.
+type MockBigSegmentStoreWriter struct {
+	syncedOn  ldtime.UnixMillisecondTime
+	cursor    string
+	included  map[string]map[string]bool // context hash -> segment ref -> true
+	excluded  map[string]map[string]bool // context hash -> segment ref -> true
+	fakeError error
+	lock      sync.Mutex
+}
+
+// NewMockBigSegmentStoreWriter creates an empty MockBigSegmentStoreWriter.
+func NewMockBigSegmentStoreWriter() *MockBigSegmentStoreWriter {
+	return &MockBigSegmentStoreWriter{
+		included: make(map[string]map[string]bool),
+		excluded: make(map[string]map[string]bool),
+	}
+}
+
+func (m *MockBigSegmentStoreWriter) Close() error { //nolint:revive
+	return nil
+}
+
+func (m *MockBigSegmentStoreWriter) GetMetadata() (subsystems.BigSegmentStoreMetadata, error) { //nolint:revive
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return subsystems.BigSegmentStoreMetadata{LastUpToDate: m.syncedOn}, m.fakeError
+}
+
+func (m *MockBigSegmentStoreWriter) GetMembership( //nolint:revive
+	contextHash string,
+) (subsystems.BigSegmentMembership, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fakeError != nil {
+		return nil, m.fakeError
+	}
+	if len(m.included[contextHash]) == 0 && len(m.excluded[contextHash]) == 0 {
+		return nil, nil
+	}
+	membership := make(mockBigSegmentMembership)
+	for segmentRef := range m.excluded[contextHash] {
+		membership[segmentRef] = false
+	}
+	for segmentRef := range m.included[contextHash] {
+		membership[segmentRef] = true
+	}
+	return membership, nil
+}
+
+func (m *MockBigSegmentStoreWriter) ApplyMembershipDelta( //nolint:revive
+	delta subsystems.BigSegmentMembershipDelta,
+) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fakeError != nil {
+		return m.fakeError
+	}
+	for _, h := range delta.RemoveIncluded {
+		delete(m.included[h], delta.SegmentRef)
+	}
+	for _, h := range delta.RemoveExcluded {
+		delete(m.excluded[h], delta.SegmentRef)
+	}
+	addMemberships(m.included, delta.SegmentRef, delta.AddIncluded)
+	addMemberships(m.excluded, delta.SegmentRef, delta.AddExcluded)
+	return nil
+}
+
+func (m *MockBigSegmentStoreWriter) ReplaceSegmentMembership( //nolint:revive
+	segmentRef string,
+	includedContextHashes, excludedContextHashes []string,
+) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fakeError != nil {
+		return m.fakeError
+	}
+	for _, memberships := range []map[string]map[string]bool{m.included, m.excluded} {
+		for _, refs := range memberships {
+			delete(refs, segmentRef)
+		}
+	}
+	addMemberships(m.included, segmentRef, includedContextHashes)
+	addMemberships(m.excluded, segmentRef, excludedContextHashes)
+	return nil
+}
+
+func (m *MockBigSegmentStoreWriter) SetSyncedOn(syncedOn ldtime.UnixMillisecondTime) error { //nolint:revive
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fakeError != nil {
+		return m.fakeError
+	}
+	m.syncedOn = syncedOn
+	return nil
+}
+
+func (m *MockBigSegmentStoreWriter) GetSyncCursor() (string, error) { //nolint:revive
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.cursor, m.fakeError
+}
+
+func (m *MockBigSegmentStoreWriter) SetSyncCursor(cursor string) error { //nolint:revive
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.fakeError != nil {
+		return m.fakeError
+	}
+	m.cursor = cursor
+	return nil
+}
+
+// SetFakeError causes subsequent store operations to return an error, or stops them from doing so if
+// err is nil.
+func (m *MockBigSegmentStoreWriter) SetFakeError(err error) {
+	m.lock.Lock()
+	m.fakeError = err
+	m.lock.Unlock()
+}
+
+func addMemberships(memberships map[string]map[string]bool, segmentRef string, contextHashes []string) {
+	for _, h := range contextHashes {
+		if memberships[h] == nil {
+			memberships[h] = make(map[string]bool)
+		}
+		memberships[h][segmentRef] = true
+	}
+}
+
+// mockBigSegmentMembership maps segment references to true for included, or false for excluded.
+type mockBigSegmentMembership map[string]bool
+
+func (m mockBigSegmentMembership) CheckMembership(segmentRef string) ldvalue.OptionalBool {
+	if value, ok := m[segmentRef]; ok {
+		return ldvalue.NewOptionalBool(value)
+	}
+	return ldvalue.OptionalBool{}
+}
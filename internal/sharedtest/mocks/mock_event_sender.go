@@ -0,0 +1,39 @@
+package mocks
+
+import (
+	"sync"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// MockEventSender is a mock implementation of subsystems.EventSender. It records every payload that it
+// receives, and returns the errors that were specified with TestSetErrors, in order, before succeeding.
+type MockEventSender struct {
+	PayloadsCh chan subsystems.EventPayload
+	errors     []error
+	lock       sync.Mutex
+}
+
+// NewMockEventSender creates a MockEventSender.
+func NewMockEventSender() *MockEventSender {
+	return &MockEventSender{PayloadsCh: make(chan subsystems.EventPayload, 100)}
+}
+
+func (m *MockEventSender) SendEventData(payload subsystems.EventPayload) error { //nolint:revive
+	m.PayloadsCh <- payload
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.errors) == 0 {
+		return nil
+	}
+	err := m.errors[0]
+	m.errors = m.errors[1:]
+	return err
+}
+
+// TestSetErrors specifies errors to be returned by subsequent calls to SendEventData.
+func (m *MockEventSender) TestSetErrors(errs ...error) { //nolint:revive
+	m.lock.Lock()
+	m.errors = append(m.errors, errs...)
+	m.lock.Unlock()
+}
@@ -254,6 +254,9 @@ func (m *MockPersistentDataStore) Upsert(
 			return false, nil
 		}
 	}
+	if m.data[kind] == nil {
+		m.data[kind] = make(map[string]ldstoretypes.SerializedItemDescriptor)
+	}
 	m.data[kind][key] = m.storableItem(newItem)
 	return true, nil
 }
@@ -301,3 +304,35 @@ func (m *MockPersistentDataStore) storableItem(
 	}
 	return item
 }
+
+// MockPersistentDataStoreWithChangeNotifier is a MockPersistentDataStore that also implements
+// PersistentDataStoreChangeNotifier. Changes are only reported when the test calls NotifyChange.
+type MockPersistentDataStoreWithChangeNotifier struct {
+	*MockPersistentDataStore
+	onChange func(ldstoretypes.DataKind, string)
+}
+
+// NewMockPersistentDataStoreWithChangeNotifier creates an instance of MockPersistentDataStoreWithChangeNotifier.
+func NewMockPersistentDataStoreWithChangeNotifier() *MockPersistentDataStoreWithChangeNotifier {
+	return &MockPersistentDataStoreWithChangeNotifier{MockPersistentDataStore: NewMockPersistentDataStore()}
+}
+
+// SubscribeToChanges is a mock implementation of PersistentDataStoreChangeNotifier.
+func (m *MockPersistentDataStoreWithChangeNotifier) SubscribeToChanges(
+	onChange func(ldstoretypes.DataKind, string),
+) error {
+	m.lock.Lock()
+	m.onChange = onChange
+	m.lock.Unlock()
+	return nil
+}
+
+// NotifyChange calls the function that was passed to SubscribeToChanges, if any.
+func (m *MockPersistentDataStoreWithChangeNotifier) NotifyChange(kind ldstoretypes.DataKind, key string) {
+	m.lock.Lock()
+	onChange := m.onChange
+	m.lock.Unlock()
+	if onChange != nil {
+		onChange(kind, key)
+	}
+}
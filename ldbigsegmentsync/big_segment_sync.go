@@ -0,0 +1,166 @@
+package ldbigsegmentsync
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// AfterParam is the name of the query parameter that [SyncFromURL] uses to tell the server which updates
+// it has already applied. Its value is the cursor of the last applied update.
+const AfterParam = "after"
+
+// Result describes what a sync operation did.
+type Result struct {
+	// Applied is the number of updates that were written to the store.
+	Applied int
+
+	// Skipped is the number of updates that were ignored because the store had already been updated
+	// with them.
+	Skipped int
+
+	// Cursor is the cursor of the last update that has been applied to the store, which was either
+	// applied by this operation or by a previous one. It is empty if no updates have ever been applied.
+	Cursor string
+}
+
+// OpenWriter creates a Big Segment store from the same kind of builder that is used to configure the
+// SDK, such as [github.com/launchdarkly/go-server-sdk/ldsqlstore.BigSegmentStore], and returns an
+// error if the store does not implement [subsystems.BigSegmentStoreWriter]. The caller is responsible for
+// closing the store.
+func OpenWriter(
+	configurer subsystems.ComponentConfigurer[subsystems.BigSegmentStore],
+	loggers ldlog.Loggers,
+) (subsystems.BigSegmentStoreWriter, error) {
+	if configurer == nil {
+		return nil, errors.New("a Big Segment store configuration is required")
+	}
+	store, err := configurer.Build(subsystems.BasicClientContext{
+		Logging: subsystems.LoggingConfiguration{Loggers: loggers},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writer, ok := store.(subsystems.BigSegmentStoreWriter)
+	if !ok {
+		_ = store.Close()
+		return nil, errors.New("this Big Segment store implementation does not support updates")
+	}
+	return writer, nil
+}
+
+// Sync reads a stream of updates from r, as described in the package documentation, and applies them
+// to the store. When it has reached the end of the stream, it sets the store's synced-on time to the
+// current time, so that the SDK will consider the store's data to be up to date.
+//
+// If the store already has a sync cursor from a previous sync, Sync assumes that the stream starts at
+// the same place as it did in the previous sync: it skips all updates up to and including the one with
+// that cursor. If there is no such update, it returns an error without changing the store, since there
+// would be no way to tell which updates had already been applied.
+func Sync(r io.Reader, writer subsystems.BigSegmentStoreWriter) (Result, error) {
+	cursor, err := writer.GetSyncCursor()
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Cursor: cursor}
+	if cursor == "" {
+		return result, applyUpdates(r, writer, &result)
+	}
+
+	// We have to find the stored cursor before we change anything, so read the updates that follow it
+	// into memory.
+	var pending []Update
+	found := false
+	err = ReadUpdates(r, func(update Update) error {
+		if found {
+			pending = append(pending, update)
+		} else {
+			result.Skipped++
+			found = update.Cursor == cursor
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if !found {
+		return result, fmt.Errorf("the updates do not include the cursor %q from the previous sync", cursor)
+	}
+	for _, update := range pending {
+		if err := applyUpdate(update, writer, &result); err != nil {
+			return result, err
+		}
+	}
+	return result, writer.SetSyncedOn(ldtime.UnixMillisNow())
+}
+
+// SyncFromURL requests a stream of updates from an HTTP server with a GET request, and applies them to
+// the store in the same way as [Sync].
+//
+// If the store already has a sync cursor from a previous sync, SyncFromURL adds it to the URL as the
+// value of the "after" query parameter ([AfterParam]), and assumes that the server returns only the
+// updates that follow it. The server should return an error status if it does not recognize the cursor.
+//
+// If client is nil, it uses [http.DefaultClient].
+func SyncFromURL(
+	client *http.Client,
+	streamURL string,
+	writer subsystems.BigSegmentStoreWriter,
+) (Result, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	cursor, err := writer.GetSyncCursor()
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Cursor: cursor}
+
+	requestURL, err := url.Parse(streamURL)
+	if err != nil {
+		return result, err
+	}
+	if cursor != "" {
+		query := requestURL.Query()
+		query.Set(AfterParam, cursor)
+		requestURL.RawQuery = query.Encode()
+	}
+	resp, err := client.Get(requestURL.String())
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1000))
+		return result, fmt.Errorf("HTTP error %d from %s: %s", resp.StatusCode, streamURL, message)
+	}
+	return result, applyUpdates(resp.Body, writer, &result)
+}
+
+func applyUpdates(r io.Reader, writer subsystems.BigSegmentStoreWriter, result *Result) error {
+	err := ReadUpdates(r, func(update Update) error {
+		return applyUpdate(update, writer, result)
+	})
+	if err != nil {
+		return err
+	}
+	return writer.SetSyncedOn(ldtime.UnixMillisNow())
+}
+
+func applyUpdate(update Update, writer subsystems.BigSegmentStoreWriter, result *Result) error {
+	if err := update.applyTo(writer); err != nil {
+		return fmt.Errorf("failed to apply update %q: %w", update.Cursor, err)
+	}
+	if err := writer.SetSyncCursor(update.Cursor); err != nil {
+		return err
+	}
+	result.Applied++
+	result.Cursor = update.Cursor
+	return nil
+}
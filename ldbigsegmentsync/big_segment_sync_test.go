@@ -0,0 +1,215 @@
+package ldbigsegmentsync
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	firstUpdates = `{"cursor": "1", "segmentRef": "seg.g1", "replace": true, "included": ["h1", "h2"]}
+{"cursor": "2", "segmentRef": "seg.g1", "addExcluded": ["h3"], "removeIncluded": ["h2"]}
+`
+	laterUpdates = `{"cursor": "3", "segmentRef": "seg.g1", "addIncluded": ["h2"], "removeExcluded": ["h3"]}
+{"cursor": "4", "segmentRef": "other.g1", "replace": true, "excluded": ["h1"]}
+`
+)
+
+// withTestWriter calls action with a writer for the data in store. It can be called more than once with
+// the same store to simulate restarting a sync process.
+func withTestWriter(
+	t *testing.T,
+	store *mocks.MockBigSegmentStoreWriter,
+	action func(subsystems.BigSegmentStoreWriter),
+) {
+	writer, err := OpenWriter(
+		mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: store},
+		ldlog.NewDisabledLoggers(),
+	)
+	require.NoError(t, err)
+	defer writer.Close()
+	action(writer)
+}
+
+func assertMembership(t *testing.T, writer subsystems.BigSegmentStoreWriter, contextHash string, expected map[string]bool) {
+	membership, err := writer.GetMembership(contextHash)
+	require.NoError(t, err)
+	for _, segmentRef := range []string{"seg.g1", "other.g1"} {
+		value, ok := expected[segmentRef]
+		if !ok {
+			assert.False(t, membership != nil && membership.CheckMembership(segmentRef).IsDefined(),
+				"%s should not be in %s", contextHash, segmentRef)
+			continue
+		}
+		require.NotNil(t, membership)
+		assert.Equal(t, value, membership.CheckMembership(segmentRef).BoolValue(), "%s in %s", contextHash, segmentRef)
+	}
+}
+
+func assertFinalState(t *testing.T, writer subsystems.BigSegmentStoreWriter) {
+	assertMembership(t, writer, "h1", map[string]bool{"seg.g1": true, "other.g1": false})
+	assertMembership(t, writer, "h2", map[string]bool{"seg.g1": true})
+	assertMembership(t, writer, "h3", nil)
+	cursor, err := writer.GetSyncCursor()
+	require.NoError(t, err)
+	assert.Equal(t, "4", cursor)
+}
+
+func TestOpenWriter(t *testing.T) {
+	t.Run("store is not a writer", func(t *testing.T) {
+		store := &mocks.MockBigSegmentStore{}
+		_, err := OpenWriter(mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: store},
+			ldlog.NewDisabledLoggers())
+		assert.Error(t, err)
+	})
+
+	t.Run("store cannot be created", func(t *testing.T) {
+		_, err := OpenWriter(
+			mocks.ComponentConfigurerThatReturnsError[subsystems.BigSegmentStore]{Err: errors.New("sorry")},
+			ldlog.NewDisabledLoggers(),
+		)
+		assert.Error(t, err)
+	})
+
+	t.Run("no configuration", func(t *testing.T) {
+		_, err := OpenWriter(nil, ldlog.NewDisabledLoggers())
+		assert.Error(t, err)
+	})
+}
+
+func TestSync(t *testing.T) {
+	t.Run("applies all updates and sets synced-on time", func(t *testing.T) {
+		withTestWriter(t, mocks.NewMockBigSegmentStoreWriter(), func(writer subsystems.BigSegmentStoreWriter) {
+			before := ldtime.UnixMillisNow()
+			result, err := Sync(strings.NewReader(firstUpdates+laterUpdates), writer)
+			require.NoError(t, err)
+			assert.Equal(t, Result{Applied: 4, Cursor: "4"}, result)
+			assertFinalState(t, writer)
+
+			meta, err := writer.GetMetadata()
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, meta.LastUpToDate, before)
+		})
+	})
+
+	t.Run("resumes after the stored cursor", func(t *testing.T) {
+		store := mocks.NewMockBigSegmentStoreWriter()
+		withTestWriter(t, store, func(writer subsystems.BigSegmentStoreWriter) {
+			result, err := Sync(strings.NewReader(firstUpdates), writer)
+			require.NoError(t, err)
+			assert.Equal(t, Result{Applied: 2, Cursor: "2"}, result)
+		})
+		withTestWriter(t, store, func(writer subsystems.BigSegmentStoreWriter) {
+			result, err := Sync(strings.NewReader(firstUpdates+laterUpdates), writer)
+			require.NoError(t, err)
+			assert.Equal(t, Result{Applied: 2, Skipped: 2, Cursor: "4"}, result)
+			assertFinalState(t, writer)
+		})
+		withTestWriter(t, store, func(writer subsystems.BigSegmentStoreWriter) {
+			result, err := Sync(strings.NewReader(firstUpdates+laterUpdates), writer)
+			require.NoError(t, err)
+			assert.Equal(t, Result{Skipped: 4, Cursor: "4"}, result)
+			assertFinalState(t, writer)
+		})
+	})
+
+	t.Run("stored cursor is not in updates", func(t *testing.T) {
+		withTestWriter(t, mocks.NewMockBigSegmentStoreWriter(), func(writer subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, writer.SetSyncCursor("unknown"))
+			_, err := Sync(strings.NewReader(firstUpdates), writer)
+			assert.Error(t, err)
+
+			assertMembership(t, writer, "h1", nil)
+			meta, err := writer.GetMetadata()
+			require.NoError(t, err)
+			assert.Equal(t, ldtime.UnixMillisecondTime(0), meta.LastUpToDate)
+		})
+	})
+
+	t.Run("invalid update stops the sync after the previous update", func(t *testing.T) {
+		withTestWriter(t, mocks.NewMockBigSegmentStoreWriter(), func(writer subsystems.BigSegmentStoreWriter) {
+			result, err := Sync(strings.NewReader(firstUpdates+`{"segmentRef": "seg.g1"}`), writer)
+			assert.Error(t, err)
+			assert.Equal(t, Result{Applied: 2, Cursor: "2"}, result)
+			cursor, err := writer.GetSyncCursor()
+			require.NoError(t, err)
+			assert.Equal(t, "2", cursor)
+		})
+	})
+
+	t.Run("store error", func(t *testing.T) {
+		store := mocks.NewMockBigSegmentStoreWriter()
+		fakeError := errors.New("sorry")
+		store.SetFakeError(fakeError)
+		withTestWriter(t, store, func(writer subsystems.BigSegmentStoreWriter) {
+			_, err := Sync(strings.NewReader(firstUpdates), writer)
+			assert.True(t, errors.Is(err, fakeError), "unexpected error: %s", err)
+		})
+	})
+}
+
+func TestSyncFromURL(t *testing.T) {
+	updatesFile := filepath.Join(t.TempDir(), "updates.ndjson")
+	server := httptest.NewServer(NewUpdateFileHandler(updatesFile))
+	defer server.Close()
+
+	t.Run("resumes after the stored cursor", func(t *testing.T) {
+		store := mocks.NewMockBigSegmentStoreWriter()
+		require.NoError(t, os.WriteFile(updatesFile, []byte(firstUpdates), 0600))
+		withTestWriter(t, store, func(writer subsystems.BigSegmentStoreWriter) {
+			result, err := SyncFromURL(nil, server.URL, writer)
+			require.NoError(t, err)
+			assert.Equal(t, Result{Applied: 2, Cursor: "2"}, result)
+		})
+
+		require.NoError(t, os.WriteFile(updatesFile, []byte(firstUpdates+laterUpdates), 0600))
+		withTestWriter(t, store, func(writer subsystems.BigSegmentStoreWriter) {
+			result, err := SyncFromURL(server.Client(), server.URL, writer)
+			require.NoError(t, err)
+			assert.Equal(t, Result{Applied: 2, Cursor: "4"}, result)
+			assertFinalState(t, writer)
+
+			meta, err := writer.GetMetadata()
+			require.NoError(t, err)
+			assert.NotEqual(t, ldtime.UnixMillisecondTime(0), meta.LastUpToDate)
+		})
+	})
+
+	t.Run("HTTP error", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(updatesFile, []byte(firstUpdates), 0600))
+		withTestWriter(t, mocks.NewMockBigSegmentStoreWriter(), func(writer subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, writer.SetSyncCursor("unknown"))
+			_, err := SyncFromURL(nil, server.URL, writer)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "404")
+		})
+	})
+
+	t.Run("invalid URL", func(t *testing.T) {
+		withTestWriter(t, mocks.NewMockBigSegmentStoreWriter(), func(writer subsystems.BigSegmentStoreWriter) {
+			_, err := SyncFromURL(nil, "::", writer)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("request fails", func(t *testing.T) {
+		closedServer := httptest.NewServer(http.NotFoundHandler())
+		closedServer.Close()
+		withTestWriter(t, mocks.NewMockBigSegmentStoreWriter(), func(writer subsystems.BigSegmentStoreWriter) {
+			_, err := SyncFromURL(nil, closedServer.URL, writer)
+			assert.Error(t, err)
+		})
+	})
+}
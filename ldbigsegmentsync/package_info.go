@@ -0,0 +1,42 @@
+// Package ldbigsegmentsync provides functions for populating a Big Segment store from a stream of segment
+// membership updates, such as an export of a segment's memberships or a feed of changes to them. This is
+// useful for testing Big Segments, or for environments where the LaunchDarkly Relay Proxy is not used to
+// keep the store up to date.
+//
+// It works with any Big Segment store that implements
+// [github.com/launchdarkly/go-server-sdk/v6/subsystems.BigSegmentStoreWriter], such as the one provided by
+// [github.com/launchdarkly/go-server-sdk/ldsqlstore.BigSegmentStore]. You can obtain one from the same
+// builder that you would use to configure the SDK, by calling [OpenWriter]:
+//
+//	writer, err := ldbigsegmentsync.OpenWriter(
+//	    ldsqlstore.BigSegmentStore(ldsqlstore.DialectPostgres).Open("postgres", "postgres://my-db-host/mydb"),
+//	    ldlog.NewDefaultLoggers(),
+//	)
+//	f, err := os.Open("./segment-updates.ndjson")
+//	result, err := ldbigsegmentsync.Sync(f, writer)
+//
+// The updates are in newline-delimited JSON, with one object per line. Each update applies to a single
+// segment, and is either a replacement of all of the segment's memberships:
+//
+//	{"cursor": "1", "segmentRef": "my-segment.g1", "replace": true, "included": ["HASH1"], "excluded": ["HASH2"]}
+//
+// or a change to some of them:
+//
+//	{"cursor": "2", "segmentRef": "my-segment.g1", "addIncluded": ["HASH3"], "removeIncluded": ["HASH1"]}
+//
+// The properties of a change are "addIncluded", "removeIncluded", "addExcluded", and "removeExcluded".
+// Contexts are identified by context hashes, in the base64-encoded SHA-256 form that the SDK uses when it
+// queries a Big Segment store; segments are identified by a segment reference, in the form
+// SEGMENT_KEY.gGENERATION.
+//
+// Every update must have a "cursor" property, which is an opaque string that identifies its position in
+// the stream. After applying each update, the sync process stores its cursor in the Big Segment store. If
+// the process is stopped and restarted, [Sync] skips the updates up to and including the stored cursor,
+// and [SyncFromURL] asks the server for only the updates after it, so the sync resumes where it left off.
+// Since every update is idempotent, it does no harm if the update that was in progress when the process
+// stopped is applied again.
+//
+// [NewUpdateFileHandler] provides a simple HTTP server for a file of updates, which can be used as a local
+// stand-in for a real source of updates with SyncFromURL. The command-line tool in cmd/ldbigsegmentsync
+// provides the same operations for the Big Segment store implementations that are part of this module.
+package ldbigsegmentsync
@@ -0,0 +1,51 @@
+package ldbigsegmentsync
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// NewUpdateFileHandler returns an HTTP handler that serves the updates in a file, in the format that
+// [SyncFromURL] expects. It is a simple stand-in for a real source of updates, for testing and local
+// development.
+//
+// The file is read again for every request, so updates can be appended to it while the handler is in use.
+// If the request has an "after" query parameter ([AfterParam]), the handler returns only the updates
+// that follow the one with that cursor; if there is no such update, it returns a 404 error.
+func NewUpdateFileHandler(filePath string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		data, err := os.ReadFile(filePath) //nolint:gosec // G304: ok to read file into variable
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		after := r.URL.Query().Get(AfterParam)
+		found := after == ""
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		err = ReadUpdates(bytes.NewReader(data), func(update Update) error {
+			if found {
+				return encoder.Encode(update)
+			}
+			found = update.Cursor == after
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, fmt.Sprintf("unknown cursor %q", after), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
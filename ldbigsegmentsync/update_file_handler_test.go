@@ -0,0 +1,81 @@
+package ldbigsegmentsync
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateFileHandler(t *testing.T) {
+	updatesFile := filepath.Join(t.TempDir(), "updates.ndjson")
+	require.NoError(t, os.WriteFile(updatesFile, []byte(firstUpdates+laterUpdates), 0600))
+	handler := NewUpdateFileHandler(updatesFile)
+
+	get := func(target string) (int, []Update) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		if w.Code != http.StatusOK {
+			return w.Code, nil
+		}
+		body, err := io.ReadAll(w.Body)
+		require.NoError(t, err)
+		updates, err := readAllUpdates(t, string(body))
+		require.NoError(t, err)
+		return w.Code, updates
+	}
+	cursors := func(updates []Update) []string {
+		var ret []string
+		for _, u := range updates {
+			ret = append(ret, u.Cursor)
+		}
+		return ret
+	}
+
+	t.Run("all updates", func(t *testing.T) {
+		status, updates := get("/")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"1", "2", "3", "4"}, cursors(updates))
+		assert.Equal(t, []string{"h1", "h2"}, updates[0].Included)
+	})
+
+	t.Run("updates after cursor", func(t *testing.T) {
+		status, updates := get("/?after=2")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, []string{"3", "4"}, cursors(updates))
+
+		status, updates = get("/?after=4")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Len(t, updates, 0)
+	})
+
+	t.Run("unknown cursor", func(t *testing.T) {
+		status, _ := get("/?after=5")
+		assert.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewUpdateFileHandler(filepath.Join(t.TempDir(), "nope")).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		badFile := filepath.Join(t.TempDir(), "bad.ndjson")
+		require.NoError(t, os.WriteFile(badFile, []byte("{"), 0600))
+		w := httptest.NewRecorder()
+		NewUpdateFileHandler(badFile).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+}
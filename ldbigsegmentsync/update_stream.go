@@ -0,0 +1,99 @@
+package ldbigsegmentsync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// Update is one entry in a stream of segment membership updates. See the package documentation for the
+// JSON representation.
+type Update struct {
+	// Cursor identifies the position of this update in the stream. It is required.
+	Cursor string `json:"cursor"`
+
+	// SegmentRef is the segment reference, in the form SEGMENT_KEY.gGENERATION. It is required.
+	SegmentRef string `json:"segmentRef"`
+
+	// Replace is true if this update replaces all of the segment's memberships with Included and
+	// Excluded, or false if it changes the memberships with AddIncluded, RemoveIncluded, AddExcluded,
+	// and RemoveExcluded.
+	Replace bool `json:"replace,omitempty"`
+
+	// Included contains the context hashes that are included in the segment, if Replace is true.
+	Included []string `json:"included,omitempty"`
+
+	// Excluded contains the context hashes that are excluded from the segment, if Replace is true.
+	Excluded []string `json:"excluded,omitempty"`
+
+	// AddIncluded contains context hashes to add to the segment's included list, if Replace is false.
+	AddIncluded []string `json:"addIncluded,omitempty"`
+
+	// RemoveIncluded contains context hashes to remove from the segment's included list, if Replace is
+	// false.
+	RemoveIncluded []string `json:"removeIncluded,omitempty"`
+
+	// AddExcluded contains context hashes to add to the segment's excluded list, if Replace is false.
+	AddExcluded []string `json:"addExcluded,omitempty"`
+
+	// RemoveExcluded contains context hashes to remove from the segment's excluded list, if Replace is
+	// false.
+	RemoveExcluded []string `json:"removeExcluded,omitempty"`
+}
+
+func (u Update) validate() error {
+	if u.Cursor == "" {
+		return errors.New("cursor is required")
+	}
+	if u.SegmentRef == "" {
+		return errors.New("segmentRef is required")
+	}
+	if u.Replace {
+		if len(u.AddIncluded) != 0 || len(u.RemoveIncluded) != 0 || len(u.AddExcluded) != 0 ||
+			len(u.RemoveExcluded) != 0 {
+			return errors.New("a replacement cannot also have addIncluded, removeIncluded, addExcluded, or removeExcluded")
+		}
+	} else if len(u.Included) != 0 || len(u.Excluded) != 0 {
+		return errors.New("included and excluded can only be used if replace is true")
+	}
+	return nil
+}
+
+func (u Update) applyTo(writer subsystems.BigSegmentStoreWriter) error {
+	if u.Replace {
+		return writer.ReplaceSegmentMembership(u.SegmentRef, u.Included, u.Excluded)
+	}
+	return writer.ApplyMembershipDelta(subsystems.BigSegmentMembershipDelta{
+		SegmentRef:     u.SegmentRef,
+		AddIncluded:    u.AddIncluded,
+		RemoveIncluded: u.RemoveIncluded,
+		AddExcluded:    u.AddExcluded,
+		RemoveExcluded: u.RemoveExcluded,
+	})
+}
+
+// ReadUpdates reads a stream of updates in newline-delimited JSON from r, and calls action for each one
+// in order. It stops at the end of the stream, or at the first error from either the stream or action.
+// Every update is checked for validity before action is called.
+func ReadUpdates(r io.Reader, action func(Update) error) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	for n := 1; ; n++ {
+		var update Update
+		if err := decoder.Decode(&update); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("update %d: %w", n, err)
+		}
+		if err := update.validate(); err != nil {
+			return fmt.Errorf("update %d: %w", n, err)
+		}
+		if err := action(update); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ldbigsegmentsync
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readAllUpdates(t *testing.T, data string) ([]Update, error) {
+	var updates []Update
+	err := ReadUpdates(strings.NewReader(data), func(u Update) error {
+		updates = append(updates, u)
+		return nil
+	})
+	return updates, err
+}
+
+func TestReadUpdates(t *testing.T) {
+	t.Run("valid updates", func(t *testing.T) {
+		updates, err := readAllUpdates(t, `
+{"cursor": "1", "segmentRef": "seg.g1", "replace": true, "included": ["h1"], "excluded": ["h2"]}
+{"cursor": "2", "segmentRef": "seg.g1", "addIncluded": ["h3"], "removeIncluded": ["h1"],
+  "addExcluded": ["h4"], "removeExcluded": ["h2"]}
+`)
+		require.NoError(t, err)
+		assert.Equal(t, []Update{
+			{Cursor: "1", SegmentRef: "seg.g1", Replace: true, Included: []string{"h1"}, Excluded: []string{"h2"}},
+			{Cursor: "2", SegmentRef: "seg.g1", AddIncluded: []string{"h3"}, RemoveIncluded: []string{"h1"},
+				AddExcluded: []string{"h4"}, RemoveExcluded: []string{"h2"}},
+		}, updates)
+	})
+
+	t.Run("empty stream", func(t *testing.T) {
+		updates, err := readAllUpdates(t, "")
+		require.NoError(t, err)
+		assert.Len(t, updates, 0)
+	})
+
+	t.Run("invalid updates", func(t *testing.T) {
+		for _, data := range []string{
+			`{"segmentRef": "seg.g1"}`,
+			`{"cursor": "1"}`,
+			`{"cursor": "1", "segmentRef": "seg.g1", "replace": true, "addIncluded": ["h1"]}`,
+			`{"cursor": "1", "segmentRef": "seg.g1", "included": ["h1"]}`,
+			`{"cursor": "1", "segmentRef": "seg.g1", "unknownProperty": true}`,
+			`{"cursor": "1", "segmentRef": "seg.g1"`,
+		} {
+			_, err := readAllUpdates(t, data)
+			assert.Error(t, err, data)
+		}
+	})
+
+	t.Run("error identifies the update", func(t *testing.T) {
+		updates, err := readAllUpdates(t, `{"cursor": "1", "segmentRef": "seg.g1"}
+{"cursor": "2"}`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "update 2")
+		assert.Len(t, updates, 1)
+	})
+
+	t.Run("stops at error from action", func(t *testing.T) {
+		fakeError := errors.New("sorry")
+		count := 0
+		err := ReadUpdates(strings.NewReader(`{"cursor": "1", "segmentRef": "seg.g1"}
+{"cursor": "2", "segmentRef": "seg.g1"}`), func(Update) error {
+			count++
+			return fakeError
+		})
+		assert.Equal(t, fakeError, err)
+		assert.Equal(t, 1, count)
+	})
+}
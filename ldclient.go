@@ -23,6 +23,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/datasource"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/events"
 	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
@@ -50,9 +51,11 @@ type LDClient struct {
 	sdkKey                           string
 	loggers                          ldlog.Loggers
 	eventProcessor                   ldevents.EventProcessor
+	contextEventRecorder             events.ContextEventRecorder
 	dataSource                       subsystems.DataSource
 	store                            subsystems.DataStore
 	evaluator                        ldeval.Evaluator
+	evaluatorDataProvider            ldeval.DataProvider
 	dataSourceStatusBroadcaster      *internal.Broadcaster[interfaces.DataSourceStatus]
 	dataSourceStatusProvider         interfaces.DataSourceStatusProvider
 	dataStoreStatusBroadcaster       *internal.Broadcaster[interfaces.DataStoreStatus]
@@ -62,6 +65,8 @@ type LDClient struct {
 	bigSegmentStoreStatusBroadcaster *internal.Broadcaster[interfaces.BigSegmentStoreStatus]
 	bigSegmentStoreStatusProvider    interfaces.BigSegmentStoreStatusProvider
 	bigSegmentStoreWrapper           *ldstoreimpl.BigSegmentStoreWrapper
+	eventTap                         *events.EventTap
+	evaluationMetrics                subsystems.EvaluationMetricsRecorder
 	eventsDefault                    eventsScope
 	eventsWithReasons                eventsScope
 	withEventsDisabled               interfaces.LDClientInterface
@@ -177,6 +182,9 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 		}
 	}
 
+	client.eventTap = events.NewEventTap()
+	clientContext.EventTap = client.eventTap
+
 	loggers := clientContext.GetLogging().Loggers
 	loggers.Infof("Starting LaunchDarkly client %s", Version)
 
@@ -184,6 +192,7 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 	client.logEvaluationErrors = clientContext.GetLogging().LogEvaluationErrors
 
 	client.offline = config.Offline
+	client.evaluationMetrics = config.EvaluationMetrics
 
 	client.dataStoreStatusBroadcaster = internal.NewBroadcaster[interfaces.DataStoreStatus]()
 	dataStoreUpdateSink := datastore.NewDataStoreUpdateSinkImpl(client.dataStoreStatusBroadcaster)
@@ -208,22 +217,27 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 		return nil, err
 	}
 	bsStore := bsConfig.GetStore()
+	var bsContextNotFoundCacheTime time.Duration // zero means the same as the context cache time
+	if c, ok := bsConfig.(subsystems.BigSegmentsContextNotFoundCacheConfiguration); ok {
+		bsContextNotFoundCacheTime = c.GetContextNotFoundCacheTime()
+	}
 	client.bigSegmentStoreStatusBroadcaster = internal.NewBroadcaster[interfaces.BigSegmentStoreStatus]()
 	if bsStore != nil {
 		client.bigSegmentStoreWrapper = ldstoreimpl.NewBigSegmentStoreWrapperWithConfig(
 			ldstoreimpl.BigSegmentsConfigurationProperties{
-				Store:              bsStore,
-				StartPolling:       true,
-				StatusPollInterval: bsConfig.GetStatusPollInterval(),
-				StaleAfter:         bsConfig.GetStaleAfter(),
-				ContextCacheSize:   bsConfig.GetContextCacheSize(),
-				ContextCacheTime:   bsConfig.GetContextCacheTime(),
+				Store:                    bsStore,
+				StartPolling:             true,
+				StatusPollInterval:       bsConfig.GetStatusPollInterval(),
+				StaleAfter:               bsConfig.GetStaleAfter(),
+				ContextCacheSize:         bsConfig.GetContextCacheSize(),
+				ContextCacheTime:         bsConfig.GetContextCacheTime(),
+				ContextNotFoundCacheTime: bsContextNotFoundCacheTime,
 			},
 			client.bigSegmentStoreStatusBroadcaster.Broadcast,
 			loggers,
 		)
 		client.bigSegmentStoreStatusProvider = bigsegments.NewBigSegmentStoreStatusProviderImpl(
-			client.bigSegmentStoreWrapper.GetStatus,
+			client.bigSegmentStoreWrapper,
 			client.bigSegmentStoreStatusBroadcaster,
 		)
 	} else {
@@ -240,6 +254,7 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 		evalOptions = append(evalOptions, ldeval.EvaluatorOptionBigSegmentProvider(client.bigSegmentStoreWrapper))
 	}
 	client.evaluator = ldeval.NewEvaluatorWithOptions(dataProvider, evalOptions...)
+	client.evaluatorDataProvider = dataProvider
 
 	client.dataStoreStatusProvider = datastore.NewDataStoreStatusProviderImpl(store, dataStoreUpdateSink)
 
@@ -258,6 +273,7 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 	if err != nil {
 		return nil, err
 	}
+	client.contextEventRecorder, _ = client.eventProcessor.(events.ContextEventRecorder)
 	if isNullEventProcessorFactory(eventProcessorFactory) {
 		client.eventsDefault = newDisabledEventsScope()
 		client.eventsWithReasons = newDisabledEventsScope()
@@ -289,7 +305,7 @@ func MakeCustomClient(sdkKey string, config Config, waitFor time.Duration) (*LDC
 
 	clientValid = true
 	client.dataSource.Start(closeWhenReady)
-	if waitFor > 0 && client.dataSource != datasource.NewNullDataSource() {
+	if waitFor > 0 && !datasource.IsNullDataSource(client.dataSource) {
 		loggers.Infof("Waiting up to %d milliseconds for LaunchDarkly client to start...",
 			waitFor/time.Millisecond)
 		timeout := time.After(waitFor)
@@ -346,7 +362,7 @@ func (client *LDClient) Identify(context ldcontext.Context) error {
 		return nil // Don't return an error value because we didn't in the past and it might confuse users
 	}
 	evt := client.eventsDefault.factory.NewIdentifyEventData(ldevents.Context(context))
-	client.eventProcessor.RecordIdentifyEvent(evt)
+	client.recordIdentifyEvent(evt, context)
 	return nil
 }
 
@@ -381,14 +397,16 @@ func (client *LDClient) TrackData(eventName string, context ldcontext.Context, d
 		client.loggers.Warnf("Track called with invalid context: %s", err)
 		return nil // Don't return an error value because we didn't in the past and it might confuse users
 	}
-	client.eventProcessor.RecordCustomEvent(
+	client.recordCustomEvent(
 		client.eventsDefault.factory.NewCustomEventData(
 			eventName,
 			ldevents.Context(context),
 			data,
 			false,
 			0,
-		))
+		),
+		context,
+	)
 	return nil
 }
 
@@ -417,14 +435,16 @@ func (client *LDClient) TrackMetric(
 		client.loggers.Warnf("TrackMetric called with invalid context: %s", err)
 		return nil // Don't return an error value because we didn't in the past and it might confuse users
 	}
-	client.eventProcessor.RecordCustomEvent(
+	client.recordCustomEvent(
 		client.eventsDefault.factory.NewCustomEventData(
 			eventName,
 			ldevents.Context(context),
 			data,
 			true,
 			metricValue,
-		))
+		),
+		context,
+	)
 	return nil
 }
 
@@ -499,6 +519,9 @@ func (client *LDClient) Close() error {
 	if client.bigSegmentStoreWrapper != nil {
 		client.bigSegmentStoreWrapper.Close()
 	}
+	if client.eventTap != nil {
+		client.eventTap.Close()
+	}
 	return nil
 }
 
@@ -577,32 +600,40 @@ func (client *LDClient) AllFlagsState(context ldcontext.Context, options ...flag
 		}
 	}
 
-	state := flagstate.NewAllFlagsBuilder(options...)
+	keys := make([]string, 0, len(items))
+	flags := make([]*ldmodel.FeatureFlag, 0, len(items))
 	for _, item := range items {
 		if item.Item.Item != nil {
 			if flag, ok := item.Item.Item.(*ldmodel.FeatureFlag); ok {
 				if clientSideOnly && !flag.ClientSideAvailability.UsingEnvironmentID {
 					continue
 				}
-
-				result := client.evaluator.Evaluate(flag, context, nil)
-
-				state.AddFlag(
-					item.Key,
-					flagstate.FlagState{
-						Value:                result.Detail.Value,
-						Variation:            result.Detail.VariationIndex,
-						Reason:               result.Detail.Reason,
-						Version:              flag.Version,
-						TrackEvents:          flag.TrackEvents || result.IsExperiment,
-						TrackReason:          result.IsExperiment,
-						DebugEventsUntilDate: flag.DebugEventsUntilDate,
-					},
-				)
+				keys = append(keys, item.Key)
+				flags = append(flags, flag)
 			}
 		}
 	}
 
+	client.prefetchBigSegmentMemberships(context, flags...)
+
+	state := flagstate.NewAllFlagsBuilder(options...)
+	for i, flag := range flags {
+		result := client.evaluator.Evaluate(flag, context, nil)
+
+		state.AddFlag(
+			keys[i],
+			flagstate.FlagState{
+				Value:                result.Detail.Value,
+				Variation:            result.Detail.VariationIndex,
+				Reason:               result.Detail.Reason,
+				Version:              flag.Version,
+				TrackEvents:          flag.TrackEvents || result.IsExperiment,
+				TrackReason:          result.IsExperiment,
+				DebugEventsUntilDate: flag.DebugEventsUntilDate,
+			},
+		)
+	}
+
 	return state.Build()
 }
 
@@ -794,6 +825,35 @@ func (client *LDClient) GetBigSegmentStoreStatusProvider() interfaces.BigSegment
 	return client.bigSegmentStoreStatusProvider
 }
 
+// AddEventListener subscribes to copies of the analytics events that the SDK generates. This is meant
+// for debugging and testing, as a way to see what the SDK is sending to LaunchDarkly without capturing
+// network traffic.
+//
+// The returned [interfaces.EventListener] has two channels. Its Events channel receives every evaluation,
+// identify, and custom event as a structured value when it is recorded, and every summary event when it
+// is sent. Its Payloads channel receives each payload in the JSON format that is sent to LaunchDarkly.
+// The contexts in these events never include attributes that are private or that are removed by a
+// redaction policy (see [ldcomponents.EventProcessorBuilder.RedactionPolicy]).
+// The bufferSize parameter is the buffer size of each channel; if it is zero or negative, a default of
+// 1000 is used.
+//
+// The SDK never waits for the application to read from these channels. If a channel is full, the value
+// is discarded and counted by the listener's GetDroppedCount method, so a listener does not slow down
+// evaluations even if it is not being read. However, copying the events does have some cost, so the
+// listener should be removed with [LDClient.RemoveEventListener] when it is no longer needed.
+//
+// Events are only delivered if the client was configured with the SDK's standard event processor
+// ([ldcomponents.SendEvents]). If events are disabled, the listener does not receive anything.
+func (client *LDClient) AddEventListener(bufferSize int) interfaces.EventListener {
+	return client.eventTap.AddListener(bufferSize)
+}
+
+// RemoveEventListener unsubscribes a listener that was returned by [LDClient.AddEventListener], and
+// closes its channels. If the listener was already removed, the method has no effect.
+func (client *LDClient) RemoveEventListener(listener interfaces.EventListener) {
+	client.eventTap.RemoveListener(listener)
+}
+
 // WithEventsDisabled returns a decorator for the LDClient that implements the same basic operations
 // but will not generate any analytics events.
 //
@@ -823,6 +883,27 @@ func (client *LDClient) variation(
 	defaultVal ldvalue.Value,
 	checkType bool,
 	eventsScope eventsScope,
+) (ldreason.EvaluationDetail, error) {
+	if client.evaluationMetrics == nil {
+		return client.variationInternal(key, context, defaultVal, checkType, eventsScope)
+	}
+	startTime := time.Now()
+	detail, err := client.variationInternal(key, context, defaultVal, checkType, eventsScope)
+	client.evaluationMetrics.RecordEvaluation(subsystems.EvaluationMetric{
+		FlagKey:   key,
+		Variation: detail.VariationIndex,
+		Reason:    detail.Reason,
+		Duration:  time.Since(startTime),
+	})
+	return detail, err
+}
+
+func (client *LDClient) variationInternal(
+	key string,
+	context ldcontext.Context,
+	defaultVal ldvalue.Value,
+	checkType bool,
+	eventsScope eventsScope,
 ) (ldreason.EvaluationDetail, error) {
 	if err := context.Err(); err != nil {
 		client.loggers.Warnf("Tried to evaluate a flag with an invalid context: %s", err)
@@ -863,7 +944,7 @@ func (client *LDClient) variation(
 				"",
 			)
 		}
-		client.eventProcessor.RecordEvaluation(eval)
+		client.recordEvaluation(eval, context)
 	}
 
 	return result.Detail, err
@@ -927,6 +1008,8 @@ func (client *LDClient) evaluateInternal(
 			fmt.Errorf("unknown feature key: %s. Verify that this feature key exists. Returning default value", key))
 	}
 
+	client.prefetchBigSegmentMemberships(context, feature)
+
 	result := client.evaluator.Evaluate(feature, context, eventsScope.prerequisiteEventRecorder)
 	if result.Detail.Reason.GetKind() == ldreason.EvalReasonError && client.logEvaluationErrors {
 		client.loggers.Warnf("Flag evaluation for %s failed with error %s, default value was returned",
@@ -938,6 +1021,17 @@ func (client *LDClient) evaluateInternal(
 	return result, feature, nil
 }
 
+// prefetchBigSegmentMemberships is called before evaluating flags for a multi-kind context, so that if the
+// flags reference Big Segments for more than one of the context's kinds, the memberships for all of those
+// can be queried at once (if the Big Segment store supports that) rather than one at a time.
+func (client *LDClient) prefetchBigSegmentMemberships(context ldcontext.Context, flags ...*ldmodel.FeatureFlag) {
+	if client.bigSegmentStoreWrapper == nil || !context.Multiple() {
+		return
+	}
+	client.bigSegmentStoreWrapper.PrefetchMemberships(
+		bigsegments.ContextKeysForFlags(context, flags, client.evaluatorDataProvider))
+}
+
 func newEvaluationError(jsonValue ldvalue.Value, errorKind ldreason.EvalErrorKind) ldreason.EvaluationDetail {
 	return ldreason.EvaluationDetail{
 		Value:  jsonValue,
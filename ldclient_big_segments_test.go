@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/bigsegments"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
 
@@ -116,3 +118,149 @@ func TestEvalWithBigSegments(t *testing.T) {
 		})
 	})
 }
+
+func TestBigSegmentStoreStatusProviderCacheMethods(t *testing.T) {
+	t.Run("cache stats", func(t *testing.T) {
+		doBigSegmentsTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStore) {
+			for i := 0; i < 2; i++ {
+				_, err := client.BoolVariation(evalFlagKey, evalTestUser, false)
+				require.NoError(t, err)
+			}
+
+			stats := client.GetBigSegmentStoreStatusProvider().(interfaces.BigSegmentCacheController).GetCacheStats()
+			assert.True(t, stats.Enabled)
+			assert.Equal(t, int64(1), stats.Misses)
+			assert.Equal(t, int64(1), stats.Hits)
+			assert.Equal(t, int64(1), stats.NotFoundHits)
+			assert.Equal(t, int64(1), stats.StoreQueries)
+		})
+	})
+
+	t.Run("invalidate context", func(t *testing.T) {
+		doBigSegmentsTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStore) {
+			value, err := client.BoolVariation(evalFlagKey, evalTestUser, false)
+			require.NoError(t, err)
+			assert.False(t, value)
+
+			membership := ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(
+				[]string{makeBigSegmentRef(bigSegmentKey, 1)}, nil)
+			bsStore.TestSetMembership(bigsegments.HashForContextKey(evalTestUser.Key()), membership)
+			client.GetBigSegmentStoreStatusProvider().(interfaces.BigSegmentCacheController).
+				InvalidateContext(evalTestUser.Key())
+
+			value, err = client.BoolVariation(evalFlagKey, evalTestUser, false)
+			require.NoError(t, err)
+			assert.True(t, value)
+			assert.Len(t, bsStore.TestGetMembershipQueries(), 2)
+		})
+	})
+
+	t.Run("store not configured", func(t *testing.T) {
+		withClientEvalTestParams(func(p clientEvalTestParams) {
+			controller := p.client.GetBigSegmentStoreStatusProvider().(interfaces.BigSegmentCacheController)
+			assert.Equal(t, interfaces.BigSegmentCacheStats{}, controller.GetCacheStats())
+			controller.InvalidateContext(evalTestUser.Key()) // does not panic
+		})
+	})
+}
+
+func TestEvalWithBigSegmentsForMultiKindContext(t *testing.T) {
+	userSegment := ldbuilders.NewSegmentBuilder("user-segment").Unbounded(true).Generation(1).Build()
+	orgSegment := ldbuilders.NewSegmentBuilder("org-segment").Unbounded(true).UnboundedContextKind("org").
+		Generation(1).Build()
+	makeFlag := func(key string, segmentKeys ...string) ldmodel.FeatureFlag {
+		builder := ldbuilders.NewFlagBuilder(key).On(true).
+			Variations(ldvalue.Bool(false), ldvalue.Bool(true)).FallthroughVariation(0)
+		for _, segmentKey := range segmentKeys {
+			builder.AddRule(ldbuilders.NewRuleBuilder().Variation(1).Clauses(
+				ldbuilders.Clause("", ldmodel.OperatorSegmentMatch, ldvalue.String(segmentKey)),
+			))
+		}
+		return builder.Build()
+	}
+	userContext := ldcontext.New("user-key")
+	orgContext := ldcontext.NewWithKind("org", "org-key")
+	multiContext := ldcontext.NewMulti(userContext, orgContext)
+	expectedHashes := []string{
+		bigsegments.HashForContextKey(userContext.Key()),
+		bigsegments.HashForContextKey(orgContext.Key()),
+	}
+
+	doTest := func(t *testing.T, action func(client *LDClient, bsStore *mocks.MockBigSegmentStoreBatch)) {
+		mockLog := ldlogtest.NewMockLog()
+		defer mockLog.DumpIfTestFailed(t)
+		testData := ldtestdata.DataSource()
+		testData.UsePreconfiguredSegment(userSegment)
+		testData.UsePreconfiguredSegment(orgSegment)
+		testData.UsePreconfiguredFlag(makeFlag("user-flag", userSegment.Key))
+		testData.UsePreconfiguredFlag(makeFlag("org-flag", orgSegment.Key))
+		testData.UsePreconfiguredFlag(makeFlag("both-flag", userSegment.Key, orgSegment.Key))
+		bsStore := &mocks.MockBigSegmentStoreBatch{}
+		bsStore.TestSetMetadataToCurrentTime()
+		bsStore.TestSetMembership(bigsegments.HashForContextKey(orgContext.Key()),
+			ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs([]string{makeBigSegmentRef(orgSegment.Key, 1)}, nil))
+
+		client := makeTestClientWithConfig(func(c *Config) {
+			c.DataSource = testData
+			c.BigSegments = ldcomponents.BigSegments(
+				mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: bsStore},
+			)
+			c.Logging = ldcomponents.Logging().Loggers(mockLog.Loggers)
+		})
+		defer client.Close()
+
+		action(client, bsStore)
+	}
+
+	t.Run("flag evaluation queries memberships for all kinds at once", func(t *testing.T) {
+		doTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStoreBatch) {
+			value, detail, err := client.BoolVariationDetail("both-flag", multiContext, false)
+			require.NoError(t, err)
+			assert.True(t, value)
+			assert.Equal(t, ldreason.BigSegmentsHealthy, detail.Reason.GetBigSegmentsStatus())
+
+			batchQueries := bsStore.TestGetBatchMembershipQueries()
+			require.Len(t, batchQueries, 1)
+			assert.ElementsMatch(t, expectedHashes, batchQueries[0])
+			assert.Len(t, bsStore.TestGetMembershipQueries(), 0)
+		})
+	})
+
+	t.Run("flag evaluation that needs only one kind does not do a batch query", func(t *testing.T) {
+		doTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStoreBatch) {
+			value, err := client.BoolVariation("org-flag", multiContext, false)
+			require.NoError(t, err)
+			assert.True(t, value)
+
+			assert.Len(t, bsStore.TestGetBatchMembershipQueries(), 0)
+			assert.Equal(t, expectedHashes[1:], bsStore.TestGetMembershipQueries())
+		})
+	})
+
+	t.Run("AllFlagsState queries memberships for all flags at once", func(t *testing.T) {
+		doTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStoreBatch) {
+			state := client.AllFlagsState(multiContext)
+			assert.True(t, state.IsValid())
+			assert.Equal(t, ldvalue.Bool(false), state.GetValue("user-flag"))
+			assert.Equal(t, ldvalue.Bool(true), state.GetValue("org-flag"))
+			assert.Equal(t, ldvalue.Bool(true), state.GetValue("both-flag"))
+
+			batchQueries := bsStore.TestGetBatchMembershipQueries()
+			require.Len(t, batchQueries, 1)
+			assert.ElementsMatch(t, expectedHashes, batchQueries[0])
+			assert.Len(t, bsStore.TestGetMembershipQueries(), 0)
+		})
+	})
+
+	t.Run("memberships are queried individually if batch query fails", func(t *testing.T) {
+		doTest(t, func(client *LDClient, bsStore *mocks.MockBigSegmentStoreBatch) {
+			bsStore.TestSetMembershipError(errors.New("sorry"))
+
+			_, detail, err := client.BoolVariationDetail("both-flag", multiContext, false)
+			require.NoError(t, err)
+			assert.Equal(t, ldreason.BigSegmentsStoreError, detail.Reason.GetBigSegmentsStatus())
+			assert.Len(t, bsStore.TestGetBatchMembershipQueries(), 1)
+			assert.NotEqual(t, 0, len(bsStore.TestGetMembershipQueries()))
+		})
+	})
+}
@@ -3,6 +3,7 @@ package ldclient
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
@@ -20,6 +21,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v6/ldmetrics"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
 	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldtestdata"
@@ -812,3 +814,30 @@ func TestEvalUsesStoreAndLogsWarningIfClientIsNotInitializedButStoreIsInitialize
 	assert.Len(t, mockLoggers.GetOutput(ldlog.Warn), 1)
 	assert.Contains(t, mockLoggers.GetOutput(ldlog.Warn)[0], "using last known values")
 }
+
+func TestEvaluationMetricsAreRecorded(t *testing.T) {
+	metrics := ldmetrics.NewEvaluationMetrics(ldmetrics.Options{})
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag(evalFlagKey).BooleanFlag().VariationForAll(true))
+	client := makeTestClientWithConfig(func(c *Config) {
+		c.DataSource = td
+		c.EvaluationMetrics = metrics
+	})
+	defer client.Close()
+
+	_, _ = client.BoolVariation(evalFlagKey, evalTestUser, false)
+	_, _, _ = client.BoolVariationDetail(evalFlagKey, evalTestUser, false)
+	_, _ = client.StringVariation("unknown-flag", evalTestUser, "x")
+	_, _ = client.BoolVariation(evalFlagKey, ldcontext.New(""), false)
+
+	var labels []string
+	for _, s := range metrics.Snapshot() {
+		labels = append(labels, fmt.Sprintf("%s/%s/%s/%d", s.FlagKey, s.Variation, s.ReasonKind, s.Count))
+		assert.Equal(t, s.Count, s.Latency.Count)
+	}
+	assert.Equal(t, []string{
+		evalFlagKey + "/0/FALLTHROUGH/2",
+		evalFlagKey + "/default/ERROR/1",
+		"unknown-flag/default/ERROR/1",
+	}, labels)
+}
@@ -68,7 +68,7 @@ func newEventsScope(client *LDClient, withReasons bool) eventsScope {
 	return eventsScope{
 		factory: factory,
 		prerequisiteEventRecorder: func(params ldeval.PrerequisiteFlagEvent) {
-			client.eventProcessor.RecordEvaluation(factory.NewEvaluationData(
+			client.recordEvaluation(factory.NewEvaluationData(
 				ldevents.FlagEventProperties{
 					Key:                  params.PrerequisiteFlag.Key,
 					Version:              params.PrerequisiteFlag.Version,
@@ -80,11 +80,39 @@ func newEventsScope(client *LDClient, withReasons bool) eventsScope {
 				params.PrerequisiteResult.IsExperiment,
 				ldvalue.Null(),
 				params.TargetFlagKey,
-			))
+			), params.Context)
 		},
 	}
 }
 
+// The following methods pass an event to the EventProcessor along with the context it was created from,
+// if the EventProcessor supports that (see events.ContextEventRecorder), so that a decorator such as the
+// one that applies a redaction policy can transform the context before it is formatted.
+
+func (client *LDClient) recordEvaluation(e ldevents.EvaluationData, context ldcontext.Context) {
+	if client.contextEventRecorder != nil {
+		client.contextEventRecorder.RecordEvaluationForContext(e, context)
+	} else {
+		client.eventProcessor.RecordEvaluation(e)
+	}
+}
+
+func (client *LDClient) recordIdentifyEvent(e ldevents.IdentifyEventData, context ldcontext.Context) {
+	if client.contextEventRecorder != nil {
+		client.contextEventRecorder.RecordIdentifyEventForContext(e, context)
+	} else {
+		client.eventProcessor.RecordIdentifyEvent(e)
+	}
+}
+
+func (client *LDClient) recordCustomEvent(e ldevents.CustomEventData, context ldcontext.Context) {
+	if client.contextEventRecorder != nil {
+		client.contextEventRecorder.RecordCustomEventForContext(e, context)
+	} else {
+		client.eventProcessor.RecordCustomEvent(e)
+	}
+}
+
 // This implementation of interfaces.LDClientInterface delegates all client operations to the
 // underlying LDClient, but suppresses the generation of analytics events.
 type clientEventsDisabledDecorator struct {
@@ -4,8 +4,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/launchdarkly/go-server-sdk/v6/internal/events"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
 	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
@@ -214,6 +216,32 @@ func TestWithEventsDisabledDecorator(t *testing.T) {
 		false)
 }
 
+func TestEventsArePassedWithTheirContextIfEventProcessorIsAContextEventRecorder(t *testing.T) {
+	capturing := &mocks.CapturingEventProcessor{}
+	redacted := lduser.NewUser("redacted")
+	ep := events.NewContextEventProcessor(capturing, events.ContextEventProcessorConfig{
+		RedactContext: func(ldcontext.Context) ldcontext.Context { return redacted },
+	})
+	client, err := MakeCustomClient("", Config{
+		DataSource: ldcomponents.ExternalUpdatesOnly(),
+		Events:     mocks.SingleComponentConfigurer[ldevents.EventProcessor]{Instance: ep},
+	}, 0)
+	require.NoError(t, err)
+	defer client.Close()
+
+	user := lduser.NewUser("userkey")
+	_, _ = client.BoolVariation("flagkey", user, false)
+	_ = client.Identify(user)
+	_ = client.TrackEvent("eventkey", user)
+	_ = client.TrackMetric("eventkey", user, 1.5, ldvalue.Null())
+
+	require.Len(t, capturing.Events, 4)
+	assert.Equal(t, ldevents.Context(redacted), capturing.Events[0].(ldevents.EvaluationData).Context)
+	assert.Equal(t, ldevents.Context(redacted), capturing.Events[1].(ldevents.IdentifyEventData).Context)
+	assert.Equal(t, ldevents.Context(redacted), capturing.Events[2].(ldevents.CustomEventData).Context)
+	assert.Equal(t, ldevents.Context(redacted), capturing.Events[3].(ldevents.CustomEventData).Context)
+}
+
 func TestFlushAsync(t *testing.T) {
 	g := newGatedEventSender()
 	client := makeTestClientWithEventSender(g)
@@ -2,6 +2,7 @@ package ldclient
 
 import (
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type clientExternalUpdatesTestParams struct {
@@ -74,4 +76,29 @@ func TestClientExternalUpdatesMode(t *testing.T) {
 			assert.True(t, result)
 		})
 	})
+	t.Run("sends flag change events for changes made to store", func(t *testing.T) {
+		store := datastore.NewInMemoryDataStore(ldlog.NewDisabledLoggers())
+		config := Config{
+			DataSource: ldcomponents.ExternalUpdatesOnly().ChangePollInterval(time.Millisecond * 10),
+			DataStore:  mocks.SingleComponentConfigurer[subsystems.DataStore]{Instance: store},
+			Logging:    ldcomponents.NoLogging(),
+		}
+		client, _ := MakeCustomClient("sdk_key", config, 0)
+		defer client.Close()
+		ch := client.GetFlagTracker().AddFlagChangeListener()
+
+		// The SDK reads the initial state of the store in the background, and changes made before that
+		// do not generate events, so we keep changing the flag until we see an event.
+		for version := 1; ; version++ {
+			flag := ldbuilders.NewFlagBuilder("flagkey").Version(version).SingleVariation(ldvalue.Bool(true)).Build()
+			_, _ = store.Upsert(ldstoreimpl.Features(), flag.Key, sharedtest.FlagDescriptor(flag))
+			select {
+			case event := <-ch:
+				assert.Equal(t, flag.Key, event.Key)
+				return
+			case <-time.After(time.Millisecond * 50):
+				require.Less(t, version, 20, "timed out waiting for flag change event")
+			}
+		}
+	})
 }
@@ -7,8 +7,10 @@ import (
 
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
 	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
@@ -229,3 +231,76 @@ func TestBigSegmentsStoreStatusProvider(t *testing.T) {
 			})
 	})
 }
+
+func TestEventListener(t *testing.T) {
+	t.Run("receives events and payloads", func(t *testing.T) {
+		sender := mocks.NewMockEventSender()
+		clientListenersTestWithConfig(
+			func(c *Config) {
+				c.Events = ldcomponents.SendEvents().
+					EventSender(mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: sender})
+				c.DiagnosticOptOut = true
+			},
+			func(p clientListenersTestParams) {
+				p.testData.Update(p.testData.Flag(evalFlagKey).BooleanFlag().VariationForAll(true))
+				listener := p.client.AddEventListener(100)
+
+				_, _ = p.client.BoolVariation(evalFlagKey, evalTestUser, false)
+				assert.NoError(t, p.client.TrackEvent("custom-event", evalTestUser))
+				p.client.Flush()
+
+				evalEvent := th.RequireValue(t, listener.Events(), time.Second)
+				assert.Equal(t, interfaces.EvaluationAnalyticsEvent, evalEvent.Kind)
+				if assert.NotNil(t, evalEvent.Evaluation) {
+					assert.Equal(t, evalFlagKey, evalEvent.Evaluation.Key)
+					assert.Equal(t, ldvalue.Bool(true), evalEvent.Evaluation.Value)
+				}
+				customEvent := th.RequireValue(t, listener.Events(), time.Second)
+				assert.Equal(t, interfaces.CustomAnalyticsEvent, customEvent.Kind)
+				summaryEvent := th.RequireValue(t, listener.Events(), time.Second)
+				assert.Equal(t, interfaces.SummaryAnalyticsEvent, summaryEvent.Kind)
+				if assert.NotNil(t, summaryEvent.Summary) {
+					assert.Equal(t, 1, summaryEvent.Summary.Features[evalFlagKey].Counters[0].Count)
+				}
+
+				payload := th.RequireValue(t, listener.Payloads(), time.Second)
+				assert.Equal(t, "analytics", payload.Kind)
+				sent := th.RequireValue(t, sender.PayloadsCh, time.Second)
+				assert.JSONEq(t, string(sent.Data), string(payload.Data))
+				assert.Equal(t, 0, listener.GetDroppedCount())
+
+				p.client.RemoveEventListener(listener)
+				th.AssertChannelClosed(t, listener.Events(), time.Second)
+				th.AssertChannelClosed(t, listener.Payloads(), time.Second)
+			})
+	})
+
+	t.Run("receives contexts without private attributes", func(t *testing.T) {
+		clientListenersTestWithConfig(
+			func(c *Config) {
+				c.Events = ldcomponents.SendEvents().
+					EventSender(mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: mocks.NewMockEventSender()}).
+					PrivateAttributes("email")
+				c.DiagnosticOptOut = true
+			},
+			func(p clientListenersTestParams) {
+				listener := p.client.AddEventListener(100)
+				context := ldcontext.NewBuilder("user-key").Name("a").SetString("email", "b").Build()
+				assert.NoError(t, p.client.Identify(context))
+
+				identifyEvent := th.RequireValue(t, listener.Events(), time.Second)
+				if assert.NotNil(t, identifyEvent.Identify) {
+					expected := ldcontext.NewBuilder("user-key").Name("a").Build()
+					assert.Equal(t, ldevents.Context(expected), identifyEvent.Identify.Context)
+				}
+			})
+	})
+
+	t.Run("receives nothing when events are disabled", func(t *testing.T) {
+		clientListenersTest(func(p clientListenersTestParams) {
+			listener := p.client.AddEventListener(0)
+			assert.NoError(t, p.client.TrackEvent("custom-event", evalTestUser))
+			th.AssertNoMoreValues(t, listener.Events(), time.Millisecond*50)
+		})
+	})
+}
@@ -5,9 +5,14 @@ import (
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
 	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldtestdata"
 
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -36,3 +41,114 @@ func TestClientWithTestDataSource(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, value)
 }
+
+func TestClientWithTestDataSourceSegments(t *testing.T) {
+	td := ldtestdata.DataSource()
+	td.UpdateSegment(td.Segment("segment1").Included("included-user"))
+	td.UpdateSegment(td.Segment("segment2").Unbounded(ldcontext.DefaultKind).BigSegmentIncluded("big-user"))
+	td.Update(td.Flag("flagkey").FallthroughVariation(false).
+		IfInSegment("segment1").ThenReturn(true).
+		IfInSegment("segment2").ThenReturn(true))
+
+	config := Config{
+		DataSource:  td,
+		BigSegments: ldcomponents.BigSegments(td.BigSegmentStore()),
+		Events:      ldcomponents.NoEvents(),
+	}
+	client, err := MakeCustomClient("", config, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	for _, key := range []string{"included-user", "big-user"} {
+		value, err := client.BoolVariation("flagkey", ldcontext.New(key), false)
+		require.NoError(t, err)
+		assert.True(t, value, key)
+	}
+
+	value, err := client.BoolVariation("flagkey", ldcontext.New("other-user"), false)
+	require.NoError(t, err)
+	assert.False(t, value)
+
+	td.UpdateSegment(td.Segment("segment1").Excluded("included-user"))
+	value, err = client.BoolVariation("flagkey", ldcontext.New("included-user"), false)
+	require.NoError(t, err)
+	assert.False(t, value)
+}
+
+func TestClientWithTestDataSourcePrerequisitesAndRollouts(t *testing.T) {
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("prereq").VariationForAll(true))
+	td.Update(td.Flag("flagkey").Prerequisite("prereq", true).
+		IfMatchOperator("version", ldmodel.OperatorSemVerLessThan, ldvalue.String("2.0.0")).ThenReturn(false).
+		FallthroughRolloutWith(ldtestdata.Rollout(0, ldtestdata.RolloutWeightTotal).Experiment()))
+
+	config := Config{
+		DataSource: td,
+		Events:     ldcomponents.NoEvents(),
+	}
+	client, err := MakeCustomClient("", config, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	value, detail, err := client.BoolVariationDetail("flagkey", ldcontext.New("userkey"), true)
+	require.NoError(t, err)
+	assert.False(t, value)
+	assert.True(t, detail.Reason.IsInExperiment())
+
+	oldVersionUser := ldcontext.NewBuilder("userkey").SetString("version", "1.5.0").Build()
+	value, detail, err = client.BoolVariationDetail("flagkey", oldVersionUser, true)
+	require.NoError(t, err)
+	assert.False(t, value)
+	assert.Equal(t, ldreason.EvalReasonRuleMatch, detail.Reason.GetKind())
+
+	td.Update(td.Flag("prereq").VariationForAll(false))
+	_, detail, err = client.BoolVariationDetail("flagkey", ldcontext.New("userkey"), true)
+	require.NoError(t, err)
+	assert.Equal(t, ldreason.EvalReasonPrerequisiteFailed, detail.Reason.GetKind())
+}
+
+func TestClientWithTestDataSourceDeletesAndBatches(t *testing.T) {
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("flag1").VariationForAll(true))
+	td.Update(td.Flag("flag2").VariationForAll(true))
+	td.Update(td.Flag("flag3").VariationForAll(true))
+	td.UpdateSegment(td.Segment("segment1").Included("user1"))
+	td.Update(td.Flag("flag4").FallthroughVariation(false).IfInSegment("segment1").ThenReturn(true))
+
+	config := Config{
+		DataSource: td,
+		Events:     ldcomponents.NoEvents(),
+	}
+	client, err := MakeCustomClient("", config, time.Second)
+	require.NoError(t, err)
+	defer client.Close()
+
+	changes := client.GetFlagTracker().AddFlagChangeListener()
+	defer client.GetFlagTracker().RemoveFlagChangeListener(changes)
+
+	td.Delete("flag1")
+	assert.Equal(t, "flag1", th.RequireValue(t, changes, time.Second).Key)
+	th.AssertNoMoreValues(t, changes, time.Millisecond*50)
+
+	value, detail, err := client.BoolVariationDetail("flag1", ldcontext.New("user1"), false)
+	assert.Error(t, err)
+	assert.False(t, value)
+	assert.Equal(t, ldreason.EvalErrorFlagNotFound, detail.Reason.GetErrorKind())
+
+	td.Batch(func(tx *ldtestdata.Transaction) {
+		tx.Update(td.Flag("flag2").VariationForAll(false)).
+			Update(td.Flag("flag2").VariationForAll(false).On(false)).
+			Delete("flag3").
+			DeleteSegment("segment1")
+	})
+	var changedKeys []string
+	for i := 0; i < 3; i++ {
+		changedKeys = append(changedKeys, th.RequireValue(t, changes, time.Second).Key)
+	}
+	th.AssertNoMoreValues(t, changes, time.Millisecond*50)
+	assert.ElementsMatch(t, []string{"flag2", "flag3", "flag4"}, changedKeys)
+
+	value, err = client.BoolVariation("flag4", ldcontext.New("user1"), false)
+	require.NoError(t, err)
+	assert.False(t, value)
+}
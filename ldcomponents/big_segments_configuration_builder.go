@@ -65,6 +65,11 @@ type BigSegmentsConfigurationBuilder struct {
 // specifically for the Redis integration, whereas ContextCacheSize() is an option that can be used
 // for any data store type.
 //
+// For local development and testing, the SDK also provides a Big Segment store that reads memberships
+// from files: [github.com/launchdarkly/go-server-sdk/v6/ldfilebigsegments]. There is also a Big Segment
+// store for SQL databases, [github.com/launchdarkly/go-server-sdk/ldsqlstore.BigSegmentStore], which
+// can be populated with [github.com/launchdarkly/go-server-sdk/v6/ldbigsegmentsync].
+//
 // If you do not set Config.BigSegments-- or if you pass a nil storeConfigurer to this function-- the
 // Big Segments feature will be disabled, and any feature flags that reference a Big Segment will
 // behave as if the evaluation context was not included in the segment.
@@ -115,6 +120,21 @@ func (b *BigSegmentsConfigurationBuilder) ContextCacheTime(
 	return b
 }
 
+// ContextNotFoundCacheTime sets the maximum length of time that the SDK will cache the result that an
+// evaluation context has no Big Segment memberships at all, for instance because the context is not in
+// any Big Segments. By default, this is the same as [BigSegmentsConfigurationBuilder.ContextCacheTime].
+//
+// Applications that evaluate flags for many contexts that are not in any Big Segments may want to use
+// a longer time for these results, so that they do not fill the cache with short-lived entries; or, if
+// newly created contexts are likely to be added to segments soon, a shorter time. A negative value means
+// that these results are not cached at all, so the store will be queried every time.
+func (b *BigSegmentsConfigurationBuilder) ContextNotFoundCacheTime(
+	contextNotFoundCacheTime time.Duration,
+) *BigSegmentsConfigurationBuilder {
+	b.config.ContextNotFoundCacheTime = contextNotFoundCacheTime
+	return b
+}
+
 // StatusPollInterval sets the interval at which the SDK will poll the Big Segment store to make sure
 // it is available and to determine how long ago it was updated. The default value is
 // [DefaultBigSegmentsStatusPollInterval].
@@ -41,6 +41,8 @@ func TestBigSegmentsConfigurationBuilder(t *testing.T) {
 		assert.Equal(t, mockBigSegmentStore{}, c.GetStore())
 		assert.Equal(t, DefaultBigSegmentsContextCacheSize, c.GetContextCacheSize())
 		assert.Equal(t, DefaultBigSegmentsContextCacheTime, c.GetContextCacheTime())
+		assert.Equal(t, time.Duration(0),
+			c.(subsystems.BigSegmentsContextNotFoundCacheConfiguration).GetContextNotFoundCacheTime())
 		assert.Equal(t, DefaultBigSegmentsStatusPollInterval, c.GetStatusPollInterval())
 		assert.Equal(t, DefaultBigSegmentsStaleAfter, c.GetStaleAfter())
 	})
@@ -68,6 +70,15 @@ func TestBigSegmentsConfigurationBuilder(t *testing.T) {
 		assert.Equal(t, time.Second*999, c.GetContextCacheTime())
 	})
 
+	t.Run("ContextNotFoundCacheTime", func(t *testing.T) {
+		c, err := BigSegments(mockBigSegmentStoreFactory{}).
+			ContextNotFoundCacheTime(time.Second * 999).
+			Build(context)
+		require.NoError(t, err)
+		assert.Equal(t, time.Second*999,
+			c.(subsystems.BigSegmentsContextNotFoundCacheConfiguration).GetContextNotFoundCacheTime())
+	})
+
 	t.Run("StatusPollInterval", func(t *testing.T) {
 		c, err := BigSegments(mockBigSegmentStoreFactory{}).
 			StatusPollInterval(time.Second * 999).
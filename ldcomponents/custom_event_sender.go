@@ -0,0 +1,130 @@
+package ldcomponents
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/events"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+const (
+	eventSchemaHeader          = "X-LaunchDarkly-Event-Schema"
+	eventPayloadIDHeader       = "X-LaunchDarkly-Payload-ID"
+	currentEventSchema         = "4"
+	defaultEventSendRetryDelay = time.Second
+)
+
+// customEventSender adapts a subsystems.EventSender to the ldevents.EventSender interface that is used by
+// the event processor. It provides the same headers and retry behavior as the default HTTP implementation.
+//
+// If the event spool is enabled, sender is the events.EventSpool, and spool refers to the same object so
+// that its statistics can be added to diagnostic events.
+type customEventSender struct {
+	sender      subsystems.EventSender
+	spool       *events.EventSpool
+	baseHeaders http.Header
+	retryDelay  time.Duration
+	loggers     ldlog.Loggers
+}
+
+func newCustomEventSender(
+	sender subsystems.EventSender,
+	baseHeaders http.Header,
+	sdkKey string,
+	loggers ldlog.Loggers,
+) *customEventSender {
+	headers := make(http.Header, len(baseHeaders)+2)
+	for k, vv := range baseHeaders {
+		headers[k] = vv
+	}
+	headers.Set("Authorization", sdkKey)
+	headers.Set("Content-Type", "application/json")
+	return &customEventSender{
+		sender:      sender,
+		baseHeaders: headers,
+		retryDelay:  defaultEventSendRetryDelay,
+		loggers:     loggers,
+	}
+}
+
+func (s *customEventSender) SendEventData(
+	kind ldevents.EventDataKind,
+	data []byte,
+	eventCount int,
+) ldevents.EventSenderResult {
+	payload := subsystems.EventPayload{
+		Data:       data,
+		EventCount: eventCount,
+	}
+	switch kind {
+	case ldevents.AnalyticsEventDataKind:
+		payload.Kind = subsystems.AnalyticsEventPayload
+		payloadUUID, _ := uuid.NewRandom()
+		payload.PayloadID = payloadUUID.String() // if NewRandom somehow failed, we'll just use an empty string
+	case ldevents.DiagnosticEventDataKind:
+		payload.Kind = subsystems.DiagnosticEventPayload
+		if s.spool != nil {
+			payload.Data = addSpoolStatsToDiagnosticEvent(payload.Data, s.spool)
+		}
+	default:
+		return ldevents.EventSenderResult{}
+	}
+	payload.Headers = s.makeHeaders(payload.Kind, payload.PayloadID)
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			s.loggers.Warnf("Will retry sending events after %s", s.retryDelay)
+			time.Sleep(s.retryDelay)
+		}
+		err := s.sender.SendEventData(payload)
+		if err == nil {
+			return ldevents.EventSenderResult{Success: true}
+		}
+		if errors.As(err, &events.UnrecoverableHTTPError{}) {
+			s.loggers.Errorf("Error sending events (giving up permanently): %s", err)
+			return ldevents.EventSenderResult{MustShutDown: true}
+		}
+		var retryable subsystems.RetryableEventSenderError
+		if !errors.As(err, &retryable) {
+			s.loggers.Warnf("Error sending events, some events were dropped: %s", err)
+			return ldevents.EventSenderResult{}
+		}
+		if attempt == 0 {
+			s.loggers.Warnf("Error sending events, will retry: %s", err)
+		} else {
+			s.loggers.Warnf("Error sending events, some events were dropped: %s", err)
+		}
+	}
+	return ldevents.EventSenderResult{}
+}
+
+func (s *customEventSender) makeHeaders(kind subsystems.EventPayloadKind, payloadID string) http.Header {
+	headers := s.baseHeaders.Clone()
+	if kind == subsystems.AnalyticsEventPayload {
+		headers.Set(eventSchemaHeader, currentEventSchema)
+		headers.Set(eventPayloadIDHeader, payloadID)
+	}
+	return headers
+}
+
+// addSpoolStatsToDiagnosticEvent adds the event spool statistics to a periodic diagnostic event. The
+// diagnostic events are generated by go-sdk-events, which does not know about the spool.
+func addSpoolStatsToDiagnosticEvent(data []byte, spool *events.EventSpool) []byte {
+	event := ldvalue.Parse(data)
+	if event.GetByKey("kind").StringValue() != "diagnostic" { // the diagnostic-init event is left unchanged
+		return data
+	}
+	stats := spool.GetAndResetStats()
+	props := event.AsValueMap().AsMap()
+	props["spooledEvents"] = ldvalue.Int(stats.SpooledEvents)
+	props["spoolDroppedEvents"] = ldvalue.Int(stats.DroppedEvents)
+	props["spoolPendingPayloads"] = ldvalue.Int(stats.PendingPayloads)
+	return []byte(ldvalue.CopyObject(props).JSONString())
+}
@@ -0,0 +1,162 @@
+package ldcomponents
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/events"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestCustomEventSender(sender subsystems.EventSender) *customEventSender {
+	baseHeaders := make(http.Header)
+	baseHeaders.Set("User-Agent", "FakeSDK/1.0")
+	s := newCustomEventSender(sender, baseHeaders, testSdkKey, sharedtest.NewTestLoggers())
+	s.retryDelay = time.Millisecond
+	return s
+}
+
+func TestCustomEventSenderSendsAnalyticsPayload(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	s := makeTestCustomEventSender(sender)
+
+	result := s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[{"kind":"custom"}]`), 1)
+	assert.Equal(t, ldevents.EventSenderResult{Success: true}, result)
+
+	payload := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	assert.Equal(t, subsystems.AnalyticsEventPayload, payload.Kind)
+	assert.Equal(t, `[{"kind":"custom"}]`, string(payload.Data))
+	assert.Equal(t, 1, payload.EventCount)
+	assert.NotEqual(t, "", payload.PayloadID)
+	assert.Equal(t, payload.PayloadID, payload.Headers.Get("X-LaunchDarkly-Payload-ID"))
+	assert.Equal(t, currentEventSchema, payload.Headers.Get("X-LaunchDarkly-Event-Schema"))
+	assert.Equal(t, testSdkKey, payload.Headers.Get("Authorization"))
+	assert.Equal(t, "application/json", payload.Headers.Get("Content-Type"))
+	assert.Equal(t, "FakeSDK/1.0", payload.Headers.Get("User-Agent"))
+}
+
+func TestCustomEventSenderSendsDiagnosticPayload(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	s := makeTestCustomEventSender(sender)
+
+	result := s.SendEventData(ldevents.DiagnosticEventDataKind, []byte(`{"kind":"diagnostic"}`), 1)
+	assert.Equal(t, ldevents.EventSenderResult{Success: true}, result)
+
+	payload := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	assert.Equal(t, subsystems.DiagnosticEventPayload, payload.Kind)
+	assert.Equal(t, "", payload.PayloadID)
+	assert.Equal(t, "", payload.Headers.Get("X-LaunchDarkly-Payload-ID"))
+	assert.Equal(t, "", payload.Headers.Get("X-LaunchDarkly-Event-Schema"))
+	assert.Equal(t, testSdkKey, payload.Headers.Get("Authorization"))
+}
+
+func TestCustomEventSenderUsesNewPayloadIDForEachPayload(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	s := makeTestCustomEventSender(sender)
+
+	s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+	s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+
+	payload1 := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	payload2 := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	assert.NotEqual(t, payload1.PayloadID, payload2.PayloadID)
+}
+
+func TestCustomEventSenderRetriesOnceAfterRetryableError(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	sender.TestSetErrors(subsystems.RetryableEventSenderError{Err: errors.New("sorry")})
+	s := makeTestCustomEventSender(sender)
+
+	result := s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+	assert.Equal(t, ldevents.EventSenderResult{Success: true}, result)
+
+	payload1 := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	payload2 := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	assert.Equal(t, payload1.PayloadID, payload2.PayloadID)
+}
+
+func TestCustomEventSenderGivesUpAfterSecondRetryableError(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	err := subsystems.RetryableEventSenderError{Err: errors.New("sorry")}
+	sender.TestSetErrors(err, err)
+	s := makeTestCustomEventSender(sender)
+
+	result := s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+	assert.Equal(t, ldevents.EventSenderResult{}, result)
+	assert.Len(t, sender.PayloadsCh, 2)
+}
+
+func TestCustomEventSenderDoesNotRetryAfterOtherError(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	sender.TestSetErrors(errors.New("sorry"))
+	s := makeTestCustomEventSender(sender)
+
+	result := s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+	assert.Equal(t, ldevents.EventSenderResult{}, result)
+	assert.Len(t, sender.PayloadsCh, 1)
+}
+
+func TestCustomEventSenderRecognizesWrappedRetryableError(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	sender.TestSetErrors(fmt.Errorf("wrapped: %w", subsystems.RetryableEventSenderError{Err: errors.New("sorry")}))
+	s := makeTestCustomEventSender(sender)
+
+	result := s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+	assert.Equal(t, ldevents.EventSenderResult{Success: true}, result)
+	assert.Len(t, sender.PayloadsCh, 2)
+}
+
+func TestCustomEventSenderReturnsMustShutDownForUnrecoverableHTTPError(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	sender.TestSetErrors(events.UnrecoverableHTTPError{StatusCode: 401})
+	s := makeTestCustomEventSender(sender)
+
+	result := s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[]`), 0)
+	assert.Equal(t, ldevents.EventSenderResult{MustShutDown: true}, result)
+	assert.Len(t, sender.PayloadsCh, 1)
+}
+
+func TestCustomEventSenderAddsSpoolStatsToPeriodicDiagnosticEvent(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	sender.TestSetErrors(subsystems.RetryableEventSenderError{Err: errors.New("sorry")})
+	s := makeTestCustomEventSender(sender)
+	spool, err := events.NewEventSpool(events.EventSpoolConfig{
+		Directory:         t.TempDir(),
+		MaxSize:           DefaultEventSpoolMaxSize,
+		Sender:            sender,
+		MakeHeaders:       s.makeHeaders,
+		InitialRetryDelay: time.Hour,
+		Loggers:           sharedtest.NewTestLoggers(),
+	})
+	require.NoError(t, err)
+	defer spool.Close()
+	s.sender, s.spool = spool, spool
+
+	s.SendEventData(ldevents.AnalyticsEventDataKind, []byte(`[{"kind":"custom"},{"kind":"custom"}]`), 2)
+	th.RequireValue(t, sender.PayloadsCh, time.Second)
+
+	s.SendEventData(ldevents.DiagnosticEventDataKind, []byte(`{"kind":"diagnostic-init","id":{}}`), 1)
+	initEvent := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	assert.JSONEq(t, `{"kind":"diagnostic-init","id":{}}`, string(initEvent.Data))
+
+	s.SendEventData(ldevents.DiagnosticEventDataKind, []byte(`{"kind":"diagnostic","droppedEvents":0}`), 1)
+	periodicEvent := th.RequireValue(t, sender.PayloadsCh, time.Second)
+	assert.JSONEq(t, `{"kind":"diagnostic","droppedEvents":0,`+
+		`"spooledEvents":2,"spoolDroppedEvents":0,"spoolPendingPayloads":1}`, string(periodicEvent.Data))
+
+	s.SendEventData(ldevents.DiagnosticEventDataKind, []byte(`{"kind":"diagnostic","droppedEvents":0}`), 1)
+	periodicEvent = th.RequireValue(t, sender.PayloadsCh, time.Second)
+	assert.JSONEq(t, `{"kind":"diagnostic","droppedEvents":0,`+
+		`"spooledEvents":0,"spoolDroppedEvents":0,"spoolPendingPayloads":1}`, string(periodicEvent.Data))
+}
@@ -0,0 +1,118 @@
+package ldcomponents
+
+import (
+	"errors"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// EncryptedPersistentDataStoreBuilder is a configurable factory for a persistent data store that encrypts
+// the data in another persistent data store.
+//
+// See [EncryptedPersistentDataStore] for usage.
+type EncryptedPersistentDataStoreBuilder struct {
+	persistentDataStoreFactory subsystems.ComponentConfigurer[subsystems.PersistentDataStore]
+	config                     datastore.EncryptedPersistentDataStoreConfig
+}
+
+// EncryptedPersistentDataStore returns a configuration builder for encrypting the data in a persistent
+// data store, so that flag and segment data (such as the context keys in segments) are not stored in
+// plaintext in a shared database.
+//
+// This can be used with any persistent data store implementation. Place it between
+// [PersistentDataStore] and the builder for the specific data store, and specify the encryption key with
+// [EncryptedPersistentDataStoreBuilder.PrimaryKey]:
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(
+//	        ldcomponents.EncryptedPersistentDataStore(
+//	            ldredis.DataStore().URL("redis://my-redis-host"),
+//	        ).PrimaryKey("2023-06", keyBytes),
+//	    ),
+//	}
+//
+// Each item is encrypted with AES-GCM. Its key, version, and deleted state are not encrypted, so the data
+// store can still compare versions; however, they are authenticated, so they cannot be altered without
+// detection. Every SDK instance and Relay Proxy instance that uses the same data must be configured with
+// the same keys.
+//
+// To rotate keys, make the new key the primary key and add the old one with
+// [EncryptedPersistentDataStoreBuilder.DecryptionKey]. Items are re-encrypted with the new key whenever
+// they are next written, which for all items is no later than the next time the SDK receives a full set
+// of flag data from LaunchDarkly (for instance, when it restarts). To re-encrypt all of the items right
+// away, use [github.com/launchdarkly/go-server-sdk/v6/ldstoremigrate.Rewrite]. After that, the old key
+// can be removed.
+//
+// The write-behind journal file that can be configured with
+// [PersistentDataStoreBuilder.WriteBehindJournalFile] is not encrypted, so it cannot be used together
+// with this option.
+func EncryptedPersistentDataStore(
+	persistentDataStoreFactory subsystems.ComponentConfigurer[subsystems.PersistentDataStore],
+) *EncryptedPersistentDataStoreBuilder {
+	return &EncryptedPersistentDataStoreBuilder{persistentDataStoreFactory: persistentDataStoreFactory}
+}
+
+// PrimaryKey sets the key that will be used to encrypt all data written to the store, and the ID that
+// identifies it. The key must be 16, 24, or 32 bytes long, to select AES-128, AES-192, or AES-256.
+//
+// This is required. If it has not been set, the SDK client will fail to start.
+func (b *EncryptedPersistentDataStoreBuilder) PrimaryKey(
+	keyID string,
+	key []byte,
+) *EncryptedPersistentDataStoreBuilder {
+	b.config.PrimaryKey = datastore.EncryptionKey{ID: keyID, Key: key}
+	return b
+}
+
+// DecryptionKey adds a key that data in the store may have been encrypted with, such as the previous
+// primary key after a key rotation. It is used only for decryption. The key ID must be different from that
+// of any other key.
+func (b *EncryptedPersistentDataStoreBuilder) DecryptionKey(
+	keyID string,
+	key []byte,
+) *EncryptedPersistentDataStoreBuilder {
+	b.config.DecryptionKeys = append(b.config.DecryptionKeys, datastore.EncryptionKey{ID: keyID, Key: key})
+	return b
+}
+
+// AllowUnencryptedData sets whether unencrypted items in the data store should be accepted.
+//
+// By default, this is false, and the SDK treats an unencrypted item as an error. Set it to true if you are
+// enabling encryption for a data store that already contains data; the existing items will be encrypted
+// the next time they are written.
+func (b *EncryptedPersistentDataStoreBuilder) AllowUnencryptedData(value bool) *EncryptedPersistentDataStoreBuilder {
+	b.config.AllowUnencrypted = value
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *EncryptedPersistentDataStoreBuilder) Build(
+	context subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	if b.config.PrimaryKey.Key == nil {
+		return nil, errors.New("encrypted persistent data store requires a primary key")
+	}
+	if b.persistentDataStoreFactory == nil {
+		return nil, errors.New("encrypted persistent data store requires a data store implementation")
+	}
+	core, err := b.persistentDataStoreFactory.Build(context)
+	if err != nil {
+		return nil, err
+	}
+	store, err := datastore.NewEncryptedPersistentDataStore(core, b.config)
+	if err != nil {
+		_ = core.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+// DescribeConfiguration is used internally by the SDK to inspect the configuration.
+func (b *EncryptedPersistentDataStoreBuilder) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
+	if dd, ok := b.persistentDataStoreFactory.(subsystems.DiagnosticDescription); ok {
+		return dd.DescribeConfiguration(context)
+	}
+	return ldvalue.String("custom")
+}
@@ -0,0 +1,70 @@
+package ldcomponents
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedPersistentDataStoreBuilder(t *testing.T) {
+	key1 := []byte("0123456789abcdef")
+	key2 := []byte("0123456789abcdef0123456789abcdef")
+
+	t.Run("keys", func(t *testing.T) {
+		f := EncryptedPersistentDataStore(&mockPersistentDataStoreFactory{}).
+			PrimaryKey("k2", key2).DecryptionKey("k1", key1)
+		assert.Equal(t, datastore.EncryptionKey{ID: "k2", Key: key2}, f.config.PrimaryKey)
+		assert.Equal(t, []datastore.EncryptionKey{{ID: "k1", Key: key1}}, f.config.DecryptionKeys)
+		assert.False(t, f.config.AllowUnencrypted)
+	})
+
+	t.Run("AllowUnencryptedData", func(t *testing.T) {
+		f := EncryptedPersistentDataStore(&mockPersistentDataStoreFactory{}).AllowUnencryptedData(true)
+		assert.True(t, f.config.AllowUnencrypted)
+	})
+
+	t.Run("calls factory", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{store: mocks.NewMockPersistentDataStore()}
+		store, err := EncryptedPersistentDataStore(pdsf).PrimaryKey("k1", key1).Build(sharedtest.NewSimpleTestContext(""))
+		require.NoError(t, err)
+		require.NotNil(t, store)
+		assert.NotEqual(t, pdsf.store, store)
+		assert.NoError(t, store.Close())
+
+		pdsf.store = nil
+		pdsf.fakeError = errors.New("sorry")
+		store, err = EncryptedPersistentDataStore(pdsf).PrimaryKey("k1", key1).Build(sharedtest.NewSimpleTestContext(""))
+		assert.Equal(t, pdsf.fakeError, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("primary key is required", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{store: mocks.NewMockPersistentDataStore()}
+		_, err := EncryptedPersistentDataStore(pdsf).Build(sharedtest.NewSimpleTestContext(""))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid key is rejected", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{store: mocks.NewMockPersistentDataStore()}
+		_, err := EncryptedPersistentDataStore(pdsf).PrimaryKey("k1", []byte("too short")).
+			Build(sharedtest.NewSimpleTestContext(""))
+		assert.Error(t, err)
+	})
+
+	t.Run("diagnostic description", func(t *testing.T) {
+		value := ldvalue.String("my-store")
+		f := EncryptedPersistentDataStore(&mockPersistentDataStoreFactoryWithDescription{value})
+		assert.Equal(t, value, f.DescribeConfiguration(sharedtest.NewSimpleTestContext("")))
+
+		f = EncryptedPersistentDataStore(&mockPersistentDataStoreFactory{})
+		assert.Equal(t, ldvalue.String("custom"), f.DescribeConfiguration(sharedtest.NewSimpleTestContext("")))
+	})
+}
@@ -1,13 +1,38 @@
 package ldcomponents
 
 import (
+	"time"
+
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/datasource"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 )
 
-type nullDataSourceFactory struct{}
+// DefaultExternalUpdatesChangePollInterval is the default value for
+// [ExternalUpdatesDataSourceConfigurer.ChangePollInterval].
+const DefaultExternalUpdatesChangePollInterval = 30 * time.Second
+
+// ExternalUpdatesDataSourceConfigurer is the type of the configuration object returned by
+// [ExternalUpdatesOnly]. It can be used wherever a data source configuration is expected, and also has
+// a method for changing how the SDK detects changes made by the external process.
+type ExternalUpdatesDataSourceConfigurer interface {
+	subsystems.ComponentConfigurer[subsystems.DataSource]
+
+	// ChangePollInterval sets how often the SDK will query the data store to detect changes made by the
+	// external process, if the data store does not support change notifications.
+	//
+	// The default value is [DefaultExternalUpdatesChangePollInterval]. Each query reads all of the flags
+	// and segments. Since the queries go through the data store's cache, a change will not be detected
+	// until the cached data has expired, so there is no point in making this interval shorter than the
+	// cache TTL. A value of zero or less disables the queries, so flag change events will only be
+	// generated if the data store supports change notifications.
+	ChangePollInterval(changePollInterval time.Duration) ExternalUpdatesDataSourceConfigurer
+}
+
+type externalUpdatesDataSourceBuilder struct {
+	changePollInterval time.Duration
+}
 
 // ExternalUpdatesOnly returns a configuration object that disables a direct connection with LaunchDarkly
 // for feature flag updates.
@@ -23,23 +48,39 @@ type nullDataSourceFactory struct{}
 //	config := ld.Config{
 //	    DataSource: ldcomponents.ExternalUpdatesOnly(),
 //	}
-func ExternalUpdatesOnly() subsystems.ComponentConfigurer[subsystems.DataSource] {
-	return nullDataSourceFactory{}
+//
+// In this mode, the SDK can still generate flag change events (see
+// [github.com/launchdarkly/go-server-sdk/v6/interfaces.FlagTracker]) when the external process changes
+// the data. If the persistent data store implements
+// [github.com/launchdarkly/go-server-sdk/v6/subsystems.PersistentDataStoreChangeNotifier], it will tell
+// the SDK about changes; otherwise, the SDK queries the data store at intervals (see
+// [ExternalUpdatesDataSourceConfigurer.ChangePollInterval]).
+func ExternalUpdatesOnly() ExternalUpdatesDataSourceConfigurer {
+	return &externalUpdatesDataSourceBuilder{
+		changePollInterval: DefaultExternalUpdatesChangePollInterval,
+	}
+}
+
+func (b *externalUpdatesDataSourceBuilder) ChangePollInterval(
+	changePollInterval time.Duration,
+) ExternalUpdatesDataSourceConfigurer {
+	b.changePollInterval = changePollInterval
+	return b
 }
 
-// DataSourceFactory implementation
-func (f nullDataSourceFactory) Build(
+// Build is called internally by the SDK.
+func (b *externalUpdatesDataSourceBuilder) Build(
 	context subsystems.ClientContext,
 ) (subsystems.DataSource, error) {
 	context.GetLogging().Loggers.Info("LaunchDarkly client will not connect to Launchdarkly for feature flag data")
 	if context.GetDataSourceUpdateSink() != nil {
 		context.GetDataSourceUpdateSink().UpdateStatus(interfaces.DataSourceStateValid, interfaces.DataSourceErrorInfo{})
 	}
-	return datasource.NewNullDataSource(), nil
+	return datasource.NewExternalUpdatesDataSource(context.GetDataSourceUpdateSink(), b.changePollInterval), nil
 }
 
-// DiagnosticDescription implementation
-func (f nullDataSourceFactory) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
+// DescribeConfiguration is used internally by the SDK to inspect the configuration.
+func (b *externalUpdatesDataSourceBuilder) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
 	// This information is only used for diagnostic events, and if we're able to send diagnostic events,
 	// then by definition we're not completely offline so we must be using daemon mode.
 	return ldvalue.ObjectBuild().
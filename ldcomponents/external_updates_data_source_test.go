@@ -2,6 +2,7 @@ package ldcomponents
 
 import (
 	"testing"
+	"time"
 
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
 
@@ -27,3 +28,15 @@ func TestExternalUpdatesOnly(t *testing.T) {
 
 	dsu.RequireStatusOf(t, interfaces.DataSourceStateValid)
 }
+
+func TestExternalUpdatesOnlyChangePollInterval(t *testing.T) {
+	pollInterval := func(c ExternalUpdatesDataSourceConfigurer) time.Duration {
+		return c.(*externalUpdatesDataSourceBuilder).changePollInterval
+	}
+	assert.Equal(t, DefaultExternalUpdatesChangePollInterval, pollInterval(ExternalUpdatesOnly()))
+	assert.Equal(t, time.Minute, pollInterval(ExternalUpdatesOnly().ChangePollInterval(time.Minute)))
+	assert.Equal(t, time.Duration(0), pollInterval(ExternalUpdatesOnly().ChangePollInterval(0)))
+
+	var c subsystems.ComponentConfigurer[subsystems.DataSource] = ExternalUpdatesOnly()
+	assert.NotNil(t, c)
+}
@@ -1,6 +1,7 @@
 package ldcomponents
 
 import (
+	"errors"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
@@ -34,6 +35,10 @@ const PersistentDataStoreDefaultCacheTime = 15 * time.Second
 //
 // See PersistentDataStoreBuilder for more on how this method is used.
 //
+// The SDK itself includes two persistent data store implementations: one that keeps data in a local
+// file ([github.com/launchdarkly/go-server-sdk/ldfilestore]), and one that uses a SQL database
+// through database/sql ([github.com/launchdarkly/go-server-sdk/ldsqlstore]).
+//
 // For more information on the available persistent data store implementations, see the reference
 // guide on "Persistent data stores": https://docs.launchdarkly.com/sdk/concepts/data-stores
 func PersistentDataStore(
@@ -61,6 +66,14 @@ func PersistentDataStore(
 type PersistentDataStoreBuilder struct {
 	persistentDataStoreFactory subsystems.ComponentConfigurer[subsystems.PersistentDataStore]
 	cacheTTL                   time.Duration
+	cacheMaxItems              int
+	cacheMaxBytes              int64
+	cacheMaxStaleness          time.Duration
+	cacheRefreshAhead          time.Duration
+	journalMaxItems            int
+	journalFilePath            string
+	compressItems              bool
+	compressionMinSize         int
 }
 
 // CacheTime specifies the cache TTL. Items will be evicted from the cache after this amount of time
@@ -91,6 +104,139 @@ func (b *PersistentDataStoreBuilder) CacheForever() *PersistentDataStoreBuilder
 	return b.CacheTime(-1 * time.Millisecond)
 }
 
+// CacheMaxItems specifies the maximum number of entries in the in-memory cache. When the cache is full,
+// the least recently used entries are evicted. Each flag or segment is one entry, and the full set of
+// flags or segments, which the SDK queries for operations such as AllFlagsState, is also one entry.
+//
+// The default is zero, meaning that there is no limit. A limit is useful if the environment has so many
+// flags that keeping all of them in memory would be a problem, especially when using
+// [PersistentDataStoreBuilder.CacheForever]. In that case, some queries will go to the persistent store
+// even in CacheForever mode, and if the store becomes unavailable, the SDK will not be able to rewrite
+// its cached data to the store when it recovers, so it will instead ask the data source for a full
+// refresh of the data.
+//
+// To find out how well the cache is working with a given limit, use
+// [github.com/launchdarkly/go-server-sdk/v6/interfaces.DataStoreCacheStatsProvider].
+func (b *PersistentDataStoreBuilder) CacheMaxItems(maxItems int) *PersistentDataStoreBuilder {
+	if maxItems < 0 {
+		maxItems = 0
+	}
+	b.cacheMaxItems = maxItems
+	return b
+}
+
+// CacheMaxBytes specifies the maximum total size of the in-memory cache, in bytes. When the cache is
+// full, the least recently used entries are evicted.
+//
+// The size of each entry is estimated from the size of its serialized JSON data; the actual memory used
+// by the deserialized data may be several times larger. An entry that is larger than the limit by itself,
+// such as the full set of flags in a large environment, is not cached at all.
+//
+// The default is zero, meaning that there is no limit. See [PersistentDataStoreBuilder.CacheMaxItems]
+// for other considerations that apply to both kinds of limits.
+func (b *PersistentDataStoreBuilder) CacheMaxBytes(maxBytes int64) *PersistentDataStoreBuilder {
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	b.cacheMaxBytes = maxBytes
+	return b
+}
+
+// CacheStaleWhileRevalidate specifies that when a cached item has expired, the SDK should keep using the
+// expired item for up to maxStaleness longer, while it reads the current item from the persistent store
+// in the background. This means that flag evaluations do not have to wait for a store query just because
+// a cache entry has expired. If an item is requested when it has been expired for longer than
+// maxStaleness, or if the background query fails for that long, the SDK goes back to querying the store
+// before returning a result.
+//
+// The default is zero, meaning that expired items are never used. This option has no effect if caching
+// is disabled or if the cache never expires (see [PersistentDataStoreBuilder.CacheForever]).
+func (b *PersistentDataStoreBuilder) CacheStaleWhileRevalidate(maxStaleness time.Duration) *PersistentDataStoreBuilder {
+	if maxStaleness < 0 {
+		maxStaleness = 0
+	}
+	b.cacheMaxStaleness = maxStaleness
+	return b
+}
+
+// CacheRefreshAhead specifies that if a cached item is requested when it is within the specified amount
+// of time of expiring, the SDK should return the cached item and also start reading the current item from
+// the persistent store in the background. Items that are requested often will then be refreshed before
+// they expire, so flag evaluations rarely have to wait for a store query.
+//
+// The default is zero, meaning that items are only read from the store after they expire. This option has
+// no effect if caching is disabled, if the cache never expires (see [PersistentDataStoreBuilder.CacheForever]),
+// or if the value is not less than the cache TTL.
+func (b *PersistentDataStoreBuilder) CacheRefreshAhead(window time.Duration) *PersistentDataStoreBuilder {
+	if window < 0 {
+		window = 0
+	}
+	b.cacheRefreshAhead = window
+	return b
+}
+
+// WriteBehindJournal enables a journal of updates that could not be written to the persistent store
+// because it was unavailable. When the store becomes available again, the SDK writes those updates to it
+// in version order.
+//
+// Without a journal, updates that are received during an outage are lost unless the cache never expires
+// (see [PersistentDataStoreBuilder.CacheForever]), so the SDK has to ask LaunchDarkly for all of the data
+// again once the store has recovered. With a journal, that is only necessary if the journal could not hold
+// all of the updates: that is, if there were updates for more than maxItems distinct flags or segments,
+// or if the data source tried to replace all of the data during the outage.
+//
+// The number of updates currently in the journal is reported in the JournalDepth property of
+// [github.com/launchdarkly/go-server-sdk/v6/interfaces.DataStoreStatus].
+//
+// The default is zero, meaning that there is no journal. The journal is kept in memory unless you also
+// specify [PersistentDataStoreBuilder.WriteBehindJournalFile].
+func (b *PersistentDataStoreBuilder) WriteBehindJournal(maxItems int) *PersistentDataStoreBuilder {
+	if maxItems < 0 {
+		maxItems = 0
+	}
+	b.journalMaxItems = maxItems
+	return b
+}
+
+// WriteBehindJournalFile specifies that the write-behind journal should also be saved in a file, so that
+// updates that have not yet been written to the store are not lost if the application is restarted. When
+// the SDK starts, it writes any updates that it finds in the file to the store.
+//
+// This has no effect unless the journal is enabled with [PersistentDataStoreBuilder.WriteBehindJournal].
+// The file should not be shared with any other SDK instance.
+//
+// The journal file contains the serialized flag and segment data in plaintext: it is not encrypted even if
+// the store is configured with [EncryptedPersistentDataStore]. For that reason, the SDK client will fail to
+// start if both a journal file and encryption are configured.
+func (b *PersistentDataStoreBuilder) WriteBehindJournalFile(filePath string) *PersistentDataStoreBuilder {
+	b.journalFilePath = filePath
+	return b
+}
+
+// CompressItems specifies that flags and segments whose serialized size is at least minSize bytes should
+// be compressed with gzip before they are written to the persistent store. This reduces the memory and
+// network usage of the store for large items, such as segments with long lists of included context keys.
+// For instance, CompressItems(1024) compresses items of 1KB or more, and CompressItems(0) compresses all
+// items.
+//
+// Compression is disabled by default. However, this version of the SDK can always read compressed items,
+// whether or not this option is enabled, so it can be enabled gradually across SDK instances that share a
+// store, as long as they are all using a version of the SDK that supports compression. However,
+// do not enable it if any other applications that read from the same store, such as SDKs for other
+// languages, cannot read compressed items. Also, compressed items are binary data rather than JSON, so
+// this option should only be used with data store implementations that store the version of each item
+// separately rather than getting it from the JSON data.
+//
+// If the data is encrypted with [EncryptedPersistentDataStore], it is compressed before it is encrypted.
+func (b *PersistentDataStoreBuilder) CompressItems(minSize int) *PersistentDataStoreBuilder {
+	if minSize < 0 {
+		minSize = 0
+	}
+	b.compressItems = true
+	b.compressionMinSize = minSize
+	return b
+}
+
 // NoCaching specifies that the SDK should not use an in-memory cache for the persistent data store.
 // This means that every feature flag evaluation will trigger a data store query.
 func (b *PersistentDataStoreBuilder) NoCaching() *PersistentDataStoreBuilder {
@@ -103,8 +249,29 @@ func (b *PersistentDataStoreBuilder) Build(clientContext subsystems.ClientContex
 	if err != nil {
 		return nil, err
 	}
-	return datastore.NewPersistentDataStoreWrapper(core, clientContext.GetDataStoreUpdateSink(), b.cacheTTL,
-		clientContext.GetLogging().Loggers), nil
+	if b.journalMaxItems > 0 && b.journalFilePath != "" && datastore.IsEncryptedPersistentDataStore(core) {
+		_ = core.Close()
+		return nil, errors.New("a write-behind journal file cannot be used with an encrypted data store," +
+			" because the journal is not encrypted")
+	}
+	// Compressed items are always readable, even if this instance does not compress the items it writes.
+	core = datastore.NewCompressedPersistentDataStore(core, datastore.PersistentDataStoreCompressionConfig{
+		Enabled: b.compressItems,
+		MinSize: b.compressionMinSize,
+	})
+	cacheConfig := datastore.PersistentDataStoreCacheConfig{
+		TTL:          b.cacheTTL,
+		MaxItems:     b.cacheMaxItems,
+		MaxBytes:     b.cacheMaxBytes,
+		MaxStaleness: b.cacheMaxStaleness,
+		RefreshAhead: b.cacheRefreshAhead,
+	}
+	journalConfig := datastore.PersistentDataStoreJournalConfig{
+		MaxItems: b.journalMaxItems,
+		FilePath: b.journalFilePath,
+	}
+	return datastore.NewPersistentDataStoreWrapper(core, clientContext.GetDataStoreUpdateSink(), cacheConfig,
+		journalConfig, clientContext.GetLogging().Loggers), nil
 }
 
 // DescribeConfiguration is used internally by the SDK to inspect the configuration.
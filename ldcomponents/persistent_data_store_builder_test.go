@@ -2,6 +2,8 @@ package ldcomponents
 
 import (
 	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -82,6 +84,161 @@ func TestPersistentDataStoreBuilder(t *testing.T) {
 		assert.Equal(t, time.Duration(0), f.cacheTTL)
 	})
 
+	t.Run("CacheMaxItems", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+
+		f.CacheMaxItems(100)
+		assert.Equal(t, 100, f.cacheMaxItems)
+
+		f.CacheMaxItems(-1)
+		assert.Equal(t, 0, f.cacheMaxItems)
+	})
+
+	t.Run("CacheMaxBytes", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+
+		f.CacheMaxBytes(1000000)
+		assert.Equal(t, int64(1000000), f.cacheMaxBytes)
+
+		f.CacheMaxBytes(-1)
+		assert.Equal(t, int64(0), f.cacheMaxBytes)
+	})
+
+	t.Run("CacheStaleWhileRevalidate", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+
+		f.CacheStaleWhileRevalidate(time.Minute)
+		assert.Equal(t, time.Minute, f.cacheMaxStaleness)
+
+		f.CacheStaleWhileRevalidate(-1)
+		assert.Equal(t, time.Duration(0), f.cacheMaxStaleness)
+	})
+
+	t.Run("CacheRefreshAhead", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+
+		f.CacheRefreshAhead(time.Second)
+		assert.Equal(t, time.Second, f.cacheRefreshAhead)
+
+		f.CacheRefreshAhead(-1)
+		assert.Equal(t, time.Duration(0), f.cacheRefreshAhead)
+	})
+
+	t.Run("WriteBehindJournal", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+
+		f.WriteBehindJournal(1000)
+		assert.Equal(t, 1000, f.journalMaxItems)
+
+		f.WriteBehindJournal(-1)
+		assert.Equal(t, 0, f.journalMaxItems)
+	})
+
+	t.Run("WriteBehindJournalFile", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+
+		f.WriteBehindJournalFile("journal.ndjson")
+		assert.Equal(t, "journal.ndjson", f.journalFilePath)
+	})
+
+	t.Run("journal file cannot be used with encryption", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		encrypted := EncryptedPersistentDataStore(&mockPersistentDataStoreFactory{store: core}).
+			PrimaryKey("key1", []byte("0123456789abcdef"))
+		clientContext := basicClientContext()
+
+		_, err := PersistentDataStore(encrypted).WriteBehindJournal(100).
+			WriteBehindJournalFile(filepath.Join(t.TempDir(), "journal.ndjson")).Build(clientContext)
+		assert.Error(t, err)
+
+		store, err := PersistentDataStore(encrypted).WriteBehindJournal(100).Build(clientContext)
+		require.NoError(t, err)
+		_ = store.Close()
+	})
+
+	t.Run("journal file cannot be used with encryption inside another builder", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		encrypted := EncryptedPersistentDataStore(&mockPersistentDataStoreFactory{store: core}).
+			PrimaryKey("key1", []byte("0123456789abcdef"))
+		wrapper := &wrappingPersistentDataStoreFactory{inner: encrypted}
+
+		_, err := PersistentDataStore(wrapper).WriteBehindJournal(100).
+			WriteBehindJournalFile(filepath.Join(t.TempDir(), "journal.ndjson")).Build(basicClientContext())
+		assert.Error(t, err)
+	})
+
+	t.Run("CompressItems", func(t *testing.T) {
+		pdsf := &mockPersistentDataStoreFactory{}
+		f := PersistentDataStore(pdsf)
+		assert.False(t, f.compressItems)
+
+		f.CompressItems(1024)
+		assert.True(t, f.compressItems)
+		assert.Equal(t, 1024, f.compressionMinSize)
+
+		f.CompressItems(-1)
+		assert.Equal(t, 0, f.compressionMinSize)
+	})
+
+	t.Run("compressed items are written to store", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		f := PersistentDataStore(&mockPersistentDataStoreFactory{store: core}).NoCaching().CompressItems(0)
+		logConfig := subsystems.LoggingConfiguration{Loggers: ldlog.NewDisabledLoggers()}
+		clientContext := sharedtest.NewTestContext("", nil, &logConfig)
+		broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+		defer broadcaster.Close()
+		clientContext.DataStoreUpdateSink = datastore.NewDataStoreUpdateSinkImpl(broadcaster)
+
+		store, err := f.Build(clientContext)
+		require.NoError(t, err)
+		defer store.Close()
+
+		item := mocks.MockDataItem{Key: "key", Version: 1, Name: strings.Repeat("x", 1000)}
+		_, err = store.Upsert(mocks.MockData, item.Key, item.ToItemDescriptor())
+		require.NoError(t, err)
+		assert.Less(t, len(core.ForceGet(mocks.MockData, item.Key).SerializedItem), 1000)
+
+		result, err := store.Get(mocks.MockData, item.Key)
+		require.NoError(t, err)
+		assert.Equal(t, item.ToItemDescriptor(), result)
+	})
+
+	t.Run("compressed items can be read without CompressItems", func(t *testing.T) {
+		core := mocks.NewMockPersistentDataStore()
+		logConfig := subsystems.LoggingConfiguration{Loggers: ldlog.NewDisabledLoggers()}
+		clientContext := sharedtest.NewTestContext("", nil, &logConfig)
+		broadcaster := internal.NewBroadcaster[interfaces.DataStoreStatus]()
+		defer broadcaster.Close()
+		clientContext.DataStoreUpdateSink = datastore.NewDataStoreUpdateSinkImpl(broadcaster)
+
+		writer, err := PersistentDataStore(&mockPersistentDataStoreFactory{store: core}).NoCaching().CompressItems(0).
+			Build(clientContext)
+		require.NoError(t, err)
+		item := mocks.MockDataItem{Key: "key", Version: 1, Name: strings.Repeat("x", 1000)}
+		_, err = writer.Upsert(mocks.MockData, item.Key, item.ToItemDescriptor())
+		require.NoError(t, err)
+		require.Less(t, len(core.ForceGet(mocks.MockData, item.Key).SerializedItem), 1000)
+
+		reader, err := PersistentDataStore(&mockPersistentDataStoreFactory{store: core}).NoCaching().
+			Build(clientContext)
+		require.NoError(t, err)
+		defer reader.Close()
+		result, err := reader.Get(mocks.MockData, item.Key)
+		require.NoError(t, err)
+		assert.Equal(t, item.ToItemDescriptor(), result)
+
+		other := mocks.MockDataItem{Key: "other", Version: 1, Name: strings.Repeat("x", 1000)}
+		_, err = reader.Upsert(mocks.MockData, other.Key, other.ToItemDescriptor())
+		require.NoError(t, err)
+		assert.Equal(t, other.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, other.Key))
+	})
+
 	t.Run("diagnostic description", func(t *testing.T) {
 		f1 := PersistentDataStore(&mockPersistentDataStoreFactory{})
 		assert.Equal(t, ldvalue.String("custom"), f1.DescribeConfiguration(basicClientContext()))
@@ -104,6 +261,18 @@ func (m *mockPersistentDataStoreFactory) Build(
 	return m.store, m.fakeError
 }
 
+// wrappingPersistentDataStoreFactory is a builder, such as an application might write, that delegates to
+// another builder.
+type wrappingPersistentDataStoreFactory struct {
+	inner subsystems.ComponentConfigurer[subsystems.PersistentDataStore]
+}
+
+func (w *wrappingPersistentDataStoreFactory) Build(
+	context subsystems.ClientContext,
+) (subsystems.PersistentDataStore, error) {
+	return w.inner.Build(context)
+}
+
 type mockPersistentDataStoreFactoryWithDescription struct {
 	description ldvalue.Value
 }
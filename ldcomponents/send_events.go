@@ -9,6 +9,8 @@ import (
 	ldevents "github.com/launchdarkly/go-sdk-events/v2"
 	"github.com/launchdarkly/go-server-sdk/v6/internal"
 	"github.com/launchdarkly/go-server-sdk/v6/internal/endpoints"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/events"
+	"github.com/launchdarkly/go-server-sdk/v6/ldredaction"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 )
 
@@ -27,6 +29,8 @@ const (
 	DefaultContextKeysFlushInterval = 5 * time.Minute
 	// MinimumDiagnosticRecordingInterval is the minimum value for [EventProcessorBuilder.DiagnosticRecordingInterval].
 	MinimumDiagnosticRecordingInterval = 60 * time.Second
+	// DefaultEventSpoolMaxSize is the default value for [EventProcessorBuilder.SpoolMaxSize].
+	DefaultEventSpoolMaxSize = 10 * 1024 * 1024
 )
 
 // EventProcessorBuilder provides methods for configuring analytics event behavior.
@@ -42,6 +46,13 @@ type EventProcessorBuilder struct {
 	privateAttributes           []ldattr.Ref
 	contextKeysCapacity         int
 	contextKeysFlushInterval    time.Duration
+	eventSenderConfigurer       subsystems.ComponentConfigurer[subsystems.EventSender]
+	spoolDirectory              string
+	spoolMaxSize                int64
+	featureEventSamplingRatio   int
+	flagFeatureEventRatios      map[string]int
+	customEventSamplingRatio    int
+	redactionPolicy             *ldredaction.Policy
 }
 
 // SendEvents returns a configuration builder for analytics event delivery.
@@ -62,6 +73,9 @@ func SendEvents() *EventProcessorBuilder {
 		flushInterval:               DefaultFlushInterval,
 		contextKeysCapacity:         DefaultContextKeysCapacity,
 		contextKeysFlushInterval:    DefaultContextKeysFlushInterval,
+		spoolMaxSize:                DefaultEventSpoolMaxSize,
+		featureEventSamplingRatio:   1,
+		customEventSamplingRatio:    1,
 	}
 }
 
@@ -71,23 +85,76 @@ func (b *EventProcessorBuilder) Build(
 ) (ldevents.EventProcessor, error) {
 	loggers := context.GetLogging().Loggers
 
-	configuredBaseURI := endpoints.SelectBaseURI(
-		context.GetServiceEndpoints(),
-		endpoints.EventsService,
-		b.baseURI,
-		loggers,
-	)
-
 	headers := context.GetHTTP().DefaultHeaders
-	eventSender := ldevents.NewServerSideEventSender(
-		ldevents.EventSenderConfiguration{
-			Client:      context.GetHTTP().CreateHTTPClient(),
-			BaseURI:     configuredBaseURI,
-			BaseHeaders: func() http.Header { return headers },
+	var customSender subsystems.EventSender
+	if b.eventSenderConfigurer != nil {
+		sender, err := b.eventSenderConfigurer.Build(context)
+		if err != nil {
+			return nil, err
+		}
+		customSender = sender
+	}
+	var configuredBaseURI string
+	if customSender == nil {
+		configuredBaseURI = endpoints.SelectBaseURI(
+			context.GetServiceEndpoints(),
+			endpoints.EventsService,
+			b.baseURI,
+			loggers,
+		)
+	}
+
+	var eventSender ldevents.EventSender
+	var spool *events.EventSpool
+	switch {
+	case b.spoolDirectory != "":
+		if customSender == nil {
+			// The default sender from go-sdk-events can't be used here, since it generates a new payload ID
+			// every time, so that LaunchDarkly would not be able to discard duplicates of resent payloads.
+			customSender = events.NewHTTPEventSender(context.GetHTTP().CreateHTTPClient(), configuredBaseURI, loggers)
+		}
+		adapter := newCustomEventSender(customSender, headers, context.GetSDKKey(), loggers)
+		var err error
+		spool, err = events.NewEventSpool(events.EventSpoolConfig{
+			Directory:   b.spoolDirectory,
+			MaxSize:     b.spoolMaxSize,
+			Sender:      customSender,
+			MakeHeaders: adapter.makeHeaders,
 			Loggers:     loggers,
-		},
-		context.GetSDKKey(),
-	)
+		})
+		if err != nil {
+			return nil, err
+		}
+		adapter.sender = spool
+		adapter.spool = spool
+		eventSender = adapter
+	case customSender != nil:
+		eventSender = newCustomEventSender(customSender, headers, context.GetSDKKey(), loggers)
+	default:
+		eventSender = ldevents.NewServerSideEventSender(
+			ldevents.EventSenderConfiguration{
+				Client:      context.GetHTTP().CreateHTTPClient(),
+				BaseURI:     configuredBaseURI,
+				BaseHeaders: func() http.Header { return headers },
+				Loggers:     loggers,
+			},
+			context.GetSDKKey(),
+		)
+	}
+	var eventTap *events.EventTap
+	cci, _ := context.(*internal.ClientContextImpl)
+	if cci != nil && cci.EventTap != nil {
+		eventTap = cci.EventTap
+		eventSender = events.NewTappingEventSender(eventSender, eventTap)
+	}
+	samplingConfig := events.EventSamplingConfig{
+		FeatureEventRatio:      b.featureEventSamplingRatio,
+		FlagFeatureEventRatios: make(map[string]int, len(b.flagFeatureEventRatios)),
+		CustomEventRatio:       b.customEventSamplingRatio,
+	}
+	for flagKey, ratio := range b.flagFeatureEventRatios { // copied in case the builder is modified later
+		samplingConfig.FlagFeatureEventRatios[flagKey] = ratio
+	}
 	eventsConfig := ldevents.EventsConfiguration{
 		AllAttributesPrivate:        b.allAttributesPrivate,
 		Capacity:                    b.capacity,
@@ -100,10 +167,28 @@ func (b *EventProcessorBuilder) Build(
 		UserKeysCapacity:            b.contextKeysCapacity,
 		UserKeysFlushInterval:       b.contextKeysFlushInterval,
 	}
-	if cci, ok := context.(*internal.ClientContextImpl); ok {
+	if cci != nil {
 		eventsConfig.DiagnosticsManager = cci.DiagnosticsManager
 	}
-	return ldevents.NewDefaultEventProcessor(eventsConfig), nil
+	ep := ldevents.NewDefaultEventProcessor(eventsConfig)
+	if spool != nil {
+		ep = spoolingEventProcessor{EventProcessor: ep, spool: spool}
+	}
+	if b.redactionPolicy != nil || eventTap != nil || samplingConfig.IsEnabled() {
+		contextConfig := events.ContextEventProcessorConfig{
+			Tap:                  eventTap,
+			AllAttributesPrivate: b.allAttributesPrivate,
+			PrivateAttributes:    b.privateAttributes,
+		}
+		if b.redactionPolicy != nil {
+			contextConfig.RedactContext = b.redactionPolicy.Apply
+		}
+		if samplingConfig.IsEnabled() {
+			contextConfig.Sampling = &samplingConfig
+		}
+		ep = events.NewContextEventProcessor(ep, contextConfig)
+	}
+	return ep, nil
 }
 
 // AllAttributesPrivate sets whether or not all optional context attributes should be hidden from LaunchDarkly.
@@ -141,6 +226,118 @@ func (b *EventProcessorBuilder) DiagnosticRecordingInterval(interval time.Durati
 	return b
 }
 
+// EventSender specifies a custom component for delivering event data, instead of posting it to
+// LaunchDarkly over HTTP. This allows an application to route analytics events through its own pipeline,
+// such as a message queue or a sidecar process.
+//
+// The SDK still does all of the work of buffering, summarizing, and formatting the events, and passes
+// the finished payloads to the [subsystems.EventSender]. The SDK provides one implementation that writes
+// the payloads to a file, [github.com/launchdarkly/go-server-sdk/v6/ldfileevents]:
+//
+//	config := ld.Config{
+//	    Events: ldcomponents.SendEvents().
+//	        EventSender(ldfileevents.EventSender().FilePath("./events.ndjson")),
+//	}
+//
+// If you use a custom EventSender, the events URI in Config.ServiceEndpoints is ignored. Passing nil
+// restores the default behavior.
+func (b *EventProcessorBuilder) EventSender(
+	eventSenderConfigurer subsystems.ComponentConfigurer[subsystems.EventSender],
+) *EventProcessorBuilder {
+	b.eventSenderConfigurer = eventSenderConfigurer
+	return b
+}
+
+// SpoolDirectory enables the event spool, which stores undelivered events on disk so that they are not
+// lost during an outage or when the application restarts.
+//
+// Normally, if the SDK is unable to deliver a payload of events, it retries once and then discards the
+// events. With the spool enabled, it instead writes the payload to a file in this directory, and keeps
+// trying to resend it in the background, with an increasing delay between attempts. When the SDK starts,
+// it resends any payloads that were left in the directory by an earlier process. Each payload keeps its
+// original payload ID, so LaunchDarkly can discard any duplicates.
+//
+// The directory is created if it does not exist. It should not be shared by more than one SDK instance at
+// a time. The total size of the stored payloads is limited by [EventProcessorBuilder.SpoolMaxSize].
+//
+// Numbers of spooled and dropped events are included in the SDK's periodic diagnostic events, unless
+// Config.DiagnosticOptOut is true.
+//
+// This can be used along with [EventProcessorBuilder.EventSender]: the spool then stores the payloads that
+// the custom EventSender could not deliver because of a [subsystems.RetryableEventSenderError].
+//
+// By default, the spool is not enabled. An empty string disables it.
+func (b *EventProcessorBuilder) SpoolDirectory(path string) *EventProcessorBuilder {
+	b.spoolDirectory = path
+	return b
+}
+
+// SpoolMaxSize sets the maximum total size, in bytes, of the payloads that are stored by the event spool
+// (see [EventProcessorBuilder.SpoolDirectory]). If the spool is full, the oldest payloads are discarded.
+//
+// The default value is [DefaultEventSpoolMaxSize].
+func (b *EventProcessorBuilder) SpoolMaxSize(maxSize int64) *EventProcessorBuilder {
+	if maxSize <= 0 {
+		maxSize = DefaultEventSpoolMaxSize
+	}
+	b.spoolMaxSize = maxSize
+	return b
+}
+
+// FeatureEventSamplingRatio sets the sampling ratio for full feature events: a ratio of N means that
+// only 1 out of every N feature events is sent. A ratio of 1 means that all are sent, and 0 means that
+// none are sent.
+//
+// Full feature events are only generated for flags that have event tracking enabled in LaunchDarkly,
+// such as flags that are used in experiments. All evaluations are still counted in the summary events,
+// so the evaluation counts on your dashboard remain exact; only the individual events are sampled.
+//
+// Whether an event is sent is determined by a hash of the flag key and the context key, so that a given
+// context is either always or never sampled for a given flag. This ensures that sampling does not bias
+// an experiment toward any particular contexts. Each event that is sent includes the sampling ratio, so
+// that LaunchDarkly can account for the events that were not sent. Events that are not sent are discarded
+// before they are queued, so they do not take up space in the buffer (see [EventProcessorBuilder.Capacity]).
+//
+// This can be overridden for specific flags with [EventProcessorBuilder.FlagFeatureEventSamplingRatio].
+// The default value is 1. A negative value is treated as 1.
+func (b *EventProcessorBuilder) FeatureEventSamplingRatio(ratio int) *EventProcessorBuilder {
+	b.featureEventSamplingRatio = validSamplingRatio(ratio)
+	return b
+}
+
+// FlagFeatureEventSamplingRatio sets the sampling ratio for the full feature events of a specific flag,
+// overriding the value of [EventProcessorBuilder.FeatureEventSamplingRatio] for that flag. The ratio has
+// the same meaning as in FeatureEventSamplingRatio.
+//
+//	config := ld.Config{
+//	    Events: ldcomponents.SendEvents().
+//	        FeatureEventSamplingRatio(10).
+//	        FlagFeatureEventSamplingRatio("checkout-experiment", 1),
+//	}
+//
+// Calling this method more than once for the same flag key replaces the previous value.
+func (b *EventProcessorBuilder) FlagFeatureEventSamplingRatio(flagKey string, ratio int) *EventProcessorBuilder {
+	if b.flagFeatureEventRatios == nil {
+		b.flagFeatureEventRatios = make(map[string]int)
+	}
+	b.flagFeatureEventRatios[flagKey] = validSamplingRatio(ratio)
+	return b
+}
+
+// CustomEventSamplingRatio sets the sampling ratio for custom events, which are generated by
+// [github.com/launchdarkly/go-server-sdk/v6.LDClient.TrackEvent] and related methods. A ratio of N means
+// that only 1 out of every N custom events is sent. A ratio of 1 means that all are sent, and 0 means
+// that none are sent.
+//
+// As with [EventProcessorBuilder.FeatureEventSamplingRatio], whether an event is sent is determined by a
+// hash of the event key and the context key, and each event that is sent includes the sampling ratio.
+//
+// The default value is 1. A negative value is treated as 1.
+func (b *EventProcessorBuilder) CustomEventSamplingRatio(ratio int) *EventProcessorBuilder {
+	b.customEventSamplingRatio = validSamplingRatio(ratio)
+	return b
+}
+
 // FlushInterval sets the interval between flushes of the event buffer.
 //
 // Decreasing the flush interval means that the event buffer is less likely to reach capacity (see
@@ -181,6 +378,28 @@ func (b *EventProcessorBuilder) PrivateAttributes(attributes ...string) *EventPr
 	return b
 }
 
+// RedactionPolicy specifies rules for redacting context attributes in analytics events, in addition to
+// any private attributes.
+//
+// Unlike [EventProcessorBuilder.PrivateAttributes], which always removes a named attribute, a policy can
+// select attributes by context kind, path pattern, or value, and can hash or truncate values instead of
+// removing them, and can also hash or truncate context keys. It is applied to the context of each event
+// before the event processor formats it, so an attribute that the policy removes is reported in
+// "redactedAttributes" just like a private attribute. See [ldredaction.NewPolicy]:
+//
+//	policy, err := ldredaction.NewPolicy(
+//	    ldredaction.Rule{ValuePattern: `@example\.com$`, Action: ldredaction.HashValue},
+//	)
+//	config := ld.Config{
+//	    Events: ldcomponents.SendEvents().RedactionPolicy(policy),
+//	}
+//
+// By default, there is no redaction policy. Passing nil removes the policy.
+func (b *EventProcessorBuilder) RedactionPolicy(policy *ldredaction.Policy) *EventProcessorBuilder {
+	b.redactionPolicy = policy
+	return b
+}
+
 // ContextKeysCapacity sets the number of context keys that the event processor can remember at any one
 // time.
 //
@@ -215,6 +434,26 @@ func (b *EventProcessorBuilder) DescribeConfiguration(context subsystems.ClientC
 		Build()
 }
 
+// spoolingEventProcessor stops the event spool's background activity after the event processor has done
+// its final flush.
+type spoolingEventProcessor struct {
+	ldevents.EventProcessor
+	spool *events.EventSpool
+}
+
+func (p spoolingEventProcessor) Close() error {
+	err := p.EventProcessor.Close()
+	p.spool.Close()
+	return err
+}
+
+func validSamplingRatio(ratio int) int {
+	if ratio < 0 {
+		return 1
+	}
+	return ratio
+}
+
 func durationToMillisValue(d time.Duration) ldvalue.Value {
 	return ldvalue.Float64(float64(uint64(d / time.Millisecond)))
 }
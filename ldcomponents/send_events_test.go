@@ -1,17 +1,27 @@
 package ldcomponents
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
 	"github.com/launchdarkly/go-sdk-common/v3/lduser"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	ldevents "github.com/launchdarkly/go-sdk-events/v2"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/events"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/ldredaction"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldservices"
 
+	th "github.com/launchdarkly/go-test-helpers/v3"
 	"github.com/launchdarkly/go-test-helpers/v3/httphelpers"
 	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
 
@@ -78,6 +88,85 @@ func TestEventProcessorBuilder(t *testing.T) {
 		assert.Equal(t, 333, b.contextKeysCapacity)
 	})
 
+	t.Run("EventSender", func(t *testing.T) {
+		b := SendEvents()
+		assert.Nil(t, b.eventSenderConfigurer)
+
+		configurer := mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: mocks.NewMockEventSender()}
+		b.EventSender(configurer)
+		assert.Equal(t, configurer, b.eventSenderConfigurer)
+
+		b.EventSender(nil)
+		assert.Nil(t, b.eventSenderConfigurer)
+	})
+
+	t.Run("SpoolDirectory", func(t *testing.T) {
+		b := SendEvents()
+		assert.Equal(t, "", b.spoolDirectory)
+
+		b.SpoolDirectory("./spool")
+		assert.Equal(t, "./spool", b.spoolDirectory)
+	})
+
+	t.Run("SpoolMaxSize", func(t *testing.T) {
+		b := SendEvents()
+		assert.Equal(t, int64(DefaultEventSpoolMaxSize), b.spoolMaxSize)
+
+		b.SpoolMaxSize(1000)
+		assert.Equal(t, int64(1000), b.spoolMaxSize)
+
+		b.SpoolMaxSize(0)
+		assert.Equal(t, int64(DefaultEventSpoolMaxSize), b.spoolMaxSize)
+	})
+
+	t.Run("FeatureEventSamplingRatio", func(t *testing.T) {
+		b := SendEvents()
+		assert.Equal(t, 1, b.featureEventSamplingRatio)
+
+		b.FeatureEventSamplingRatio(10)
+		assert.Equal(t, 10, b.featureEventSamplingRatio)
+
+		b.FeatureEventSamplingRatio(0)
+		assert.Equal(t, 0, b.featureEventSamplingRatio)
+
+		b.FeatureEventSamplingRatio(-1)
+		assert.Equal(t, 1, b.featureEventSamplingRatio)
+	})
+
+	t.Run("FlagFeatureEventSamplingRatio", func(t *testing.T) {
+		b := SendEvents()
+		assert.Len(t, b.flagFeatureEventRatios, 0)
+
+		b.FlagFeatureEventSamplingRatio("flag1", 10)
+		b.FlagFeatureEventSamplingRatio("flag2", -1)
+		b.FlagFeatureEventSamplingRatio("flag1", 20)
+		assert.Equal(t, map[string]int{"flag1": 20, "flag2": 1}, b.flagFeatureEventRatios)
+	})
+
+	t.Run("CustomEventSamplingRatio", func(t *testing.T) {
+		b := SendEvents()
+		assert.Equal(t, 1, b.customEventSamplingRatio)
+
+		b.CustomEventSamplingRatio(10)
+		assert.Equal(t, 10, b.customEventSamplingRatio)
+
+		b.CustomEventSamplingRatio(-1)
+		assert.Equal(t, 1, b.customEventSamplingRatio)
+	})
+
+	t.Run("RedactionPolicy", func(t *testing.T) {
+		b := SendEvents()
+		assert.Nil(t, b.redactionPolicy)
+
+		policy, err := ldredaction.NewPolicy(ldredaction.Rule{Attribute: "email", Action: ldredaction.RemoveValue})
+		require.NoError(t, err)
+		b.RedactionPolicy(policy)
+		assert.Equal(t, policy, b.redactionPolicy)
+
+		b.RedactionPolicy(nil)
+		assert.Nil(t, b.redactionPolicy)
+	})
+
 	t.Run("ContextKeysFlushInterval", func(t *testing.T) {
 		b := SendEvents()
 		assert.Equal(t, DefaultContextKeysFlushInterval, b.contextKeysFlushInterval)
@@ -108,6 +197,175 @@ func TestDefaultEventsConfigWithoutDiagnostics(t *testing.T) {
 	})
 }
 
+func TestEventsConfigWithCustomEventSender(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	ep, err := SendEvents().
+		EventSender(mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: sender}).
+		Build(makeTestContextWithBaseURIs("http://not-used"))
+	require.NoError(t, err)
+	defer ep.Close()
+
+	ef := ldevents.NewEventFactory(false, nil)
+	ce := ef.NewCustomEventData("event-key", ldevents.Context(lduser.NewUser("key")), ldvalue.Null(), false, 0)
+	ep.RecordCustomEvent(ce)
+	ep.Flush()
+
+	payload := th.RequireValue(t, sender.PayloadsCh, time.Second*5)
+	assert.Equal(t, subsystems.AnalyticsEventPayload, payload.Kind)
+	assert.Equal(t, 2, payload.EventCount)
+	var jsonData ldvalue.Value
+	require.NoError(t, json.Unmarshal(payload.Data, &jsonData))
+	assert.Equal(t, ldvalue.String("index"), jsonData.GetByIndex(0).GetByKey("kind"))
+	assert.Equal(t, ldvalue.String("custom"), jsonData.GetByIndex(1).GetByKey("kind"))
+}
+
+func TestEventsConfigWithSampling(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	ep, err := SendEvents().
+		EventSender(mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: sender}).
+		FeatureEventSamplingRatio(0).
+		FlagFeatureEventSamplingRatio("tracked-flag", 1).
+		CustomEventSamplingRatio(0).
+		Build(makeTestContextWithBaseURIs("http://not-used"))
+	require.NoError(t, err)
+	defer ep.Close()
+
+	recorder, ok := ep.(events.ContextEventRecorder)
+	require.True(t, ok)
+	ef := ldevents.NewEventFactory(false, nil)
+	user := lduser.NewUser("key")
+	for _, flagKey := range []string{"tracked-flag", "other-flag"} {
+		flag := ldevents.FlagEventProperties{Key: flagKey, Version: 1, RequireFullEvent: true}
+		recorder.RecordEvaluationForContext(ef.NewEvaluationData(flag, ldevents.Context(user),
+			ldreason.NewEvaluationDetail(ldvalue.Bool(true), 0, ldreason.NewEvalReasonFallthrough()),
+			false, ldvalue.Bool(false), ""), user)
+	}
+	recorder.RecordCustomEventForContext(
+		ef.NewCustomEventData("event-key", ldevents.Context(user), ldvalue.Null(), false, 0), user)
+	ep.Flush()
+
+	payload := th.RequireValue(t, sender.PayloadsCh, time.Second*5)
+	var jsonData ldvalue.Value
+	require.NoError(t, json.Unmarshal(payload.Data, &jsonData))
+	var kinds []string
+	for _, e := range jsonData.AsValueArray().AsSlice() {
+		kinds = append(kinds, e.GetByKey("kind").StringValue()+":"+e.GetByKey("key").StringValue())
+		assert.False(t, e.GetByKey("samplingRatio").IsDefined())
+	}
+	assert.Equal(t, []string{"index:", "feature:tracked-flag", "summary:"}, kinds)
+	assert.Equal(t, 3, payload.EventCount)
+	summaryFlags := jsonData.GetByIndex(2).GetByKey("features")
+	assert.ElementsMatch(t, []string{"tracked-flag", "other-flag"}, summaryFlags.Keys(nil))
+}
+
+func TestEventsConfigWithRedactionPolicy(t *testing.T) {
+	sender := mocks.NewMockEventSender()
+	policy, err := ldredaction.NewPolicy(
+		ldredaction.Rule{ValuePattern: "@", Action: ldredaction.HashValue},
+		ldredaction.Rule{Attribute: "name", Action: ldredaction.RemoveValue},
+	)
+	require.NoError(t, err)
+	ep, err := SendEvents().
+		EventSender(mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: sender}).
+		RedactionPolicy(policy).
+		Build(makeTestContextWithBaseURIs("http://not-used"))
+	require.NoError(t, err)
+	defer ep.Close()
+
+	recorder, ok := ep.(events.ContextEventRecorder)
+	require.True(t, ok)
+	ef := ldevents.NewEventFactory(false, nil)
+	user := lduser.NewUserBuilder("a@example.com").Name("a").Email("b@example.com").Build()
+	recorder.RecordIdentifyEventForContext(ef.NewIdentifyEventData(ldevents.Context(user)), user)
+	ep.Flush()
+
+	hashOf := func(s string) string {
+		hash := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(hash[:])
+	}
+	payload := th.RequireValue(t, sender.PayloadsCh, time.Second*5)
+	var jsonData ldvalue.Value
+	require.NoError(t, json.Unmarshal(payload.Data, &jsonData))
+	assert.Equal(t,
+		ldvalue.ObjectBuild().
+			SetString("kind", "user").
+			SetString("key", hashOf("a@example.com")).
+			SetString("email", hashOf("b@example.com")).
+			Set("_meta", ldvalue.ObjectBuild().Set("redactedAttributes", ldvalue.ArrayOf(ldvalue.String("name"))).Build()).
+			Build(),
+		jsonData.GetByIndex(0).GetByKey("context"))
+}
+
+func TestEventsConfigWithCustomEventSenderThatFailsToBuild(t *testing.T) {
+	fakeError := errors.New("sorry")
+	_, err := SendEvents().
+		EventSender(mocks.ComponentConfigurerThatReturnsError[subsystems.EventSender]{Err: fakeError}).
+		Build(basicClientContext())
+	assert.Equal(t, fakeError, err)
+}
+
+func TestEventsConfigWithSpoolResendsUndeliveredPayload(t *testing.T) {
+	spoolDir := t.TempDir()
+	eventsHandler, requestsCh := httphelpers.RecordingHandler(
+		httphelpers.SequentialHandler(
+			httphelpers.HandlerWithStatus(503),
+			ldservices.ServerSideEventsServiceHandler(),
+		),
+	)
+	httphelpers.WithServer(eventsHandler, func(server *httptest.Server) {
+		ep, err := SendEvents().
+			SpoolDirectory(spoolDir).
+			Build(makeTestContextWithBaseURIs(server.URL))
+		require.NoError(t, err)
+		defer ep.Close()
+
+		ef := ldevents.NewEventFactory(false, nil)
+		ce := ef.NewCustomEventData("event-key", ldevents.Context(lduser.NewUser("key")), ldvalue.Null(), false, 0)
+		ep.RecordCustomEvent(ce)
+		ep.Flush()
+
+		r1 := th.RequireValue(t, requestsCh, time.Second*5)
+		r2 := th.RequireValue(t, requestsCh, time.Second*5) // resent from the spool after a delay
+		assert.Equal(t, "/bulk", r2.Request.URL.Path)
+		assert.Equal(t, testSdkKey, r2.Request.Header.Get("Authorization"))
+		assert.Equal(t, currentEventSchema, r2.Request.Header.Get("X-LaunchDarkly-Event-Schema"))
+		assert.NotEqual(t, "", r1.Request.Header.Get("X-LaunchDarkly-Payload-ID"))
+		assert.Equal(t, r1.Request.Header.Get("X-LaunchDarkly-Payload-ID"), r2.Request.Header.Get("X-LaunchDarkly-Payload-ID"))
+		assert.Equal(t, string(r1.Body), string(r2.Body))
+	})
+}
+
+func TestEventsConfigWithSpoolResendsPayloadsFromEarlierRun(t *testing.T) {
+	spoolDir := t.TempDir()
+	sender := mocks.NewMockEventSender()
+	sender.TestSetErrors(subsystems.RetryableEventSenderError{Err: errors.New("sorry")})
+	ep1, err := SendEvents().
+		EventSender(mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: sender}).
+		SpoolDirectory(spoolDir).
+		Build(basicClientContext())
+	require.NoError(t, err)
+	ef := ldevents.NewEventFactory(false, nil)
+	ce := ef.NewCustomEventData("event-key", ldevents.Context(lduser.NewUser("key")), ldvalue.Null(), false, 0)
+	ep1.RecordCustomEvent(ce)
+	ep1.Flush()
+	payload1 := th.RequireValue(t, sender.PayloadsCh, time.Second*5)
+	require.NoError(t, ep1.Close())
+	files, _ := os.ReadDir(spoolDir)
+	require.Len(t, files, 1)
+
+	sender2 := mocks.NewMockEventSender()
+	ep2, err := SendEvents().
+		EventSender(mocks.SingleComponentConfigurer[subsystems.EventSender]{Instance: sender2}).
+		SpoolDirectory(spoolDir).
+		Build(basicClientContext())
+	require.NoError(t, err)
+	defer ep2.Close()
+
+	payload2 := th.RequireValue(t, sender2.PayloadsCh, time.Second*5)
+	assert.Equal(t, payload1.PayloadID, payload2.PayloadID)
+	assert.Equal(t, string(payload1.Data), string(payload2.Data))
+}
+
 func TestDefaultEventsConfigWithDiagnostics(t *testing.T) {
 	eventsHandler, requestsCh := httphelpers.RecordingHandler(ldservices.ServerSideEventsServiceHandler())
 	diagnosticsManager := ldevents.NewDiagnosticsManager(
@@ -0,0 +1,40 @@
+package ldfilebigsegments
+
+import (
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// BigSegmentStoreBuilder is a builder for configuring the file-based Big Segment store.
+//
+// Obtain an instance of this type by calling [BigSegmentStore]. After calling its methods to specify any
+// desired custom settings, wrap it in a BigSegmentsConfigurationBuilder by calling
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.BigSegments], and then store this in the
+// BigSegments field of [github.com/launchdarkly/go-server-sdk/v6.Config].
+//
+// Builder calls can be chained, for example:
+//
+//	config.BigSegments = ldcomponents.BigSegments(
+//	    ldfilebigsegments.BigSegmentStore().FilePaths("file1.csv").FilePaths("file2.ndjson"),
+//	)
+//
+// You do not need to call the builder's Build method yourself; that will be done by the SDK.
+type BigSegmentStoreBuilder struct {
+	filePaths []string
+}
+
+// BigSegmentStore returns a configurable builder for a file-based Big Segment store.
+func BigSegmentStore() *BigSegmentStoreBuilder {
+	return &BigSegmentStoreBuilder{}
+}
+
+// FilePaths specifies the input data files. The paths may be any number of absolute or relative file paths.
+// See the package documentation for the file formats.
+func (b *BigSegmentStoreBuilder) FilePaths(paths ...string) *BigSegmentStoreBuilder {
+	b.filePaths = append(b.filePaths, paths...)
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *BigSegmentStoreBuilder) Build(context subsystems.ClientContext) (subsystems.BigSegmentStore, error) {
+	return newFileBigSegmentStoreImpl(b.filePaths, context.GetLogging().Loggers)
+}
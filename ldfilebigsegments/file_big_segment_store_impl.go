@@ -0,0 +1,150 @@
+package ldfilebigsegments
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+)
+
+// fileState is what we know about a file at the time we last loaded it, so we can tell if it has changed.
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+type fileBigSegmentStore struct {
+	absFilePaths []string
+	loggers      ldlog.Loggers
+	fileStates   []fileState
+	index        map[string]subsystems.BigSegmentMembership
+	lastUpToDate ldtime.UnixMillisecondTime
+	loadErr      error
+	lock         sync.Mutex
+}
+
+func newFileBigSegmentStoreImpl(filePaths []string, loggers ldlog.Loggers) (subsystems.BigSegmentStore, error) {
+	absPaths := make([]string, 0, len(filePaths))
+	for _, p := range filePaths {
+		absPath, err := filepath.Abs(p)
+		if err != nil {
+			// COVERAGE: there's no reliable cross-platform way to simulate an invalid path in unit tests
+			return nil, fmt.Errorf("unable to determine absolute path for '%s'", p)
+		}
+		absPaths = append(absPaths, absPath)
+	}
+	store := &fileBigSegmentStore{
+		absFilePaths: absPaths,
+		loggers:      loggers,
+	}
+	store.loggers.SetPrefix("FileBigSegmentStore:")
+	store.reloadIfChanged()
+	return store, nil
+}
+
+func (s *fileBigSegmentStore) GetMetadata() (subsystems.BigSegmentStoreMetadata, error) {
+	s.reloadIfChanged()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return subsystems.BigSegmentStoreMetadata{LastUpToDate: s.lastUpToDate}, s.loadErr
+}
+
+func (s *fileBigSegmentStore) GetMembership(contextHash string) (subsystems.BigSegmentMembership, error) {
+	s.reloadIfChanged()
+	s.lock.Lock()
+	membership := s.index[contextHash]
+	s.lock.Unlock()
+	if membership == nil {
+		return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(nil, nil), nil
+	}
+	return membership, nil
+}
+
+func (s *fileBigSegmentStore) GetMemberships(
+	contextHashes []string,
+) (map[string]subsystems.BigSegmentMembership, error) {
+	s.reloadIfChanged()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	ret := make(map[string]subsystems.BigSegmentMembership, len(contextHashes))
+	for _, contextHash := range contextHashes {
+		if membership := s.index[contextHash]; membership != nil {
+			ret[contextHash] = membership
+		}
+	}
+	return ret, nil
+}
+
+func (s *fileBigSegmentStore) Close() error {
+	return nil
+}
+
+// reloadIfChanged rereads all of the files if any of them has been created, deleted, or modified since
+// they were last loaded. If any file cannot be loaded, we keep the previous data, and the error will be
+// returned by GetMetadata until a later reload succeeds.
+func (s *fileBigSegmentStore) reloadIfChanged() {
+	states := make([]fileState, len(s.absFilePaths))
+	for i, path := range s.absFilePaths {
+		if info, err := os.Stat(path); err == nil {
+			states[i] = fileState{exists: true, modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.fileStates != nil && statesEqual(s.fileStates, states) {
+		return
+	}
+	if s.fileStates != nil {
+		s.loggers.Info("Reloading Big Segment data after detecting a change")
+	}
+	s.fileStates = states
+
+	indexBuilder := newMembershipIndexBuilder()
+	var lastModified time.Time
+	for i, path := range s.absFilePaths {
+		if !states[i].exists {
+			s.loggers.Warnf("Big Segment data file does not exist [%s]", path)
+			continue
+		}
+		if err := loadFile(path, indexBuilder); err != nil {
+			s.loggers.Errorf("Unable to load Big Segment data: %s [%s]", err, path)
+			s.loadErr = fmt.Errorf("unable to load Big Segment data from %s: %w", path, err)
+			return
+		}
+		if states[i].modTime.After(lastModified) {
+			lastModified = states[i].modTime
+		}
+	}
+	s.index = indexBuilder.build()
+	s.lastUpToDate = 0
+	if !lastModified.IsZero() {
+		s.lastUpToDate = ldtime.UnixMillisFromTime(lastModified)
+	}
+	s.loadErr = nil
+}
+
+func loadFile(path string, indexBuilder *membershipIndexBuilder) error {
+	f, err := os.Open(path) //nolint:gosec // G304: ok to read file into variable
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint:errcheck
+	return parseMembershipFile(path, f, indexBuilder)
+}
+
+func statesEqual(a, b []fileState) bool {
+	for i := range a {
+		if a[i].exists != b[i].exists || !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
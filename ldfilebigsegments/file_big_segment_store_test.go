@@ -0,0 +1,219 @@
+package ldfilebigsegments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+	ld "github.com/launchdarkly/go-server-sdk/v6"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/bigsegments"
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldtestdata"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileBigSegmentStore(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "big-segments.csv")
+
+	storetest.NewBigSegmentStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+			return BigSegmentStore().FilePaths(filePath)
+		},
+		func(prefix string) error {
+			if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			return nil
+		},
+		func(prefix string, metadata subsystems.BigSegmentStoreMetadata) error {
+			if err := os.WriteFile(filePath, nil, 0600); err != nil {
+				return err
+			}
+			modTime := time.UnixMilli(int64(metadata.LastUpToDate))
+			return os.Chtimes(filePath, modTime, modTime)
+		},
+		func(prefix string, userHashKey string, included []string, excluded []string) error {
+			// add to any existing data, since the suite may call this for more than one context
+			existing, err := os.ReadFile(filePath)
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			lines := []string{"contextHash,segmentRef,included"}
+			if len(existing) != 0 {
+				lines = []string{strings.TrimSuffix(string(existing), "\n")}
+			}
+			for _, ref := range included {
+				lines = append(lines, userHashKey+","+ref+",true")
+			}
+			for _, ref := range excluded {
+				lines = append(lines, userHashKey+","+ref+",false")
+			}
+			return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0600)
+		},
+	).Run(t)
+}
+
+func makeTestStore(t *testing.T, mockLog *ldlogtest.MockLog, paths ...string) subsystems.BigSegmentStore {
+	loggers := ldlog.NewDisabledLoggers()
+	if mockLog != nil {
+		loggers = mockLog.Loggers
+	}
+	store, err := newFileBigSegmentStoreImpl(paths, loggers)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func writeFile(t *testing.T, path, content string, modTime time.Time) {
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func assertMembership(t *testing.T, store subsystems.BigSegmentStore, contextKey, segmentRef string,
+	expected ldvalue.OptionalBool) {
+	t.Helper()
+	membership, err := store.GetMembership(bigsegments.HashForContextKey(contextKey))
+	require.NoError(t, err)
+	assert.Equal(t, expected, membership.CheckMembership(segmentRef), "context %q, segment %q", contextKey, segmentRef)
+}
+
+func TestMembershipsFromMultipleFiles(t *testing.T) {
+	dir := t.TempDir()
+	csvPath, ndjsonPath := filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.ndjson")
+	writeFile(t, csvPath, "contextKey,segmentRef\nuser-1,seg1.g1\nuser-2,seg1.g1\n", time.Now())
+	writeFile(t, ndjsonPath, `{"contextKey": "user-1", "segmentRef": "seg2.g3"}
+{"contextHash": "`+bigsegments.HashForContextKey("user-2")+`", "segmentRef": "seg2.g3", "included": false}
+`, time.Now())
+
+	store := makeTestStore(t, nil, csvPath, ndjsonPath)
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.NewOptionalBool(true))
+	assertMembership(t, store, "user-1", "seg2.g3", ldvalue.NewOptionalBool(true))
+	assertMembership(t, store, "user-2", "seg1.g1", ldvalue.NewOptionalBool(true))
+	assertMembership(t, store, "user-2", "seg2.g3", ldvalue.NewOptionalBool(false))
+	assertMembership(t, store, "user-3", "seg1.g1", ldvalue.OptionalBool{})
+}
+
+func TestLastUpToDateIsMostRecentModificationTime(t *testing.T) {
+	dir := t.TempDir()
+	path1, path2 := filepath.Join(dir, "a.csv"), filepath.Join(dir, "b.csv")
+	time1, time2 := time.UnixMilli(1000000), time.UnixMilli(2000000)
+	writeFile(t, path1, "", time2)
+	writeFile(t, path2, "", time1)
+
+	store := makeTestStore(t, nil, path1, path2, filepath.Join(dir, "missing.csv"))
+	meta, err := store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(2000000), meta.LastUpToDate)
+}
+
+func TestMissingFilesAreTreatedAsEmpty(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	path := filepath.Join(t.TempDir(), "missing.csv")
+	store := makeTestStore(t, mockLog, path)
+
+	meta, err := store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(0), meta.LastUpToDate)
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.OptionalBool{})
+	mockLog.AssertMessageMatch(t, true, ldlog.Warn, "does not exist")
+}
+
+func TestStoreReloadsChangedFiles(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	path := filepath.Join(t.TempDir(), "a.csv")
+	store := makeTestStore(t, mockLog, path)
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.OptionalBool{})
+
+	writeFile(t, path, "contextKey,segmentRef\nuser-1,seg1.g1\n", time.UnixMilli(1000000))
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.NewOptionalBool(true))
+	meta, err := store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(1000000), meta.LastUpToDate)
+
+	writeFile(t, path, "contextKey,segmentRef,included\nuser-1,seg1.g1,false\n", time.UnixMilli(2000000))
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.NewOptionalBool(false))
+	meta, err = store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(2000000), meta.LastUpToDate)
+	mockLog.AssertMessageMatch(t, true, ldlog.Info, "Reloading Big Segment data")
+
+	require.NoError(t, os.Remove(path))
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.OptionalBool{})
+	meta, err = store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(0), meta.LastUpToDate)
+}
+
+func TestInvalidFileKeepsPreviousDataAndReportsError(t *testing.T) {
+	mockLog := ldlogtest.NewMockLog()
+	path := filepath.Join(t.TempDir(), "a.csv")
+	writeFile(t, path, "contextKey,segmentRef\nuser-1,seg1.g1\n", time.UnixMilli(1000000))
+	store := makeTestStore(t, mockLog, path)
+
+	writeFile(t, path, "contextKey,segmentRef\nuser-1\n", time.UnixMilli(2000000))
+	meta, err := store.GetMetadata()
+	assert.Error(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(1000000), meta.LastUpToDate)
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.NewOptionalBool(true))
+	mockLog.AssertMessageMatch(t, true, ldlog.Error, "Unable to load Big Segment data")
+
+	writeFile(t, path, "contextKey,segmentRef\nuser-2,seg1.g1\n", time.UnixMilli(3000000))
+	meta, err = store.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(3000000), meta.LastUpToDate)
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.OptionalBool{})
+	assertMembership(t, store, "user-2", "seg1.g1", ldvalue.NewOptionalBool(true))
+}
+
+func TestInvalidFileAtStartupReportsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	writeFile(t, path, "", time.Now())
+	store := makeTestStore(t, nil, path)
+
+	meta, err := store.GetMetadata()
+	assert.Error(t, err)
+	assert.Equal(t, ldtime.UnixMillisecondTime(0), meta.LastUpToDate)
+	assertMembership(t, store, "user-1", "seg1.g1", ldvalue.OptionalBool{})
+}
+
+func TestStoreWithSDKClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.csv")
+	writeFile(t, path, "contextKey,segmentRef\nuser-1,big-segment.g1\n", time.Now())
+
+	td := ldtestdata.DataSource()
+	td.UpdateSegment(td.Segment("big-segment").Unbounded(""))
+	td.Update(td.Flag("flag").BooleanFlag().FallthroughVariation(false).
+		IfMatchOperator("", ldmodel.OperatorSegmentMatch, ldvalue.String("big-segment")).ThenReturn(true))
+	config := ld.Config{
+		DataSource:  td,
+		BigSegments: ldcomponents.BigSegments(BigSegmentStore().FilePaths(path)),
+		Events:      ldcomponents.NoEvents(),
+		Logging:     ldcomponents.NoLogging(),
+	}
+	client, err := ld.MakeCustomClient("sdk-key", config, 5*time.Second)
+	require.NoError(t, err)
+	defer client.Close() //nolint:errcheck
+
+	value, detail, err := client.BoolVariationDetail("flag", ldcontext.New("user-1"), false)
+	require.NoError(t, err)
+	assert.True(t, value)
+	assert.Equal(t, ldreason.BigSegmentsHealthy, detail.Reason.GetBigSegmentsStatus())
+
+	value, _, err = client.BoolVariationDetail("flag", ldcontext.New("user-2"), false)
+	require.NoError(t, err)
+	assert.False(t, value)
+}
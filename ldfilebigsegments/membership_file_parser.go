@@ -0,0 +1,163 @@
+package ldfilebigsegments
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/bigsegments"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+)
+
+const (
+	contextKeyProperty  = "contextKey"
+	contextHashProperty = "contextHash"
+	segmentRefProperty  = "segmentRef"
+	includedProperty    = "included"
+)
+
+// membershipRecord is one row of a CSV file or one line of an NDJSON file.
+type membershipRecord struct {
+	ContextKey  string `json:"contextKey"`
+	ContextHash string `json:"contextHash"`
+	SegmentRef  string `json:"segmentRef"`
+	Included    *bool  `json:"included"`
+}
+
+// membershipIndexBuilder accumulates the records from all of the files.
+type membershipIndexBuilder struct {
+	included map[string][]string
+	excluded map[string][]string
+}
+
+func newMembershipIndexBuilder() *membershipIndexBuilder {
+	return &membershipIndexBuilder{
+		included: make(map[string][]string),
+		excluded: make(map[string][]string),
+	}
+}
+
+func (b *membershipIndexBuilder) add(r membershipRecord) error {
+	if r.SegmentRef == "" {
+		return fmt.Errorf("%s is required", segmentRefProperty)
+	}
+	var contextHash string
+	switch {
+	case r.ContextKey != "" && r.ContextHash != "":
+		return fmt.Errorf("%s and %s cannot both be specified", contextKeyProperty, contextHashProperty)
+	case r.ContextKey != "":
+		contextHash = bigsegments.HashForContextKey(r.ContextKey)
+	case r.ContextHash != "":
+		contextHash = r.ContextHash
+	default:
+		return fmt.Errorf("either %s or %s is required", contextKeyProperty, contextHashProperty)
+	}
+	if r.Included == nil || *r.Included {
+		b.included[contextHash] = append(b.included[contextHash], r.SegmentRef)
+	} else {
+		b.excluded[contextHash] = append(b.excluded[contextHash], r.SegmentRef)
+	}
+	return nil
+}
+
+func (b *membershipIndexBuilder) build() map[string]subsystems.BigSegmentMembership {
+	index := make(map[string]subsystems.BigSegmentMembership, len(b.included)+len(b.excluded))
+	for contextHash, refs := range b.included {
+		index[contextHash] = ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(refs, b.excluded[contextHash])
+	}
+	for contextHash, refs := range b.excluded {
+		if _, ok := b.included[contextHash]; !ok {
+			index[contextHash] = ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(nil, refs)
+		}
+	}
+	return index
+}
+
+// parseMembershipFile reads all of the records from a file, choosing the format based on the file
+// extension, and adds them to the index.
+func parseMembershipFile(path string, r io.Reader, index *membershipIndexBuilder) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return parseCSV(r, index)
+	case ".ndjson", ".jsonl":
+		return parseNDJSON(r, index)
+	default:
+		return errors.New("unrecognized file extension; must be .csv, .ndjson, or .jsonl")
+	}
+}
+
+func parseCSV(r io.Reader, index *membershipIndexBuilder) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		switch name {
+		case contextKeyProperty, contextHashProperty, segmentRefProperty, includedProperty:
+			columns[name] = i
+		default:
+			return fmt.Errorf("unrecognized column %q in header", name)
+		}
+	}
+	column := func(row []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		record := membershipRecord{
+			ContextKey:  column(row, contextKeyProperty),
+			ContextHash: column(row, contextHashProperty),
+			SegmentRef:  column(row, segmentRefProperty),
+		}
+		if value := column(row, includedProperty); value != "" {
+			included, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid value %q for %s", line, value, includedProperty)
+			}
+			record.Included = &included
+		}
+		if err := index.add(record); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+func parseNDJSON(r io.Reader, index *membershipIndexBuilder) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	for n := 1; ; n++ {
+		var record membershipRecord
+		if err := decoder.Decode(&record); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+		if err := index.add(record); err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+	}
+}
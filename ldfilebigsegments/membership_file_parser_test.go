@@ -0,0 +1,83 @@
+package ldfilebigsegments
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/bigsegments"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseTestData(path, data string) (*membershipIndexBuilder, error) {
+	index := newMembershipIndexBuilder()
+	err := parseMembershipFile(path, strings.NewReader(data), index)
+	return index, err
+}
+
+func TestParseCSV(t *testing.T) {
+	index, err := parseTestData("a.CSV", `segmentRef, included, contextKey
+seg1.g1, true, user-1
+seg2.g1, false, user-1
+seg1.g1, , user-2
+seg2.g1, FALSE, "user,3"
+`)
+	require.NoError(t, err)
+	memberships := index.build()
+	assert.Len(t, memberships, 3)
+	assert.Equal(t, ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs([]string{"seg1.g1"}, []string{"seg2.g1"}),
+		memberships[bigsegments.HashForContextKey("user-1")])
+	assert.Equal(t, ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs([]string{"seg1.g1"}, nil),
+		memberships[bigsegments.HashForContextKey("user-2")])
+	assert.Equal(t, ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(nil, []string{"seg2.g1"}),
+		memberships[bigsegments.HashForContextKey("user,3")])
+}
+
+func TestParseNDJSON(t *testing.T) {
+	for _, ext := range []string{".ndjson", ".jsonl"} {
+		t.Run(ext, func(t *testing.T) {
+			index, err := parseTestData("a"+ext, `{"contextHash": "abc", "segmentRef": "seg1.g1"}
+
+{"contextHash": "abc", "segmentRef": "seg2.g1", "included": false}
+`)
+			require.NoError(t, err)
+			assert.Equal(t, ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs([]string{"seg1.g1"}, []string{"seg2.g1"}),
+				index.build()["abc"])
+		})
+	}
+}
+
+func TestParseEmptyFile(t *testing.T) {
+	for _, path := range []string{"a.csv", "a.ndjson"} {
+		index, err := parseTestData(path, "")
+		require.NoError(t, err)
+		assert.Len(t, index.build(), 0)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, p := range []struct {
+		name, path, data, message string
+	}{
+		{"unknown extension", "a.json", "", "unrecognized file extension"},
+		{"unknown CSV column", "a.csv", "contextKey,segment\n", `unrecognized column "segment"`},
+		{"wrong number of CSV fields", "a.csv", "contextKey,segmentRef\nuser-1\n", "wrong number of fields"},
+		{"invalid included value", "a.csv", "contextKey,segmentRef,included\nuser-1,seg1.g1,no\n",
+			`line 2: invalid value "no" for included`},
+		{"no segment ref", "a.csv", "contextKey,segmentRef\nuser-1,\n", "line 2: segmentRef is required"},
+		{"no context", "a.csv", "contextKey,segmentRef\n,seg1.g1\n", "line 2: either contextKey or contextHash"},
+		{"key and hash", "a.csv", "contextKey,contextHash,segmentRef\nuser-1,abc,seg1.g1\n",
+			"line 2: contextKey and contextHash cannot both be specified"},
+		{"malformed JSON", "a.ndjson", `{"contextKey": "user-1", "segmentRef": "seg1.g1"}` + "\n{", "record 2"},
+		{"unknown JSON property", "a.ndjson", `{"contextKey": "user-1", "segment": "seg1.g1"}`, "record 1"},
+		{"invalid JSON record", "a.ndjson", `{"contextKey": "user-1"}`, "record 1: segmentRef is required"},
+	} {
+		t.Run(p.name, func(t *testing.T) {
+			_, err := parseTestData(p.path, p.data)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), p.message)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+// Package ldfilebigsegments provides a Big Segment store for the LaunchDarkly SDK that reads Big Segment
+// memberships from local files, so that Big Segments can be used in development and CI environments
+// without an external database.
+//
+// To use it, pass the builder returned by [BigSegmentStore] to
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.BigSegments]:
+//
+//	config := ld.Config{
+//	    BigSegments: ldcomponents.BigSegments(
+//	        ldfilebigsegments.BigSegmentStore().FilePaths("./big-segments.csv"),
+//	    ),
+//	}
+//
+// Each file contains one membership record per row or line. A file whose name ends in ".csv" is parsed as
+// CSV, with a header row that names the columns; a file whose name ends in ".ndjson" or ".jsonl" is parsed
+// as newline-delimited JSON, with one object per line. The columns or properties are:
+//   - "contextKey": The key of the evaluation context. The store hashes it in the same way as the SDK.
+//   - "contextHash": Alternatively, a context key that has already been hashed, in the base64-encoded
+//     SHA-256 form that the SDK uses when it queries a Big Segment store. Each record must have
+//     either contextKey or contextHash, but not both.
+//   - "segmentRef": The segment reference, in the form SEGMENT_KEY.gGENERATION, for instance
+//     "my-segment.g1" for generation 1 of the segment "my-segment".
+//   - "included": Optional; "true" or "false". If it is false, the context is excluded from the segment
+//     rather than included in it. The default is true. Inclusion takes priority over exclusion.
+//
+// For example, this CSV file and this NDJSON file are equivalent:
+//
+//	contextKey,segmentRef,included
+//	user-1,my-segment.g1,true
+//	user-2,my-segment.g1,false
+//
+//	{"contextKey": "user-1", "segmentRef": "my-segment.g1"}
+//	{"contextKey": "user-2", "segmentRef": "my-segment.g1", "included": false}
+//
+// All of the files are loaded into memory. The store reports the most recent modification time of the
+// files as the time when the Big Segment data was last updated; the SDK compares this to its
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.BigSegmentsConfigurationBuilder.StaleAfter]
+// setting, so you may want to use a long StaleAfter time with this store, or touch the files to mark
+// them as current. Whenever the SDK queries the store, the store checks whether any of the files has
+// been modified, created, or deleted, and if so it reloads all of them.
+//
+// A file that does not exist is treated as empty. If a file cannot be read or parsed, the store logs
+// an error, reports an error to the SDK's Big Segment status monitoring, and continues to use the data
+// that it last loaded successfully, if any.
+package ldfilebigsegments
@@ -0,0 +1,47 @@
+package ldfileevents
+
+import (
+	"errors"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// EventSenderBuilder is a builder for configuring the file-based event sender.
+//
+// Obtain an instance of this type by calling [EventSender]. After calling its methods to specify any
+// desired custom settings, pass it to
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.EventProcessorBuilder.EventSender].
+//
+// You do not need to call the builder's Build method yourself; that will be done by the SDK.
+type EventSenderBuilder struct {
+	filePath           string
+	excludeDiagnostics bool
+}
+
+// EventSender returns a configurable builder for a file-based event sender.
+func EventSender() *EventSenderBuilder {
+	return &EventSenderBuilder{}
+}
+
+// FilePath specifies the output file. This is required.
+func (b *EventSenderBuilder) FilePath(filePath string) *EventSenderBuilder {
+	b.filePath = filePath
+	return b
+}
+
+// ExcludeDiagnostics specifies whether diagnostic events should be left out of the file, so that it only
+// contains analytics events. By default, they are included.
+//
+// Diagnostic events are only generated if Config.DiagnosticOptOut is false.
+func (b *EventSenderBuilder) ExcludeDiagnostics(exclude bool) *EventSenderBuilder {
+	b.excludeDiagnostics = exclude
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *EventSenderBuilder) Build(context subsystems.ClientContext) (subsystems.EventSender, error) {
+	if b.filePath == "" {
+		return nil, errors.New("file event sender requires a file path")
+	}
+	return newFileEventSenderImpl(b.filePath, b.excludeDiagnostics, context.GetLogging().Loggers)
+}
@@ -0,0 +1,81 @@
+package ldfileevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+type fileEventSender struct {
+	absFilePath        string
+	excludeDiagnostics bool
+	loggers            ldlog.Loggers
+	lock               sync.Mutex
+}
+
+// payloadRecord is the JSON representation of one line in the output file.
+type payloadRecord struct {
+	Kind       subsystems.EventPayloadKind `json:"kind"`
+	PayloadID  string                      `json:"payloadId,omitempty"`
+	EventCount int                         `json:"eventCount"`
+	Timestamp  ldtime.UnixMillisecondTime  `json:"timestamp"`
+	Data       json.RawMessage             `json:"data"`
+}
+
+func newFileEventSenderImpl(
+	filePath string,
+	excludeDiagnostics bool,
+	loggers ldlog.Loggers,
+) (subsystems.EventSender, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		// COVERAGE: there's no reliable cross-platform way to simulate an invalid path in unit tests
+		return nil, fmt.Errorf("unable to determine absolute path for '%s'", filePath)
+	}
+	sender := &fileEventSender{
+		absFilePath:        absPath,
+		excludeDiagnostics: excludeDiagnostics,
+		loggers:            loggers,
+	}
+	sender.loggers.SetPrefix("FileEventSender:")
+	return sender, nil
+}
+
+func (s *fileEventSender) SendEventData(payload subsystems.EventPayload) error {
+	if payload.Kind == subsystems.DiagnosticEventPayload && s.excludeDiagnostics {
+		return nil
+	}
+	line, err := json.Marshal(payloadRecord{
+		Kind:       payload.Kind,
+		PayloadID:  payload.PayloadID,
+		EventCount: payload.EventCount,
+		Timestamp:  ldtime.UnixMillisNow(),
+		Data:       payload.Data,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid event payload: %w", err) // not retryable, since it would fail again
+	}
+	line = append(line, '\n')
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	file, err := os.OpenFile(s.absFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return subsystems.RetryableEventSenderError{Err: err}
+	}
+	_, err = file.Write(line)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return subsystems.RetryableEventSenderError{Err: err}
+	}
+	s.loggers.Debugf("Wrote %d event(s) to %s", payload.EventCount, s.absFilePath)
+	return nil
+}
@@ -0,0 +1,164 @@
+package ldfileevents
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	ld "github.com/launchdarkly/go-server-sdk/v6"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldtestdata"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readRecords(t *testing.T, filePath string) []ldvalue.Value {
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+	var ret []ldvalue.Value
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record ldvalue.Value
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		ret = append(ret, record)
+	}
+	require.NoError(t, scanner.Err())
+	return ret
+}
+
+func makeTestSender(t *testing.T, builder *EventSenderBuilder) subsystems.EventSender {
+	sender, err := builder.Build(sharedtest.NewSimpleTestContext("sdk-key"))
+	require.NoError(t, err)
+	return sender
+}
+
+func TestBuilderRequiresFilePath(t *testing.T) {
+	_, err := EventSender().Build(sharedtest.NewSimpleTestContext("sdk-key"))
+	assert.Error(t, err)
+}
+
+func TestWritesOneLinePerPayload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.ndjson")
+	sender := makeTestSender(t, EventSender().FilePath(filePath))
+
+	require.NoError(t, sender.SendEventData(subsystems.EventPayload{
+		Kind:       subsystems.AnalyticsEventPayload,
+		Data:       []byte(`[{"kind":"custom","key":"a"}, {"kind":"custom","key":"b"}]`),
+		EventCount: 2,
+		PayloadID:  "payload-1",
+	}))
+	require.NoError(t, sender.SendEventData(subsystems.EventPayload{
+		Kind:       subsystems.DiagnosticEventPayload,
+		Data:       []byte(`{"kind":"diagnostic"}`),
+		EventCount: 1,
+	}))
+
+	records := readRecords(t, filePath)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "analytics", records[0].GetByKey("kind").StringValue())
+	assert.Equal(t, "payload-1", records[0].GetByKey("payloadId").StringValue())
+	assert.Equal(t, 2, records[0].GetByKey("eventCount").IntValue())
+	assert.NotEqual(t, 0, records[0].GetByKey("timestamp").IntValue())
+	assert.JSONEq(t, `[{"kind":"custom","key":"a"}, {"kind":"custom","key":"b"}]`,
+		records[0].GetByKey("data").JSONString())
+
+	assert.Equal(t, "diagnostic", records[1].GetByKey("kind").StringValue())
+	assert.False(t, records[1].GetByKey("payloadId").IsDefined())
+	assert.JSONEq(t, `{"kind":"diagnostic"}`, records[1].GetByKey("data").JSONString())
+}
+
+func TestAppendsToExistingFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.ndjson")
+	require.NoError(t, os.WriteFile(filePath, []byte(`{"kind":"analytics","eventCount":0,"data":[]}`+"\n"), 0600))
+	sender := makeTestSender(t, EventSender().FilePath(filePath))
+
+	require.NoError(t, sender.SendEventData(subsystems.EventPayload{
+		Kind: subsystems.AnalyticsEventPayload,
+		Data: []byte(`[]`),
+	}))
+
+	assert.Len(t, readRecords(t, filePath), 2)
+}
+
+func TestCanExcludeDiagnostics(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.ndjson")
+	sender := makeTestSender(t, EventSender().FilePath(filePath).ExcludeDiagnostics(true))
+
+	require.NoError(t, sender.SendEventData(subsystems.EventPayload{
+		Kind: subsystems.DiagnosticEventPayload,
+		Data: []byte(`{"kind":"diagnostic"}`),
+	}))
+	require.NoError(t, sender.SendEventData(subsystems.EventPayload{
+		Kind: subsystems.AnalyticsEventPayload,
+		Data: []byte(`[]`),
+	}))
+
+	records := readRecords(t, filePath)
+	require.Len(t, records, 1)
+	assert.Equal(t, "analytics", records[0].GetByKey("kind").StringValue())
+}
+
+func TestInvalidPayloadIsNotRetryable(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.ndjson")
+	sender := makeTestSender(t, EventSender().FilePath(filePath))
+
+	err := sender.SendEventData(subsystems.EventPayload{
+		Kind: subsystems.AnalyticsEventPayload,
+		Data: []byte(`not JSON`),
+	})
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &subsystems.RetryableEventSenderError{}))
+	_, statErr := os.Stat(filePath)
+	assert.True(t, errors.Is(statErr, os.ErrNotExist))
+}
+
+func TestWriteErrorIsRetryable(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "no-such-directory", "events.ndjson")
+	sender := makeTestSender(t, EventSender().FilePath(filePath))
+
+	err := sender.SendEventData(subsystems.EventPayload{
+		Kind: subsystems.AnalyticsEventPayload,
+		Data: []byte(`[]`),
+	})
+	require.Error(t, err)
+	assert.True(t, errors.As(err, &subsystems.RetryableEventSenderError{}))
+}
+
+func TestClientWritesEventsToFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.ndjson")
+	td := ldtestdata.DataSource()
+	td.Update(td.Flag("flag").BooleanFlag().VariationForAll(true))
+	config := ld.Config{
+		DataSource:       td,
+		Events:           ldcomponents.SendEvents().EventSender(EventSender().FilePath(filePath)),
+		DiagnosticOptOut: true,
+		Logging:          ldcomponents.NoLogging(),
+	}
+	client, err := ld.MakeCustomClient("sdk-key", config, 5*time.Second)
+	require.NoError(t, err)
+
+	context := ldcontext.New("user-key")
+	_, _ = client.BoolVariation("flag", context, false)
+	require.NoError(t, client.TrackEvent("custom-event", context))
+	require.NoError(t, client.Close())
+
+	records := readRecords(t, filePath)
+	require.Len(t, records, 1)
+	assert.Equal(t, "analytics", records[0].GetByKey("kind").StringValue())
+	var kinds []string
+	for _, event := range records[0].GetByKey("data").AsValueArray().AsSlice() {
+		kinds = append(kinds, event.GetByKey("kind").StringValue())
+	}
+	assert.Equal(t, []string{"index", "custom", "summary"}, kinds)
+}
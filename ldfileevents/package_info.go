@@ -0,0 +1,26 @@
+// Package ldfileevents provides an event sender for the LaunchDarkly SDK that appends analytics event
+// data to a local file, instead of sending it to LaunchDarkly. This can be used to inspect the events
+// that an application generates, or to have another process such as a log shipper deliver them.
+//
+// To use it, pass the builder returned by [EventSender] to
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.EventProcessorBuilder.EventSender]:
+//
+//	config := ld.Config{
+//	    Events: ldcomponents.SendEvents().
+//	        EventSender(ldfileevents.EventSender().FilePath("./events.ndjson")),
+//	}
+//
+// The file is in newline-delimited JSON format: each payload that the SDK would have sent to LaunchDarkly
+// is written as one line containing a JSON object, with these properties:
+//   - "kind": "analytics" for a payload of analytics events, or "diagnostic" for a diagnostic event.
+//   - "payloadId": For analytics payloads, the unique payload ID that the SDK would have sent in the
+//     X-LaunchDarkly-Payload-ID header.
+//   - "eventCount": The number of events in the payload.
+//   - "timestamp": The time that the payload was written, in Unix milliseconds.
+//   - "data": The payload itself, which is a JSON array of events for analytics payloads, or a JSON
+//     object for diagnostic payloads.
+//
+// The file is created if it does not exist, and opened in append mode each time a payload is written, so
+// it is safe to rotate or truncate it while the SDK is running. The HTTP headers of the payload, which
+// include the SDK key, are not written.
+package ldfileevents
@@ -0,0 +1,83 @@
+package ldfilestore
+
+import (
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+const (
+	// DefaultPrefix is the string that is used to distinguish LaunchDarkly data from any other data
+	// in the file, if you do not specify a different prefix with [DataStoreBuilder.Prefix].
+	DefaultPrefix = "launchdarkly"
+
+	// DefaultLockTimeout is the default value for [DataStoreBuilder.LockTimeout].
+	DefaultLockTimeout = 5 * time.Second
+)
+
+// DataStoreBuilder is a builder for configuring the file-backed persistent data store.
+//
+// Obtain an instance of this type by calling [DataStore]. After calling its methods to specify any
+// desired custom settings, wrap it in a PersistentDataStoreBuilder by calling
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStore], and then store this in
+// the DataStore field of [github.com/launchdarkly/go-server-sdk/v6.Config].
+//
+// Builder calls can be chained, for example:
+//
+//	config.DataStore = ldcomponents.PersistentDataStore(
+//	    ldfilestore.DataStore("./launchdarkly-flags.db").Prefix("prod"),
+//	)
+//
+// You do not need to call the builder's Build method yourself; that will be done by the SDK.
+type DataStoreBuilder struct {
+	filePath    string
+	prefix      string
+	lockTimeout time.Duration
+}
+
+// DataStore returns a configurable builder for a persistent data store that uses the file at filePath.
+// The file does not need to exist yet.
+func DataStore(filePath string) *DataStoreBuilder {
+	return &DataStoreBuilder{
+		filePath:    filePath,
+		prefix:      DefaultPrefix,
+		lockTimeout: DefaultLockTimeout,
+	}
+}
+
+// Prefix specifies a string that should be used to distinguish the data for one LaunchDarkly
+// environment from data for other environments that is stored in the same file.
+//
+// The default value is [DefaultPrefix] ("launchdarkly"). If you specify an empty string, it uses
+// the default.
+func (b *DataStoreBuilder) Prefix(prefix string) *DataStoreBuilder {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	b.prefix = prefix
+	return b
+}
+
+// LockTimeout specifies how long a data store operation will wait to obtain a lock on the file, if
+// another SDK instance is currently using it. If the lock cannot be obtained within this time, the
+// operation fails with an error, which the SDK treats like any other database error.
+//
+// The default value is [DefaultLockTimeout]. Zero or a negative value means to wait indefinitely.
+func (b *DataStoreBuilder) LockTimeout(lockTimeout time.Duration) *DataStoreBuilder {
+	if lockTimeout < 0 {
+		lockTimeout = 0
+	}
+	b.lockTimeout = lockTimeout
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *DataStoreBuilder) Build(context subsystems.ClientContext) (subsystems.PersistentDataStore, error) {
+	return newFileDataStoreImpl(b.filePath, b.prefix, b.lockTimeout, context.GetLogging().Loggers), nil
+}
+
+// DescribeConfiguration is used internally by the SDK to inspect the configuration.
+func (b *DataStoreBuilder) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
+	return ldvalue.String("File")
+}
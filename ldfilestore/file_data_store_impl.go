@@ -0,0 +1,265 @@
+package ldfilestore
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Internal implementation of the file-backed PersistentDataStore.
+//
+// Data is stored as follows in the bolt database:
+//
+// 1. There is one top-level bucket for each prefix.
+//
+// 2. Within that bucket, there is a nested bucket for each data kind, whose name is the namespace of
+// the data kind (such as "features"). It contains one value per item, whose key is the item key.
+//
+// 3. The prefix bucket also contains the key "$inited", whose presence indicates that Init has been
+// called at least once for this prefix.
+//
+// Each item value consists of the item version as a signed varint, one byte that is 1 if the item is
+// deleted or 0 otherwise, and then the serialized item data (which may be empty for a deleted item).
+// Storing the version separately means that we never need to deserialize items in order to compare
+// versions.
+//
+// The database file is not kept open between operations, since bolt holds a lock on the file for as
+// long as it is open: a shared lock for a read-only connection, or an exclusive one for a read-write
+// connection. Opening it for each operation allows other SDK instances, in this process or others, to
+// use the same file. Because an Upsert reads the old version and writes the new item within a single
+// exclusive transaction, no other instance can modify the item in between.
+
+const initedKey = "$inited"
+
+type fileDataStoreImpl struct {
+	filePath    string
+	prefix      []byte
+	lockTimeout time.Duration
+	loggers     ldlog.Loggers
+}
+
+func newFileDataStoreImpl(
+	filePath string,
+	prefix string,
+	lockTimeout time.Duration,
+	loggers ldlog.Loggers,
+) *fileDataStoreImpl {
+	loggers.SetPrefix("FileDataStore:")
+	loggers.Infof("Using file %s", filePath)
+	return &fileDataStoreImpl{
+		filePath:    filePath,
+		prefix:      []byte(prefix),
+		lockTimeout: lockTimeout,
+		loggers:     loggers,
+	}
+}
+
+func (store *fileDataStoreImpl) Init(allData []ldstoretypes.SerializedCollection) error {
+	return store.update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(store.prefix); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		root, err := tx.CreateBucket(store.prefix)
+		if err != nil {
+			return err
+		}
+		for _, coll := range allData {
+			bucket, err := root.CreateBucketIfNotExists([]byte(coll.Kind.GetName()))
+			if err != nil {
+				return err
+			}
+			for _, keyedItem := range coll.Items {
+				if err := bucket.Put([]byte(keyedItem.Key), encodeItem(keyedItem.Item)); err != nil {
+					return err
+				}
+			}
+		}
+		return root.Put([]byte(initedKey), []byte{})
+	})
+}
+
+func (store *fileDataStoreImpl) Get(
+	kind ldstoretypes.DataKind,
+	key string,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	ret := ldstoretypes.SerializedItemDescriptor{}.NotFound()
+	err := store.view(func(root *bolt.Bucket) error {
+		bucket := root.Bucket([]byte(kind.GetName()))
+		if bucket == nil {
+			return nil
+		}
+		data := bucket.Get([]byte(key))
+		if data == nil {
+			if store.loggers.IsDebugEnabled() {
+				store.loggers.Debugf("Key: %s not found in \"%s\"", key, kind.GetName())
+			}
+			return nil
+		}
+		var err error
+		ret, err = decodeItem(data)
+		return err
+	})
+	if err != nil {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	return ret, nil
+}
+
+func (store *fileDataStoreImpl) GetAll(
+	kind ldstoretypes.DataKind,
+) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	var results []ldstoretypes.KeyedSerializedItemDescriptor
+	err := store.view(func(root *bolt.Bucket) error {
+		bucket := root.Bucket([]byte(kind.GetName()))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			item, err := decodeItem(v)
+			if err != nil {
+				return err
+			}
+			results = append(results, ldstoretypes.KeyedSerializedItemDescriptor{Key: string(k), Item: item})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (store *fileDataStoreImpl) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	updated := false
+	err := store.update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists(store.prefix)
+		if err != nil {
+			return err
+		}
+		bucket, err := root.CreateBucketIfNotExists([]byte(kind.GetName()))
+		if err != nil {
+			return err
+		}
+		if oldData := bucket.Get([]byte(key)); oldData != nil {
+			oldItem, err := decodeItem(oldData)
+			if err != nil {
+				return err
+			}
+			if oldItem.Version >= newItem.Version {
+				if store.loggers.IsDebugEnabled() {
+					store.loggers.Debugf(
+						`Attempted to update key: %s version: %d in "%s" with a version that is the same or older: %d`,
+						key, oldItem.Version, kind.GetName(), newItem.Version)
+				}
+				return nil
+			}
+		}
+		updated = true
+		return bucket.Put([]byte(key), encodeItem(newItem))
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated, nil
+}
+
+func (store *fileDataStoreImpl) IsInitialized() bool {
+	inited := false
+	_ = store.view(func(root *bolt.Bucket) error {
+		inited = root.Get([]byte(initedKey)) != nil
+		return nil
+	})
+	return inited
+}
+
+func (store *fileDataStoreImpl) IsStoreAvailable() bool {
+	err := store.view(func(root *bolt.Bucket) error { return nil })
+	if err == nil {
+		// If the file does not exist yet, it will be created on the first write, which can only
+		// succeed if its directory exists.
+		var info os.FileInfo
+		if info, err = os.Stat(filepath.Dir(store.filePath)); err == nil && !info.IsDir() {
+			err = fmt.Errorf("%s is not a directory", filepath.Dir(store.filePath))
+		}
+	}
+	return err == nil
+}
+
+func (store *fileDataStoreImpl) Close() error {
+	return nil
+}
+
+// view runs a function within a read-only transaction, passing it the bucket for our prefix. If the
+// file or the bucket does not exist yet, the function is not called and there is no error.
+func (store *fileDataStoreImpl) view(fn func(root *bolt.Bucket) error) error {
+	if _, err := os.Stat(store.filePath); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	db, err := store.open(true)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	return db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket(store.prefix)
+		if root == nil {
+			return nil
+		}
+		return fn(root)
+	})
+}
+
+// update runs a function within a read-write transaction, creating the file if necessary. If the
+// function returns an error, none of its changes are saved.
+func (store *fileDataStoreImpl) update(fn func(tx *bolt.Tx) error) error {
+	db, err := store.open(false)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	return db.Update(fn)
+}
+
+func (store *fileDataStoreImpl) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(store.filePath, 0600, &bolt.Options{Timeout: store.lockTimeout, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("unable to open data store file %s: %w", store.filePath, err)
+	}
+	return db, nil
+}
+
+func encodeItem(item ldstoretypes.SerializedItemDescriptor) []byte {
+	data := make([]byte, binary.MaxVarintLen64+1+len(item.SerializedItem))
+	n := binary.PutVarint(data, int64(item.Version))
+	if item.Deleted {
+		data[n] = 1
+	}
+	n++
+	n += copy(data[n:], item.SerializedItem)
+	return data[:n]
+}
+
+func decodeItem(data []byte) (ldstoretypes.SerializedItemDescriptor, error) {
+	version, n := binary.Varint(data)
+	if n <= 0 || n >= len(data) {
+		return ldstoretypes.SerializedItemDescriptor{}, errors.New("invalid item data in data store file")
+	}
+	item := ldstoretypes.SerializedItemDescriptor{Version: int(version), Deleted: data[n] == 1}
+	if rest := data[n+1:]; len(rest) > 0 {
+		// The slice returned by bolt is only valid during the transaction, so we must copy it.
+		item.SerializedItem = append([]byte(nil), rest...)
+	}
+	return item, nil
+}
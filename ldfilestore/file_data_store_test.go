@@ -0,0 +1,168 @@
+package ldfilestore
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestFileDataStore(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return DataStore(filePath).Prefix(prefix)
+		},
+		func(prefix string) error {
+			return clearData(filePath, prefix)
+		},
+	).ErrorStoreFactory(
+		// A directory can't be opened as a database file
+		DataStore(t.TempDir()),
+		nil,
+	).Run(t)
+}
+
+func TestFileDataStoreChaos(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+
+	storetest.NewPersistentDataStoreChaosTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return DataStore(filePath).Prefix(prefix)
+		},
+		func(prefix string) error {
+			return clearData(filePath, prefix)
+		},
+	).Run(t)
+}
+
+func TestFileDataStoreWithEncryption(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return ldcomponents.EncryptedPersistentDataStore(DataStore(filePath).Prefix(prefix)).
+				PrimaryKey("key1", []byte("0123456789abcdef"))
+		},
+		func(prefix string) error {
+			return clearData(filePath, prefix)
+		},
+	).Run(t)
+}
+
+func TestFileDataStoreWaitsForLockHeldByAnotherConnection(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+	store := makeTestStore(t, DataStore(filePath).LockTimeout(time.Millisecond*50))
+	require.NoError(t, store.Init(makeTestData(makeTestFlag("a", 1))))
+
+	db, err := bolt.Open(filePath, 0600, nil) // holds an exclusive lock until closed
+	require.NoError(t, err)
+
+	_, err = store.Get(ldstoreimpl.Features(), "a")
+	assert.True(t, errors.Is(err, bolt.ErrTimeout), "unexpected error: %s", err)
+	assert.False(t, store.IsStoreAvailable())
+
+	require.NoError(t, db.Close())
+
+	item, err := store.Get(ldstoreimpl.Features(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, item.Version)
+	assert.True(t, store.IsStoreAvailable())
+}
+
+func TestFileDataStoreConcurrentUpsertsFromSeparateInstances(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+	store1 := makeTestStore(t, DataStore(filePath))
+	store2 := makeTestStore(t, DataStore(filePath))
+	require.NoError(t, store1.Init(makeTestData()))
+
+	var wg sync.WaitGroup
+	for i, store := range []subsystems.PersistentDataStore{store1, store2} {
+		wg.Add(1)
+		go func(store subsystems.PersistentDataStore, offset int) {
+			defer wg.Done()
+			for version := 1 + offset; version <= 20; version += 2 {
+				_, err := store.Upsert(ldstoreimpl.Features(), "a", makeTestFlag("a", version).Item)
+				assert.NoError(t, err)
+			}
+		}(store, i)
+	}
+	wg.Wait()
+
+	item, err := store1.Get(ldstoreimpl.Features(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, 20, item.Version)
+}
+
+func TestFileDataStoreNotInitializedIfFileDoesNotExist(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+	store := makeTestStore(t, DataStore(filePath))
+
+	assert.False(t, store.IsInitialized())
+	assert.True(t, store.IsStoreAvailable())
+	item, err := store.Get(ldstoreimpl.Features(), "a")
+	assert.NoError(t, err)
+	assert.Equal(t, -1, item.Version)
+
+	store = makeTestStore(t, DataStore(filepath.Join(filePath, "no-such-dir", "test.db")))
+	assert.False(t, store.IsStoreAvailable())
+}
+
+func makeTestStore(t *testing.T, builder *DataStoreBuilder) subsystems.PersistentDataStore {
+	store, err := builder.Build(subsystems.BasicClientContext{
+		Logging: subsystems.LoggingConfiguration{Loggers: ldlog.NewDisabledLoggers()},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func makeTestFlag(key string, version int) st.KeyedSerializedItemDescriptor {
+	flag := ldbuilders.NewFlagBuilder(key).Version(version).Build()
+	return st.KeyedSerializedItemDescriptor{
+		Key: key,
+		Item: st.SerializedItemDescriptor{
+			Version:        version,
+			SerializedItem: ldstoreimpl.Features().Serialize(st.ItemDescriptor{Version: version, Item: &flag}),
+		},
+	}
+}
+
+func makeTestData(flags ...st.KeyedSerializedItemDescriptor) []st.SerializedCollection {
+	return []st.SerializedCollection{
+		{Kind: ldstoreimpl.Segments(), Items: nil},
+		{Kind: ldstoreimpl.Features(), Items: flags},
+	}
+}
+
+func clearData(filePath, prefix string) error {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	db, err := bolt.Open(filePath, 0600, nil)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(prefix)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		return nil
+	})
+}
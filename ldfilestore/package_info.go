@@ -0,0 +1,26 @@
+// Package ldfilestore provides a persistent data store for the LaunchDarkly SDK that keeps feature flag
+// data in a local file, so that it is retained across application restarts without an external database.
+//
+// To use it, pass the builder returned by [DataStore] to
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStore]:
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(
+//	        ldfilestore.DataStore("./launchdarkly-flags.db"),
+//	    ),
+//	}
+//
+// The file is an embedded key-value database in the format used by bbolt (https://github.com/etcd-io/bbolt).
+// It is created the first time the SDK writes to it. Several SDK instances, in the same process or in
+// different processes on the same host, can share one file: each operation locks the file for as long
+// as it takes, using a shared lock for reads and an exclusive lock for writes. To keep data for several
+// LaunchDarkly environments in the same file, give each of them a different [DataStoreBuilder.Prefix].
+//
+// Since every read of the store involves opening the file, it is best to use this store with the SDK's
+// default in-memory caching (see [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStoreBuilder]).
+//
+// The file must be on a local filesystem. Network filesystems may not implement file locking reliably.
+//
+// This package is a separate module from the SDK, github.com/launchdarkly/go-server-sdk/ldfilestore, so
+// that applications that do not use it do not need bbolt as a dependency.
+package ldfilestore
@@ -0,0 +1,216 @@
+package ldmetrics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+const (
+	// DefaultMaxFlagKeys is the default value for [Options.MaxFlagKeys].
+	DefaultMaxFlagKeys = 1000
+
+	// DefaultVariationLabel is the variation label for evaluations that returned the application's default
+	// value, rather than one of the flag's variations.
+	DefaultVariationLabel = "default"
+)
+
+// DefaultLatencyBuckets returns the default value for [Options.LatencyBuckets]. These range from 10
+// microseconds to 100 milliseconds; an evaluation that does not need to query a database normally takes
+// well under a millisecond.
+func DefaultLatencyBuckets() []time.Duration {
+	return []time.Duration{
+		10 * time.Microsecond,
+		25 * time.Microsecond,
+		50 * time.Microsecond,
+		100 * time.Microsecond,
+		250 * time.Microsecond,
+		500 * time.Microsecond,
+		time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+	}
+}
+
+// Options contains the parameters for [NewEvaluationMetrics].
+type Options struct {
+	// MaxFlagKeys is the maximum number of distinct flag keys to keep metrics for. Evaluations of any
+	// other flags are counted under the flag key [OtherFlagsKey]. If zero, [DefaultMaxFlagKeys] is used.
+	MaxFlagKeys int
+
+	// LatencyBuckets contains the upper bounds of the latency histogram buckets, in increasing order. If
+	// empty, [DefaultLatencyBuckets] is used.
+	LatencyBuckets []time.Duration
+}
+
+// Source is an interface for reading evaluation metrics. It is implemented by [EvaluationMetrics], and
+// is used by exporters such as the Prometheus adapter.
+type Source interface {
+	// Snapshot returns the current values of all metrics.
+	Snapshot() []Series
+}
+
+// Series contains the metrics for one combination of flag key, variation, and reason kind.
+type Series struct {
+	// FlagKey is the flag key, or [OtherFlagsKey].
+	FlagKey string
+
+	// Variation is the variation index as a string, or [DefaultVariationLabel].
+	Variation string
+
+	// ReasonKind is the kind of the evaluation reason, such as "FALLTHROUGH" or "ERROR".
+	ReasonKind string
+
+	// Count is the total number of evaluations.
+	Count int64
+
+	// Latency is a histogram of evaluation durations.
+	Latency Histogram
+}
+
+// Histogram contains the values of a latency histogram.
+type Histogram struct {
+	// Buckets contains the upper bounds of the histogram buckets.
+	Buckets []time.Duration
+
+	// BucketCounts contains the cumulative count for each bucket: that is, BucketCounts[i] is the number
+	// of evaluations that took no longer than Buckets[i].
+	BucketCounts []int64
+
+	// Count is the total number of evaluations, including those that exceeded the largest bucket.
+	Count int64
+
+	// Sum is the total duration of all evaluations.
+	Sum time.Duration
+}
+
+// EvaluationMetrics is an implementation of [subsystems.EvaluationMetricsRecorder] that keeps an evaluation
+// counter and a latency histogram for each combination of flag key, variation, and evaluation reason kind.
+//
+// To use it, create an instance with [NewEvaluationMetrics] and put it in the EvaluationMetrics field of
+// [github.com/launchdarkly/go-server-sdk/v6.Config]. The metrics can then be read with Snapshot, or
+// exported with one of the adapters described in the package documentation.
+//
+// The number of flag keys is limited by [Options.MaxFlagKeys]. The number of variations and reason kinds
+// for each flag is small, so the total number of series is bounded.
+type EvaluationMetrics struct {
+	buckets []time.Duration
+	limiter *FlagKeyLimiter
+	series  map[seriesKey]*seriesData
+	lock    sync.RWMutex
+}
+
+type seriesKey struct {
+	flagKey    string
+	variation  int // -1 for the default value
+	reasonKind string
+}
+
+type seriesData struct {
+	sumNanos     int64
+	bucketCounts []int64 // not cumulative; the last element is for values above the largest bucket
+}
+
+// NewEvaluationMetrics creates an EvaluationMetrics instance.
+func NewEvaluationMetrics(options Options) *EvaluationMetrics {
+	buckets := options.LatencyBuckets
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets()
+	}
+	return &EvaluationMetrics{
+		buckets: append([]time.Duration(nil), buckets...),
+		limiter: NewFlagKeyLimiter(options.MaxFlagKeys),
+		series:  make(map[seriesKey]*seriesData),
+	}
+}
+
+// RecordEvaluation updates the metrics for an evaluation. This is called by the SDK.
+func (m *EvaluationMetrics) RecordEvaluation(metric subsystems.EvaluationMetric) {
+	key := seriesKey{
+		flagKey:    m.limiter.Label(metric.FlagKey),
+		variation:  metric.Variation.OrElse(-1),
+		reasonKind: string(metric.Reason.GetKind()),
+	}
+	m.lock.RLock()
+	data := m.series[key]
+	m.lock.RUnlock()
+	if data == nil {
+		m.lock.Lock()
+		if data = m.series[key]; data == nil {
+			data = &seriesData{bucketCounts: make([]int64, len(m.buckets)+1)}
+			m.series[key] = data
+		}
+		m.lock.Unlock()
+	}
+	bucket := sort.Search(len(m.buckets), func(i int) bool { return metric.Duration <= m.buckets[i] })
+	atomic.AddInt64(&data.bucketCounts[bucket], 1)
+	atomic.AddInt64(&data.sumNanos, int64(metric.Duration))
+}
+
+// Snapshot returns the current values of all metrics, sorted by flag key, variation, and reason kind.
+//
+// Since evaluations may be recorded while the snapshot is being taken, the latency sum of a series might
+// not include exactly the same evaluations as its counts, but no count is ever lower than in a previous
+// snapshot.
+func (m *EvaluationMetrics) Snapshot() []Series {
+	m.lock.RLock()
+	ret := make([]Series, 0, len(m.series))
+	for key, data := range m.series {
+		s := Series{
+			FlagKey:    key.flagKey,
+			Variation:  DefaultVariationLabel,
+			ReasonKind: key.reasonKind,
+			Latency: Histogram{
+				Buckets:      m.buckets,
+				BucketCounts: make([]int64, len(m.buckets)),
+				Sum:          time.Duration(atomic.LoadInt64(&data.sumNanos)),
+			},
+		}
+		if key.variation >= 0 {
+			s.Variation = strconv.Itoa(key.variation)
+		}
+		var cumulative int64
+		for i := range data.bucketCounts {
+			cumulative += atomic.LoadInt64(&data.bucketCounts[i])
+			if i < len(m.buckets) {
+				s.Latency.BucketCounts[i] = cumulative
+			}
+		}
+		s.Count = cumulative
+		s.Latency.Count = cumulative
+		ret = append(ret, s)
+	}
+	m.lock.RUnlock()
+	sort.Slice(ret, func(i, j int) bool {
+		a, b := ret[i], ret[j]
+		if a.FlagKey != b.FlagKey {
+			return a.FlagKey < b.FlagKey
+		}
+		if a.Variation != b.Variation {
+			return a.Variation < b.Variation
+		}
+		return a.ReasonKind < b.ReasonKind
+	})
+	return ret
+}
+
+// Combine returns an EvaluationMetricsRecorder that passes every evaluation to each of the specified
+// recorders. This can be used to record metrics with [EvaluationMetrics] and also with another
+// implementation, such as the OpenTelemetry adapter.
+func Combine(recorders ...subsystems.EvaluationMetricsRecorder) subsystems.EvaluationMetricsRecorder {
+	return combinedRecorder(append([]subsystems.EvaluationMetricsRecorder(nil), recorders...))
+}
+
+type combinedRecorder []subsystems.EvaluationMetricsRecorder
+
+func (c combinedRecorder) RecordEvaluation(metric subsystems.EvaluationMetric) {
+	for _, r := range c {
+		r.RecordEvaluation(metric)
+	}
+}
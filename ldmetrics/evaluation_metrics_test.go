@@ -0,0 +1,98 @@
+package ldmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type capturingRecorder struct {
+	metrics []subsystems.EvaluationMetric
+}
+
+func (c *capturingRecorder) RecordEvaluation(metric subsystems.EvaluationMetric) {
+	c.metrics = append(c.metrics, metric)
+}
+
+func makeMetric(flagKey string, variation int, reason ldreason.EvaluationReason, d time.Duration) subsystems.EvaluationMetric {
+	m := subsystems.EvaluationMetric{FlagKey: flagKey, Reason: reason, Duration: d}
+	if variation >= 0 {
+		m.Variation = ldvalue.NewOptionalInt(variation)
+	}
+	return m
+}
+
+func TestEvaluationMetricsWithNoEvaluations(t *testing.T) {
+	m := NewEvaluationMetrics(Options{})
+	assert.Len(t, m.Snapshot(), 0)
+	assert.Equal(t, DefaultLatencyBuckets(), m.buckets)
+}
+
+func TestEvaluationMetricsCountsBySeries(t *testing.T) {
+	m := NewEvaluationMetrics(Options{})
+	fallthroughReason := ldreason.NewEvalReasonFallthrough()
+	m.RecordEvaluation(makeMetric("flag1", 1, fallthroughReason, time.Microsecond))
+	m.RecordEvaluation(makeMetric("flag1", 1, fallthroughReason, time.Microsecond))
+	m.RecordEvaluation(makeMetric("flag1", 0, ldreason.NewEvalReasonTargetMatch(), time.Microsecond))
+	m.RecordEvaluation(makeMetric("flag2", -1, ldreason.NewEvalReasonError(ldreason.EvalErrorFlagNotFound),
+		time.Microsecond))
+
+	var labels []string
+	var counts []int64
+	for _, s := range m.Snapshot() {
+		labels = append(labels, s.FlagKey+"/"+s.Variation+"/"+s.ReasonKind)
+		counts = append(counts, s.Count)
+	}
+	assert.Equal(t, []string{"flag1/0/TARGET_MATCH", "flag1/1/FALLTHROUGH", "flag2/default/ERROR"}, labels)
+	assert.Equal(t, []int64{1, 2, 1}, counts)
+}
+
+func TestEvaluationMetricsLatencyHistogram(t *testing.T) {
+	buckets := []time.Duration{time.Millisecond, 10 * time.Millisecond}
+	m := NewEvaluationMetrics(Options{LatencyBuckets: buckets})
+	reason := ldreason.NewEvalReasonFallthrough()
+	for _, d := range []time.Duration{time.Microsecond, time.Millisecond, 5 * time.Millisecond, time.Second} {
+		m.RecordEvaluation(makeMetric("flag", 0, reason, d))
+	}
+
+	snapshot := m.Snapshot()
+	require.Len(t, snapshot, 1)
+	assert.Equal(t, Histogram{
+		Buckets:      buckets,
+		BucketCounts: []int64{2, 3},
+		Count:        4,
+		Sum:          time.Microsecond + time.Millisecond + 5*time.Millisecond + time.Second,
+	}, snapshot[0].Latency)
+	assert.Equal(t, int64(4), snapshot[0].Count)
+}
+
+func TestEvaluationMetricsLimitsFlagKeys(t *testing.T) {
+	m := NewEvaluationMetrics(Options{MaxFlagKeys: 1})
+	reason := ldreason.NewEvalReasonFallthrough()
+	m.RecordEvaluation(makeMetric("flag1", 0, reason, 0))
+	m.RecordEvaluation(makeMetric("flag2", 0, reason, 0))
+	m.RecordEvaluation(makeMetric("flag3", 0, reason, 0))
+
+	snapshot := m.Snapshot()
+	require.Len(t, snapshot, 2)
+	assert.Equal(t, OtherFlagsKey, snapshot[0].FlagKey)
+	assert.Equal(t, int64(2), snapshot[0].Count)
+	assert.Equal(t, "flag1", snapshot[1].FlagKey)
+	assert.Equal(t, int64(1), snapshot[1].Count)
+}
+
+func TestCombine(t *testing.T) {
+	r1, r2 := &capturingRecorder{}, &capturingRecorder{}
+	metric := makeMetric("flag", 0, ldreason.NewEvalReasonOff(), time.Millisecond)
+
+	Combine(r1, r2).RecordEvaluation(metric)
+
+	assert.Equal(t, []subsystems.EvaluationMetric{metric}, r1.metrics)
+	assert.Equal(t, []subsystems.EvaluationMetric{metric}, r2.metrics)
+}
@@ -0,0 +1,50 @@
+package ldmetrics
+
+import "sync"
+
+// OtherFlagsKey is the flag key label that is used for all flags beyond the limit of a [FlagKeyLimiter].
+const OtherFlagsKey = "__other__"
+
+// FlagKeyLimiter bounds the number of distinct flag keys that are used as metric labels, so that an
+// application that evaluates a very large or unbounded set of flag keys does not create an unbounded
+// number of time series. The first flag keys that it sees are kept, up to the limit; any others are
+// replaced with [OtherFlagsKey].
+//
+// A FlagKeyLimiter is safe for concurrent use.
+type FlagKeyLimiter struct {
+	maxFlagKeys int
+	flagKeys    map[string]struct{}
+	lock        sync.RWMutex
+}
+
+// NewFlagKeyLimiter creates a FlagKeyLimiter that keeps up to maxFlagKeys flag keys. If maxFlagKeys is
+// zero or negative, [DefaultMaxFlagKeys] is used.
+func NewFlagKeyLimiter(maxFlagKeys int) *FlagKeyLimiter {
+	if maxFlagKeys <= 0 {
+		maxFlagKeys = DefaultMaxFlagKeys
+	}
+	return &FlagKeyLimiter{maxFlagKeys: maxFlagKeys, flagKeys: make(map[string]struct{})}
+}
+
+// Label returns the flag key, if it is one of the flag keys that are being kept, or OtherFlagsKey.
+func (l *FlagKeyLimiter) Label(flagKey string) string {
+	l.lock.RLock()
+	_, found := l.flagKeys[flagKey]
+	full := len(l.flagKeys) >= l.maxFlagKeys
+	l.lock.RUnlock()
+	if found {
+		return flagKey
+	}
+	if full {
+		return OtherFlagsKey
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if _, found := l.flagKeys[flagKey]; !found {
+		if len(l.flagKeys) >= l.maxFlagKeys {
+			return OtherFlagsKey
+		}
+		l.flagKeys[flagKey] = struct{}{}
+	}
+	return flagKey
+}
@@ -0,0 +1,44 @@
+package ldmetrics
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagKeyLimiterKeepsFirstKeysUpToLimit(t *testing.T) {
+	l := NewFlagKeyLimiter(2)
+	assert.Equal(t, "a", l.Label("a"))
+	assert.Equal(t, "b", l.Label("b"))
+	assert.Equal(t, OtherFlagsKey, l.Label("c"))
+	assert.Equal(t, "a", l.Label("a"))
+	assert.Equal(t, "b", l.Label("b"))
+	assert.Equal(t, OtherFlagsKey, l.Label("c"))
+}
+
+func TestFlagKeyLimiterUsesDefaultLimit(t *testing.T) {
+	l := NewFlagKeyLimiter(0)
+	for i := 0; i < DefaultMaxFlagKeys; i++ {
+		key := fmt.Sprintf("flag%d", i)
+		assert.Equal(t, key, l.Label(key))
+	}
+	assert.Equal(t, OtherFlagsKey, l.Label("one-more"))
+}
+
+func TestFlagKeyLimiterIsSafeForConcurrentUse(t *testing.T) {
+	l := NewFlagKeyLimiter(10)
+	var wg sync.WaitGroup
+	for g := 0; g < 10; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				_ = l.Label(fmt.Sprintf("flag%d", i))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, l.flagKeys, 10)
+}
@@ -0,0 +1,10 @@
+// Package ldotel records the LaunchDarkly SDK's evaluation metrics with OpenTelemetry.
+//
+// It is a separate module from the SDK, so that applications that do not use OpenTelemetry do not need
+// the OpenTelemetry libraries as a dependency.
+//
+//	recorder, err := ldotel.NewRecorder(otel.Meter("my-service"), ldmetrics.Options{})
+//	client, err := ld.MakeCustomClient(sdkKey, ld.Config{EvaluationMetrics: recorder}, 5*time.Second)
+//
+// To also keep the metrics in memory with [ldmetrics.EvaluationMetrics], use [ldmetrics.Combine].
+package ldotel
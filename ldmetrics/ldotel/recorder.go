@@ -0,0 +1,106 @@
+package ldotel
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/launchdarkly/go-server-sdk/v6/ldmetrics"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+const (
+	// EvaluationsMetricName is the name of the evaluation counter.
+	EvaluationsMetricName = "launchdarkly.flag.evaluations"
+	// EvaluationDurationMetricName is the name of the evaluation duration histogram.
+	EvaluationDurationMetricName = "launchdarkly.flag.evaluation.duration"
+
+	flagKeyAttribute    = "flag_key"
+	variationAttribute  = "variation"
+	reasonKindAttribute = "reason_kind"
+)
+
+// Recorder is an implementation of [subsystems.EvaluationMetricsRecorder] that records evaluations with
+// an OpenTelemetry Meter. It provides two instruments, each with the attributes "flag_key", "variation",
+// and "reason_kind":
+//   - launchdarkly.flag.evaluations: a counter of evaluations.
+//   - launchdarkly.flag.evaluation.duration: a histogram of evaluation durations, in seconds.
+//
+// The number of distinct flag keys is limited in the same way as for [ldmetrics.EvaluationMetrics].
+type Recorder struct {
+	evaluations metric.Int64Counter
+	duration    metric.Float64Histogram
+	limiter     *ldmetrics.FlagKeyLimiter
+	attributes  sync.Map // attributesKey -> metric.MeasurementOption
+}
+
+type attributesKey struct {
+	flagKey    string
+	variation  int
+	reasonKind string
+}
+
+// NewRecorder creates a Recorder that uses the specified Meter. The MaxFlagKeys and LatencyBuckets
+// properties of options have the same meaning as for [ldmetrics.NewEvaluationMetrics].
+func NewRecorder(meter metric.Meter, options ldmetrics.Options) (*Recorder, error) {
+	evaluations, err := meter.Int64Counter(
+		EvaluationsMetricName,
+		metric.WithDescription("Number of feature flag evaluations."),
+		metric.WithUnit("{evaluation}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	buckets := options.LatencyBuckets
+	if len(buckets) == 0 {
+		buckets = ldmetrics.DefaultLatencyBuckets()
+	}
+	bounds := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		bounds = append(bounds, b.Seconds())
+	}
+	duration, err := meter.Float64Histogram(
+		EvaluationDurationMetricName,
+		metric.WithDescription("Duration of feature flag evaluations."),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(bounds...),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{
+		evaluations: evaluations,
+		duration:    duration,
+		limiter:     ldmetrics.NewFlagKeyLimiter(options.MaxFlagKeys),
+	}, nil
+}
+
+// RecordEvaluation records an evaluation. This is called by the SDK.
+func (r *Recorder) RecordEvaluation(m subsystems.EvaluationMetric) {
+	key := attributesKey{
+		flagKey:    r.limiter.Label(m.FlagKey),
+		variation:  m.Variation.OrElse(-1),
+		reasonKind: string(m.Reason.GetKind()),
+	}
+	// The attribute sets are cached, since creating them is relatively expensive; the number of them is
+	// bounded by the flag key limit.
+	attrs, ok := r.attributes.Load(key)
+	if !ok {
+		variation := ldmetrics.DefaultVariationLabel
+		if key.variation >= 0 {
+			variation = strconv.Itoa(key.variation)
+		}
+		attrs, _ = r.attributes.LoadOrStore(key, metric.WithAttributeSet(attribute.NewSet(
+			attribute.String(flagKeyAttribute, key.flagKey),
+			attribute.String(variationAttribute, variation),
+			attribute.String(reasonKindAttribute, key.reasonKind),
+		)))
+	}
+	option := attrs.(metric.MeasurementOption)
+	ctx := context.Background()
+	r.evaluations.Add(ctx, 1, option)
+	r.duration.Record(ctx, m.Duration.Seconds(), option)
+}
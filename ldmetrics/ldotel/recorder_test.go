@@ -0,0 +1,102 @@
+package ldotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/ldmetrics"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestRecorder(t *testing.T, options ldmetrics.Options) (*Recorder, *sdkmetric.ManualReader) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	recorder, err := NewRecorder(provider.Meter("test"), options)
+	require.NoError(t, err)
+	return recorder, reader
+}
+
+func collectMetrics(t *testing.T, reader *sdkmetric.ManualReader) map[string]metricdata.Metrics {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	ret := make(map[string]metricdata.Metrics)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			ret[m.Name] = m
+		}
+	}
+	return ret
+}
+
+func attributesOf(set attribute.Set) map[string]string {
+	ret := make(map[string]string)
+	for _, kv := range set.ToSlice() {
+		ret[string(kv.Key)] = kv.Value.AsString()
+	}
+	return ret
+}
+
+func TestRecorderRecordsCounterAndHistogram(t *testing.T) {
+	recorder, reader := makeTestRecorder(t, ldmetrics.Options{
+		LatencyBuckets: []time.Duration{time.Millisecond, 10 * time.Millisecond},
+	})
+	for _, d := range []time.Duration{500 * time.Microsecond, 5 * time.Millisecond} {
+		recorder.RecordEvaluation(subsystems.EvaluationMetric{
+			FlagKey:   "flag-key",
+			Variation: ldvalue.NewOptionalInt(1),
+			Reason:    ldreason.NewEvalReasonFallthrough(),
+			Duration:  d,
+		})
+	}
+	recorder.RecordEvaluation(subsystems.EvaluationMetric{
+		FlagKey: "flag-key",
+		Reason:  ldreason.NewEvalReasonError(ldreason.EvalErrorWrongType),
+	})
+
+	metrics := collectMetrics(t, reader)
+
+	sum, ok := metrics[EvaluationsMetricName].Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	counts := make(map[string]int64)
+	for _, dp := range sum.DataPoints {
+		attrs := attributesOf(dp.Attributes)
+		assert.Equal(t, "flag-key", attrs["flag_key"])
+		counts[attrs["variation"]+"/"+attrs["reason_kind"]] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{"1/FALLTHROUGH": 2, "default/ERROR": 1}, counts)
+
+	histogram, ok := metrics[EvaluationDurationMetricName].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	for _, dp := range histogram.DataPoints {
+		if attributesOf(dp.Attributes)["variation"] == "1" {
+			assert.Equal(t, []float64{0.001, 0.01}, dp.Bounds)
+			assert.Equal(t, []uint64{1, 1, 0}, dp.BucketCounts)
+			assert.Equal(t, uint64(2), dp.Count)
+		}
+	}
+}
+
+func TestRecorderLimitsFlagKeys(t *testing.T) {
+	recorder, reader := makeTestRecorder(t, ldmetrics.Options{MaxFlagKeys: 1})
+	for _, key := range []string{"flag1", "flag2", "flag3"} {
+		recorder.RecordEvaluation(subsystems.EvaluationMetric{FlagKey: key, Reason: ldreason.NewEvalReasonOff()})
+	}
+
+	sum, ok := collectMetrics(t, reader)[EvaluationsMetricName].Data.(metricdata.Sum[int64])
+	require.True(t, ok)
+	counts := make(map[string]int64)
+	for _, dp := range sum.DataPoints {
+		counts[attributesOf(dp.Attributes)["flag_key"]] = dp.Value
+	}
+	assert.Equal(t, map[string]int64{"flag1": 1, ldmetrics.OtherFlagsKey: 2}, counts)
+}
@@ -0,0 +1,88 @@
+package ldprometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/launchdarkly/go-server-sdk/v6/ldmetrics"
+)
+
+const (
+	// DefaultNamespace is the default value for [Options.Namespace].
+	DefaultNamespace = "launchdarkly"
+
+	flagKeyLabel    = "flag_key"
+	variationLabel  = "variation"
+	reasonKindLabel = "reason_kind"
+)
+
+// Options contains optional parameters for [NewCollector].
+type Options struct {
+	// Namespace is the prefix for the metric names. If empty, [DefaultNamespace] is used.
+	Namespace string
+
+	// ConstLabels contains labels that are added to every metric.
+	ConstLabels prometheus.Labels
+}
+
+type collector struct {
+	source       ldmetrics.Source
+	countDesc    *prometheus.Desc
+	durationDesc *prometheus.Desc
+}
+
+// NewCollector creates a Prometheus Collector that exports the metrics from an [ldmetrics.Source], such
+// as [ldmetrics.EvaluationMetrics]. It provides two metrics, each with the labels "flag_key", "variation",
+// and "reason_kind":
+//   - launchdarkly_flag_evaluations_total: a counter of evaluations.
+//   - launchdarkly_flag_evaluation_duration_seconds: a histogram of evaluation durations.
+//
+// The Collector reads the current values from the Source each time it is scraped.
+func NewCollector(source ldmetrics.Source, options Options) prometheus.Collector {
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	labels := []string{flagKeyLabel, variationLabel, reasonKindLabel}
+	return &collector{
+		source: source,
+		countDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "flag", "evaluations_total"),
+			"Number of feature flag evaluations.",
+			labels,
+			options.ConstLabels,
+		),
+		durationDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "flag", "evaluation_duration_seconds"),
+			"Duration of feature flag evaluations.",
+			labels,
+			options.ConstLabels,
+		),
+	}
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.countDesc
+	ch <- c.durationDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.source.Snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			c.countDesc,
+			prometheus.CounterValue,
+			float64(s.Count),
+			s.FlagKey, s.Variation, s.ReasonKind,
+		)
+		buckets := make(map[float64]uint64, len(s.Latency.Buckets))
+		for i, upperBound := range s.Latency.Buckets {
+			buckets[upperBound.Seconds()] = uint64(s.Latency.BucketCounts[i])
+		}
+		ch <- prometheus.MustNewConstHistogram(
+			c.durationDesc,
+			uint64(s.Latency.Count),
+			s.Latency.Sum.Seconds(),
+			buckets,
+			s.FlagKey, s.Variation, s.ReasonKind,
+		)
+	}
+}
@@ -0,0 +1,66 @@
+package ldprometheus
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/ldmetrics"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorExportsMetrics(t *testing.T) {
+	metrics := ldmetrics.NewEvaluationMetrics(ldmetrics.Options{
+		LatencyBuckets: []time.Duration{time.Millisecond, 10 * time.Millisecond},
+	})
+	for _, d := range []time.Duration{500 * time.Microsecond, 5 * time.Millisecond} {
+		metrics.RecordEvaluation(subsystems.EvaluationMetric{
+			FlagKey:   "flag-key",
+			Variation: ldvalue.NewOptionalInt(1),
+			Reason:    ldreason.NewEvalReasonFallthrough(),
+			Duration:  d,
+		})
+	}
+	collector := NewCollector(metrics, Options{})
+
+	expected := `
+# HELP launchdarkly_flag_evaluation_duration_seconds Duration of feature flag evaluations.
+# TYPE launchdarkly_flag_evaluation_duration_seconds histogram
+launchdarkly_flag_evaluation_duration_seconds_bucket{flag_key="flag-key",reason_kind="FALLTHROUGH",variation="1",le="0.001"} 1
+launchdarkly_flag_evaluation_duration_seconds_bucket{flag_key="flag-key",reason_kind="FALLTHROUGH",variation="1",le="0.01"} 2
+launchdarkly_flag_evaluation_duration_seconds_bucket{flag_key="flag-key",reason_kind="FALLTHROUGH",variation="1",le="+Inf"} 2
+launchdarkly_flag_evaluation_duration_seconds_sum{flag_key="flag-key",reason_kind="FALLTHROUGH",variation="1"} 0.0055
+launchdarkly_flag_evaluation_duration_seconds_count{flag_key="flag-key",reason_kind="FALLTHROUGH",variation="1"} 2
+# HELP launchdarkly_flag_evaluations_total Number of feature flag evaluations.
+# TYPE launchdarkly_flag_evaluations_total counter
+launchdarkly_flag_evaluations_total{flag_key="flag-key",reason_kind="FALLTHROUGH",variation="1"} 2
+`
+	require.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+}
+
+func TestCollectorWithNamespaceAndConstLabels(t *testing.T) {
+	metrics := ldmetrics.NewEvaluationMetrics(ldmetrics.Options{})
+	metrics.RecordEvaluation(subsystems.EvaluationMetric{FlagKey: "flag-key", Reason: ldreason.NewEvalReasonOff()})
+	collector := NewCollector(metrics, Options{Namespace: "myapp", ConstLabels: prometheus.Labels{"env": "test"}})
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range families {
+		names = append(names, f.GetName())
+		assert.Equal(t, "env", f.GetMetric()[0].GetLabel()[0].GetName())
+	}
+	assert.Equal(t, []string{"myapp_flag_evaluation_duration_seconds", "myapp_flag_evaluations_total"}, names)
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "myapp_flag_evaluations_total"))
+}
@@ -0,0 +1,9 @@
+// Package ldprometheus exports the LaunchDarkly SDK's evaluation metrics to Prometheus.
+//
+// It is a separate module from the SDK, so that applications that do not use Prometheus do not need
+// the Prometheus client library as a dependency.
+//
+//	metrics := ldmetrics.NewEvaluationMetrics(ldmetrics.Options{})
+//	prometheus.MustRegister(ldprometheus.NewCollector(metrics, ldprometheus.Options{}))
+//	client, err := ld.MakeCustomClient(sdkKey, ld.Config{EvaluationMetrics: metrics}, 5*time.Second)
+package ldprometheus
@@ -0,0 +1,19 @@
+// Package ldmetrics provides evaluation metrics for the LaunchDarkly SDK: counters and latency histograms
+// for flag evaluations, by flag key, variation, and evaluation reason kind. These can be used to chart
+// evaluations in an application's own monitoring system, without parsing analytics events.
+//
+// To enable the metrics, create an [EvaluationMetrics] instance and put it in the EvaluationMetrics field
+// of [github.com/launchdarkly/go-server-sdk/v6.Config]:
+//
+//	metrics := ldmetrics.NewEvaluationMetrics(ldmetrics.Options{MaxFlagKeys: 200})
+//	config := ld.Config{EvaluationMetrics: metrics}
+//
+// The metrics can be read at any time with [EvaluationMetrics.Snapshot]. There are also adapters for two
+// common metrics libraries. Since those libraries have their own dependencies and Go version requirements,
+// the adapters are in separate modules, so that applications that do not use them are not affected:
+//   - github.com/launchdarkly/go-server-sdk/ldmetrics/ldprometheus provides a Collector for the
+//     Prometheus client library, which exports the contents of a [Source] such as EvaluationMetrics.
+//   - github.com/launchdarkly/go-server-sdk/ldmetrics/ldotel provides an
+//     [github.com/launchdarkly/go-server-sdk/v6/subsystems.EvaluationMetricsRecorder] that records
+//     evaluations with an OpenTelemetry Meter. To use it along with EvaluationMetrics, use [Combine].
+package ldmetrics
@@ -0,0 +1,30 @@
+// Package ldredaction provides redaction policies for the context attributes in analytics events, as a
+// more flexible alternative to marking attributes as private.
+//
+// Private attributes always remove a named attribute entirely. A redaction policy instead consists of rules
+// that can select attributes by context kind, by a path pattern, and by a regular expression that the
+// value must match, and can hash or truncate the value instead of removing it. To use a policy, pass it to
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.EventProcessorBuilder.RedactionPolicy]:
+//
+//	policy, err := ldredaction.NewPolicy(
+//	    ldredaction.Rule{
+//	        ValuePattern: `^[^@\s]+@[^@\s]+$`, // any attribute that looks like an email address
+//	        Action:       ldredaction.HashValue,
+//	    },
+//	    ldredaction.Rule{
+//	        ContextKind: "device",
+//	        Attribute:   "/location/*",
+//	        Action:      ldredaction.RemoveValue,
+//	    },
+//	)
+//	config := ld.Config{
+//	    Events: ldcomponents.SendEvents().RedactionPolicy(policy),
+//	}
+//
+// The policy is applied to the context of each event before the SDK formats it, so it affects every place
+// the context appears in the SDK's analytics events: the context details in identify, index, and debug
+// events, and the context keys in feature and custom events. An attribute that a rule removes is reported
+// as redacted, in the same way as a private attribute. Rules can hash or truncate the context key, but not
+// remove it, and the kind, anonymous, and _meta attributes are never redacted. A [Policy] can also be used
+// directly, for instance to test its effect on a context in a unit test.
+package ldredaction
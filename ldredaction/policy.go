@@ -0,0 +1,269 @@
+package ldredaction
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// Action is a parameter type for [Rule], indicating what to do with a matching attribute value.
+type Action string
+
+const (
+	// RemoveValue means that the attribute is removed from the context, just as if it were a private
+	// attribute. Its path is listed in the context's redactedAttributes metadata. It never applies to the
+	// context key, since every context must have one.
+	RemoveValue Action = "remove"
+	// HashValue means that the value is replaced with the hexadecimal SHA-256 hash of the value. This
+	// allows values to be compared for equality without revealing them, but short or predictable values
+	// can be recovered by hashing all of the possible values. It only applies to string values.
+	HashValue Action = "hash"
+	// TruncateValue means that the value is shortened to the number of characters in
+	// [Rule.TruncateLength]. It only applies to string values.
+	TruncateValue Action = "truncate"
+)
+
+// Rule describes which attribute values a [Policy] should redact, and how.
+//
+// All of the conditions that are set must match. A rule with no conditions matches every attribute.
+type Rule struct {
+	// ContextKind restricts the rule to contexts of this kind, such as "user". In a multi-kind context,
+	// it applies to the individual context of this kind. If empty, the rule applies to all kinds.
+	ContextKind ldcontext.Kind
+
+	// Attribute restricts the rule to attributes with a matching path. A name that does not start with a
+	// slash is a top-level attribute name, such as "email". A name that starts with a slash is a
+	// slash-delimited path that can denote a property within a JSON object, as in
+	// [github.com/launchdarkly/go-sdk-common/v3/ldattr.NewRef], and a path component of "*" matches any
+	// single property name: "/address/*" matches "/address/street" but not "/address" or
+	// "/address/geo/lat". If empty, the rule applies to attributes at any path.
+	//
+	// The context key is the "key" attribute, so it can be hashed or truncated by a rule for "key" or by a
+	// rule with no Attribute. The redacted key is then used everywhere that the key appears in analytics
+	// events, so LaunchDarkly sees all contexts with the same redacted key as the same context.
+	Attribute string
+
+	// ValuePattern is a regular expression, in the syntax of the regexp package, that restricts the rule
+	// to string values that it matches. If empty, the value is not checked.
+	ValuePattern string
+
+	// Action is what to do with a matching value.
+	Action Action
+
+	// TruncateLength is the maximum number of characters to keep when the Action is TruncateValue. It
+	// must be at least 1.
+	TruncateLength int
+}
+
+// Policy is a set of redaction rules that can be applied to an evaluation context. See the package
+// documentation for more details.
+//
+// For the context key, and for each attribute of the context and each property within an attribute whose
+// value is a JSON object, the rules are checked in order and the first one that matches is applied. If no
+// rule matches an object, its properties are checked in turn. The "kind" and "anonymous" attributes are
+// never redacted, and [RemoveValue] rules never apply to the "key" attribute.
+//
+// A Policy is immutable and safe for concurrent use. A nil *Policy does not redact anything.
+type Policy struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	rule         Rule
+	path         []string // nil to match any path
+	valuePattern *regexp.Regexp
+}
+
+const wildcardPathComponent = "*"
+
+//nolint:gochecknoglobals
+var protectedAttributes = map[string]bool{
+	string(ldattr.KindAttr): true, string(ldattr.AnonymousAttr): true, "_meta": true,
+}
+
+// NewPolicy creates a Policy from a list of rules. It returns an error if any rule is invalid, including a
+// rule whose Attribute is "kind" or "anonymous", or a RemoveValue rule whose Attribute is "key", since those
+// attributes cannot be redacted that way.
+func NewPolicy(rules ...Rule) (*Policy, error) {
+	p := &Policy{}
+	for i, r := range rules {
+		cr := compiledRule{rule: r}
+		switch r.Action {
+		case RemoveValue, HashValue:
+		case TruncateValue:
+			if r.TruncateLength < 1 {
+				return nil, fmt.Errorf("redaction rule %d: TruncateLength must be at least 1", i)
+			}
+		default:
+			return nil, fmt.Errorf("redaction rule %d: unknown action %q", i, r.Action)
+		}
+		if r.Attribute != "" {
+			cr.path = parseAttributePath(r.Attribute)
+			if len(cr.path) == 1 && protectedAttributes[cr.path[0]] {
+				return nil, fmt.Errorf("redaction rule %d: the %q attribute cannot be redacted", i, cr.path[0])
+			}
+			if len(cr.path) == 1 && cr.path[0] == string(ldattr.KeyAttr) && r.Action == RemoveValue {
+				return nil, fmt.Errorf("redaction rule %d: the context key cannot be removed", i)
+			}
+		}
+		if r.ValuePattern != "" {
+			pattern, err := regexp.Compile(r.ValuePattern)
+			if err != nil {
+				return nil, fmt.Errorf("redaction rule %d: invalid value pattern: %w", i, err)
+			}
+			cr.valuePattern = pattern
+		}
+		p.rules = append(p.rules, cr)
+	}
+	return p, nil
+}
+
+// Apply returns a copy of the context with the policy's rules applied. The SDK calls this method for the
+// context of every analytics event, before the event is processed; it can also be called directly to test
+// a policy.
+//
+// Values that are hashed or truncated are replaced in the copy. Attributes that are removed are instead
+// marked as private in the copy (see [ldcontext.Builder.Private]), so that they are removed when the event
+// is formatted, and listed in the context's redactedAttributes metadata, just like any other private
+// attribute. If the context is invalid, or if no rules apply to it, it is returned unchanged.
+func (p *Policy) Apply(context ldcontext.Context) ldcontext.Context {
+	if p == nil || len(p.rules) == 0 || context.Err() != nil {
+		return context
+	}
+	if !context.Multiple() {
+		return p.applyToSingleContext(context)
+	}
+	builder := ldcontext.NewMultiBuilder()
+	for i := 0; i < context.IndividualContextCount(); i++ {
+		builder.Add(p.applyToSingleContext(context.IndividualContextByIndex(i)))
+	}
+	return builder.Build()
+}
+
+func (p *Policy) applyToSingleContext(context ldcontext.Context) ldcontext.Context {
+	kind := context.Kind()
+	var builder *ldcontext.Builder // only created if something changes
+	getBuilder := func() *ldcontext.Builder {
+		if builder == nil {
+			builder = ldcontext.NewBuilderFromContext(context)
+		}
+		return builder
+	}
+
+	keyPath := []string{string(ldattr.KeyAttr)}
+	if newKey, changed := p.applyToValue(kind, keyPath, ldvalue.String(context.Key()), nil); changed {
+		getBuilder().Key(newKey.StringValue())
+	}
+	for _, name := range context.GetOptionalAttributeNames(nil) {
+		var removed []ldattr.Ref
+		if newValue, changed := p.applyToValue(kind, []string{name}, context.GetValue(name), &removed); changed {
+			getBuilder().SetValue(name, newValue)
+		}
+		if len(removed) != 0 {
+			getBuilder().PrivateRef(removed...)
+		}
+	}
+	if builder == nil {
+		return context
+	}
+	return builder.Build()
+}
+
+// applyToValue returns the redacted value and true, or the original value and false if it was not changed.
+// The paths of any values that should be removed are added to removed, but the values are not changed; if
+// removed is nil, RemoveValue rules are skipped.
+func (p *Policy) applyToValue(
+	kind ldcontext.Kind,
+	path []string,
+	value ldvalue.Value,
+	removed *[]ldattr.Ref,
+) (ldvalue.Value, bool) {
+	for _, r := range p.rules {
+		if (removed == nil && r.rule.Action == RemoveValue) || !r.matches(kind, path, value) {
+			continue
+		}
+		switch r.rule.Action {
+		case HashValue:
+			hash := sha256.Sum256([]byte(value.StringValue()))
+			return ldvalue.String(hex.EncodeToString(hash[:])), true
+		case TruncateValue:
+			if chars := []rune(value.StringValue()); len(chars) > r.rule.TruncateLength {
+				return ldvalue.String(string(chars[:r.rule.TruncateLength])), true
+			}
+			return value, false
+		default:
+			*removed = append(*removed, attributeRef(path))
+			return value, false
+		}
+	}
+	if value.Type() != ldvalue.ObjectType {
+		return value, false
+	}
+	var props map[string]ldvalue.Value // only copied if a property changes
+	for _, name := range value.Keys(nil) {
+		propPath := append(path[:len(path):len(path)], name)
+		if newValue, changed := p.applyToValue(kind, propPath, value.GetByKey(name), removed); changed {
+			if props == nil {
+				props = value.AsValueMap().AsMap()
+			}
+			props[name] = newValue
+		}
+	}
+	if props == nil {
+		return value, false
+	}
+	return ldvalue.CopyObject(props), true
+}
+
+func (r compiledRule) matches(kind ldcontext.Kind, path []string, value ldvalue.Value) bool {
+	if r.rule.ContextKind != "" && r.rule.ContextKind != kind {
+		return false
+	}
+	if r.rule.Action != RemoveValue && value.Type() != ldvalue.StringType {
+		return false
+	}
+	if r.path != nil {
+		if len(r.path) != len(path) {
+			return false
+		}
+		for i, component := range r.path {
+			if component != wildcardPathComponent && component != path[i] {
+				return false
+			}
+		}
+	}
+	if r.valuePattern != nil {
+		return value.Type() == ldvalue.StringType && r.valuePattern.MatchString(value.StringValue())
+	}
+	return true
+}
+
+func parseAttributePath(attribute string) []string {
+	if !strings.HasPrefix(attribute, "/") {
+		return []string{attribute}
+	}
+	components := strings.Split(attribute[1:], "/")
+	for i, c := range components {
+		components[i] = strings.ReplaceAll(strings.ReplaceAll(c, "~1", "/"), "~0", "~")
+	}
+	return components
+}
+
+// attributeRef returns the attribute reference for a path, as used for private attributes.
+func attributeRef(path []string) ldattr.Ref {
+	if len(path) == 1 {
+		return ldattr.NewLiteralRef(path[0])
+	}
+	var b strings.Builder
+	for _, c := range path {
+		b.WriteString("/")
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(c, "~", "~0"), "/", "~1"))
+	}
+	return ldattr.NewRef(b.String())
+}
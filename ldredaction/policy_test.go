@@ -0,0 +1,204 @@
+package ldredaction
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hashOf(s string) string {
+	hash := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(hash[:])
+}
+
+func mustPolicy(t *testing.T, rules ...Rule) *Policy {
+	p, err := NewPolicy(rules...)
+	require.NoError(t, err)
+	return p
+}
+
+func assertContextEqual(t *testing.T, expected, actual ldcontext.Context) {
+	assert.True(t, expected.Equal(actual), "expected: %s\nactual: %s", expected, actual)
+}
+
+func TestNewPolicyValidatesRules(t *testing.T) {
+	_, err := NewPolicy(Rule{Action: "explode"})
+	assert.Error(t, err)
+
+	_, err = NewPolicy(Rule{Action: TruncateValue, TruncateLength: 0})
+	assert.Error(t, err)
+
+	_, err = NewPolicy(Rule{Action: RemoveValue, ValuePattern: "("})
+	assert.Error(t, err)
+
+	_, err = NewPolicy(Rule{Attribute: "kind", Action: HashValue})
+	assert.Error(t, err)
+
+	_, err = NewPolicy(Rule{Attribute: "/anonymous", Action: RemoveValue})
+	assert.Error(t, err)
+
+	_, err = NewPolicy(Rule{Attribute: "key", Action: RemoveValue})
+	assert.Error(t, err)
+
+	_, err = NewPolicy(Rule{Action: RemoveValue}, Rule{Action: HashValue},
+		Rule{Action: TruncateValue, TruncateLength: 1}, Rule{Attribute: "key", Action: HashValue})
+	assert.NoError(t, err)
+}
+
+func TestNilOrEmptyPolicyDoesNothing(t *testing.T) {
+	context := ldcontext.NewBuilder("a").SetString("email", "x@example.com").Build()
+	var nilPolicy *Policy
+	assert.Equal(t, context, nilPolicy.Apply(context))
+	assert.Equal(t, context, mustPolicy(t).Apply(context))
+}
+
+func TestContextIsUnchangedIfNoRulesApply(t *testing.T) {
+	p := mustPolicy(t, Rule{Attribute: "email", Action: RemoveValue})
+	context := ldcontext.NewBuilder("a").SetString("name", "b").Build()
+	assert.Equal(t, context, p.Apply(context))
+}
+
+func TestRemoveByAttributeName(t *testing.T) {
+	p := mustPolicy(t, Rule{Attribute: "email", Action: RemoveValue})
+	context := ldcontext.NewBuilder("a").SetString("email", "x@example.com").SetString("name", "b").Build()
+
+	expected := ldcontext.NewBuilderFromContext(context).Private("email").Build()
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestRemoveAddsToExistingPrivateAttributes(t *testing.T) {
+	p := mustPolicy(t, Rule{Attribute: "email", Action: RemoveValue})
+	context := ldcontext.NewBuilder("a").SetString("email", "x").Name("b").Private("name").Build()
+
+	expected := ldcontext.NewBuilderFromContext(context).Private("email").Build()
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestRemoveByPathPattern(t *testing.T) {
+	p := mustPolicy(t, Rule{Attribute: "/address/*", Action: RemoveValue})
+	context := ldcontext.NewBuilder("a").
+		SetValue("address", ldvalue.Parse([]byte(`{"street":"x","city":"y","geo":{"lat":1}}`))).
+		SetString("street", "z").
+		Build()
+
+	expected := ldcontext.NewBuilderFromContext(context).
+		Private("/address/city", "/address/geo", "/address/street").
+		Build()
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestPathWithEscapedCharacters(t *testing.T) {
+	p := mustPolicy(t, Rule{Attribute: "/a~1b/c~0d", Action: RemoveValue})
+	context := ldcontext.NewBuilder("a").SetValue("a/b", ldvalue.Parse([]byte(`{"c~d":1,"e":2}`))).Build()
+
+	expected := ldcontext.NewBuilderFromContext(context).Private("/a~1b/c~0d").Build()
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestHashByValuePattern(t *testing.T) {
+	p := mustPolicy(t, Rule{ValuePattern: `^[^@]+@[^@]+$`, Action: HashValue})
+	context := ldcontext.NewBuilder("me@example.com").
+		SetString("email", "x@example.com").
+		Name("b").
+		SetValue("prefs", ldvalue.ObjectBuild().SetString("alt", "y@example.com").SetInt("n", 1).Build()).
+		Build()
+
+	expected := ldcontext.NewBuilder(hashOf("me@example.com")).
+		SetString("email", hashOf("x@example.com")).
+		Name("b").
+		SetValue("prefs", ldvalue.ObjectBuild().SetString("alt", hashOf("y@example.com")).SetInt("n", 1).Build()).
+		Build()
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestHashKey(t *testing.T) {
+	p := mustPolicy(t, Rule{ContextKind: "user", Attribute: "key", Action: HashValue})
+	context := ldcontext.NewMulti(
+		ldcontext.NewBuilder("u").Name("b").Build(),
+		ldcontext.NewBuilder("o").Kind("org").Build(),
+	)
+
+	expected := ldcontext.NewMulti(
+		ldcontext.NewBuilder(hashOf("u")).Name("b").Build(),
+		ldcontext.NewBuilder("o").Kind("org").Build(),
+	)
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestRemoveRuleDoesNotApplyToKey(t *testing.T) {
+	p := mustPolicy(t,
+		Rule{ValuePattern: "@", Action: RemoveValue},
+		Rule{ValuePattern: "@", Action: TruncateValue, TruncateLength: 2},
+	)
+	context := ldcontext.NewBuilder("me@example.com").SetString("email", "x@example.com").Build()
+
+	expected := ldcontext.NewBuilder("me").SetString("email", "x@example.com").Private("email").Build()
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestHashAndTruncateOnlyApplyToStrings(t *testing.T) {
+	p := mustPolicy(t,
+		Rule{Attribute: "a", Action: HashValue},
+		Rule{Attribute: "b", Action: TruncateValue, TruncateLength: 2},
+	)
+	context := ldcontext.NewBuilder("k").SetInt("a", 1).SetBool("b", true).Build()
+	assert.Equal(t, context, p.Apply(context))
+}
+
+func TestTruncate(t *testing.T) {
+	p := mustPolicy(t, Rule{Attribute: "name", Action: TruncateValue, TruncateLength: 3})
+
+	context := ldcontext.NewBuilder("k").Name("ébcdef").Build()
+	assertContextEqual(t, ldcontext.NewBuilder("k").Name("ébc").Build(), p.Apply(context))
+
+	context = ldcontext.NewBuilder("k").Name("ab").Build()
+	assert.Equal(t, context, p.Apply(context))
+}
+
+func TestRuleForContextKind(t *testing.T) {
+	p := mustPolicy(t, Rule{ContextKind: "org", Attribute: "name", Action: RemoveValue})
+
+	userContext := ldcontext.NewBuilder("a").Name("b").Build()
+	assert.Equal(t, userContext, p.Apply(userContext))
+
+	orgContext := ldcontext.NewBuilder("a").Kind("org").Name("b").Build()
+	assertContextEqual(t, ldcontext.NewBuilderFromContext(orgContext).Private("name").Build(), p.Apply(orgContext))
+}
+
+func TestMultiKindContext(t *testing.T) {
+	p := mustPolicy(t, Rule{ContextKind: "org", Attribute: "name", Action: RemoveValue})
+	userContext := ldcontext.NewBuilder("u").Name("b").Build()
+	orgContext := ldcontext.NewBuilder("o").Kind("org").Name("c").Build()
+
+	expected := ldcontext.NewMulti(userContext, ldcontext.NewBuilderFromContext(orgContext).Private("name").Build())
+	assertContextEqual(t, expected, p.Apply(ldcontext.NewMulti(userContext, orgContext)))
+}
+
+func TestFirstMatchingRuleWins(t *testing.T) {
+	p := mustPolicy(t,
+		Rule{Attribute: "email", Action: TruncateValue, TruncateLength: 1},
+		Rule{Attribute: "email", Action: RemoveValue},
+	)
+	context := ldcontext.NewBuilder("a").SetString("email", "xyz").Build()
+	assertContextEqual(t, ldcontext.NewBuilder("a").SetString("email", "x").Build(), p.Apply(context))
+}
+
+func TestProtectedAttributesAreNotRedacted(t *testing.T) {
+	p := mustPolicy(t, Rule{Action: RemoveValue})
+	context := ldcontext.NewBuilder("a").Anonymous(true).Name("b").Build()
+
+	expected := ldcontext.NewBuilderFromContext(context).Private("name").Build()
+	assertContextEqual(t, expected, p.Apply(context))
+}
+
+func TestInvalidContextIsUnchanged(t *testing.T) {
+	p := mustPolicy(t, Rule{Action: HashValue})
+	context := ldcontext.New("")
+	assert.Equal(t, context, p.Apply(context))
+}
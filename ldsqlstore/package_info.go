@@ -0,0 +1,29 @@
+// Package ldsqlstore provides a persistent data store for the LaunchDarkly SDK that keeps feature flag
+// data in a SQL database, using the standard database/sql package.
+//
+// It supports SQLite, PostgreSQL, and MySQL (see [Dialect]). It does not import any database driver
+// itself; the application must import a driver for its database, and either provide an open *sql.DB
+// with [DataStoreBuilder.DB] or specify the driver and data source name with [DataStoreBuilder.Open].
+// It is a separate module from the SDK, github.com/launchdarkly/go-server-sdk/ldsqlstore, so that
+// applications that do not use it do not need its dependencies.
+//
+//	import (
+//	    _ "github.com/lib/pq"
+//	)
+//
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(
+//	        ldsqlstore.DataStore(ldsqlstore.DialectPostgres).Open("postgres", "postgres://my-db-host/mydb"),
+//	    ),
+//	}
+//
+// The store creates its own tables the first time it is used, and upgrades them automatically if a later
+// version of the SDK uses a different schema. The table names start with [DefaultTablePrefix], or with a
+// different prefix that you specify with [DataStoreBuilder.TablePrefix]; use different prefixes to keep
+// data for several LaunchDarkly environments in the same database.
+//
+// The package also provides a Big Segment store (see [BigSegmentStore]), which keeps Big Segment
+// memberships in the same database. The SDK only reads from that store; to populate it, use a tool such
+// as [github.com/launchdarkly/go-server-sdk/v6/ldbigsegmentsync], which writes to it through the
+// [github.com/launchdarkly/go-server-sdk/v6/subsystems.BigSegmentStoreWriter] interface.
+package ldsqlstore
@@ -0,0 +1,83 @@
+package ldsqlstore
+
+import (
+	"database/sql"
+
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// BigSegmentStoreBuilder is a builder for configuring the SQL-based Big Segment store.
+//
+// Obtain an instance of this type by calling [BigSegmentStore]. After calling its methods to specify any
+// desired custom settings, wrap it in a BigSegmentsConfigurationBuilder by calling
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.BigSegments], and then store this in the
+// BigSegments field of [github.com/launchdarkly/go-server-sdk/v6.Config].
+//
+// Builder calls can be chained, for example:
+//
+//	config.BigSegments = ldcomponents.BigSegments(
+//	    ldsqlstore.BigSegmentStore(ldsqlstore.DialectMySQL).DB(myDB).TablePrefix("ld_prod_"),
+//	)
+//
+// You do not need to call the builder's Build method yourself; that will be done by the SDK.
+type BigSegmentStoreBuilder struct {
+	dialect        Dialect
+	db             *sql.DB
+	driverName     string
+	dataSourceName string
+	tablePrefix    string
+}
+
+// BigSegmentStore returns a configurable builder for a SQL-based Big Segment store that uses the
+// specified dialect. You must also specify a database with either [BigSegmentStoreBuilder.DB] or
+// [BigSegmentStoreBuilder.Open].
+//
+// The store that it creates also implements [subsystems.BigSegmentStoreWriter], so it can be populated
+// by a tool such as [github.com/launchdarkly/go-server-sdk/v6/ldbigsegmentsync].
+func BigSegmentStore(dialect Dialect) *BigSegmentStoreBuilder {
+	return &BigSegmentStoreBuilder{dialect: dialect, tablePrefix: DefaultTablePrefix}
+}
+
+// DB specifies an existing database handle for the store to use. The store does not close it when the
+// SDK client is closed.
+//
+// This overrides any previous call to [BigSegmentStoreBuilder.Open].
+func (b *BigSegmentStoreBuilder) DB(db *sql.DB) *BigSegmentStoreBuilder {
+	b.db = db
+	b.driverName, b.dataSourceName = "", ""
+	return b
+}
+
+// Open specifies that the store should open its own database handle with [sql.Open], using the
+// specified driver name and data source name. The driver must have been registered by importing its
+// package. The store closes the handle when the SDK client is closed.
+//
+// This overrides any previous call to [BigSegmentStoreBuilder.DB].
+func (b *BigSegmentStoreBuilder) Open(driverName, dataSourceName string) *BigSegmentStoreBuilder {
+	b.db = nil
+	b.driverName, b.dataSourceName = driverName, dataSourceName
+	return b
+}
+
+// TablePrefix specifies a string that is prepended to the names of the tables used by the store. It has
+// the same meaning as [DataStoreBuilder.TablePrefix], and if you are using both stores for the same
+// LaunchDarkly environment, you should use the same prefix for both.
+//
+// The default value is [DefaultTablePrefix] ("launchdarkly_"). If you specify an empty string, it uses
+// the default.
+func (b *BigSegmentStoreBuilder) TablePrefix(tablePrefix string) *BigSegmentStoreBuilder {
+	if tablePrefix == "" {
+		tablePrefix = DefaultTablePrefix
+	}
+	b.tablePrefix = tablePrefix
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *BigSegmentStoreBuilder) Build(context subsystems.ClientContext) (subsystems.BigSegmentStore, error) {
+	db, closeDB, err := openDatabase(b.dialect, b.db, b.driverName, b.dataSourceName, b.tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLBigSegmentStoreImpl(db, closeDB, b.dialect, b.tablePrefix, context.GetLogging().Loggers), nil
+}
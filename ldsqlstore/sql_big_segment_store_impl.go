@@ -0,0 +1,265 @@
+package ldsqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+)
+
+// Internal implementation of the SQL-based BigSegmentStore.
+//
+// Memberships are in the "big_segments" table, with one row for each combination of context hash, segment
+// reference, and included/excluded state. The primary key starts with the context hash, since querying a
+// context's memberships is the operation that the SDK does during evaluations. The sync time and cursor
+// are rows in the same metadata table that is used by the data store.
+
+const (
+	bigSegmentsSyncedOnKey = "$bigSegmentsSyncedOn"
+	bigSegmentsCursorKey   = "$bigSegmentsCursor"
+
+	// maxContextHashesPerQuery limits the number of parameters in a GetMemberships query, since some
+	// databases have a low limit on the number of parameters in a statement.
+	maxContextHashesPerQuery = 500
+)
+
+type sqlBigSegmentStoreImpl struct {
+	db         *sql.DB
+	closeDB    bool
+	dialect    Dialect
+	schema     *sqlSchema
+	statements sqlStatements
+	loggers    ldlog.Loggers
+}
+
+func newSQLBigSegmentStoreImpl(
+	db *sql.DB,
+	closeDB bool,
+	dialect Dialect,
+	tablePrefix string,
+	loggers ldlog.Loggers,
+) *sqlBigSegmentStoreImpl {
+	loggers.SetPrefix("SQLBigSegmentStore:")
+	loggers.Infof("Using %s database with table prefix %s", dialect, tablePrefix)
+	statements := makeSQLStatements(dialect, tablePrefix)
+	return &sqlBigSegmentStoreImpl{
+		db:         db,
+		closeDB:    closeDB,
+		dialect:    dialect,
+		schema:     newSQLSchema(db, dialect, tablePrefix, statements, loggers),
+		statements: statements,
+		loggers:    loggers,
+	}
+}
+
+func (store *sqlBigSegmentStoreImpl) GetMetadata() (subsystems.BigSegmentStoreMetadata, error) {
+	value, err := store.getMetadataValue(bigSegmentsSyncedOnKey)
+	if err != nil || value == "" {
+		return subsystems.BigSegmentStoreMetadata{}, err
+	}
+	syncedOn, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return subsystems.BigSegmentStoreMetadata{}, fmt.Errorf("invalid Big Segments sync time %q", value)
+	}
+	return subsystems.BigSegmentStoreMetadata{LastUpToDate: ldtime.UnixMillisecondTime(syncedOn)}, nil
+}
+
+func (store *sqlBigSegmentStoreImpl) GetMembership(contextHash string) (subsystems.BigSegmentMembership, error) {
+	if err := store.schema.ensure(); err != nil {
+		return nil, err
+	}
+	rows, err := store.db.Query(store.statements.getBigSegmentMembership, contextHash)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var included, excluded []string
+	for rows.Next() {
+		var segmentRef string
+		var isIncluded int
+		if err := rows.Scan(&segmentRef, &isIncluded); err != nil {
+			return nil, err
+		}
+		if isIncluded != 0 {
+			included = append(included, segmentRef)
+		} else {
+			excluded = append(excluded, segmentRef)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(included, excluded), nil
+}
+
+func (store *sqlBigSegmentStoreImpl) GetMemberships(
+	contextHashes []string,
+) (map[string]subsystems.BigSegmentMembership, error) {
+	if err := store.schema.ensure(); err != nil {
+		return nil, err
+	}
+	included, excluded := make(map[string][]string), make(map[string][]string)
+	for start := 0; start < len(contextHashes); start += maxContextHashesPerQuery {
+		end := start + maxContextHashesPerQuery
+		if end > len(contextHashes) {
+			end = len(contextHashes)
+		}
+		if err := store.queryMemberships(contextHashes[start:end], included, excluded); err != nil {
+			return nil, err
+		}
+	}
+	ret := make(map[string]subsystems.BigSegmentMembership, len(contextHashes))
+	for _, contextHash := range contextHashes {
+		ret[contextHash] = ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(
+			included[contextHash], excluded[contextHash])
+	}
+	return ret, nil
+}
+
+func (store *sqlBigSegmentStoreImpl) queryMemberships(
+	contextHashes []string,
+	included, excluded map[string][]string,
+) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(contextHashes)), ", ")
+	query := store.dialect.rebind(store.statements.getBigSegmentMembershipsPrefix + "(" + placeholders + ")")
+	args := make([]interface{}, 0, len(contextHashes))
+	for _, contextHash := range contextHashes {
+		args = append(args, contextHash)
+	}
+	rows, err := store.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var contextHash, segmentRef string
+		var isIncluded int
+		if err := rows.Scan(&contextHash, &segmentRef, &isIncluded); err != nil {
+			return err
+		}
+		if isIncluded != 0 {
+			included[contextHash] = append(included[contextHash], segmentRef)
+		} else {
+			excluded[contextHash] = append(excluded[contextHash], segmentRef)
+		}
+	}
+	return rows.Err()
+}
+
+func (store *sqlBigSegmentStoreImpl) ApplyMembershipDelta(delta subsystems.BigSegmentMembershipDelta) error {
+	return store.inTransaction(func(tx *sql.Tx) error {
+		for _, change := range []struct {
+			statement     string
+			included      bool
+			contextHashes []string
+		}{
+			{store.statements.deleteBigSegmentMember, true, delta.RemoveIncluded},
+			{store.statements.deleteBigSegmentMember, false, delta.RemoveExcluded},
+			{store.statements.insertBigSegmentMember, true, delta.AddIncluded},
+			{store.statements.insertBigSegmentMember, false, delta.AddExcluded},
+		} {
+			if err := execForEach(tx, change.statement, change.contextHashes, delta.SegmentRef, change.included); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (store *sqlBigSegmentStoreImpl) ReplaceSegmentMembership(
+	segmentRef string,
+	includedContextHashes, excludedContextHashes []string,
+) error {
+	return store.inTransaction(func(tx *sql.Tx) error {
+		if _, err := tx.Exec(store.statements.deleteSegmentMembers, segmentRef); err != nil {
+			return err
+		}
+		insert := store.statements.insertBigSegmentMember
+		if err := execForEach(tx, insert, includedContextHashes, segmentRef, true); err != nil {
+			return err
+		}
+		return execForEach(tx, insert, excludedContextHashes, segmentRef, false)
+	})
+}
+
+func (store *sqlBigSegmentStoreImpl) SetSyncedOn(syncedOn ldtime.UnixMillisecondTime) error {
+	return store.setMetadataValue(bigSegmentsSyncedOnKey, strconv.FormatUint(uint64(syncedOn), 10))
+}
+
+func (store *sqlBigSegmentStoreImpl) GetSyncCursor() (string, error) {
+	return store.getMetadataValue(bigSegmentsCursorKey)
+}
+
+func (store *sqlBigSegmentStoreImpl) SetSyncCursor(cursor string) error {
+	return store.setMetadataValue(bigSegmentsCursorKey, cursor)
+}
+
+func (store *sqlBigSegmentStoreImpl) Close() error {
+	if store.closeDB {
+		return store.db.Close()
+	}
+	return nil
+}
+
+// getMetadataValue returns an empty string, rather than an error, if there is no such row.
+func (store *sqlBigSegmentStoreImpl) getMetadataValue(key string) (string, error) {
+	if err := store.schema.ensure(); err != nil {
+		return "", err
+	}
+	var value string
+	err := store.db.QueryRow(store.statements.getMetadata, key).Scan(&value)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return value, nil
+}
+
+func (store *sqlBigSegmentStoreImpl) setMetadataValue(key, value string) error {
+	if err := store.schema.ensure(); err != nil {
+		return err
+	}
+	_, err := store.db.Exec(store.statements.setMetadata, key, value)
+	return err
+}
+
+func (store *sqlBigSegmentStoreImpl) inTransaction(action func(*sql.Tx) error) error {
+	if err := store.schema.ensure(); err != nil {
+		return err
+	}
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // has no effect if the transaction was committed
+	if err := action(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execForEach executes a membership statement, whose parameters are the context hash, the segment
+// reference, and the included state, once for each context hash.
+func execForEach(tx *sql.Tx, statement string, contextHashes []string, segmentRef string, included bool) error {
+	if len(contextHashes) == 0 {
+		return nil
+	}
+	prepared, err := tx.Prepare(statement)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = prepared.Close() }()
+	for _, contextHash := range contextHashes {
+		if _, err := prepared.Exec(contextHash, segmentRef, boolToInt(included)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
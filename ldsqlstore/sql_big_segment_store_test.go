@@ -0,0 +1,147 @@
+package ldsqlstore
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLBigSegmentStoreWithSQLite(t *testing.T) {
+	db := openTestDB(t)
+	storeFactory := func(prefix string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+		return BigSegmentStore(DialectSQLite).DB(db).TablePrefix(prefix)
+	}
+	clearDataFn := func(prefix string) error {
+		return clearData(db, prefix)
+	}
+
+	t.Run("BigSegmentStore", func(t *testing.T) {
+		storetest.NewBigSegmentStoreTestSuite(
+			storeFactory,
+			clearDataFn,
+			func(prefix string, metadata subsystems.BigSegmentStoreMetadata) error {
+				return newTestBigSegmentStore(db, prefix).SetSyncedOn(metadata.LastUpToDate)
+			},
+			func(prefix, contextHash string, included []string, excluded []string) error {
+				store := newTestBigSegmentStore(db, prefix)
+				if err := store.schema.ensure(); err != nil {
+					return err
+				}
+				for _, segmentRefs := range []struct {
+					refs     []string
+					included bool
+				}{{included, true}, {excluded, false}} {
+					for _, ref := range segmentRefs.refs {
+						if _, err := db.Exec(store.statements.insertBigSegmentMember,
+							contextHash, ref, boolToInt(segmentRefs.included)); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		).Run(t)
+	})
+
+	t.Run("BigSegmentStoreWriter", func(t *testing.T) {
+		storetest.NewBigSegmentStoreWriterTestSuite(storeFactory, clearDataFn).Run(t)
+	})
+}
+
+func TestSQLBigSegmentStoreBuilder(t *testing.T) {
+	context := makeTestContext()
+
+	t.Run("DB", func(t *testing.T) {
+		db := openTestDB(t)
+		store, err := BigSegmentStore(DialectSQLite).DB(db).Build(context)
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+		assert.NoError(t, db.Ping(), "store should not have closed a database that it did not open")
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		store, err := BigSegmentStore(DialectSQLite).Open("sqlite3", ":memory:").Build(context)
+		require.NoError(t, err)
+		db := store.(*sqlBigSegmentStoreImpl).db
+		_, err = store.GetMetadata()
+		assert.NoError(t, err)
+		require.NoError(t, store.Close())
+		assert.Error(t, db.Ping(), "store should have closed the database that it opened")
+	})
+
+	t.Run("no database", func(t *testing.T) {
+		_, err := BigSegmentStore(DialectSQLite).Build(context)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid TablePrefix", func(t *testing.T) {
+		_, err := BigSegmentStore(DialectSQLite).DB(openTestDB(t)).TablePrefix("a-b").Build(context)
+		assert.Error(t, err)
+	})
+}
+
+func TestSQLBigSegmentStoreSharesSchemaWithDataStore(t *testing.T) {
+	db := openTestDB(t)
+	dataStore := newSQLDataStoreImpl(db, false, DialectSQLite, DefaultTablePrefix, ldlog.NewDisabledLoggers())
+	require.NoError(t, dataStore.Init(nil))
+
+	bigSegmentStore := newTestBigSegmentStore(db, DefaultTablePrefix)
+	require.NoError(t, bigSegmentStore.SetSyncedOn(1000))
+	require.NoError(t, bigSegmentStore.ReplaceSegmentMembership("key1", []string{"hash1"}, nil))
+
+	assert.True(t, dataStore.IsInitialized())
+	meta, err := bigSegmentStore.GetMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, 1000, int(meta.LastUpToDate))
+}
+
+func TestSQLBigSegmentStoreUpgradesOlderSchema(t *testing.T) {
+	db := openTestDB(t)
+	statements := makeSQLStatements(DialectSQLite, DefaultTablePrefix)
+	_, err := db.Exec(statements.createMetadataTable)
+	require.NoError(t, err)
+	for _, statement := range schemaMigrations[0](DialectSQLite, DefaultTablePrefix) {
+		_, err = db.Exec(statement)
+		require.NoError(t, err)
+	}
+	_, err = db.Exec(statements.setMetadata, schemaVersionKey, "1")
+	require.NoError(t, err)
+
+	store := newTestBigSegmentStore(db, DefaultTablePrefix)
+	require.NoError(t, store.ReplaceSegmentMembership("key1", []string{"hash1"}, nil))
+	membership, err := store.GetMembership("hash1")
+	require.NoError(t, err)
+	assert.True(t, membership.CheckMembership("key1").BoolValue())
+}
+
+func newTestBigSegmentStore(db *sql.DB, prefix string) *sqlBigSegmentStoreImpl {
+	if prefix == "" {
+		prefix = DefaultTablePrefix
+	}
+	return newSQLBigSegmentStoreImpl(db, false, DialectSQLite, prefix, ldlog.NewDisabledLoggers())
+}
+
+func TestSQLBigSegmentStoreGetMembershipsWithManyContexts(t *testing.T) {
+	store := newTestBigSegmentStore(openTestDB(t), DefaultTablePrefix)
+	var hashes []string
+	for i := 0; i < maxContextHashesPerQuery*2+1; i++ {
+		hashes = append(hashes, "hash"+strconv.Itoa(i))
+	}
+	require.NoError(t, store.ReplaceSegmentMembership("key1", hashes[1:], []string{hashes[0]}))
+
+	memberships, err := store.GetMemberships(hashes)
+	require.NoError(t, err)
+	require.Len(t, memberships, len(hashes))
+	assert.Equal(t, ldvalue.NewOptionalBool(false), memberships[hashes[0]].CheckMembership("key1"))
+	for _, hash := range hashes[1:] {
+		assert.Equal(t, ldvalue.NewOptionalBool(true), memberships[hash].CheckMembership("key1"), hash)
+	}
+}
@@ -0,0 +1,122 @@
+package ldsqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// DefaultTablePrefix is the string that is prepended to the names of the tables used by the data
+// store, if you do not specify a different prefix with [DataStoreBuilder.TablePrefix].
+const DefaultTablePrefix = "launchdarkly_"
+
+var validTablePrefix = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`) //nolint:gochecknoglobals
+
+// DataStoreBuilder is a builder for configuring the SQL-based persistent data store.
+//
+// Obtain an instance of this type by calling [DataStore]. After calling its methods to specify any
+// desired custom settings, wrap it in a PersistentDataStoreBuilder by calling
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStore], and then store this in
+// the DataStore field of [github.com/launchdarkly/go-server-sdk/v6.Config].
+//
+// Builder calls can be chained, for example:
+//
+//	config.DataStore = ldcomponents.PersistentDataStore(
+//	    ldsqlstore.DataStore(ldsqlstore.DialectMySQL).DB(myDB).TablePrefix("ld_prod_"),
+//	)
+//
+// You do not need to call the builder's Build method yourself; that will be done by the SDK.
+type DataStoreBuilder struct {
+	dialect        Dialect
+	db             *sql.DB
+	driverName     string
+	dataSourceName string
+	tablePrefix    string
+}
+
+// DataStore returns a configurable builder for a SQL-based persistent data store that uses the
+// specified dialect. You must also specify a database with either [DataStoreBuilder.DB] or
+// [DataStoreBuilder.Open].
+func DataStore(dialect Dialect) *DataStoreBuilder {
+	return &DataStoreBuilder{dialect: dialect, tablePrefix: DefaultTablePrefix}
+}
+
+// DB specifies an existing database handle for the data store to use. The data store does not close
+// it when the SDK client is closed.
+//
+// This overrides any previous call to [DataStoreBuilder.Open].
+func (b *DataStoreBuilder) DB(db *sql.DB) *DataStoreBuilder {
+	b.db = db
+	b.driverName, b.dataSourceName = "", ""
+	return b
+}
+
+// Open specifies that the data store should open its own database handle with [sql.Open], using the
+// specified driver name and data source name. The driver must have been registered by importing its
+// package. The data store closes the handle when the SDK client is closed.
+//
+// This overrides any previous call to [DataStoreBuilder.DB].
+func (b *DataStoreBuilder) Open(driverName, dataSourceName string) *DataStoreBuilder {
+	b.db = nil
+	b.driverName, b.dataSourceName = driverName, dataSourceName
+	return b
+}
+
+// TablePrefix specifies a string that is prepended to the names of the tables used by the data store,
+// to distinguish them from other tables in the database. Use a different prefix for each LaunchDarkly
+// environment whose data is stored in the same database.
+//
+// The prefix can contain only ASCII letters, digits, and underscores, and cannot start with a digit.
+// The default value is [DefaultTablePrefix] ("launchdarkly_"). If you specify an empty string, it uses
+// the default.
+func (b *DataStoreBuilder) TablePrefix(tablePrefix string) *DataStoreBuilder {
+	if tablePrefix == "" {
+		tablePrefix = DefaultTablePrefix
+	}
+	b.tablePrefix = tablePrefix
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *DataStoreBuilder) Build(context subsystems.ClientContext) (subsystems.PersistentDataStore, error) {
+	db, closeDB, err := openDatabase(b.dialect, b.db, b.driverName, b.dataSourceName, b.tablePrefix)
+	if err != nil {
+		return nil, err
+	}
+	return newSQLDataStoreImpl(db, closeDB, b.dialect, b.tablePrefix, context.GetLogging().Loggers), nil
+}
+
+// DescribeConfiguration is used internally by the SDK to inspect the configuration.
+func (b *DataStoreBuilder) DescribeConfiguration(context subsystems.ClientContext) ldvalue.Value {
+	return ldvalue.String("SQL")
+}
+
+// openDatabase validates the configuration that is common to the data store and the Big Segment store,
+// and opens a database handle if one was not provided. The closeDB result is true if the store should
+// close the handle.
+func openDatabase(
+	dialect Dialect,
+	db *sql.DB,
+	driverName, dataSourceName, tablePrefix string,
+) (result *sql.DB, closeDB bool, err error) {
+	if err := dialect.validate(); err != nil {
+		return nil, false, err
+	}
+	if !validTablePrefix.MatchString(tablePrefix) {
+		return nil, false, fmt.Errorf("invalid table prefix %q", tablePrefix)
+	}
+	if db != nil {
+		return db, false, nil
+	}
+	if driverName == "" {
+		return nil, false, errors.New("no database was specified for the SQL data store")
+	}
+	if db, err = sql.Open(driverName, dataSourceName); err != nil {
+		return nil, false, err
+	}
+	return db, true, nil
+}
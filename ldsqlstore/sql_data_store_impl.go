@@ -0,0 +1,243 @@
+package ldsqlstore
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// Internal implementation of the SQL-based PersistentDataStore.
+//
+// The store uses two tables, whose names begin with the configured table prefix:
+//
+// 1. "items" contains one row per data item, keyed by the namespace of its data kind (such as "features")
+// and its key. The version and deleted state are stored in their own columns, so that we never need to
+// deserialize items in order to compare versions.
+//
+// 2. "metadata" contains name-value pairs. The presence of the "$inited" row indicates that Init has been
+// called at least once. See sqlSchema for other rows.
+//
+// The schema is created or upgraded the first time the store is used, rather than when it is built, so
+// that a database outage at startup time is handled like any other database error.
+
+const initedKey = "$inited"
+
+type sqlDataStoreImpl struct {
+	db             *sql.DB
+	closeDB        bool
+	schema         *sqlSchema
+	statements     sqlStatements
+	loggers        ldlog.Loggers
+	testUpsertHook func()
+}
+
+func newSQLDataStoreImpl(
+	db *sql.DB,
+	closeDB bool,
+	dialect Dialect,
+	tablePrefix string,
+	loggers ldlog.Loggers,
+) *sqlDataStoreImpl {
+	loggers.SetPrefix("SQLDataStore:")
+	loggers.Infof("Using %s database with table prefix %s", dialect, tablePrefix)
+	statements := makeSQLStatements(dialect, tablePrefix)
+	return &sqlDataStoreImpl{
+		db:         db,
+		closeDB:    closeDB,
+		schema:     newSQLSchema(db, dialect, tablePrefix, statements, loggers),
+		statements: statements,
+		loggers:    loggers,
+	}
+}
+
+func (store *sqlDataStoreImpl) Init(allData []ldstoretypes.SerializedCollection) error {
+	if err := store.schema.ensure(); err != nil {
+		return err
+	}
+	tx, err := store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // has no effect if the transaction was committed
+
+	if _, err := tx.Exec(store.statements.deleteAllItems); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(store.statements.insertItem)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insert.Close() }()
+	for _, coll := range allData {
+		for _, keyedItem := range coll.Items {
+			item := keyedItem.Item
+			if _, err := insert.Exec(coll.Kind.GetName(), keyedItem.Key, item.Version, boolToInt(item.Deleted),
+				item.SerializedItem); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.Exec(store.statements.setMetadata, initedKey, "1"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (store *sqlDataStoreImpl) Get(
+	kind ldstoretypes.DataKind,
+	key string,
+) (ldstoretypes.SerializedItemDescriptor, error) {
+	if err := store.schema.ensure(); err != nil {
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	row := store.db.QueryRow(store.statements.getItem, kind.GetName(), key)
+	item, err := scanItem(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if store.loggers.IsDebugEnabled() {
+				store.loggers.Debugf("Key: %s not found in \"%s\"", key, kind.GetName())
+			}
+			return ldstoretypes.SerializedItemDescriptor{}.NotFound(), nil
+		}
+		return ldstoretypes.SerializedItemDescriptor{}.NotFound(), err
+	}
+	return item, nil
+}
+
+func (store *sqlDataStoreImpl) GetAll(
+	kind ldstoretypes.DataKind,
+) ([]ldstoretypes.KeyedSerializedItemDescriptor, error) {
+	if err := store.schema.ensure(); err != nil {
+		return nil, err
+	}
+	rows, err := store.db.Query(store.statements.getAllItems, kind.GetName())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var results []ldstoretypes.KeyedSerializedItemDescriptor
+	for rows.Next() {
+		var key string
+		item, err := scanItem(rows, &key)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, ldstoretypes.KeyedSerializedItemDescriptor{Key: key, Item: item})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (store *sqlDataStoreImpl) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	newItem ldstoretypes.SerializedItemDescriptor,
+) (bool, error) {
+	if err := store.schema.ensure(); err != nil {
+		return false, err
+	}
+
+	// Reading the old version first means that in the common case where the item has not changed, for
+	// instance because several SDK instances are receiving the same update, we do not need to write.
+	var oldVersion int
+	err := store.db.QueryRow(store.statements.getVersion, kind.GetName(), key).Scan(&oldVersion)
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	if found && oldVersion >= newItem.Version {
+		if store.loggers.IsDebugEnabled() {
+			store.loggers.Debugf(
+				`Attempted to update key: %s version: %d in "%s" with a version that is the same or older: %d`,
+				key, oldVersion, kind.GetName(), newItem.Version)
+		}
+		return false, nil
+	}
+
+	if store.testUpsertHook != nil {
+		store.testUpsertHook()
+	}
+
+	// Another SDK instance may have written the same item since we read it, so the writes below are
+	// conditional. If there was no item, we insert one unless it has been inserted in the meantime; if
+	// there was an item, or it has just been inserted, we update it only if our version is newer.
+	if !found {
+		result, err := store.db.Exec(store.statements.insertItemIfAbsent,
+			kind.GetName(), key, newItem.Version, boolToInt(newItem.Deleted), newItem.SerializedItem)
+		if err != nil {
+			return false, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+	}
+	result, err := store.db.Exec(store.statements.updateItemIfNewer,
+		newItem.Version, boolToInt(newItem.Deleted), newItem.SerializedItem, kind.GetName(), key, newItem.Version)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n == 0 {
+		if store.loggers.IsDebugEnabled() {
+			store.loggers.Debugf(`Key: %s in "%s" was updated to version %d or higher by another process`,
+				key, kind.GetName(), newItem.Version)
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (store *sqlDataStoreImpl) IsInitialized() bool {
+	if store.schema.ensure() != nil {
+		return false
+	}
+	var value string
+	err := store.db.QueryRow(store.statements.getMetadata, initedKey).Scan(&value)
+	return err == nil
+}
+
+func (store *sqlDataStoreImpl) IsStoreAvailable() bool {
+	return store.db.Ping() == nil
+}
+
+func (store *sqlDataStoreImpl) Close() error {
+	if store.closeDB {
+		return store.db.Close()
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanItem reads the version, deleted, and item_data columns from a row, preceded by any other columns
+// specified in otherDest.
+func scanItem(row rowScanner, otherDest ...interface{}) (ldstoretypes.SerializedItemDescriptor, error) {
+	var item ldstoretypes.SerializedItemDescriptor
+	var deleted int
+	if err := row.Scan(append(otherDest, &item.Version, &deleted, &item.SerializedItem)...); err != nil {
+		return item, err
+	}
+	item.Deleted = deleted != 0
+	return item, nil
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+package ldsqlstore
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/storetest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	dsn := "file:" + filepath.Join(t.TempDir(), "test.db") + "?_busy_timeout=5000"
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestSQLDataStoreWithSQLite(t *testing.T) {
+	db := openTestDB(t)
+
+	errorDB, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	require.NoError(t, errorDB.Close()) // all operations on a closed database return an error
+
+	storetest.NewPersistentDataStoreTestSuite(
+		func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+			return DataStore(DialectSQLite).DB(db).TablePrefix(prefix)
+		},
+		func(prefix string) error {
+			return clearData(db, prefix)
+		},
+	).ErrorStoreFactory(
+		DataStore(DialectSQLite).DB(errorDB),
+		nil,
+	).ConcurrentModificationHook(
+		func(store subsystems.PersistentDataStore, hook func()) {
+			store.(*sqlDataStoreImpl).testUpsertHook = hook
+		},
+	).Run(t)
+}
+
+func TestSQLDataStoreBuilder(t *testing.T) {
+	context := makeTestContext()
+
+	t.Run("DB", func(t *testing.T) {
+		db := openTestDB(t)
+		store, err := DataStore(DialectSQLite).DB(db).Build(context)
+		require.NoError(t, err)
+		require.NoError(t, store.Close())
+		assert.NoError(t, db.Ping(), "store should not have closed a database that it did not open")
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		store, err := DataStore(DialectSQLite).Open("sqlite3", ":memory:").Build(context)
+		require.NoError(t, err)
+		db := store.(*sqlDataStoreImpl).db
+		assert.True(t, store.IsStoreAvailable())
+		require.NoError(t, store.Close())
+		assert.Error(t, db.Ping(), "store should have closed the database that it opened")
+	})
+
+	t.Run("no database", func(t *testing.T) {
+		_, err := DataStore(DialectSQLite).Build(context)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown dialect", func(t *testing.T) {
+		_, err := DataStore(Dialect("oracle")).DB(openTestDB(t)).Build(context)
+		assert.Error(t, err)
+	})
+
+	t.Run("TablePrefix", func(t *testing.T) {
+		for _, prefix := range []string{"a", "_a", "my_prefix_1_"} {
+			_, err := DataStore(DialectSQLite).DB(openTestDB(t)).TablePrefix(prefix).Build(context)
+			assert.NoError(t, err, prefix)
+		}
+		for _, prefix := range []string{"1a", "a-b", "a;DROP TABLE x;"} {
+			_, err := DataStore(DialectSQLite).DB(openTestDB(t)).TablePrefix(prefix).Build(context)
+			assert.Error(t, err, prefix)
+		}
+	})
+}
+
+func TestSQLDataStoreSchemaMigration(t *testing.T) {
+	db := openTestDB(t)
+	store := newSQLDataStoreImpl(db, false, DialectSQLite, DefaultTablePrefix, ldlog.NewDisabledLoggers())
+
+	flag := ldbuilders.NewFlagBuilder("a").Version(1).Build()
+	require.NoError(t, store.Init([]st.SerializedCollection{
+		{Kind: ldstoreimpl.Features(), Items: []st.KeyedSerializedItemDescriptor{{
+			Key: "a",
+			Item: st.SerializedItemDescriptor{
+				Version:        1,
+				SerializedItem: ldstoreimpl.Features().Serialize(st.ItemDescriptor{Version: 1, Item: &flag}),
+			},
+		}}},
+	}))
+
+	var value string
+	require.NoError(t, db.QueryRow("SELECT meta_value FROM launchdarkly_metadata WHERE meta_key = ?",
+		schemaVersionKey).Scan(&value))
+	assert.Equal(t, strconv.Itoa(len(schemaMigrations)), value)
+
+	t.Run("a new store instance does not recreate existing tables", func(t *testing.T) {
+		store2 := newSQLDataStoreImpl(db, false, DialectSQLite, DefaultTablePrefix, ldlog.NewDisabledLoggers())
+		assert.True(t, store2.IsInitialized())
+		item, err := store2.Get(ldstoreimpl.Features(), "a")
+		require.NoError(t, err)
+		assert.Equal(t, 1, item.Version)
+	})
+
+	t.Run("an invalid schema version is an error", func(t *testing.T) {
+		_, err := db.Exec("UPDATE launchdarkly_metadata SET meta_value = 'x' WHERE meta_key = ?", schemaVersionKey)
+		require.NoError(t, err)
+		store3 := newSQLDataStoreImpl(db, false, DialectSQLite, DefaultTablePrefix, ldlog.NewDisabledLoggers())
+		_, err = store3.Get(ldstoreimpl.Features(), "a")
+		assert.Error(t, err)
+	})
+}
+
+func makeTestContext() subsystems.ClientContext {
+	return subsystems.BasicClientContext{
+		Logging: subsystems.LoggingConfiguration{Loggers: ldlog.NewDisabledLoggers()},
+	}
+}
+
+func clearData(db *sql.DB, prefix string) error {
+	if prefix == "" {
+		prefix = DefaultTablePrefix
+	}
+	for _, table := range []string{prefix + "items", prefix + "big_segments", prefix + "metadata"} {
+		if _, err := db.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
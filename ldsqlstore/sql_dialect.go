@@ -0,0 +1,149 @@
+package ldsqlstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect identifies the flavor of SQL that is used by the database. It is a parameter to [DataStore].
+type Dialect string
+
+const (
+	// DialectSQLite is the dialect for SQLite 3.24 or later.
+	DialectSQLite Dialect = "sqlite"
+
+	// DialectPostgres is the dialect for PostgreSQL 9.5 or later.
+	DialectPostgres Dialect = "postgres"
+
+	// DialectMySQL is the dialect for MySQL 5.7 or later.
+	DialectMySQL Dialect = "mysql"
+)
+
+func (d Dialect) validate() error {
+	switch d {
+	case DialectSQLite, DialectPostgres, DialectMySQL:
+		return nil
+	default:
+		return fmt.Errorf("unknown SQL dialect %q", d)
+	}
+}
+
+// rebind converts a query that uses "?" placeholders into the placeholder syntax of the dialect. None
+// of our queries contain a literal question mark.
+func (d Dialect) rebind(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, ch := range query {
+		if ch == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+		} else {
+			b.WriteRune(ch)
+		}
+	}
+	return b.String()
+}
+
+func (d Dialect) blobType() string {
+	switch d {
+	case DialectPostgres:
+		return "BYTEA"
+	case DialectMySQL:
+		return "LONGBLOB"
+	default:
+		return "BLOB"
+	}
+}
+
+// insertIgnoringConflict returns an INSERT statement that does nothing, rather than failing, if there is
+// already a row with the same primary key.
+func (d Dialect) insertIgnoringConflict(table string, columns ...string) string {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	values := fmt.Sprintf("%s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders)
+	if d == DialectMySQL {
+		return "INSERT IGNORE INTO " + values
+	}
+	return "INSERT INTO " + values + " ON CONFLICT DO NOTHING"
+}
+
+// upsert returns a statement that inserts a row, or replaces the non-key columns if there is already a
+// row with the same primary key.
+func (d Dialect) upsert(table string, keyColumns []string, valueColumns []string) string {
+	columns := append(append([]string(nil), keyColumns...), valueColumns...)
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders)
+	updates := make([]string, 0, len(valueColumns))
+	for _, c := range valueColumns {
+		if d == DialectMySQL {
+			updates = append(updates, fmt.Sprintf("%s = VALUES(%s)", c, c))
+		} else {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", c, c))
+		}
+	}
+	if d == DialectMySQL {
+		return insert + " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	}
+	return fmt.Sprintf("%s ON CONFLICT (%s) DO UPDATE SET %s", insert, strings.Join(keyColumns, ", "),
+		strings.Join(updates, ", "))
+}
+
+// sqlStatements contains all of the SQL statements used by the data store and the Big Segment store, other
+// than the schema migrations. They are constructed once, since the table names are not known until the
+// store is built.
+type sqlStatements struct {
+	createMetadataTable            string
+	getMetadata                    string
+	setMetadata                    string
+	getItem                        string
+	getAllItems                    string
+	getVersion                     string
+	deleteAllItems                 string
+	insertItem                     string
+	insertItemIfAbsent             string
+	updateItemIfNewer              string
+	getBigSegmentMembership        string
+	getBigSegmentMembershipsPrefix string
+	insertBigSegmentMember         string
+	deleteBigSegmentMember         string
+	deleteSegmentMembers           string
+}
+
+func makeSQLStatements(d Dialect, tablePrefix string) sqlStatements {
+	itemsTable, metadataTable, bigSegmentsTable := tablePrefix+"items", tablePrefix+"metadata",
+		tablePrefix+"big_segments"
+	itemColumns := []string{"namespace", "item_key", "version", "deleted", "item_data"}
+	return sqlStatements{
+		createMetadataTable: `CREATE TABLE IF NOT EXISTS ` + metadataTable + ` (
+			meta_key VARCHAR(100) NOT NULL PRIMARY KEY,
+			meta_value VARCHAR(255) NOT NULL
+		)`,
+		getMetadata: d.rebind("SELECT meta_value FROM " + metadataTable + " WHERE meta_key = ?"),
+		setMetadata: d.rebind(d.upsert(metadataTable, []string{"meta_key"}, []string{"meta_value"})),
+		getItem: d.rebind("SELECT version, deleted, item_data FROM " + itemsTable +
+			" WHERE namespace = ? AND item_key = ?"),
+		getAllItems: d.rebind("SELECT item_key, version, deleted, item_data FROM " + itemsTable +
+			" WHERE namespace = ?"),
+		getVersion:     d.rebind("SELECT version FROM " + itemsTable + " WHERE namespace = ? AND item_key = ?"),
+		deleteAllItems: "DELETE FROM " + itemsTable,
+		insertItem: d.rebind(fmt.Sprintf("INSERT INTO %s (%s) VALUES (?, ?, ?, ?, ?)",
+			itemsTable, strings.Join(itemColumns, ", "))),
+		insertItemIfAbsent: d.rebind(d.insertIgnoringConflict(itemsTable, itemColumns...)),
+		updateItemIfNewer: d.rebind("UPDATE " + itemsTable + " SET version = ?, deleted = ?, item_data = ?" +
+			" WHERE namespace = ? AND item_key = ? AND version < ?"),
+		getBigSegmentMembership: d.rebind("SELECT segment_ref, included FROM " + bigSegmentsTable +
+			" WHERE context_hash = ?"),
+		// The placeholders for this query are added, and the query is rebound, when it is executed, since the
+		// number of them varies.
+		getBigSegmentMembershipsPrefix: "SELECT context_hash, segment_ref, included FROM " + bigSegmentsTable +
+			" WHERE context_hash IN ",
+		insertBigSegmentMember: d.rebind(d.insertIgnoringConflict(bigSegmentsTable,
+			"context_hash", "segment_ref", "included")),
+		deleteBigSegmentMember: d.rebind("DELETE FROM " + bigSegmentsTable +
+			" WHERE context_hash = ? AND segment_ref = ? AND included = ?"),
+		deleteSegmentMembers: d.rebind("DELETE FROM " + bigSegmentsTable + " WHERE segment_ref = ?"),
+	}
+}
@@ -0,0 +1,38 @@
+package ldsqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialectRebind(t *testing.T) {
+	query := "SELECT a FROM t WHERE b = ? AND c = ?"
+	assert.Equal(t, query, DialectSQLite.rebind(query))
+	assert.Equal(t, query, DialectMySQL.rebind(query))
+	assert.Equal(t, "SELECT a FROM t WHERE b = $1 AND c = $2", DialectPostgres.rebind(query))
+}
+
+func TestDialectInsertIgnoringConflict(t *testing.T) {
+	assert.Equal(t, "INSERT INTO t (a, b) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		DialectSQLite.insertIgnoringConflict("t", "a", "b"))
+	assert.Equal(t, "INSERT INTO t (a, b) VALUES (?, ?) ON CONFLICT DO NOTHING",
+		DialectPostgres.insertIgnoringConflict("t", "a", "b"))
+	assert.Equal(t, "INSERT IGNORE INTO t (a, b) VALUES (?, ?)",
+		DialectMySQL.insertIgnoringConflict("t", "a", "b"))
+}
+
+func TestDialectUpsert(t *testing.T) {
+	assert.Equal(t, "INSERT INTO t (k, a, b) VALUES (?, ?, ?) ON CONFLICT (k) DO UPDATE SET a = excluded.a, b = excluded.b",
+		DialectSQLite.upsert("t", []string{"k"}, []string{"a", "b"}))
+	assert.Equal(t, "INSERT INTO t (k, a, b) VALUES (?, ?, ?) ON CONFLICT (k) DO UPDATE SET a = excluded.a, b = excluded.b",
+		DialectPostgres.upsert("t", []string{"k"}, []string{"a", "b"}))
+	assert.Equal(t, "INSERT INTO t (k, a, b) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE a = VALUES(a), b = VALUES(b)",
+		DialectMySQL.upsert("t", []string{"k"}, []string{"a", "b"}))
+}
+
+func TestDialectBlobType(t *testing.T) {
+	assert.Equal(t, "BLOB", DialectSQLite.blobType())
+	assert.Equal(t, "BYTEA", DialectPostgres.blobType())
+	assert.Equal(t, "LONGBLOB", DialectMySQL.blobType())
+}
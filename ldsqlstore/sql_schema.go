@@ -0,0 +1,115 @@
+package ldsqlstore
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+)
+
+// The "$schemaVersion" row in the metadata table records how many of the migrations in schemaMigrations
+// have been applied.
+const schemaVersionKey = "$schemaVersion"
+
+// schemaMigrations contains the statements that create or upgrade the tables other than the metadata
+// table. The schema version stored in the metadata table is the number of migrations that have been
+// applied, so new migrations must only be appended to this list. Each statement must be safe to run more
+// than once, in case several SDK instances are upgrading the schema at the same time.
+//
+// The data store and the Big Segment store share the schema, so that they can use the same table prefix.
+var schemaMigrations = []func(d Dialect, tablePrefix string) []string{ //nolint:gochecknoglobals
+	func(d Dialect, tablePrefix string) []string {
+		return []string{
+			`CREATE TABLE IF NOT EXISTS ` + tablePrefix + `items (
+				namespace VARCHAR(100) NOT NULL,
+				item_key VARCHAR(255) NOT NULL,
+				version BIGINT NOT NULL,
+				deleted SMALLINT NOT NULL,
+				item_data ` + d.blobType() + `,
+				PRIMARY KEY (namespace, item_key)
+			)`,
+		}
+	},
+	func(d Dialect, tablePrefix string) []string {
+		return []string{
+			`CREATE TABLE IF NOT EXISTS ` + tablePrefix + `big_segments (
+				context_hash VARCHAR(255) NOT NULL,
+				segment_ref VARCHAR(255) NOT NULL,
+				included SMALLINT NOT NULL,
+				PRIMARY KEY (context_hash, segment_ref, included)
+			)`,
+		}
+	},
+}
+
+// sqlSchema creates or upgrades the tables for one table prefix, the first time that a store needs them.
+type sqlSchema struct {
+	db          *sql.DB
+	dialect     Dialect
+	tablePrefix string
+	statements  sqlStatements
+	loggers     ldlog.Loggers
+	ready       bool
+	lock        sync.Mutex
+}
+
+func newSQLSchema(
+	db *sql.DB,
+	dialect Dialect,
+	tablePrefix string,
+	statements sqlStatements,
+	loggers ldlog.Loggers,
+) *sqlSchema {
+	return &sqlSchema{
+		db:          db,
+		dialect:     dialect,
+		tablePrefix: tablePrefix,
+		statements:  statements,
+		loggers:     loggers,
+	}
+}
+
+// ensure creates or upgrades the tables, if that has not already been done successfully.
+func (s *sqlSchema) ensure() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.ready {
+		return nil
+	}
+
+	if _, err := s.db.Exec(s.statements.createMetadataTable); err != nil {
+		return err
+	}
+	schemaVersion := 0
+	var value string
+	err := s.db.QueryRow(s.statements.getMetadata, schemaVersionKey).Scan(&value)
+	switch {
+	case err == nil:
+		if schemaVersion, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("invalid database schema version %q", value)
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		return err
+	}
+
+	if schemaVersion > len(schemaMigrations) {
+		s.loggers.Warnf("Database schema version %d is newer than this SDK's version %d",
+			schemaVersion, len(schemaMigrations))
+	}
+	for i := schemaVersion; i < len(schemaMigrations); i++ {
+		s.loggers.Infof("Upgrading database schema to version %d", i+1)
+		for _, statement := range schemaMigrations[i](s.dialect, s.tablePrefix) {
+			if _, err := s.db.Exec(statement); err != nil {
+				return err
+			}
+		}
+		if _, err := s.db.Exec(s.statements.setMetadata, schemaVersionKey, strconv.Itoa(i+1)); err != nil {
+			return err
+		}
+	}
+	s.ready = true
+	return nil
+}
@@ -0,0 +1,250 @@
+package ldstoremigrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datasource"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+var errSourceNotInitialized = errors.New( //nolint:gochecknoglobals
+	"source data store has not been initialized; there is no data to migrate")
+
+// StoreOptions contains options for [OpenStoreWithOptions]. These correspond to options of
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStoreBuilder] that change how items
+// are stored, so that the data that is written to the store is the same as what the SDK would write.
+type StoreOptions struct {
+	// CompressItems specifies that items whose serialized size is at least CompressionMinSize bytes should
+	// be compressed when they are written to the store, as with
+	// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStoreBuilder.CompressItems].
+	// Compressed items are always decompressed when they are read, whether or not this is true.
+	CompressItems bool
+
+	// CompressionMinSize is the minimum size for compressing an item, if CompressItems is true.
+	CompressionMinSize int
+}
+
+// OpenStore creates a persistent data store from the same kind of builder that is used to configure
+// the SDK, such as [github.com/launchdarkly/go-server-sdk/ldfilestore.DataStore]. The caller is
+// responsible for closing the store.
+//
+// Do not pass the result of [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStore]
+// here; the functions in this package work directly with the underlying store, without any caching.
+//
+// Items that were compressed by an SDK instance that was configured with
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStoreBuilder.CompressItems] are
+// decompressed when they are read. Items are not compressed when they are written; to do that, use
+// [OpenStoreWithOptions]. If the data is encrypted, pass the same
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.EncryptedPersistentDataStore] configuration that
+// the SDK uses:
+//
+//	store, err := ldstoremigrate.OpenStore(
+//	    ldcomponents.EncryptedPersistentDataStore(ldredis.DataStore()).PrimaryKey("2023-06", key),
+//	    ldlog.NewDefaultLoggers(),
+//	)
+func OpenStore(
+	configurer subsystems.ComponentConfigurer[subsystems.PersistentDataStore],
+	loggers ldlog.Loggers,
+) (subsystems.PersistentDataStore, error) {
+	return OpenStoreWithOptions(configurer, StoreOptions{}, loggers)
+}
+
+// OpenStoreWithOptions is the same as [OpenStore], but also applies the specified [StoreOptions].
+func OpenStoreWithOptions(
+	configurer subsystems.ComponentConfigurer[subsystems.PersistentDataStore],
+	options StoreOptions,
+	loggers ldlog.Loggers,
+) (subsystems.PersistentDataStore, error) {
+	if configurer == nil {
+		return nil, errors.New("data store configuration is required")
+	}
+	store, err := configurer.Build(subsystems.BasicClientContext{
+		Logging: subsystems.LoggingConfiguration{Loggers: loggers},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// This is the same decorator that ldcomponents.PersistentDataStoreBuilder uses; encryption, if any,
+	// has already been applied by the configurer.
+	return datastore.NewCompressedPersistentDataStore(store, datastore.PersistentDataStoreCompressionConfig{
+		Enabled: options.CompressItems,
+		MinSize: options.CompressionMinSize,
+	}), nil
+}
+
+// Export writes all of the data in a persistent data store to w as a single JSON object, in the same
+// format that the LaunchDarkly polling service uses:
+//
+//	{
+//	  "flags": { "flag1": { "key": "flag1", "version": 1, ...etc. } },
+//	  "segments": { "segment1": { "key": "segment1", "version": 1, ...etc. } }
+//	}
+//
+// An item that has been deleted is written as a placeholder with its key, its version, and a "deleted"
+// property of true. Export returns an error if the store has never been initialized.
+func Export(store subsystems.PersistentDataStore, w io.Writer) error {
+	allData, err := readAllData(store)
+	if err != nil {
+		return err
+	}
+	payload := make(map[string]map[string]json.RawMessage, len(allData))
+	for _, coll := range allData {
+		items := make(map[string]json.RawMessage, len(coll.Items))
+		for _, item := range coll.Items {
+			items[item.Key] = item.Item.SerializedItem
+		}
+		payload[payloadPropertyName(coll.Kind)] = items
+	}
+	return json.NewEncoder(w).Encode(payload)
+}
+
+// Import reads data in the format written by [Export] from r, and replaces all of the data in a
+// persistent data store with it. Any properties other than "flags" and "segments" are ignored; if one
+// of those properties is missing, the store will contain no items of that kind.
+//
+// Every item is written with the version and deleted state that it has in the JSON data.
+func Import(r io.Reader, store subsystems.PersistentDataStore) error {
+	var payload map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return fmt.Errorf("invalid data: %w", err)
+	}
+	allData := make([]st.SerializedCollection, 0, len(ldstoreimpl.AllKinds()))
+	for _, kind := range ldstoreimpl.AllKinds() {
+		coll := st.SerializedCollection{Kind: kind}
+		if data, ok := payload[payloadPropertyName(kind)]; ok {
+			var serializedItems map[string]json.RawMessage
+			if err := json.Unmarshal(data, &serializedItems); err != nil {
+				return fmt.Errorf("invalid %s data: %w", kind.GetName(), err)
+			}
+			for key, serializedItem := range serializedItems {
+				coll.Items = append(coll.Items, st.KeyedSerializedItemDescriptor{
+					Key:  key,
+					Item: st.SerializedItemDescriptor{SerializedItem: serializedItem},
+				})
+			}
+		}
+		allData = append(allData, coll)
+	}
+	return writeAllData(store, allData)
+}
+
+// Copy replaces all of the data in the dest store with the data in the source store, preserving the
+// version and deleted state of every item. It returns an error, without modifying the dest store, if
+// the source store has never been initialized.
+func Copy(source, dest subsystems.PersistentDataStore) error {
+	allData, err := readAllData(source)
+	if err != nil {
+		return err
+	}
+	return writeAllData(dest, allData)
+}
+
+// Rewrite reads all of the data in a persistent data store and writes it back, preserving the version and
+// deleted state of every item. This is useful if the store was opened with a configuration that changes
+// how items are stored. For instance, after rotating the key of a store that is encrypted with
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.EncryptedPersistentDataStore], open it with the
+// new primary key and the old key as a decryption key, and call Rewrite to re-encrypt every item with the
+// new key; after that, the old key is no longer needed.
+//
+//	store, err := ldstoremigrate.OpenStore(
+//	    ldcomponents.EncryptedPersistentDataStore(ldredis.DataStore()).
+//	        PrimaryKey("2023-07", newKey).
+//	        DecryptionKey("2023-06", oldKey),
+//	    ldlog.NewDefaultLoggers(),
+//	)
+//	err = ldstoremigrate.Rewrite(store)
+//
+// Since the store is updated all at once, like [Copy], an update that another process writes to the
+// store while Rewrite is running could be overwritten with an older version of the item. To avoid that,
+// run it while no SDK instances are updating the store, or else restart the SDK instances afterward so
+// that they write the latest data from LaunchDarkly.
+func Rewrite(store subsystems.PersistentDataStore) error {
+	return Copy(store, store)
+}
+
+// readAllData queries all items of every kind. Some stores keep only the version of a deleted item and
+// return no serialized data for it, so we fill in the placeholder that the data kind would have
+// serialized; that way, the data can be exported as JSON or copied to any other store.
+func readAllData(store subsystems.PersistentDataStore) ([]st.SerializedCollection, error) {
+	if !store.IsInitialized() {
+		return nil, errSourceNotInitialized
+	}
+	allData := make([]st.SerializedCollection, 0, len(ldstoreimpl.AllKinds()))
+	for _, kind := range ldstoreimpl.AllKinds() {
+		items, err := store.GetAll(kind)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s from data store: %w", kind.GetName(), err)
+		}
+		for i, item := range items {
+			if item.Item.SerializedItem == nil {
+				items[i].Item = st.SerializedItemDescriptor{
+					Version:        item.Item.Version,
+					Deleted:        true,
+					SerializedItem: kind.Serialize(st.ItemDescriptor{Version: item.Item.Version}),
+				}
+			}
+		}
+		allData = append(allData, st.SerializedCollection{Kind: kind, Items: items})
+	}
+	return allData, nil
+}
+
+// writeAllData initializes the store with the data in the same order that the SDK would use, so that a
+// store that is not updated atomically never contains a flag whose prerequisites are missing. The version
+// and deleted state are always taken from the serialized data, since a store that cannot track them
+// separately may not have reported them.
+func writeAllData(store subsystems.PersistentDataStore, allData []st.SerializedCollection) error {
+	type kindAndKey struct {
+		kind st.DataKind
+		key  string
+	}
+	serializedItems := make(map[kindAndKey]st.SerializedItemDescriptor)
+	parsedData := make([]st.Collection, 0, len(allData))
+	for _, coll := range allData {
+		items := make([]st.KeyedItemDescriptor, 0, len(coll.Items))
+		for _, item := range coll.Items {
+			parsedItem, err := coll.Kind.Deserialize(item.Item.SerializedItem)
+			if err != nil {
+				return fmt.Errorf("invalid %s data for %q: %w", coll.Kind.GetName(), item.Key, err)
+			}
+			serializedItems[kindAndKey{coll.Kind, item.Key}] = st.SerializedItemDescriptor{
+				Version:        parsedItem.Version,
+				Deleted:        parsedItem.Item == nil,
+				SerializedItem: item.Item.SerializedItem,
+			}
+			items = append(items, st.KeyedItemDescriptor{Key: item.Key, Item: parsedItem})
+		}
+		parsedData = append(parsedData, st.Collection{Kind: coll.Kind, Items: items})
+	}
+	sortedData := make([]st.SerializedCollection, 0, len(allData))
+	for _, coll := range datasource.SortCollectionsForDataStoreInit(parsedData) {
+		items := make([]st.KeyedSerializedItemDescriptor, 0, len(coll.Items))
+		for _, item := range coll.Items {
+			items = append(items, st.KeyedSerializedItemDescriptor{
+				Key:  item.Key,
+				Item: serializedItems[kindAndKey{coll.Kind, item.Key}],
+			})
+		}
+		sortedData = append(sortedData, st.SerializedCollection{Kind: coll.Kind, Items: items})
+	}
+	if err := store.Init(sortedData); err != nil {
+		return fmt.Errorf("unable to write to data store: %w", err)
+	}
+	return nil
+}
+
+// payloadPropertyName returns the name that LaunchDarkly uses for a data kind in a polling payload,
+// which for flags is not the same as the name of the data kind.
+func payloadPropertyName(kind st.DataKind) string {
+	if kind.GetName() == ldstoreimpl.Features().GetName() {
+		return "flags"
+	}
+	return kind.GetName()
+}
@@ -0,0 +1,329 @@
+package ldstoremigrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestFlag(key string, version int) ldmodel.FeatureFlag {
+	return ldbuilders.NewFlagBuilder(key).Version(version).SingleVariation(ldvalue.Bool(true)).Build()
+}
+
+func makeTestSegment(key string, version int) ldmodel.Segment {
+	return ldbuilders.NewSegmentBuilder(key).Version(version).Included("a").Build()
+}
+
+func serializedItem(kind st.DataKind, item st.ItemDescriptor) st.SerializedItemDescriptor {
+	return st.SerializedItemDescriptor{
+		Version:        item.Version,
+		Deleted:        item.Item == nil,
+		SerializedItem: kind.Serialize(item),
+	}
+}
+
+// makeTestStore returns a mock store containing a flag, a deleted flag, and a segment.
+func makeTestStore(t *testing.T) *mocks.MockPersistentDataStore {
+	store := mocks.NewMockPersistentDataStore()
+	require.NoError(t, store.Init([]st.SerializedCollection{
+		{Kind: datakinds.Features, Items: []st.KeyedSerializedItemDescriptor{
+			{Key: "flag1", Item: serializedItem(datakinds.Features, sharedtest.FlagDescriptor(makeTestFlag("flag1", 10)))},
+			{Key: "flag2", Item: serializedItem(datakinds.Features, st.ItemDescriptor{Version: 20})},
+		}},
+		{Kind: datakinds.Segments, Items: []st.KeyedSerializedItemDescriptor{
+			{Key: "segment1", Item: serializedItem(datakinds.Segments,
+				sharedtest.SegmentDescriptor(makeTestSegment("segment1", 30)))},
+		}},
+	}))
+	return store
+}
+
+func assertTestData(t *testing.T, store subsystems.PersistentDataStore) {
+	flag1, err := store.Get(datakinds.Features, "flag1")
+	require.NoError(t, err)
+	assert.Equal(t, 10, flag1.Version)
+	assert.False(t, flag1.Deleted)
+	parsedFlag1, err := datakinds.Features.Deserialize(flag1.SerializedItem)
+	require.NoError(t, err)
+	assert.Equal(t, "flag1", parsedFlag1.Item.(*ldmodel.FeatureFlag).Key)
+
+	flag2, err := store.Get(datakinds.Features, "flag2")
+	require.NoError(t, err)
+	assert.Equal(t, 20, flag2.Version)
+	assert.True(t, flag2.Deleted)
+
+	segment1, err := store.Get(datakinds.Segments, "segment1")
+	require.NoError(t, err)
+	assert.Equal(t, 30, segment1.Version)
+	assert.False(t, segment1.Deleted)
+
+	flags, err := store.GetAll(datakinds.Features)
+	require.NoError(t, err)
+	assert.Len(t, flags, 2)
+	segments, err := store.GetAll(datakinds.Segments)
+	require.NoError(t, err)
+	assert.Len(t, segments, 1)
+}
+
+func TestExport(t *testing.T) {
+	t.Run("writes polling payload format", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Export(makeTestStore(t), &buf))
+
+		var payload map[string]map[string]ldvalue.Value
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &payload))
+		assert.Len(t, payload, 2)
+		assert.Equal(t, ldvalue.String("flag1"), payload["flags"]["flag1"].GetByKey("key"))
+		assert.Equal(t, ldvalue.Int(10), payload["flags"]["flag1"].GetByKey("version"))
+		assert.Equal(t, ldvalue.Int(20), payload["flags"]["flag2"].GetByKey("version"))
+		assert.Equal(t, ldvalue.Bool(true), payload["flags"]["flag2"].GetByKey("deleted"))
+		assert.Equal(t, ldvalue.Int(30), payload["segments"]["segment1"].GetByKey("version"))
+	})
+
+	t.Run("fails if store is not initialized", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := Export(mocks.NewMockPersistentDataStore(), &buf)
+		assert.Equal(t, errSourceNotInitialized, err)
+		assert.Equal(t, 0, buf.Len())
+	})
+
+	t.Run("fails if store returns error", func(t *testing.T) {
+		store := makeTestStore(t)
+		fakeError := errors.New("sorry")
+		store.SetFakeError(fakeError)
+		var buf bytes.Buffer
+		err := Export(store, &buf)
+		assert.True(t, errors.Is(err, fakeError), "unexpected error: %s", err)
+		assert.Equal(t, 0, buf.Len())
+	})
+}
+
+func TestImport(t *testing.T) {
+	t.Run("writes items with versions and deleted state", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Export(makeTestStore(t), &buf))
+
+		store := mocks.NewMockPersistentDataStore()
+		require.NoError(t, Import(&buf, store))
+		assert.True(t, store.IsInitialized())
+		assertTestData(t, store)
+	})
+
+	t.Run("ignores unknown properties", func(t *testing.T) {
+		store := mocks.NewMockPersistentDataStore()
+		data := `{"flags": {"flag1": {"key": "flag1", "version": 2}}, "other": [1]}`
+		require.NoError(t, Import(strings.NewReader(data), store))
+		flag1, err := store.Get(datakinds.Features, "flag1")
+		require.NoError(t, err)
+		assert.Equal(t, 2, flag1.Version)
+		segments, err := store.GetAll(datakinds.Segments)
+		require.NoError(t, err)
+		assert.Len(t, segments, 0)
+	})
+
+	t.Run("replaces existing data", func(t *testing.T) {
+		store := makeTestStore(t)
+		require.NoError(t, Import(strings.NewReader(`{"flags": {}, "segments": {}}`), store))
+		flags, err := store.GetAll(datakinds.Features)
+		require.NoError(t, err)
+		assert.Len(t, flags, 0)
+	})
+
+	t.Run("fails for malformed JSON without modifying store", func(t *testing.T) {
+		for _, data := range []string{
+			`{"flags": `,
+			`{"flags": []}`,
+			`{"flags": {"flag1": {"key": "flag1", "version": "x"}}}`,
+		} {
+			t.Run(data, func(t *testing.T) {
+				store := makeTestStore(t)
+				assert.Error(t, Import(strings.NewReader(data), store))
+				assertTestData(t, store)
+			})
+		}
+	})
+
+	t.Run("writes prerequisite flags before flags that depend on them", func(t *testing.T) {
+		flag1 := ldbuilders.NewFlagBuilder("flag1").Version(1).AddPrerequisite("flag2", 0).Build()
+		flag2 := makeTestFlag("flag2", 1)
+		data, err := json.Marshal(map[string]interface{}{
+			"flags": map[string]interface{}{"flag1": flag1, "flag2": flag2},
+		})
+		require.NoError(t, err)
+
+		store := &initRecordingStore{MockPersistentDataStore: mocks.NewMockPersistentDataStore()}
+		require.NoError(t, Import(bytes.NewReader(data), store))
+		require.Len(t, store.received, 2)
+		assert.Equal(t, datakinds.Segments, store.received[0].Kind)
+		assert.Equal(t, datakinds.Features, store.received[1].Kind)
+		require.Len(t, store.received[1].Items, 2)
+		assert.Equal(t, "flag2", store.received[1].Items[0].Key)
+		assert.Equal(t, "flag1", store.received[1].Items[1].Key)
+	})
+
+	t.Run("fails if store returns error", func(t *testing.T) {
+		store := mocks.NewMockPersistentDataStore()
+		fakeError := errors.New("sorry")
+		store.SetFakeError(fakeError)
+		err := Import(strings.NewReader(`{"flags": {}}`), store)
+		assert.True(t, errors.Is(err, fakeError), "unexpected error: %s", err)
+	})
+}
+
+func TestCopy(t *testing.T) {
+	t.Run("copies all items", func(t *testing.T) {
+		dest := mocks.NewMockPersistentDataStore()
+		require.NoError(t, Copy(makeTestStore(t), dest))
+		assertTestData(t, dest)
+	})
+
+	t.Run("gets versions from serialized data if source store does not report them", func(t *testing.T) {
+		source := makeTestStore(t)
+		source.SetPersistOnlyAsString(true)
+		require.NoError(t, Copy(makeTestStore(t), source)) // rewrite the data in this mode
+		dest := mocks.NewMockPersistentDataStore()
+		require.NoError(t, Copy(source, dest))
+		assertTestData(t, dest)
+	})
+
+	t.Run("does not modify destination if source store is not initialized", func(t *testing.T) {
+		dest := makeTestStore(t)
+		err := Copy(mocks.NewMockPersistentDataStore(), dest)
+		assert.Equal(t, errSourceNotInitialized, err)
+		assertTestData(t, dest)
+	})
+}
+
+func TestRewrite(t *testing.T) {
+	t.Run("re-encrypts items with new primary key", func(t *testing.T) {
+		oldKey, newKey := []byte("0123456789abcdef"), []byte("fedcba9876543210")
+		core := mocks.NewMockPersistentDataStore()
+		coreConfigurer := mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: core}
+		oldStore, err := OpenStore(ldcomponents.EncryptedPersistentDataStore(coreConfigurer).PrimaryKey("old", oldKey),
+			ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		require.NoError(t, Copy(makeTestStore(t), oldStore))
+
+		rotatedStore, err := OpenStore(ldcomponents.EncryptedPersistentDataStore(coreConfigurer).
+			PrimaryKey("new", newKey).DecryptionKey("old", oldKey), ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		require.NoError(t, Rewrite(rotatedStore))
+
+		newStore, err := OpenStore(ldcomponents.EncryptedPersistentDataStore(coreConfigurer).PrimaryKey("new", newKey),
+			ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		assertTestData(t, newStore)
+		assert.Contains(t, string(core.ForceGet(datakinds.Features, "flag1").SerializedItem), `"keyId":"new"`)
+	})
+
+	t.Run("fails if store is not initialized", func(t *testing.T) {
+		assert.Equal(t, errSourceNotInitialized, Rewrite(mocks.NewMockPersistentDataStore()))
+	})
+}
+
+func TestOpenStore(t *testing.T) {
+	t.Run("no configuration", func(t *testing.T) {
+		_, err := OpenStore(nil, ldlog.NewDisabledLoggers())
+		assert.Error(t, err)
+	})
+
+	t.Run("store cannot be created", func(t *testing.T) {
+		fakeError := errors.New("sorry")
+		_, err := OpenStore(mocks.ComponentConfigurerThatReturnsError[subsystems.PersistentDataStore]{Err: fakeError},
+			ldlog.NewDisabledLoggers())
+		assert.Equal(t, fakeError, err)
+	})
+
+	t.Run("returns store", func(t *testing.T) {
+		mockStore := makeTestStore(t)
+		store, err := OpenStore(mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: mockStore},
+			ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		assertTestData(t, store)
+		assert.NoError(t, store.Close())
+	})
+}
+
+func TestCompressedStore(t *testing.T) {
+	// The flag has enough rules that its JSON representation will get smaller when it is compressed.
+	flagBuilder := ldbuilders.NewFlagBuilder("bigflag").Version(5).On(true).Variations(ldvalue.Bool(false))
+	for i := 0; i < 20; i++ {
+		flagBuilder.AddRule(ldbuilders.NewRuleBuilder().ID(fmt.Sprintf("rule%d", i)).Variation(0).
+			Clauses(ldbuilders.Clause("key", ldmodel.OperatorIn, ldvalue.String(fmt.Sprintf("value%d", i)))))
+	}
+	bigFlag := flagBuilder.Build()
+	payload := map[string]interface{}{"flags": map[string]interface{}{"bigflag": bigFlag}}
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	core := mocks.NewMockPersistentDataStore()
+	coreConfigurer := mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: core}
+	compressedStore, err := OpenStoreWithOptions(coreConfigurer, StoreOptions{CompressItems: true},
+		ldlog.NewDisabledLoggers())
+	require.NoError(t, err)
+	require.NoError(t, Import(bytes.NewReader(data), compressedStore))
+
+	raw := core.ForceGet(datakinds.Features, bigFlag.Key)
+	assert.Equal(t, 5, raw.Version)
+	assert.False(t, json.Valid(raw.SerializedItem), "item should have been compressed")
+
+	t.Run("exports decompressed data", func(t *testing.T) {
+		store, err := OpenStore(coreConfigurer, ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, Export(store, &buf))
+
+		var exported map[string]map[string]ldmodel.FeatureFlag
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &exported))
+		assert.Equal(t, bigFlag, exported["flags"][bigFlag.Key])
+	})
+
+	t.Run("copies to uncompressed store and back", func(t *testing.T) {
+		source, err := OpenStore(coreConfigurer, ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		uncompressedCore := mocks.NewMockPersistentDataStore()
+		require.NoError(t, Copy(source, uncompressedCore))
+		assert.True(t, json.Valid(uncompressedCore.ForceGet(datakinds.Features, bigFlag.Key).SerializedItem))
+
+		compressedCore := mocks.NewMockPersistentDataStore()
+		dest, err := OpenStoreWithOptions(
+			mocks.SingleComponentConfigurer[subsystems.PersistentDataStore]{Instance: compressedCore},
+			StoreOptions{CompressItems: true}, ldlog.NewDisabledLoggers())
+		require.NoError(t, err)
+		require.NoError(t, Copy(uncompressedCore, dest))
+		assert.Equal(t, raw.SerializedItem, compressedCore.ForceGet(datakinds.Features, bigFlag.Key).SerializedItem)
+
+		item, err := dest.Get(datakinds.Features, bigFlag.Key)
+		require.NoError(t, err)
+		parsed, err := datakinds.Features.Deserialize(item.SerializedItem)
+		require.NoError(t, err)
+		assert.Equal(t, sharedtest.FlagDescriptor(bigFlag), parsed)
+	})
+}
+
+type initRecordingStore struct {
+	*mocks.MockPersistentDataStore
+	received []st.SerializedCollection
+}
+
+func (s *initRecordingStore) Init(allData []st.SerializedCollection) error {
+	s.received = allData
+	return s.MockPersistentDataStore.Init(allData)
+}
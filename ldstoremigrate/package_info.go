@@ -0,0 +1,33 @@
+// Package ldstoremigrate provides functions for exporting the contents of a persistent data store,
+// importing data into a persistent data store, and copying data from one persistent data store to
+// another. This is useful when moving from one kind of database to another, or between prefixes in the
+// same database, without having to connect an SDK client to LaunchDarkly.
+//
+// These functions work with any implementation of
+// [github.com/launchdarkly/go-server-sdk/v6/subsystems.PersistentDataStore]. You can obtain one from the
+// same builder that you would use to configure the SDK, by calling [OpenStore]:
+//
+//	source, err := ldstoremigrate.OpenStore(ldfilestore.DataStore("./flags.db"), ldlog.NewDefaultLoggers())
+//	dest, err := ldstoremigrate.OpenStore(
+//	    ldsqlstore.DataStore(ldsqlstore.DialectPostgres).Open("postgres", "postgres://my-db-host/mydb"),
+//	    ldlog.NewDefaultLoggers(),
+//	)
+//	err = ldstoremigrate.Copy(source, dest)
+//
+// The data is transferred exactly as it is stored, including the version of every item and the
+// placeholders that record that an item has been deleted, so that a later update with an older version
+// is not mistakenly applied to the new store. [Rewrite] writes the data in a store back to the same
+// store, which can be used to re-encrypt it after an encryption key has been rotated.
+//
+// Stores are opened with the same data encoding that the SDK uses: items that the SDK compressed are
+// decompressed when they are read, and [OpenStoreWithOptions] can be used to compress items that are
+// written. If the SDK encrypts the data with
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.EncryptedPersistentDataStore], pass the same
+// encryption configuration to [OpenStore].
+//
+// [Export] writes the data in the same JSON format that the LaunchDarkly polling service uses, with
+// "flags" and "segments" properties; [Import] reads that format, so it can also be used to load a
+// payload that was obtained from LaunchDarkly. The command-line tool in cmd/ldstoremigrate provides the
+// same operations for the file data store in the ldfilestore module and the SQLite dialect of the SQL
+// data store in the ldsqlstore module; for other databases, call these functions from your own program.
+package ldstoremigrate
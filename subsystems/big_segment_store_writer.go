@@ -0,0 +1,61 @@
+package subsystems
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+)
+
+// BigSegmentStoreWriter is an optional interface for a [BigSegmentStore] that can also be updated. The SDK
+// itself never writes to a Big Segment store; in production, that is normally done by the LaunchDarkly
+// Relay Proxy. This interface allows other tools, such as
+// [github.com/launchdarkly/go-server-sdk/v6/ldbigsegmentsync], to populate any store that implements it.
+//
+// As in BigSegmentStore, contexts are identified by a hash of the context key, and segments by a segment
+// reference rather than the segment key.
+//
+// Every method is idempotent: applying the same change twice has the same result as applying it once. A
+// sync process can therefore record its position with SetSyncCursor after each change, and after a
+// restart it can safely repeat the change that it was making when it stopped.
+type BigSegmentStoreWriter interface {
+	BigSegmentStore
+
+	// ApplyMembershipDelta adds contexts to, and removes contexts from, the included and excluded lists of
+	// a segment. Contexts that are not mentioned in the delta are not affected.
+	ApplyMembershipDelta(delta BigSegmentMembershipDelta) error
+
+	// ReplaceSegmentMembership replaces all of the included and excluded contexts of a segment. Passing
+	// empty lists removes all memberships for the segment.
+	ReplaceSegmentMembership(segmentRef string, includedContextHashes, excludedContextHashes []string) error
+
+	// SetSyncedOn sets the time when the store was last brought up to date, which is reported as
+	// BigSegmentStoreMetadata.LastUpToDate.
+	SetSyncedOn(syncedOn ldtime.UnixMillisecondTime) error
+
+	// GetSyncCursor returns the value that was most recently stored with SetSyncCursor, or an empty string
+	// if there is none.
+	GetSyncCursor() (string, error)
+
+	// SetSyncCursor stores an opaque string that identifies how far a sync process has gotten in its
+	// source of updates.
+	SetSyncCursor(cursor string) error
+}
+
+// BigSegmentMembershipDelta is a parameter type for BigSegmentStoreWriter.ApplyMembershipDelta. It
+// describes changes to the membership of one segment. Each list contains context hashes.
+//
+// If a context hash is in both an Add list and the corresponding Remove list, the result is undefined.
+type BigSegmentMembershipDelta struct {
+	// SegmentRef is the segment reference, in the same format used by BigSegmentMembership.
+	SegmentRef string
+
+	// AddIncluded contains contexts that should be added to the segment's included list.
+	AddIncluded []string
+
+	// RemoveIncluded contains contexts that should be removed from the segment's included list.
+	RemoveIncluded []string
+
+	// AddExcluded contains contexts that should be added to the segment's excluded list.
+	AddExcluded []string
+
+	// RemoveExcluded contains contexts that should be removed from the segment's excluded list.
+	RemoveExcluded []string
+}
@@ -31,6 +31,16 @@ type BigSegmentsConfiguration interface {
 	GetStaleAfter() time.Duration
 }
 
+// BigSegmentsContextNotFoundCacheConfiguration is an optional interface that a [BigSegmentsConfiguration]
+// can implement to set a separate cache time for the result that a context has no Big Segment memberships.
+// The configuration that is built by BigSegmentsConfigurationBuilder implements it. If a configuration does
+// not implement it, such results are cached for the same time as other results.
+type BigSegmentsContextNotFoundCacheConfiguration interface {
+	// GetContextNotFoundCacheTime returns the value set by
+	// BigSegmentsConfigurationBuilder.ContextNotFoundCacheTime.
+	GetContextNotFoundCacheTime() time.Duration
+}
+
 // BigSegmentStore is an interface for a read-only data store that allows querying of context
 // membership in Big Segments.
 //
@@ -51,6 +61,20 @@ type BigSegmentStore interface {
 	GetMembership(contextHash string) (BigSegmentMembership, error)
 }
 
+// BigSegmentStoreBatch is an optional interface for a [BigSegmentStore] that can query the memberships of
+// several contexts at once. If a context has several context kinds, and a flag evaluation might reference
+// Big Segments for more than one of them, the SDK uses this interface to get all of the memberships with
+// a single query, rather than doing a separate query for each context key.
+type BigSegmentStoreBatch interface {
+	BigSegmentStore
+
+	// GetMemberships queries the store for the segment state of several evaluation contexts. The context
+	// hashes are in the same format as for GetMembership. The returned map has the context hashes as its
+	// keys; a context hash whose membership state is nil or missing from the map is treated the same as a
+	// nil result from GetMembership.
+	GetMemberships(contextHashes []string) (map[string]BigSegmentMembership, error)
+}
+
 // BigSegmentStoreMetadata contains values returned by BigSegmentStore.GetMetadata().
 type BigSegmentStoreMetadata struct {
 	// LastUpToDate is the timestamp of the last update to the BigSegmentStore. It is zero if
@@ -0,0 +1,38 @@
+package subsystems
+
+import (
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldreason"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+)
+
+// EvaluationMetricsRecorder is an interface for a component that receives information about every flag
+// evaluation, so that it can be used for metrics in the application's own monitoring system. See
+// [github.com/launchdarkly/go-server-sdk/v6.Config.EvaluationMetrics].
+//
+// The SDK provides an implementation that keeps counters and latency histograms in memory,
+// [github.com/launchdarkly/go-server-sdk/v6/ldmetrics.EvaluationMetrics].
+//
+// RecordEvaluation is called synchronously from the LDClient Variation methods, and may be called from
+// many goroutines at once, so it should be fast and must not block.
+type EvaluationMetricsRecorder interface {
+	// RecordEvaluation is called after each flag evaluation.
+	RecordEvaluation(metric EvaluationMetric)
+}
+
+// EvaluationMetric is a parameter type for [EvaluationMetricsRecorder.RecordEvaluation].
+type EvaluationMetric struct {
+	// FlagKey is the key of the flag that was evaluated.
+	FlagKey string
+
+	// Variation is the index of the variation that was returned, or undefined if the application's
+	// default value was returned.
+	Variation ldvalue.OptionalInt
+
+	// Reason is the evaluation reason.
+	Reason ldreason.EvaluationReason
+
+	// Duration is how long the evaluation took, including the recording of analytics events.
+	Duration time.Duration
+}
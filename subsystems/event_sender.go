@@ -0,0 +1,72 @@
+package subsystems
+
+import (
+	"net/http"
+)
+
+// EventSender is an interface for a component that delivers analytics event data. By default, the SDK
+// posts this data to LaunchDarkly over HTTP; an application can provide its own EventSender to route the
+// data somewhere else instead, such as a message queue or a local file. See
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.EventProcessorBuilder.EventSender].
+//
+// The SDK does all of the work of buffering, summarizing, and formatting events, so the EventSender only
+// receives finished payloads. It may be called from several goroutines at once.
+type EventSender interface {
+	// SendEventData attempts to deliver a payload of event data. It returns nil if the payload was
+	// delivered. If delivery failed but might succeed if attempted again, it should return an error that
+	// wraps [RetryableEventSenderError]; the SDK will then retry once after a short delay, as it does for
+	// a network error when sending to LaunchDarkly. Any other error causes the payload to be discarded.
+	SendEventData(payload EventPayload) error
+}
+
+// EventPayloadKind is a parameter type for [EventPayload], indicating the type of the payload.
+type EventPayloadKind string
+
+const (
+	// AnalyticsEventPayload denotes a payload of analytics events: a JSON array of event objects.
+	AnalyticsEventPayload EventPayloadKind = "analytics"
+	// DiagnosticEventPayload denotes a payload containing a single diagnostic event as a JSON object.
+	DiagnosticEventPayload EventPayloadKind = "diagnostic"
+)
+
+// EventPayload is a parameter type for [EventSender.SendEventData].
+type EventPayload struct {
+	// Kind is the type of the payload.
+	Kind EventPayloadKind
+
+	// Data is the JSON representation of the events, exactly as the SDK would send it to LaunchDarkly.
+	Data []byte
+
+	// EventCount is the number of events in the payload.
+	EventCount int
+
+	// PayloadID is a unique identifier for an analytics event payload, which LaunchDarkly uses to discard
+	// duplicates if the same payload is delivered more than once. It stays the same if the payload is
+	// retried. It is empty for diagnostic payloads.
+	PayloadID string
+
+	// Headers contains the HTTP headers that the SDK would use if it were sending the payload to
+	// LaunchDarkly, including the Authorization header with the SDK key. An EventSender that forwards the
+	// data to LaunchDarkly should send these headers; other implementations can ignore them.
+	Headers http.Header
+}
+
+// RetryableEventSenderError is an error type that an [EventSender] can return to indicate that the
+// delivery failure was temporary and the payload should be retried.
+type RetryableEventSenderError struct {
+	// Err is the underlying error.
+	Err error
+}
+
+// Error returns the message of the underlying error.
+func (e RetryableEventSenderError) Error() string {
+	if e.Err == nil {
+		return "event delivery failed"
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e RetryableEventSenderError) Unwrap() error {
+	return e.Err
+}
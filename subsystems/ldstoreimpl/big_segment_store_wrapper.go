@@ -2,6 +2,7 @@ package ldstoreimpl
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
@@ -32,19 +33,32 @@ import (
 // should not have any public methods that are not strictly necessary for its use by the SDK and by
 // the Relay Proxy.
 type BigSegmentStoreWrapper struct {
-	store          subsystems.BigSegmentStore
-	statusUpdateFn func(interfaces.BigSegmentStoreStatus)
-	staleTime      time.Duration
-	contextCache   *ccache.Cache
-	cacheTTL       time.Duration
-	pollInterval   time.Duration
-	haveStatus     bool
-	lastStatus     interfaces.BigSegmentStoreStatus
-	requests       singleflight.Group
-	pollCloser     chan struct{}
-	pollingActive  bool
-	loggers        ldlog.Loggers
-	lock           sync.RWMutex
+	stats            bigSegmentCacheCounters // first, so that its int64 fields are aligned for atomic access
+	store            subsystems.BigSegmentStore
+	statusUpdateFn   func(interfaces.BigSegmentStoreStatus)
+	staleTime        time.Duration
+	contextCache     *ccache.Cache
+	cacheTTL         time.Duration
+	notFoundCacheTTL time.Duration
+	evictions        int64
+	pollInterval     time.Duration
+	haveStatus       bool
+	lastStatus       interfaces.BigSegmentStoreStatus
+	requests         singleflight.Group
+	pollCloser       chan struct{}
+	pollingActive    bool
+	loggers          ldlog.Loggers
+	lock             sync.RWMutex
+}
+
+// bigSegmentCacheCounters are updated atomically, since they are updated during evaluations.
+type bigSegmentCacheCounters struct {
+	hits             int64
+	notFoundHits     int64
+	misses           int64
+	storeQueries     int64
+	storeQueryErrors int64
+	storeQueryNanos  int64
 }
 
 // NewBigSegmentStoreWrapperWithConfig creates a BigSegmentStoreWrapper.
@@ -62,16 +76,21 @@ func NewBigSegmentStoreWrapperWithConfig(
 	loggers ldlog.Loggers,
 ) *BigSegmentStoreWrapper {
 	pollCloser := make(chan struct{})
+	notFoundCacheTTL := config.ContextNotFoundCacheTime
+	if notFoundCacheTTL == 0 {
+		notFoundCacheTTL = config.ContextCacheTime
+	}
 	w := &BigSegmentStoreWrapper{
-		store:          config.Store,
-		statusUpdateFn: statusUpdateFn,
-		staleTime:      config.StaleAfter,
-		contextCache:   ccache.New(ccache.Configure().MaxSize(int64(config.ContextCacheSize))),
-		cacheTTL:       config.ContextCacheTime,
-		pollInterval:   config.StatusPollInterval,
-		pollCloser:     pollCloser,
-		pollingActive:  config.StartPolling,
-		loggers:        loggers,
+		store:            config.Store,
+		statusUpdateFn:   statusUpdateFn,
+		staleTime:        config.StaleAfter,
+		contextCache:     ccache.New(ccache.Configure().MaxSize(int64(config.ContextCacheSize))),
+		cacheTTL:         config.ContextCacheTime,
+		notFoundCacheTTL: notFoundCacheTTL,
+		pollInterval:     config.StatusPollInterval,
+		pollCloser:       pollCloser,
+		pollingActive:    config.StartPolling,
+		loggers:          loggers,
 	}
 
 	if config.StartPolling {
@@ -89,6 +108,7 @@ func (w *BigSegmentStoreWrapper) Close() {
 		w.pollCloser = nil
 	}
 	if w.contextCache != nil {
+		w.evictions += int64(w.contextCache.GetDropped()) // so GetCacheStats still reports the total after closing
 		w.contextCache.Stop()
 		w.contextCache = nil
 	}
@@ -117,30 +137,42 @@ func (w *BigSegmentStoreWrapper) GetMembership(
 	entry := w.safeCacheGet(contextKey)
 	var result ldeval.BigSegmentMembership
 	if entry == nil || entry.Expired() {
+		atomic.AddInt64(&w.stats.misses, 1)
 		// Use singleflight to ensure that we'll only do this query once even if multiple goroutines are
 		// requesting it
 		value, err, _ := w.requests.Do(contextKey, func() (interface{}, error) {
 			hash := bigsegments.HashForContextKey(contextKey)
 			w.loggers.Debugf("querying Big Segment state for context hash %q", hash)
-			return w.store.GetMembership(hash)
+			var membership subsystems.BigSegmentMembership
+			err := w.timeStoreQuery(func() (err error) {
+				membership, err = w.store.GetMembership(hash)
+				return err
+			})
+			return membership, err
 		})
 		if err != nil {
 			w.loggers.Errorf("Big Segment store returned error: %s", err)
 			return nil, ldreason.BigSegmentsStoreError
 		}
 		if value == nil {
-			w.safeCacheSet(contextKey, nil, w.cacheTTL) // we cache the "not found" status
+			w.cacheMembership(contextKey, nil) // we cache the "not found" status
 			return nil, ldreason.BigSegmentsHealthy
 		}
 		if membership, ok := value.(subsystems.BigSegmentMembership); ok {
-			w.safeCacheSet(contextKey, membership, w.cacheTTL)
+			w.cacheMembership(contextKey, membership)
 			result = membership
 		} else {
 			w.loggers.Error("BigSegmentStoreWrapper got wrong value type from request - this should not be possible")
 			return nil, ldreason.BigSegmentsStoreError
 		}
-	} else if entry.Value() != nil { // nil is a cached "not found" state
-		if membership, ok := entry.Value().(subsystems.BigSegmentMembership); ok {
+	} else {
+		atomic.AddInt64(&w.stats.hits, 1)
+		if entry.Value() == nil { // nil is a cached "not found" state
+			atomic.AddInt64(&w.stats.notFoundHits, 1)
+		} else if membership, ok := entry.Value().(subsystems.BigSegmentMembership); ok {
+			if isEmptyMembership(membership) {
+				atomic.AddInt64(&w.stats.notFoundHits, 1)
+			}
 			result = membership
 		} else {
 			w.loggers.Error("BigSegmentStoreWrapper got wrong value type from cache - this should not be possible")
@@ -156,6 +188,50 @@ func (w *BigSegmentStoreWrapper) GetMembership(
 	return result, status
 }
 
+// PrefetchMemberships is called by the SDK before an evaluation that might need the Big Segment
+// membership state for several context keys, as it can for a multi-kind context. If the store implements
+// [subsystems.BigSegmentStoreBatch], it queries the state for all of the keys that are not already cached
+// with a single call, and caches the results, so that GetMembership does not need to query the store for
+// them during the evaluation.
+//
+// If fewer than two of the keys need to be queried, or the store does not support batch queries, it
+// does nothing, since querying each key on demand in GetMembership takes no more calls. If the query
+// fails, it logs the error and caches nothing, so GetMembership will report the error as usual.
+func (w *BigSegmentStoreWrapper) PrefetchMemberships(contextKeys []string) {
+	batchStore, ok := w.store.(subsystems.BigSegmentStoreBatch)
+	if !ok || len(contextKeys) < 2 {
+		return
+	}
+	keysByHash := make(map[string]string, len(contextKeys))
+	hashes := make([]string, 0, len(contextKeys))
+	for _, key := range contextKeys {
+		if entry := w.safeCacheGet(key); entry != nil && !entry.Expired() {
+			continue
+		}
+		hash := bigsegments.HashForContextKey(key)
+		if _, ok := keysByHash[hash]; !ok {
+			keysByHash[hash] = key
+			hashes = append(hashes, hash)
+		}
+	}
+	if len(hashes) < 2 {
+		return
+	}
+	w.loggers.Debugf("querying Big Segment state for context hashes %v", hashes)
+	var memberships map[string]subsystems.BigSegmentMembership
+	err := w.timeStoreQuery(func() (err error) {
+		memberships, err = batchStore.GetMemberships(hashes)
+		return err
+	})
+	if err != nil {
+		w.loggers.Errorf("Big Segment store returned error: %s", err)
+		return
+	}
+	for _, hash := range hashes {
+		w.cacheMembership(keysByHash[hash], memberships[hash])
+	}
+}
+
 // GetStatus returns a BigSegmentStoreStatus describing whether the store seems to be available
 // (that is, the last query to it did not return an error) and whether it is stale (that is, the last
 // known update time is too far in the past).
@@ -191,6 +267,40 @@ func (w *BigSegmentStoreWrapper) ClearCache() {
 	w.loggers.Debug("invalidated cache")
 }
 
+// InvalidateContext removes the cached Big Segment state for a context key, if any, so the next query
+// for that key will get the latest data from the store.
+func (w *BigSegmentStoreWrapper) InvalidateContext(contextKey string) {
+	w.lock.RLock()
+	if w.contextCache != nil {
+		w.contextCache.Delete(contextKey)
+	}
+	w.lock.RUnlock()
+	w.loggers.Debugf("invalidated cache for context key %q", contextKey)
+}
+
+// GetCacheStats returns statistics about the cache of per-context Big Segment state, and about the
+// membership queries that have been made to the store.
+func (w *BigSegmentStoreWrapper) GetCacheStats() interfaces.BigSegmentCacheStats {
+	stats := interfaces.BigSegmentCacheStats{
+		Enabled:          true,
+		Hits:             atomic.LoadInt64(&w.stats.hits),
+		NotFoundHits:     atomic.LoadInt64(&w.stats.notFoundHits),
+		Misses:           atomic.LoadInt64(&w.stats.misses),
+		StoreQueries:     atomic.LoadInt64(&w.stats.storeQueries),
+		StoreQueryErrors: atomic.LoadInt64(&w.stats.storeQueryErrors),
+		StoreQueryTime:   time.Duration(atomic.LoadInt64(&w.stats.storeQueryNanos)),
+	}
+	// The cache only reports the number of evictions since the last time we asked, so we keep a total.
+	w.lock.Lock()
+	if w.contextCache != nil {
+		w.evictions += int64(w.contextCache.GetDropped())
+		stats.Items = w.contextCache.ItemCount()
+	}
+	stats.Evictions = w.evictions
+	w.lock.Unlock()
+	return stats
+}
+
 // SetPollingActive switches the polling task on or off.
 //
 // This is used by the Relay Proxy, but is not currently used by the SDK otherwise.
@@ -265,6 +375,36 @@ func (w *BigSegmentStoreWrapper) runPollTask(pollInterval time.Duration, pollClo
 	}
 }
 
+// cacheMembership caches the result of a store query. A result that has no memberships, which the store
+// may represent either as nil or as an empty BigSegmentMembership, uses the cache TTL for "not found"
+// results; if that TTL is negative, the result is not cached.
+func (w *BigSegmentStoreWrapper) cacheMembership(contextKey string, membership subsystems.BigSegmentMembership) {
+	ttl := w.cacheTTL
+	if isEmptyMembership(membership) {
+		if w.notFoundCacheTTL < 0 {
+			return
+		}
+		ttl = w.notFoundCacheTTL
+	}
+	if membership == nil {
+		w.safeCacheSet(contextKey, nil, ttl) // nil is a cached "not found" state
+	} else {
+		w.safeCacheSet(contextKey, membership, ttl)
+	}
+}
+
+// timeStoreQuery runs a membership query to the store, and updates the query statistics.
+func (w *BigSegmentStoreWrapper) timeStoreQuery(query func() error) error {
+	start := time.Now()
+	err := query()
+	atomic.AddInt64(&w.stats.storeQueryNanos, int64(time.Since(start)))
+	atomic.AddInt64(&w.stats.storeQueries, 1)
+	if err != nil {
+		atomic.AddInt64(&w.stats.storeQueryErrors, 1)
+	}
+	return err
+}
+
 // safeCacheGet and safeCacheSet are necessary because trying to use a ccache.Cache after it's been shut
 // down can cause a panic, so we nil it out on Close() and guard it with our lock.
 func (w *BigSegmentStoreWrapper) safeCacheGet(key string) *ccache.Item {
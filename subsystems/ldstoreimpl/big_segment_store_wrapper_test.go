@@ -24,6 +24,8 @@ import (
 func TestBigSegmentStoreWrapper(t *testing.T) {
 	t.Run("queries store with hashed user key", testBigSegmentStoreWrapperMembershipQuery)
 	t.Run("caches membership state", testBigSegmentStoreWrapperMembershipCaching)
+	t.Run("prefetches membership state", testBigSegmentStoreWrapperPrefetch)
+	t.Run("cache statistics", testBigSegmentStoreWrapperCacheStats)
 	t.Run("sends status updates", testBigSegmentStoreWrapperStatusUpdates)
 	t.Run("control methods", testBigSegmentStoreWrapperControlMethods)
 }
@@ -116,6 +118,58 @@ func testBigSegmentStoreWrapperMembershipCaching(t *testing.T) {
 		})
 	})
 
+	t.Run("not-found result uses not-found cache time", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		p.config.ContextNotFoundCacheTime = time.Millisecond
+		p.run(func(p *storeWrapperTestParams) {
+			userKey1, userKey2 := "userkey1", "userkey2"
+			userHash1, userHash2 := bigsegments.HashForContextKey(userKey1), bigsegments.HashForContextKey(userKey2)
+			expectedMembership1 := NewBigSegmentMembershipFromSegmentRefs([]string{"yes"}, []string{"no"})
+			p.store.TestSetMembership(userHash1, expectedMembership1)
+
+			p.assertMembership(userKey1, expectedMembership1)
+			p.assertMembership(userKey2, nil)
+			time.Sleep(time.Millisecond * 10)
+			p.assertMembership(userKey1, expectedMembership1)
+			p.assertMembership(userKey2, nil)
+
+			p.assertUserHashesQueried(userHash1, userHash2, userHash2) // the not-found result expired
+		})
+	})
+
+	t.Run("not-found result is not cached if not-found cache time is negative", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		p.config.ContextNotFoundCacheTime = -1
+		p.run(func(p *storeWrapperTestParams) {
+			userKey1, userKey2 := "userkey1", "userkey2"
+			userHash1, userHash2 := bigsegments.HashForContextKey(userKey1), bigsegments.HashForContextKey(userKey2)
+			expectedMembership1 := NewBigSegmentMembershipFromSegmentRefs([]string{"yes"}, []string{"no"})
+			p.store.TestSetMembership(userHash1, expectedMembership1)
+
+			p.assertMembership(userKey1, expectedMembership1)
+			p.assertMembership(userKey2, nil)
+			p.assertMembership(userKey1, expectedMembership1)
+			p.assertMembership(userKey2, nil)
+
+			p.assertUserHashesQueried(userHash1, userHash2, userHash2)
+		})
+	})
+
+	t.Run("empty membership is treated as not-found result", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		p.config.ContextNotFoundCacheTime = -1
+		p.run(func(p *storeWrapperTestParams) {
+			userKey := "userkey"
+			userHash := bigsegments.HashForContextKey(userKey)
+			emptyMembership := NewBigSegmentMembershipFromSegmentRefs(nil, nil)
+			p.store.TestSetMembership(userHash, emptyMembership)
+
+			p.assertMembership(userKey, emptyMembership)
+			p.assertMembership(userKey, emptyMembership)
+			p.assertUserHashesQueried(userHash, userHash)
+		})
+	})
+
 	t.Run("least recent user is evicted from cache", func(t *testing.T) {
 		p := storeWrapperTest(t)
 		p.config.ContextCacheSize = 2
@@ -156,6 +210,150 @@ func testBigSegmentStoreWrapperMembershipCaching(t *testing.T) {
 	})
 }
 
+func testBigSegmentStoreWrapperPrefetch(t *testing.T) {
+	userKey1, userKey2, userKey3 := "userkey1", "userkey2", "userkey3"
+	userHash1, userHash2, userHash3 := bigsegments.HashForContextKey(userKey1),
+		bigsegments.HashForContextKey(userKey2), bigsegments.HashForContextKey(userKey3)
+	expectedMembership1 := NewBigSegmentMembershipFromSegmentRefs([]string{"yes1"}, nil)
+	expectedMembership2 := NewBigSegmentMembershipFromSegmentRefs([]string{"yes2"}, nil)
+
+	withBatchStore := func(action func(*storeWrapperTestParams, *mocks.MockBigSegmentStoreBatch)) {
+		p := storeWrapperTest(t)
+		batchStore := &mocks.MockBigSegmentStoreBatch{}
+		batchStore.TestSetMetadataToCurrentTime()
+		batchStore.TestSetMembership(userHash1, expectedMembership1)
+		batchStore.TestSetMembership(userHash2, expectedMembership2)
+		config := p.config
+		config.Store = batchStore
+		p.wrapper = NewBigSegmentStoreWrapperWithConfig(config, nil, p.mockLog.Loggers)
+		defer p.wrapper.Close()
+		action(p, batchStore)
+	}
+
+	t.Run("queries uncached keys at once and caches results", func(t *testing.T) {
+		withBatchStore(func(p *storeWrapperTestParams, store *mocks.MockBigSegmentStoreBatch) {
+			p.assertMembership(userKey1, expectedMembership1)
+
+			p.wrapper.PrefetchMemberships([]string{userKey1, userKey2, userKey3, userKey2})
+			assert.Equal(t, [][]string{{userHash2, userHash3}}, store.TestGetBatchMembershipQueries())
+
+			p.assertMembership(userKey2, expectedMembership2)
+			p.assertMembership(userKey3, nil)
+			assert.Equal(t, []string{userHash1}, store.TestGetMembershipQueries())
+		})
+	})
+
+	t.Run("does nothing if fewer than two keys need to be queried", func(t *testing.T) {
+		withBatchStore(func(p *storeWrapperTestParams, store *mocks.MockBigSegmentStoreBatch) {
+			p.wrapper.PrefetchMemberships([]string{userKey1})
+			p.assertMembership(userKey1, expectedMembership1)
+			p.wrapper.PrefetchMemberships([]string{userKey1, userKey2})
+			assert.Len(t, store.TestGetBatchMembershipQueries(), 0)
+		})
+	})
+
+	t.Run("does not cache anything if query fails", func(t *testing.T) {
+		withBatchStore(func(p *storeWrapperTestParams, store *mocks.MockBigSegmentStoreBatch) {
+			store.TestSetMembershipError(errors.New("sorry"))
+			p.wrapper.PrefetchMemberships([]string{userKey1, userKey2})
+			assert.Len(t, store.TestGetBatchMembershipQueries(), 1)
+
+			membership, status := p.wrapper.GetMembership(userKey1)
+			assert.Nil(t, membership)
+			assert.Equal(t, ldreason.BigSegmentsStoreError, status)
+		})
+	})
+
+	t.Run("batch query is counted as one store query", func(t *testing.T) {
+		withBatchStore(func(p *storeWrapperTestParams, store *mocks.MockBigSegmentStoreBatch) {
+			p.wrapper.PrefetchMemberships([]string{userKey1, userKey2, userKey3})
+
+			stats := p.wrapper.GetCacheStats()
+			assert.Equal(t, int64(1), stats.StoreQueries)
+			assert.Equal(t, int64(0), stats.Misses)
+			assert.Equal(t, 3, stats.Items)
+		})
+	})
+
+	t.Run("does nothing if store does not support batch queries", func(t *testing.T) {
+		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
+			p.wrapper.PrefetchMemberships([]string{userKey1, userKey2})
+			p.assertUserHashesQueried()
+		})
+	})
+}
+
+func testBigSegmentStoreWrapperCacheStats(t *testing.T) {
+	t.Run("counts hits, misses, and queries", func(t *testing.T) {
+		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
+			userKey1, userKey2 := "userkey1", "userkey2"
+			expectedMembership1 := NewBigSegmentMembershipFromSegmentRefs([]string{"yes"}, []string{"no"})
+			p.store.TestSetMembership(bigsegments.HashForContextKey(userKey1), expectedMembership1)
+
+			assert.Equal(t, interfaces.BigSegmentCacheStats{Enabled: true}, p.wrapper.GetCacheStats())
+
+			p.assertMembership(userKey1, expectedMembership1)
+			p.assertMembership(userKey1, expectedMembership1)
+			p.assertMembership(userKey2, nil)
+			p.assertMembership(userKey2, nil)
+			p.assertMembership(userKey2, nil)
+
+			stats := p.wrapper.GetCacheStats()
+			assert.True(t, stats.Enabled)
+			assert.Equal(t, int64(3), stats.Hits)
+			assert.Equal(t, int64(2), stats.NotFoundHits)
+			assert.Equal(t, int64(2), stats.Misses)
+			assert.Equal(t, 2, stats.Items)
+			assert.Equal(t, int64(2), stats.StoreQueries)
+			assert.Equal(t, int64(0), stats.StoreQueryErrors)
+			assert.Equal(t, int64(0), stats.Evictions)
+		})
+	})
+
+	t.Run("counts query errors", func(t *testing.T) {
+		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
+			p.store.TestSetMembershipError(errors.New("sorry"))
+
+			_, status := p.wrapper.GetMembership("userkey")
+			assert.Equal(t, ldreason.BigSegmentsStoreError, status)
+
+			stats := p.wrapper.GetCacheStats()
+			assert.Equal(t, int64(1), stats.Misses)
+			assert.Equal(t, int64(1), stats.StoreQueries)
+			assert.Equal(t, int64(1), stats.StoreQueryErrors)
+			assert.Equal(t, 0, stats.Items)
+		})
+	})
+
+	t.Run("counts evictions", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		p.config.ContextCacheSize = 2
+		p.run(func(p *storeWrapperTestParams) {
+			p.assertMembership("userkey1", nil)
+			p.assertMembership("userkey2", nil)
+			p.assertMembership("userkey3", nil)
+
+			var evictions int64
+			require.Eventually(t, func() bool {
+				evictions = p.wrapper.GetCacheStats().Evictions
+				return evictions > 0
+			}, time.Second, time.Millisecond*10, "timed out waiting for LRU eviction")
+			assert.Equal(t, evictions, p.wrapper.GetCacheStats().Evictions) // the total is retained
+		})
+	})
+
+	t.Run("returns stats after close", func(t *testing.T) {
+		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
+			p.assertMembership("userkey", nil)
+			p.wrapper.Close()
+
+			stats := p.wrapper.GetCacheStats()
+			assert.Equal(t, int64(1), stats.Misses)
+			assert.Equal(t, 0, stats.Items)
+		})
+	})
+}
+
 func testBigSegmentStoreWrapperStatusUpdates(t *testing.T) {
 	t.Run("polling detects store unavailability", func(t *testing.T) {
 		storeWrapperTest(t).run(func(p *storeWrapperTestParams) {
@@ -250,4 +448,28 @@ func testBigSegmentStoreWrapperControlMethods(t *testing.T) {
 			p.assertUserHashesQueried(userHash, userHash) // a second query was done
 		})
 	})
+
+	t.Run("can invalidate a single context", func(t *testing.T) {
+		p := storeWrapperTest(t)
+		p.run(func(p *storeWrapperTestParams) {
+			userKey1, userKey2 := "userkey1", "userkey2"
+			userHash1, userHash2 := bigsegments.HashForContextKey(userKey1), bigsegments.HashForContextKey(userKey2)
+
+			expectedMembership1 := NewBigSegmentMembershipFromSegmentRefs([]string{"yes"}, []string{"no"})
+			p.store.TestSetMembership(userHash1, expectedMembership1)
+
+			p.assertMembership(userKey1, expectedMembership1)
+			p.assertMembership(userKey2, nil)
+			p.assertUserHashesQueried(userHash1, userHash2)
+
+			expectedMembership2 := NewBigSegmentMembershipFromSegmentRefs([]string{"maybe"}, []string{"no"})
+			p.store.TestSetMembership(userHash1, expectedMembership2)
+
+			p.wrapper.InvalidateContext(userKey1)
+
+			p.assertMembership(userKey1, expectedMembership2)
+			p.assertMembership(userKey2, nil)
+			p.assertUserHashesQueried(userHash1, userHash2, userHash1) // only the invalidated key was queried again
+		})
+	})
 }
@@ -23,6 +23,11 @@ type BigSegmentsConfigurationProperties struct {
 	// by the SDK.
 	ContextCacheTime time.Duration
 
+	// ContextNotFoundCacheTime is the maximum length of time that the SDK will cache the result that a
+	// context has no Big Segment memberships. If it is zero, ContextCacheTime is used; if it is negative,
+	// such results are not cached.
+	ContextNotFoundCacheTime time.Duration
+
 	// StatusPollInterval is the interval at which the SDK will poll the Big Segment store to make sure
 	// it is available and to determine how long ago it was updated
 	StatusPollInterval time.Duration
@@ -49,6 +54,10 @@ func (p BigSegmentsConfigurationProperties) GetContextCacheTime() time.Duration
 	return p.ContextCacheTime
 }
 
+func (p BigSegmentsConfigurationProperties) GetContextNotFoundCacheTime() time.Duration { //nolint:revive
+	return p.ContextNotFoundCacheTime
+}
+
 func (p BigSegmentsConfigurationProperties) GetStatusPollInterval() time.Duration { //nolint:revive
 	return p.StatusPollInterval
 }
@@ -43,6 +43,16 @@ func NewBigSegmentMembershipFromSegmentRefs(
 	return ret
 }
 
+// isEmptyMembership returns true if the membership state is nil, or is a value that was created by
+// NewBigSegmentMembershipFromSegmentRefs with no segment references, meaning that the context was not found.
+func isEmptyMembership(membership subsystems.BigSegmentMembership) bool {
+	if membership == nil {
+		return true
+	}
+	m, ok := membership.(bigSegmentMembershipMapImpl)
+	return ok && len(m) == 0
+}
+
 // This is the standard internal implementation of BigSegmentMembership. The map contains a true
 // value for included keys and a false value for excluded keys that are not also included (inclusions
 // override exclusions). If there are no keys at all, we store nil instead of allocating an empty map.
@@ -0,0 +1,30 @@
+package subsystems
+
+import (
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// PersistentDataStoreChangeNotifier is an optional interface that a [PersistentDataStore] can implement if it
+// is able to find out when its data has been changed by another process, such as the Relay Proxy or another
+// SDK instance. For instance, a Redis integration could use keyspace notifications, and a SQL database
+// integration could poll a table of recent changes.
+//
+// The SDK only uses this when it is configured not to connect to LaunchDarkly itself (see
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.ExternalUpdatesOnly]), so that it can still
+// generate flag change events for [github.com/launchdarkly/go-server-sdk/v6/interfaces.FlagTracker]. If the
+// store does not implement this interface, or if SubscribeToChanges returns an error, the SDK instead
+// queries the store periodically and compares version numbers (see
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.ExternalUpdatesDataSourceConfigurer]).
+type PersistentDataStoreChangeNotifier interface {
+	// SubscribeToChanges asks the store to call onChange whenever an item has been added, updated, or
+	// deleted by another process.
+	//
+	// The key parameter is the key of the item that changed. If the store can only tell that some items
+	// of a given kind may have changed, it should pass an empty string for the key. It is fine to report
+	// a change that did not really happen, or to report a change that was made by this SDK instance: the
+	// SDK compares version numbers before generating any events.
+	//
+	// The SDK calls this method at most once. The store should stop calling onChange when it is closed.
+	// It may call onChange from any goroutine.
+	SubscribeToChanges(onChange func(kind ldstoretypes.DataKind, key string)) error
+}
@@ -0,0 +1,30 @@
+// Package ldreplay provides a mechanism for recording the sequence of updates that a data source
+// delivers to the SDK, and for replaying such a recording later in a test.
+//
+// This is useful when a bug depends on a specific sequence of flag changes in a real environment.
+// To capture the sequence, wrap the data source that the application normally uses with
+// [RecordDataSource]:
+//
+//	config := ld.Config{
+//		DataSource: ldreplay.RecordDataSource(ldcomponents.StreamingDataSource(), "./flag-updates.ndjson"),
+//	}
+//
+// Every call that the wrapped data source makes to initialize the SDK's data, to update or delete a
+// flag or segment, or to report a change in its status, is then written with a timestamp as one line
+// of JSON in the specified file. The data source otherwise behaves exactly as it would without the
+// recorder.
+//
+// To reproduce the same timeline in a test, use the recording file with [DataSource]:
+//
+//	config := ld.Config{
+//		DataSource: ldreplay.DataSource("./flag-updates.ndjson").Speed(10),
+//	}
+//
+// The replay data source sends the recorded updates to the SDK in the same order, with the same
+// relative timing (optionally accelerated), as they originally happened.
+//
+// The format of each line in the recording file is a JSON object with a "time" property (an RFC3339
+// timestamp), a "kind" property that is "init", "upsert", or "status", and other properties that
+// depend on the kind. The flag and segment data within it uses the same JSON representation as the
+// LaunchDarkly services. The format is not guaranteed to be compatible with other LaunchDarkly SDKs.
+package ldreplay
@@ -0,0 +1,181 @@
+package ldreplay
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal"
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// RecordingDataSourceBuilder is a builder for a data source that records the updates delivered by
+// another data source.
+//
+// Obtain an instance of this type by calling [RecordDataSource]. After calling its methods to specify
+// any desired custom settings, store it in the DataSource field of
+// [github.com/launchdarkly/go-server-sdk/v6.Config].
+//
+// You do not need to call the builder's Build method yourself; that will be done by the SDK.
+type RecordingDataSourceBuilder struct {
+	wrapped      subsystems.ComponentConfigurer[subsystems.DataSource]
+	filePath     string
+	appendToFile bool
+}
+
+type recordingDataSource struct {
+	subsystems.DataSource
+	sink *recordingUpdateSink
+}
+
+type recordingUpdateSink struct {
+	wrapped subsystems.DataSourceUpdateSink
+	file    *os.File
+	loggers ldlog.Loggers
+	closed  bool
+	lock    sync.Mutex
+}
+
+// RecordDataSource returns a configurable builder for a data source that delegates to the data source
+// created by wrapped, and also records every update it receives from that data source in the file at
+// filePath.
+//
+// If wrapped is nil, the default streaming data source is used.
+//
+// By default, the file is truncated when the SDK client starts. To add to an existing recording
+// instead, use [RecordingDataSourceBuilder.Append].
+func RecordDataSource(
+	wrapped subsystems.ComponentConfigurer[subsystems.DataSource],
+	filePath string,
+) *RecordingDataSourceBuilder {
+	return &RecordingDataSourceBuilder{wrapped: wrapped, filePath: filePath}
+}
+
+// Append specifies whether the recorder should add to an existing file rather than truncating it.
+func (b *RecordingDataSourceBuilder) Append(appendToFile bool) *RecordingDataSourceBuilder {
+	b.appendToFile = appendToFile
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *RecordingDataSourceBuilder) Build(context subsystems.ClientContext) (subsystems.DataSource, error) {
+	flags := os.O_WRONLY | os.O_CREATE
+	if b.appendToFile {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(b.filePath, flags, 0600)
+	if err != nil {
+		return nil, err
+	}
+	sink := &recordingUpdateSink{
+		wrapped: context.GetDataSourceUpdateSink(),
+		file:    file,
+		loggers: context.GetLogging().Loggers,
+	}
+
+	wrapped := b.wrapped
+	if wrapped == nil {
+		wrapped = ldcomponents.StreamingDataSource()
+	}
+	dataSource, err := wrapped.Build(contextWithDataSourceUpdateSink(context, sink))
+	if err != nil {
+		_ = sink.close()
+		return nil, err
+	}
+	return &recordingDataSource{DataSource: dataSource, sink: sink}, nil
+}
+
+// contextWithDataSourceUpdateSink returns a copy of the ClientContext that uses a different
+// DataSourceUpdateSink. If this is the SDK's own ClientContext implementation, we preserve its
+// internal properties so that the wrapped data source still has access to them.
+func contextWithDataSourceUpdateSink(
+	context subsystems.ClientContext,
+	sink subsystems.DataSourceUpdateSink,
+) subsystems.ClientContext {
+	if cci, ok := context.(*internal.ClientContextImpl); ok {
+		contextCopy := *cci
+		contextCopy.BasicClientContext.DataSourceUpdateSink = sink
+		return &contextCopy
+	}
+	return subsystems.BasicClientContext{
+		SDKKey:               context.GetSDKKey(),
+		ApplicationInfo:      context.GetApplicationInfo(),
+		HTTP:                 context.GetHTTP(),
+		Logging:              context.GetLogging(),
+		Offline:              context.GetOffline(),
+		ServiceEndpoints:     context.GetServiceEndpoints(),
+		DataSourceUpdateSink: sink,
+		DataStoreUpdateSink:  context.GetDataStoreUpdateSink(),
+	}
+}
+
+func (d *recordingDataSource) Close() error {
+	err := d.DataSource.Close()
+	if closeErr := d.sink.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (s *recordingUpdateSink) Init(allData []ldstoretypes.Collection) bool {
+	s.record(makeInitRecord(time.Now(), allData))
+	return s.wrapped.Init(allData)
+}
+
+func (s *recordingUpdateSink) Upsert(
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.ItemDescriptor,
+) bool {
+	s.record(makeUpsertRecord(time.Now(), kind, key, item))
+	return s.wrapped.Upsert(kind, key, item)
+}
+
+func (s *recordingUpdateSink) UpdateStatus(
+	newState interfaces.DataSourceState,
+	newError interfaces.DataSourceErrorInfo,
+) {
+	s.record(makeStatusRecord(time.Now(), newState, newError))
+	s.wrapped.UpdateStatus(newState, newError)
+}
+
+func (s *recordingUpdateSink) GetDataStoreStatusProvider() interfaces.DataStoreStatusProvider {
+	return s.wrapped.GetDataStoreStatusProvider()
+}
+
+func (s *recordingUpdateSink) record(r recordedUpdate) {
+	line, err := json.Marshal(r)
+	if err != nil {
+		// COVERAGE: should be impossible, since all of the properties are JSON-serializable
+		s.loggers.Errorf("Unable to serialize data source update for recording: %s", err)
+		return
+	}
+	line = append(line, '\n')
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return
+	}
+	// Failing to write the recording is logged, but it must not prevent the SDK from receiving the update.
+	if _, err := s.file.Write(line); err != nil {
+		s.loggers.Errorf("Unable to write data source update to recording file: %s", err)
+	}
+}
+
+func (s *recordingUpdateSink) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.file.Close()
+}
@@ -0,0 +1,142 @@
+package ldreplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldtestdata"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeTestContext(updates subsystems.DataSourceUpdateSink) subsystems.ClientContext {
+	return subsystems.BasicClientContext{
+		Logging:              sharedtest.TestLoggingConfig(),
+		DataSourceUpdateSink: updates,
+	}
+}
+
+func readRecordedUpdates(t *testing.T, filePath string) []recordedUpdate {
+	file, err := os.Open(filePath)
+	require.NoError(t, err)
+	defer file.Close()
+	var ret []recordedUpdate
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r recordedUpdate
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &r))
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// recordTestData runs a TestDataSource through a recorder, performing the specified actions once it
+// has started, and returns the path of the recording file.
+func recordTestData(t *testing.T, td *ldtestdata.TestDataSource, actions func()) string {
+	filePath := filepath.Join(t.TempDir(), "recording.ndjson")
+	updates := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+
+	ds, err := RecordDataSource(td, filePath).Build(makeTestContext(updates))
+	require.NoError(t, err)
+
+	closeWhenReady := make(chan struct{})
+	ds.Start(closeWhenReady)
+	th.AssertChannelClosed(t, closeWhenReady, time.Second)
+	updates.DataStore.WaitForNextInit(t, time.Second)
+	actions()
+	require.NoError(t, ds.Close())
+	return filePath
+}
+
+func TestRecordDataSource(t *testing.T) {
+	t.Run("records updates while passing them through", func(t *testing.T) {
+		td := ldtestdata.DataSource()
+		td.Update(td.Flag("flag1").On(true))
+
+		filePath := filepath.Join(t.TempDir(), "recording.ndjson")
+		updates := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+
+		ds, err := RecordDataSource(td, filePath).Build(makeTestContext(updates))
+		require.NoError(t, err)
+		closeWhenReady := make(chan struct{})
+		ds.Start(closeWhenReady)
+		th.AssertChannelClosed(t, closeWhenReady, time.Second)
+		assert.True(t, ds.IsInitialized())
+
+		updates.DataStore.WaitForNextInit(t, time.Second)
+		updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+
+		td.Update(td.Flag("flag1").On(false))
+		updates.DataStore.WaitForUpsert(t, ldstoreimpl.Features(), "flag1", 2, time.Second)
+
+		errorInfo := interfaces.DataSourceErrorInfo{Kind: interfaces.DataSourceErrorKindNetworkError, Message: "sorry"}
+		td.UpdateStatus(interfaces.DataSourceStateInterrupted, errorInfo)
+		updates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+
+		require.NoError(t, ds.Close())
+
+		recorded := readRecordedUpdates(t, filePath)
+		require.Len(t, recorded, 4)
+
+		assert.Equal(t, recordKindInit, recorded[0].Kind)
+		assert.Contains(t, recorded[0].Data[ldstoreimpl.Features().GetName()], "flag1")
+
+		assert.Equal(t, recordKindStatus, recorded[1].Kind)
+		assert.Equal(t, interfaces.DataSourceStateValid, recorded[1].State)
+		assert.Nil(t, recorded[1].Error)
+
+		assert.Equal(t, recordKindUpsert, recorded[2].Kind)
+		assert.Equal(t, ldstoreimpl.Features().GetName(), recorded[2].DataKind)
+		assert.Equal(t, "flag1", recorded[2].Key)
+		assert.Equal(t, 2, recorded[2].Version)
+
+		assert.Equal(t, recordKindStatus, recorded[3].Kind)
+		assert.Equal(t, interfaces.DataSourceStateInterrupted, recorded[3].State)
+		require.NotNil(t, recorded[3].Error)
+		assert.Equal(t, errorInfo.Kind, recorded[3].Error.Kind)
+		assert.Equal(t, errorInfo.Message, recorded[3].Error.Message)
+
+		for i := 1; i < len(recorded); i++ {
+			assert.False(t, recorded[i].Time.Before(recorded[i-1].Time))
+		}
+	})
+
+	t.Run("truncates or appends to existing file", func(t *testing.T) {
+		td := ldtestdata.DataSource()
+		for _, appendToFile := range []bool{false, true} {
+			filePath := filepath.Join(t.TempDir(), "recording.ndjson")
+			for i := 0; i < 2; i++ {
+				updates := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+				ds, err := RecordDataSource(td, filePath).Append(appendToFile).Build(makeTestContext(updates))
+				require.NoError(t, err)
+				ds.Start(make(chan struct{}))
+				require.NoError(t, ds.Close())
+			}
+			expectedLines := 2
+			if appendToFile {
+				expectedLines = 4
+			}
+			assert.Len(t, readRecordedUpdates(t, filePath), expectedLines)
+		}
+	})
+
+	t.Run("returns error if file cannot be created", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "no-such-dir", "recording.ndjson")
+		updates := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+		_, err := RecordDataSource(ldtestdata.DataSource(), filePath).Build(makeTestContext(updates))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,138 @@
+package ldreplay
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+const (
+	recordKindInit   = "init"
+	recordKindUpsert = "upsert"
+	recordKindStatus = "status"
+)
+
+// recordedUpdate is the JSON representation of a single line in a recording file.
+type recordedUpdate struct {
+	Time     time.Time                             `json:"time"`
+	Kind     string                                `json:"kind"`
+	Data     map[string]map[string]json.RawMessage `json:"data,omitempty"`
+	DataKind string                                `json:"dataKind,omitempty"`
+	Key      string                                `json:"key,omitempty"`
+	Version  int                                   `json:"version,omitempty"`
+	Item     json.RawMessage                       `json:"item,omitempty"`
+	State    interfaces.DataSourceState            `json:"state,omitempty"`
+	Error    *recordedError                        `json:"error,omitempty"`
+}
+
+type recordedError struct {
+	Kind       interfaces.DataSourceErrorKind `json:"kind"`
+	StatusCode int                            `json:"statusCode,omitempty"`
+	Message    string                         `json:"message,omitempty"`
+	Time       time.Time                      `json:"time"`
+}
+
+func makeInitRecord(now time.Time, allData []ldstoretypes.Collection) recordedUpdate {
+	data := make(map[string]map[string]json.RawMessage, len(allData))
+	for _, coll := range allData {
+		items := make(map[string]json.RawMessage, len(coll.Items))
+		for _, item := range coll.Items {
+			items[item.Key] = coll.Kind.Serialize(item.Item)
+		}
+		data[coll.Kind.GetName()] = items
+	}
+	return recordedUpdate{Time: now, Kind: recordKindInit, Data: data}
+}
+
+func makeUpsertRecord(
+	now time.Time,
+	kind ldstoretypes.DataKind,
+	key string,
+	item ldstoretypes.ItemDescriptor,
+) recordedUpdate {
+	return recordedUpdate{
+		Time:     now,
+		Kind:     recordKindUpsert,
+		DataKind: kind.GetName(),
+		Key:      key,
+		Version:  item.Version,
+		Item:     kind.Serialize(item),
+	}
+}
+
+func makeStatusRecord(
+	now time.Time,
+	newState interfaces.DataSourceState,
+	newError interfaces.DataSourceErrorInfo,
+) recordedUpdate {
+	r := recordedUpdate{Time: now, Kind: recordKindStatus, State: newState}
+	if newError.Kind != "" {
+		r.Error = &recordedError{
+			Kind:       newError.Kind,
+			StatusCode: newError.StatusCode,
+			Message:    newError.Message,
+			Time:       newError.Time,
+		}
+	}
+	return r
+}
+
+func (r recordedUpdate) errorInfo() interfaces.DataSourceErrorInfo {
+	if r.Error == nil {
+		return interfaces.DataSourceErrorInfo{}
+	}
+	return interfaces.DataSourceErrorInfo{
+		Kind:       r.Error.Kind,
+		StatusCode: r.Error.StatusCode,
+		Message:    r.Error.Message,
+		Time:       r.Error.Time,
+	}
+}
+
+func (r recordedUpdate) allData() ([]ldstoretypes.Collection, error) {
+	allData := make([]ldstoretypes.Collection, 0, len(r.Data))
+	for _, kind := range ldstoreimpl.AllKinds() {
+		serializedItems, ok := r.Data[kind.GetName()]
+		if !ok {
+			continue
+		}
+		items := make([]ldstoretypes.KeyedItemDescriptor, 0, len(serializedItems))
+		for key, serializedItem := range serializedItems {
+			item, err := kind.Deserialize(serializedItem)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s data for %q: %w", kind, key, err)
+			}
+			items = append(items, ldstoretypes.KeyedItemDescriptor{Key: key, Item: item})
+		}
+		allData = append(allData, ldstoretypes.Collection{Kind: kind, Items: items})
+	}
+	return allData, nil
+}
+
+func (r recordedUpdate) upsertedItem() (ldstoretypes.DataKind, ldstoretypes.ItemDescriptor, error) {
+	kind := dataKindByName(r.DataKind)
+	if kind == nil {
+		return nil, ldstoretypes.ItemDescriptor{}, fmt.Errorf("unknown data kind %q", r.DataKind)
+	}
+	item, err := kind.Deserialize(r.Item)
+	if err != nil {
+		return nil, ldstoretypes.ItemDescriptor{}, fmt.Errorf("invalid %s data for %q: %w", kind, r.Key, err)
+	}
+	if r.Version != 0 {
+		item.Version = r.Version
+	}
+	return kind, item, nil
+}
+
+func dataKindByName(name string) ldstoretypes.DataKind {
+	for _, kind := range ldstoreimpl.AllKinds() {
+		if kind.GetName() == name {
+			return kind
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package ldreplay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldlog"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+)
+
+// maxRecordedLineSize is the largest line that we will accept in a recording file. An "init" line
+// contains an entire environment, so this is much larger than bufio.Scanner's default.
+const maxRecordedLineSize = 256 * 1024 * 1024
+
+// DataSourceBuilder is a builder for a data source that replays a recording made with [RecordDataSource].
+//
+// Obtain an instance of this type by calling [DataSource]. After calling its methods to specify any
+// desired custom settings, store it in the DataSource field of [github.com/launchdarkly/go-server-sdk/v6.Config].
+//
+// You do not need to call the builder's Build method yourself; that will be done by the SDK.
+type DataSourceBuilder struct {
+	filePath string
+	speed    float64
+}
+
+type replayStep struct {
+	time  time.Time
+	kind  string
+	apply func(subsystems.DataSourceUpdateSink) bool
+}
+
+type replayDataSource struct {
+	updates       subsystems.DataSourceUpdateSink
+	steps         []replayStep
+	speed         float64
+	loggers       ldlog.Loggers
+	isInitialized bool
+	readyOnce     sync.Once
+	closeOnce     sync.Once
+	closeCh       chan struct{}
+	lock          sync.Mutex
+}
+
+// DataSource returns a configurable builder for a data source that replays the recording in the file
+// at filePath.
+//
+// The file is read when the SDK client starts. If it cannot be read or contains invalid data, the SDK
+// client fails to start.
+//
+// The first recorded update is delivered as soon as the data source starts; each subsequent update is
+// delivered after the same interval that separated it from the previous one in the recording, divided
+// by the speed factor (see [DataSourceBuilder.Speed]). The SDK client is considered initialized once
+// the first recorded "init" has been delivered.
+func DataSource(filePath string) *DataSourceBuilder {
+	return &DataSourceBuilder{filePath: filePath, speed: 1}
+}
+
+// Speed sets the replay speed relative to the original timeline. The default is 1, meaning that
+// updates are delivered with the same timing as they were recorded; 10 means ten times faster.
+//
+// A value of zero or less means that all of the recorded updates are delivered in order without any
+// delay.
+func (b *DataSourceBuilder) Speed(speed float64) *DataSourceBuilder {
+	b.speed = speed
+	return b
+}
+
+// Build is called internally by the SDK.
+func (b *DataSourceBuilder) Build(context subsystems.ClientContext) (subsystems.DataSource, error) {
+	steps, err := readRecording(b.filePath)
+	if err != nil {
+		return nil, err
+	}
+	loggers := context.GetLogging().Loggers
+	loggers.SetPrefix("ReplayDataSource:")
+	return &replayDataSource{
+		updates: context.GetDataSourceUpdateSink(),
+		steps:   steps,
+		speed:   b.speed,
+		loggers: loggers,
+		closeCh: make(chan struct{}),
+	}, nil
+}
+
+func readRecording(filePath string) ([]replayStep, error) {
+	file, err := os.Open(filePath) //nolint:gosec // G304: ok to read file into variable
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var steps []replayStep
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxRecordedLineSize)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r recordedUpdate
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("invalid recording data at %s:%d: %w", filePath, lineNum, err)
+		}
+		step, err := makeReplayStep(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording data at %s:%d: %w", filePath, lineNum, err)
+		}
+		steps = append(steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+func makeReplayStep(r recordedUpdate) (replayStep, error) {
+	step := replayStep{time: r.Time, kind: r.Kind}
+	switch r.Kind {
+	case recordKindInit:
+		allData, err := r.allData()
+		if err != nil {
+			return step, err
+		}
+		step.apply = func(updates subsystems.DataSourceUpdateSink) bool {
+			return updates.Init(allData)
+		}
+	case recordKindUpsert:
+		kind, item, err := r.upsertedItem()
+		if err != nil {
+			return step, err
+		}
+		key := r.Key
+		step.apply = func(updates subsystems.DataSourceUpdateSink) bool {
+			return updates.Upsert(kind, key, item)
+		}
+	case recordKindStatus:
+		state, errorInfo := r.State, r.errorInfo()
+		step.apply = func(updates subsystems.DataSourceUpdateSink) bool {
+			updates.UpdateStatus(state, errorInfo)
+			return true
+		}
+	default:
+		return step, fmt.Errorf("unknown update kind %q", r.Kind)
+	}
+	return step, nil
+}
+
+func (d *replayDataSource) IsInitialized() bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.isInitialized
+}
+
+func (d *replayDataSource) Start(closeWhenReady chan<- struct{}) {
+	go d.run(closeWhenReady)
+}
+
+func (d *replayDataSource) run(closeWhenReady chan<- struct{}) {
+	// If the recording never successfully initialized the data, we still need to signal that the
+	// data source has done all it can.
+	defer d.readyOnce.Do(func() { close(closeWhenReady) })
+
+	for i, step := range d.steps {
+		if i > 0 && d.speed > 0 {
+			delay := time.Duration(float64(step.time.Sub(d.steps[i-1].time)) / d.speed)
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-d.closeCh:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+		}
+		select {
+		case <-d.closeCh:
+			return
+		default:
+		}
+		if step.apply(d.updates) && step.kind == recordKindInit {
+			d.lock.Lock()
+			d.isInitialized = true
+			d.lock.Unlock()
+			d.readyOnce.Do(func() { close(closeWhenReady) })
+		}
+	}
+	d.loggers.Infof("Finished replaying %d recorded updates", len(d.steps))
+}
+
+func (d *replayDataSource) Close() error {
+	d.closeOnce.Do(func() { close(d.closeCh) })
+	return nil
+}
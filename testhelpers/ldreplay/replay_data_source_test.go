@@ -0,0 +1,138 @@
+package ldreplay
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datastore"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldtestdata"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startReplay(
+	t *testing.T,
+	builder *DataSourceBuilder,
+) (subsystems.DataSource, *mocks.MockDataSourceUpdates, chan struct{}) {
+	updates := mocks.NewMockDataSourceUpdates(datastore.NewInMemoryDataStore(sharedtest.NewTestLoggers()))
+	ds, err := builder.Build(makeTestContext(updates))
+	require.NoError(t, err)
+	closeWhenReady := make(chan struct{})
+	ds.Start(closeWhenReady)
+	return ds, updates, closeWhenReady
+}
+
+func writeRecordingFile(t *testing.T, content string) string {
+	filePath := filepath.Join(t.TempDir(), "recording.ndjson")
+	require.NoError(t, os.WriteFile(filePath, []byte(content), 0600))
+	return filePath
+}
+
+func TestReplayDataSource(t *testing.T) {
+	t.Run("replays recorded updates in order", func(t *testing.T) {
+		td := ldtestdata.DataSource()
+		td.Update(td.Flag("flag1").On(true))
+		td.UpdateSegment(td.Segment("segment1").Included("a"))
+		filePath := recordTestData(t, td, func() {
+			td.Update(td.Flag("flag1").On(false))
+			td.UpdateStatus(interfaces.DataSourceStateInterrupted, interfaces.DataSourceErrorInfo{})
+		})
+
+		ds, updates, closeWhenReady := startReplay(t, DataSource(filePath).Speed(0))
+		defer ds.Close()
+
+		th.AssertChannelClosed(t, closeWhenReady, time.Second)
+		assert.True(t, ds.IsInitialized())
+
+		initData := updates.DataStore.WaitForNextInit(t, time.Second)
+		assert.Len(t, initData, 2)
+		updates.RequireStatusOf(t, interfaces.DataSourceStateValid)
+
+		item := updates.DataStore.WaitForUpsert(t, ldstoreimpl.Features(), "flag1", 2, time.Second)
+		require.NotNil(t, item.Item)
+		updates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+
+		segment, err := updates.DataStore.Get(ldstoreimpl.Segments(), "segment1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, segment.Version)
+	})
+
+	t.Run("preserves relative timing divided by speed", func(t *testing.T) {
+		now := time.Now()
+		content := makeLine(t, makeInitRecord(now, nil)) +
+			makeLine(t, makeStatusRecord(now.Add(time.Second), interfaces.DataSourceStateInterrupted,
+				interfaces.DataSourceErrorInfo{}))
+
+		ds, updates, closeWhenReady := startReplay(t, DataSource(writeRecordingFile(t, content)).Speed(5))
+		defer ds.Close()
+
+		th.AssertChannelClosed(t, closeWhenReady, time.Second)
+		startTime := time.Now()
+		updates.RequireStatusOf(t, interfaces.DataSourceStateInterrupted)
+		elapsed := time.Since(startTime)
+		assert.GreaterOrEqual(t, elapsed, time.Millisecond*150)
+		assert.Less(t, elapsed, time.Millisecond*800)
+	})
+
+	t.Run("stops replaying when closed", func(t *testing.T) {
+		now := time.Now()
+		content := makeLine(t, makeInitRecord(now, nil)) +
+			makeLine(t, makeStatusRecord(now.Add(time.Hour), interfaces.DataSourceStateInterrupted,
+				interfaces.DataSourceErrorInfo{}))
+
+		ds, updates, closeWhenReady := startReplay(t, DataSource(writeRecordingFile(t, content)))
+		th.AssertChannelClosed(t, closeWhenReady, time.Second)
+		updates.DataStore.WaitForNextInit(t, time.Second)
+		require.NoError(t, ds.Close())
+		th.AssertNoMoreValues(t, updates.Statuses, time.Millisecond*50)
+	})
+
+	t.Run("signals readiness even if recording has no init", func(t *testing.T) {
+		content := makeLine(t, makeStatusRecord(time.Now(), interfaces.DataSourceStateInterrupted,
+			interfaces.DataSourceErrorInfo{}))
+
+		ds, _, closeWhenReady := startReplay(t, DataSource(writeRecordingFile(t, content)))
+		defer ds.Close()
+
+		th.AssertChannelClosed(t, closeWhenReady, time.Second)
+		assert.False(t, ds.IsInitialized())
+	})
+
+	t.Run("Build returns error for missing file", func(t *testing.T) {
+		_, err := DataSource(filepath.Join(t.TempDir(), "no-such-file")).Build(subsystems.BasicClientContext{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Build returns error for malformed JSON", func(t *testing.T) {
+		_, err := DataSource(writeRecordingFile(t, "{no")).Build(subsystems.BasicClientContext{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Build returns error for unknown update kind", func(t *testing.T) {
+		_, err := DataSource(writeRecordingFile(t, `{"kind":"other"}`)).Build(subsystems.BasicClientContext{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Build returns error for unknown data kind", func(t *testing.T) {
+		_, err := DataSource(writeRecordingFile(t, `{"kind":"upsert","dataKind":"other","key":"x","item":{}}`)).
+			Build(subsystems.BasicClientContext{})
+		assert.Error(t, err)
+	})
+}
+
+func makeLine(t *testing.T, r recordedUpdate) string {
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	return string(data) + "\n"
+}
@@ -20,9 +20,30 @@
 //		FallthroughVariation(false))
 //
 // The above example uses a simple boolean flag, but more complex configurations are possible using
-// the methods of the [FlagBuilder] that is returned by [TestDataSource.Flag]. FlagBuilder supports many of
-// the ways a flag can be configured on the LaunchDarkly dashboard, but does not currently support 1.
-// rule operators other than "in" and "not in", or 2. percentage rollouts.
+// the methods of the [FlagBuilder] that is returned by [TestDataSource.Flag]. FlagBuilder supports most of
+// the ways a flag can be configured on the LaunchDarkly dashboard, including rules with any clause operator,
+// percentage rollouts and experiments, and prerequisites:
+//
+//	td.Update(td.Flag("flag-key-3").
+//		Prerequisite("flag-key-1", true).
+//		IfMatchOperator("appVersion", ldmodel.OperatorSemVerLessThan, ldvalue.String("2.0.0")).
+//			ThenReturn(false).
+//		FallthroughRollout(25000, 75000))
+//
+// Segments can be defined in a similar way with [TestDataSource.Segment] and [TestDataSource.UpdateSegment],
+// and referenced from flag rules with [FlagBuilder.IfInSegment]:
+//
+//	td.UpdateSegment(td.Segment("beta-testers").Included("user-key-1", "user-key-2"))
+//	td.Update(td.Flag("flag-key-4").IfInSegment("beta-testers").ThenReturn(true))
+//
+// Flags and segments can be removed with [TestDataSource.Delete] and [TestDataSource.DeleteSegment]. To
+// change several of them at once, so that the application never sees a state where only some of the
+// changes have been applied, use [TestDataSource.Batch]:
+//
+//	td.Batch(func(tx *ldtestdata.Transaction) {
+//		tx.Update(td.Flag("flag-key-1").VariationForAll(false))
+//		tx.Delete("flag-key-2")
+//	})
 //
 // If the same TestDataSource instance is used to configure multiple LDClient instances, any change
 // made to the data will propagate to all of the LDClients.
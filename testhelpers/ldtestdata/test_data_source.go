@@ -17,11 +17,12 @@ import (
 //
 // See package description for more details and usage examples.
 type TestDataSource struct {
-	currentFlags    map[string]ldstoretypes.ItemDescriptor
-	currentBuilders map[string]*FlagBuilder
-	currentSegments map[string]ldstoretypes.ItemDescriptor
-	instances       []*testDataSourceImpl
-	lock            sync.Mutex
+	currentFlags           map[string]ldstoretypes.ItemDescriptor
+	currentBuilders        map[string]*FlagBuilder
+	currentSegments        map[string]ldstoretypes.ItemDescriptor
+	currentSegmentBuilders map[string]*SegmentBuilder
+	instances              []*testDataSourceImpl
+	lock                   sync.Mutex
 }
 
 type testDataSourceImpl struct {
@@ -36,9 +37,10 @@ type testDataSourceImpl struct {
 // [TestDataSource.Update] will propagate to all LDClient instances that are using this data source.
 func DataSource() *TestDataSource {
 	return &TestDataSource{
-		currentFlags:    make(map[string]ldstoretypes.ItemDescriptor),
-		currentBuilders: make(map[string]*FlagBuilder),
-		currentSegments: make(map[string]ldstoretypes.ItemDescriptor),
+		currentFlags:           make(map[string]ldstoretypes.ItemDescriptor),
+		currentBuilders:        make(map[string]*FlagBuilder),
+		currentSegments:        make(map[string]ldstoretypes.ItemDescriptor),
+		currentSegmentBuilders: make(map[string]*SegmentBuilder),
 	}
 }
 
@@ -80,6 +82,93 @@ func (t *TestDataSource) Update(flagBuilder *FlagBuilder) *TestDataSource {
 	return t
 }
 
+// Segment creates or copies a [SegmentBuilder] for building a test segment configuration.
+//
+// If this segment key has already been defined in this TestDataSource instance with
+// [TestDataSource.UpdateSegment], then the builder starts with the same configuration that was last
+// provided for this segment.
+//
+// Otherwise, it starts with a new empty configuration in which the segment has no included or
+// excluded contexts and no rules. You can change any of those properties using the SegmentBuilder
+// methods.
+//
+// Once you have set the desired configuration, pass the builder to UpdateSegment. To make a flag
+// refer to the segment, use a flag rule such as [FlagBuilder.IfInSegment].
+func (t *TestDataSource) Segment(key string) *SegmentBuilder {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	existingBuilder := t.currentSegmentBuilders[key]
+	if existingBuilder == nil {
+		return newSegmentBuilder(key)
+	}
+	return copySegmentBuilder(existingBuilder)
+}
+
+// UpdateSegment updates the test data with the specified segment configuration.
+//
+// This has the same effect as if a segment were added or modified on the LaunchDarkly dashboard.
+// It immediately propagates the segment change to any LDClient instance(s) that you have already
+// configured to use this TestDataSource. If no LDClient has been started yet, it simply adds
+// this segment to the test data which will be provided to any LDClient that you subsequently
+// configure.
+//
+// Any subsequent changes to this SegmentBuilder instance do not affect the test data, unless
+// you call UpdateSegment again.
+func (t *TestDataSource) UpdateSegment(segmentBuilder *SegmentBuilder) *TestDataSource {
+	key := segmentBuilder.key
+	clonedBuilder := copySegmentBuilder(segmentBuilder)
+	t.updateSegmentInternal(key, segmentBuilder.createSegment, clonedBuilder)
+	return t
+}
+
+// Delete removes a flag from the test data.
+//
+// This has the same effect as if a flag were deleted on the LaunchDarkly dashboard. It immediately
+// propagates the deletion to any LDClient instance(s) that you have already configured to use this
+// TestDataSource, so that evaluating the flag returns the application default value with a
+// FLAG_NOT_FOUND error. If no LDClient has been started yet, it simply removes the flag from the test
+// data which will be provided to any LDClient that you subsequently configure.
+//
+// After a flag has been deleted, [TestDataSource.Flag] starts over with a new default configuration
+// for that key.
+func (t *TestDataSource) Delete(flagKey string) *TestDataSource {
+	t.applyChanges([]testDataChange{makeFlagChange(flagKey, nil, nil)})
+	return t
+}
+
+// DeleteSegment removes a segment from the test data.
+//
+// This has the same effect as if a segment were deleted on the LaunchDarkly dashboard. It immediately
+// propagates the deletion to any LDClient instance(s) that you have already configured to use this
+// TestDataSource. If no LDClient has been started yet, it simply removes the segment from the test
+// data which will be provided to any LDClient that you subsequently configure.
+func (t *TestDataSource) DeleteSegment(segmentKey string) *TestDataSource {
+	t.applyChanges([]testDataChange{makeSegmentChange(segmentKey, nil, nil)})
+	return t
+}
+
+// Batch applies several flag and segment changes to the test data as a single atomic update.
+//
+// The function is called with a [Transaction] that has the same update methods as TestDataSource.
+// None of the changes are visible to LDClient instances until the function returns; then, they are
+// all delivered at once, the way the SDK would receive a full data set from LaunchDarkly. The version
+// of each changed flag or segment is incremented once, even if it was changed more than once in the
+// batch, and each flag whose configuration was affected generates only one flag change event.
+//
+//	td.Batch(func(tx *ldtestdata.Transaction) {
+//		tx.Update(td.Flag("flag1").VariationForAll(true))
+//		tx.Update(td.Flag("flag2").VariationForAll(false))
+//		tx.Delete("flag3")
+//	})
+//
+// Do not call the Transaction's methods after the function has returned.
+func (t *TestDataSource) Batch(fn func(tx *Transaction)) *TestDataSource {
+	tx := &Transaction{}
+	fn(tx)
+	t.applyChanges(tx.changes)
+	return t
+}
+
 // UpdateStatus simulates a change in the data source status.
 //
 // Use this if you want to test the behavior of application code that uses
@@ -119,17 +208,7 @@ func (t *TestDataSource) UpdateStatus(
 // To construct an instance of ldmodel.FeatureFlag, rather than accessing the fields directly it is
 // recommended to use the builder API in [github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders].
 func (t *TestDataSource) UsePreconfiguredFlag(flag ldmodel.FeatureFlag) *TestDataSource {
-	t.updateInternal(
-		flag.Key,
-		func(version int) ldmodel.FeatureFlag {
-			f := flag
-			if f.Version < version {
-				f.Version = version
-			}
-			return f
-		},
-		nil,
-	)
+	t.updateInternal(flag.Key, preconfiguredFlag(flag), nil)
 	return t
 }
 
@@ -140,27 +219,17 @@ func (t *TestDataSource) UsePreconfiguredFlag(flag ldmodel.FeatureFlag) *TestDat
 // this flag to the test data which will be provided to any LDClient that you subsequently
 // configure.
 //
-// This method is currently the only way to inject user segment data, since there is no builder
-// API for segments. It is mainly intended for the SDK's own tests of user segment functionality,
-// since application tests that need to produce a desired evaluation state could do so more easily
-// by just setting flag values.
+// Use this method if you need to use advanced segment configuration properties that are not supported
+// by the simplified SegmentBuilder API. Otherwise it is recommended to use the regular
+// Segment/UpdateSegment mechanism to avoid dependencies on details of the data model.
+//
+// You cannot make incremental changes with Segment/UpdateSegment to a segment that has been added in
+// this way; you can only replace it with an entirely new segment configuration.
 //
 // To construct an instance of ldmodel.Segment, rather than accessing the fields directly it is
 // recommended to use the builder API in [github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders].
 func (t *TestDataSource) UsePreconfiguredSegment(segment ldmodel.Segment) *TestDataSource {
-	t.lock.Lock()
-	oldItem := t.currentSegments[segment.Key]
-	newSegment := segment
-	newSegment.Version = oldItem.Version + 1
-	newItem := ldstoretypes.ItemDescriptor{Version: newSegment.Version, Item: &newSegment}
-	t.currentSegments[segment.Key] = newItem
-	instances := slices.Clone(t.instances)
-	t.lock.Unlock()
-
-	for _, instance := range instances {
-		instance.updates.Upsert(ldstoreimpl.Segments(), segment.Key, newItem)
-	}
-
+	t.updateSegmentInternal(segment.Key, preconfiguredSegment(segment), nil)
 	return t
 }
 
@@ -169,18 +238,66 @@ func (t *TestDataSource) updateInternal(
 	makeFlag func(int) ldmodel.FeatureFlag,
 	builder *FlagBuilder,
 ) {
+	t.applyChanges([]testDataChange{makeFlagChange(key, makeFlag, builder)})
+}
+
+func (t *TestDataSource) updateSegmentInternal(
+	key string,
+	makeSegment func(int) ldmodel.Segment,
+	builder *SegmentBuilder,
+) {
+	t.applyChanges([]testDataChange{makeSegmentChange(key, makeSegment, builder)})
+}
+
+// applyChanges updates the test data and propagates the changes to all instances. A single change is
+// sent as an upsert; several changes are sent as a full data set, so that the SDK sees all of them at
+// once and generates only one flag change event for each affected flag.
+func (t *TestDataSource) applyChanges(changes []testDataChange) {
+	if len(changes) == 0 {
+		return
+	}
+
 	t.lock.Lock()
-	oldItem := t.currentFlags[key]
-	newVersion := oldItem.Version + 1
-	newFlag := makeFlag(newVersion)
-	newItem := ldstoretypes.ItemDescriptor{Version: newVersion, Item: &newFlag}
-	t.currentFlags[key] = newItem
-	t.currentBuilders[key] = builder
+	newVersions := make(map[ldstoretypes.DataKind]map[string]int)
+	var lastItem ldstoretypes.ItemDescriptor
+	for _, change := range changes {
+		currentItems := t.currentFlags
+		if change.kind == ldstoreimpl.Segments() {
+			currentItems = t.currentSegments
+		}
+		// If the same item is changed more than once in a batch, its version is only incremented once.
+		if newVersions[change.kind] == nil {
+			newVersions[change.kind] = make(map[string]int)
+		}
+		newVersion, ok := newVersions[change.kind][change.key]
+		if !ok {
+			newVersion = currentItems[change.key].Version + 1
+			newVersions[change.kind][change.key] = newVersion
+		}
+		lastItem = ldstoretypes.ItemDescriptor{Version: newVersion, Item: nil}
+		if change.makeItem != nil {
+			lastItem.Item = change.makeItem(newVersion)
+		}
+		currentItems[change.key] = lastItem
+		if change.kind == ldstoreimpl.Segments() {
+			t.currentSegmentBuilders[change.key] = change.segmentBuilder
+		} else {
+			t.currentBuilders[change.key] = change.flagBuilder
+		}
+	}
+	var allData []ldstoretypes.Collection
+	if len(changes) > 1 {
+		allData = t.makeInitDataLocked()
+	}
 	instances := slices.Clone(t.instances)
 	t.lock.Unlock()
 
 	for _, instance := range instances {
-		instance.updates.Upsert(ldstoreimpl.Features(), key, newItem)
+		if allData != nil {
+			_ = instance.updates.Init(allData)
+		} else {
+			instance.updates.Upsert(changes[0].kind, changes[0].key, lastItem)
+		}
 	}
 }
 
@@ -197,6 +314,12 @@ func (t *TestDataSource) Build(context subsystems.ClientContext) (subsystems.Dat
 func (t *TestDataSource) makeInitData() []ldstoretypes.Collection {
 	t.lock.Lock()
 	defer t.lock.Unlock()
+	return t.makeInitDataLocked()
+}
+
+func (t *TestDataSource) makeInitDataLocked() []ldstoretypes.Collection {
+	// Deleted items are included as tombstones with their current versions, so that the SDK does not see
+	// a flag that was deleted earlier as having changed again.
 	flags := make([]ldstoretypes.KeyedItemDescriptor, 0, len(t.currentFlags))
 	segments := make([]ldstoretypes.KeyedItemDescriptor, 0, len(t.currentSegments))
 	for key, item := range t.currentFlags {
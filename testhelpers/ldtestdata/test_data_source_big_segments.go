@@ -0,0 +1,73 @@
+package ldtestdata
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/bigsegments"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+)
+
+type testBigSegmentStoreConfigurer struct {
+	owner *TestDataSource
+}
+
+type testBigSegmentStoreImpl struct {
+	owner *TestDataSource
+}
+
+// BigSegmentStore returns a configurer for a Big Segment store that reports the Big Segment
+// memberships defined in this TestDataSource.
+//
+// Store this in the BigSegments configuration of the SDK client, along with using the TestDataSource
+// as the data source:
+//
+//	td := ldtestdata.DataSource()
+//	td.UpdateSegment(td.Segment("big-segment").Unbounded("user").BigSegmentIncluded("user-key"))
+//
+//	config := ld.Config{
+//		DataSource:  td,
+//		BigSegments: ldcomponents.BigSegments(td.BigSegmentStore()),
+//	}
+//
+// The store always reports that it is up to date. Memberships are determined by the Big Segments
+// that were most recently passed to [TestDataSource.UpdateSegment]; note that the SDK caches
+// membership queries for each context according to [ldcomponents.BigSegmentsConfigurationBuilder],
+// so a change in membership may not be visible to an SDK client that has already queried the same
+// context until the cache expires.
+func (t *TestDataSource) BigSegmentStore() subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+	return testBigSegmentStoreConfigurer{owner: t}
+}
+
+func (c testBigSegmentStoreConfigurer) Build(subsystems.ClientContext) (subsystems.BigSegmentStore, error) {
+	return &testBigSegmentStoreImpl{owner: c.owner}, nil
+}
+
+func (s *testBigSegmentStoreImpl) Close() error {
+	return nil
+}
+
+func (s *testBigSegmentStoreImpl) GetMetadata() (subsystems.BigSegmentStoreMetadata, error) {
+	return subsystems.BigSegmentStoreMetadata{LastUpToDate: ldtime.UnixMillisNow()}, nil
+}
+
+func (s *testBigSegmentStoreImpl) GetMembership(contextHash string) (subsystems.BigSegmentMembership, error) {
+	var included, excluded []string
+	s.owner.lock.Lock()
+	for _, sb := range s.owner.currentSegmentBuilders {
+		if sb == nil || !sb.unbounded {
+			continue
+		}
+		for key := range sb.bigSegmentIncluded {
+			if bigsegments.HashForContextKey(key) == contextHash {
+				included = append(included, sb.bigSegmentRef())
+			}
+		}
+		for key := range sb.bigSegmentExcluded {
+			if bigsegments.HashForContextKey(key) == contextHash {
+				excluded = append(excluded, sb.bigSegmentRef())
+			}
+		}
+	}
+	s.owner.lock.Unlock()
+	return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(included, excluded), nil
+}
@@ -20,13 +20,17 @@ const (
 
 // FlagBuilder is a builder for feature flag configurations to be used with [TestDataSource].
 type FlagBuilder struct {
-	key                  string
-	on                   bool
-	offVariation         ldvalue.OptionalInt
-	fallthroughVariation ldvalue.OptionalInt
-	variations           []ldvalue.Value
-	targets              map[ldcontext.Kind]map[int]map[string]bool
-	rules                []*RuleBuilder
+	key                    string
+	on                     bool
+	offVariation           ldvalue.OptionalInt
+	fallthroughVariation   ldvalue.OptionalInt
+	fallthroughRollout     *RolloutBuilder
+	variations             []ldvalue.Value
+	targets                map[ldcontext.Kind]map[int]map[string]bool
+	rules                  []*RuleBuilder
+	prerequisites          []ldmodel.Prerequisite
+	trackEvents            bool
+	trackEventsFallthrough bool
 }
 
 // RuleBuilder is a builder for feature flag rules to be used with [TestDataSource.]
@@ -38,11 +42,14 @@ type FlagBuilder struct {
 // To start defining a rule, use one of the flag builder's matching methods such as [RuleBuilder.IfMatch].
 // This defines the first clause for the rule. Optionally, you may add more clauses with the rule builder's
 // methods such as [RuleBuilder.AndMatch]. Finally, call [RuleBuilder.ThenReturn] or
-// [RuleBuilder.ThenReturnIndex] to finish defining the rule.
+// [RuleBuilder.ThenReturnIndex] to finish defining the rule, or [RuleBuilder.ThenRollout] to make the
+// rule serve a percentage rollout.
 type RuleBuilder struct {
-	owner     *FlagBuilder
-	variation int
-	clauses   []ldmodel.Clause
+	owner       *FlagBuilder
+	variation   int
+	rollout     *RolloutBuilder
+	clauses     []ldmodel.Clause
+	trackEvents bool
 }
 
 func newFlagBuilder(key string) *FlagBuilder {
@@ -56,6 +63,8 @@ func copyFlagBuilder(from *FlagBuilder) *FlagBuilder {
 	f := new(FlagBuilder)
 	*f = *from
 	f.variations = slices.Clone(from.variations)
+	f.fallthroughRollout = copyRolloutBuilder(from.fallthroughRollout)
+	f.prerequisites = slices.Clone(from.prerequisites)
 	if f.rules != nil {
 		f.rules = make([]*RuleBuilder, 0, len(from.rules))
 		for _, r := range from.rules {
@@ -121,6 +130,37 @@ func (f *FlagBuilder) FallthroughVariation(variation bool) *FlagBuilder {
 // [FlagBuilder.FallthroughVariation].
 func (f *FlagBuilder) FallthroughVariationIndex(variationIndex int) *FlagBuilder {
 	f.fallthroughVariation = ldvalue.NewOptionalInt(variationIndex)
+	f.fallthroughRollout = nil
+	return f
+}
+
+// FallthroughRollout specifies that the fallthrough should be a percentage rollout, in which each
+// context that was not matched by a more specific target or rule gets a variation based on a hash of
+// its key. This is a shortcut for calling [FlagBuilder.FallthroughRolloutWith] with [Rollout](weights...).
+//
+// Each weight is the share of contexts that should get the variation with the same index, in
+// thousandths of a percent; the weights should add up to [RolloutWeightTotal]. For instance, this
+// serves true to 25% of contexts and false to the rest:
+//
+//	testData.Flag("flag").FallthroughRollout(25000, 75000)
+func (f *FlagBuilder) FallthroughRollout(weights ...int) *FlagBuilder {
+	return f.FallthroughRolloutWith(Rollout(weights...))
+}
+
+// FallthroughRolloutWith specifies that the fallthrough should be a percentage rollout, using a
+// [RolloutBuilder] that can also specify the bucketing attribute and context kind, or make the rollout
+// an experiment.
+//
+// For example, this serves variation 1 to 10% of "org" contexts based on their "region" attribute,
+// and variation 0 to the rest:
+//
+//	testData.Flag("flag").
+//	    FallthroughRolloutWith(ldtestdata.Rollout(90000, 10000).ContextKind("org").BucketBy("region"))
+//
+// Any subsequent changes to the RolloutBuilder do not affect this flag.
+func (f *FlagBuilder) FallthroughRolloutWith(rollout *RolloutBuilder) *FlagBuilder {
+	f.fallthroughRollout = copyRolloutBuilder(rollout)
+	f.fallthroughVariation = ldvalue.OptionalInt{}
 	return f
 }
 
@@ -146,6 +186,60 @@ func (f *FlagBuilder) OffVariationIndex(variationIndex int) *FlagBuilder {
 	return f
 }
 
+// Prerequisite adds a prerequisite to the flag, which requires that the flag with the key prerequisiteKey
+// returns the specified boolean variation for the context. If the prerequisite is not met, this flag
+// returns its off variation.
+//
+// This assumes that the prerequisite flag uses the standard boolean configuration, as provided by
+// [FlagBuilder.BooleanFlag]. To specify the variation by variation index instead (such as for a
+// non-boolean prerequisite flag), use [FlagBuilder.PrerequisiteIndex].
+//
+// The prerequisite flag must also be defined in the same TestDataSource, or else the prerequisite is
+// never met.
+func (f *FlagBuilder) Prerequisite(prerequisiteKey string, variation bool) *FlagBuilder {
+	return f.PrerequisiteIndex(prerequisiteKey, variationForBool(variation))
+}
+
+// PrerequisiteIndex adds a prerequisite to the flag, which requires that the flag with the key
+// prerequisiteKey returns the specified variation for the context. If the prerequisite is not met,
+// this flag returns its off variation. The index is 0 for the first variation of the prerequisite
+// flag, 1 for the second, etc.
+//
+// If this flag already had a prerequisite with the same key, it is replaced.
+func (f *FlagBuilder) PrerequisiteIndex(prerequisiteKey string, variationIndex int) *FlagBuilder {
+	for i, p := range f.prerequisites {
+		if p.Key == prerequisiteKey {
+			f.prerequisites[i].Variation = variationIndex
+			return f
+		}
+	}
+	f.prerequisites = append(f.prerequisites, ldmodel.Prerequisite{Key: prerequisiteKey, Variation: variationIndex})
+	return f
+}
+
+// ClearPrerequisites removes any existing prerequisites from the flag. This undoes the effect of
+// methods like [FlagBuilder.Prerequisite].
+func (f *FlagBuilder) ClearPrerequisites() *FlagBuilder {
+	f.prerequisites = nil
+	return f
+}
+
+// TrackEvents sets whether the SDK should send full analytics events for every evaluation of this
+// flag, rather than only summary counts. This corresponds to turning on data export for the flag, or
+// using it in an experiment, on the LaunchDarkly dashboard.
+func (f *FlagBuilder) TrackEvents(trackEvents bool) *FlagBuilder {
+	f.trackEvents = trackEvents
+	return f
+}
+
+// TrackEventsFallthrough sets whether the SDK should send full analytics events for evaluations of
+// this flag that result in the fallthrough variation or rollout, as it would for an experiment on
+// the fallthrough.
+func (f *FlagBuilder) TrackEventsFallthrough(trackEvents bool) *FlagBuilder {
+	f.trackEventsFallthrough = trackEvents
+	return f
+}
+
 // VariationForAll sets the flag to return the specified boolean variation by default for all contexts.
 //
 // Targeting is switched on, any existing targets or rules are removed, and the flag's variations are
@@ -334,6 +428,97 @@ func (f *FlagBuilder) IfNotMatchContext(
 	return newTestFlagRuleBuilder(f).AndNotMatchContext(contextKind, attribute, values...)
 }
 
+// IfInSegment starts defining a flag rule that matches any context that is a member of at least one
+// of the specified segments. Segments can be defined with [TestDataSource.Segment].
+//
+// The method returns a [RuleBuilder]. Call its [RuleBuilder.ThenReturn] or [RuleBuilder.ThenReturnIndex]
+// method to finish the rule, or add more tests with another method like [RuleBuilder.AndMatch].
+//
+// For example, this creates a rule that returns true for members of the segment "beta-testers":
+//
+//	testData.Flag("flag").
+//	    IfInSegment("beta-testers").
+//	        ThenReturn(true)
+func (f *FlagBuilder) IfInSegment(segmentKeys ...string) *RuleBuilder {
+	return newTestFlagRuleBuilder(f).AndInSegment(segmentKeys...)
+}
+
+// IfNotInSegment starts defining a flag rule that matches any context that is not a member of any
+// of the specified segments. Segments can be defined with [TestDataSource.Segment].
+//
+// The method returns a [RuleBuilder]. Call its [RuleBuilder.ThenReturn] or [RuleBuilder.ThenReturnIndex]
+// method to finish the rule, or add more tests with another method like [RuleBuilder.AndMatch].
+func (f *FlagBuilder) IfNotInSegment(segmentKeys ...string) *RuleBuilder {
+	return newTestFlagRuleBuilder(f).AndNotInSegment(segmentKeys...)
+}
+
+// IfMatchOperator starts defining a flag rule, using any clause operator. This is a shortcut for
+// calling [FlagBuilder.IfMatchContextOperator] with "user" as the context kind.
+//
+// The method returns a [RuleBuilder]. Call its [RuleBuilder.ThenReturn] or [RuleBuilder.ThenReturnIndex]
+// method to finish the rule, or add more tests with another method like [RuleBuilder.AndMatch].
+//
+// For example, this creates a rule that returns true if the user email attribute ends with
+// "@example.com":
+//
+//	testData.Flag("flag").
+//	    IfMatchOperator("email", ldmodel.OperatorEndsWith, ldvalue.String("@example.com")).
+//	        ThenReturn(true)
+//
+// The values are interpreted as they would be by LaunchDarkly for the same operator: for instance,
+// semantic version operators like [ldmodel.OperatorSemVerLessThan] expect strings such as "2.0.0", and
+// date operators like [ldmodel.OperatorBefore] expect either a number of milliseconds since the Unix
+// epoch or an RFC3339 timestamp string.
+func (f *FlagBuilder) IfMatchOperator(
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
+) *RuleBuilder {
+	return newTestFlagRuleBuilder(f).AndMatchOperator(attribute, operator, values...)
+}
+
+// IfMatchContextOperator starts defining a flag rule, using any clause operator. This matching
+// expression only applies to contexts of a specific kind, identified by the contextKind parameter.
+//
+// The method returns a [RuleBuilder]. Call its [RuleBuilder.ThenReturn] or [RuleBuilder.ThenReturnIndex]
+// method to finish the rule, or add more tests with another method like [RuleBuilder.AndMatch].
+func (f *FlagBuilder) IfMatchContextOperator(
+	contextKind ldcontext.Kind,
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
+) *RuleBuilder {
+	return newTestFlagRuleBuilder(f).AndMatchContextOperator(contextKind, attribute, operator, values...)
+}
+
+// IfNotMatchOperator starts defining a flag rule, using the negation of any clause operator. This is
+// a shortcut for calling [FlagBuilder.IfNotMatchContextOperator] with "user" as the context kind.
+//
+// The method returns a [RuleBuilder]. Call its [RuleBuilder.ThenReturn] or [RuleBuilder.ThenReturnIndex]
+// method to finish the rule, or add more tests with another method like [RuleBuilder.AndMatch].
+func (f *FlagBuilder) IfNotMatchOperator(
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
+) *RuleBuilder {
+	return newTestFlagRuleBuilder(f).AndNotMatchOperator(attribute, operator, values...)
+}
+
+// IfNotMatchContextOperator starts defining a flag rule, using the negation of any clause operator.
+// This matching expression only applies to contexts of a specific kind, identified by the contextKind
+// parameter.
+//
+// The method returns a [RuleBuilder]. Call its [RuleBuilder.ThenReturn] or [RuleBuilder.ThenReturnIndex]
+// method to finish the rule, or add more tests with another method like [RuleBuilder.AndMatch].
+func (f *FlagBuilder) IfNotMatchContextOperator(
+	contextKind ldcontext.Kind,
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
+) *RuleBuilder {
+	return newTestFlagRuleBuilder(f).AndNotMatchContextOperator(contextKind, attribute, operator, values...)
+}
+
 // ClearRules removes any existing rules from the flag. This undoes the effect of methods like
 // [FlagBuilder.IfMatch].
 func (f *FlagBuilder) ClearRules() *FlagBuilder {
@@ -358,13 +543,21 @@ func (f *FlagBuilder) createFlag(version int) ldmodel.FeatureFlag {
 	fb := ldbuilders.NewFlagBuilder(f.key).
 		Version(version).
 		On(f.on).
-		Variations(f.variations...)
+		Variations(f.variations...).
+		TrackEvents(f.trackEvents).
+		TrackEventsFallthrough(f.trackEventsFallthrough)
 	if f.offVariation.IsDefined() {
 		fb.OffVariation(f.offVariation.IntValue())
 	}
 	if f.fallthroughVariation.IsDefined() {
 		fb.FallthroughVariation(f.fallthroughVariation.IntValue())
 	}
+	if f.fallthroughRollout != nil {
+		fb.Fallthrough(f.fallthroughRollout.build())
+	}
+	for _, p := range f.prerequisites {
+		fb.AddPrerequisite(p.Key, p.Variation)
+	}
 
 	// Iterate through any context kinds that there are targets for. A quirk of the data model, for
 	// backward-compatibility reasons, is that each entry in the old-style targets list (for users)
@@ -396,11 +589,15 @@ func (f *FlagBuilder) createFlag(version int) ldmodel.FeatureFlag {
 		}
 	}
 	for i, r := range f.rules {
-		fb.AddRule(ldbuilders.NewRuleBuilder().
+		rb := ldbuilders.NewRuleBuilder().
 			ID(fmt.Sprintf("rule%d", i)).
 			Variation(r.variation).
-			Clauses(r.clauses...),
-		)
+			Clauses(r.clauses...).
+			TrackEvents(r.trackEvents)
+		if r.rollout != nil {
+			rb.VariationOrRollout(r.rollout.build())
+		}
+		fb.AddRule(rb)
 	}
 	return fb.Build()
 }
@@ -410,7 +607,8 @@ func newTestFlagRuleBuilder(owner *FlagBuilder) *RuleBuilder {
 }
 
 func copyTestFlagRuleBuilder(from *RuleBuilder, owner *FlagBuilder) *RuleBuilder {
-	r := RuleBuilder{owner: owner, variation: from.variation}
+	r := RuleBuilder{owner: owner, variation: from.variation, trackEvents: from.trackEvents}
+	r.rollout = copyRolloutBuilder(from.rollout)
 	r.clauses = slices.Clone(from.clauses)
 	return &r
 }
@@ -444,8 +642,7 @@ func (r *RuleBuilder) AndMatchContext(
 	attribute string,
 	values ...ldvalue.Value,
 ) *RuleBuilder {
-	r.clauses = append(r.clauses, ldbuilders.ClauseWithKind(contextKind, attribute, ldmodel.OperatorIn, values...))
-	return r
+	return r.AndMatchContextOperator(contextKind, attribute, ldmodel.OperatorIn, values...)
 }
 
 // AndNotMatch adds another clause, using the "is not one of" operator. This is a shortcut for calling
@@ -476,9 +673,83 @@ func (r *RuleBuilder) AndNotMatchContext(
 	contextKind ldcontext.Kind,
 	attribute string,
 	values ...ldvalue.Value,
+) *RuleBuilder {
+	return r.AndNotMatchContextOperator(contextKind, attribute, ldmodel.OperatorIn, values...)
+}
+
+// AndMatchOperator adds another clause, using any clause operator. This is a shortcut for calling
+// [RuleBuilder.AndMatchContextOperator] with "user" as the context kind.
+//
+// For example, this creates a rule that returns true if the user name attribute starts with "P" and
+// the user's "appVersion" attribute is a semantic version less than 2.0.0:
+//
+//	testData.Flag("flag").
+//	    IfMatchOperator("name", ldmodel.OperatorStartsWith, ldvalue.String("P")).
+//	        AndMatchOperator("appVersion", ldmodel.OperatorSemVerLessThan, ldvalue.String("2.0.0")).
+//	        ThenReturn(true)
+func (r *RuleBuilder) AndMatchOperator(
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
+) *RuleBuilder {
+	return r.AndMatchContextOperator(ldcontext.DefaultKind, attribute, operator, values...)
+}
+
+// AndMatchContextOperator adds another clause, using any clause operator. This matching expression
+// only applies to contexts of a specific kind, identified by the contextKind parameter.
+func (r *RuleBuilder) AndMatchContextOperator(
+	contextKind ldcontext.Kind,
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
+) *RuleBuilder {
+	r.clauses = append(r.clauses, ldbuilders.ClauseWithKind(contextKind, attribute, operator, values...))
+	return r
+}
+
+// AndNotMatchOperator adds another clause, using the negation of any clause operator. This is a
+// shortcut for calling [RuleBuilder.AndNotMatchContextOperator] with "user" as the context kind.
+func (r *RuleBuilder) AndNotMatchOperator(
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
+) *RuleBuilder {
+	return r.AndNotMatchContextOperator(ldcontext.DefaultKind, attribute, operator, values...)
+}
+
+// AndNotMatchContextOperator adds another clause, using the negation of any clause operator. This
+// matching expression only applies to contexts of a specific kind, identified by the contextKind
+// parameter.
+func (r *RuleBuilder) AndNotMatchContextOperator(
+	contextKind ldcontext.Kind,
+	attribute string,
+	operator ldmodel.Operator,
+	values ...ldvalue.Value,
 ) *RuleBuilder {
 	r.clauses = append(r.clauses, ldbuilders.Negate(ldbuilders.ClauseWithKind(contextKind,
-		attribute, ldmodel.OperatorIn, values...)))
+		attribute, operator, values...)))
+	return r
+}
+
+// AndInSegment adds another clause, which matches any context that is a member of at least one of
+// the specified segments.
+//
+// For example, this creates a rule that returns true if the user is in the segment "beta-testers"
+// and the country is "gb":
+//
+//	testData.Flag("flag").
+//	    IfInSegment("beta-testers").
+//	        AndMatch("country", ldvalue.String("gb")).
+//	        ThenReturn(true)
+func (r *RuleBuilder) AndInSegment(segmentKeys ...string) *RuleBuilder {
+	r.clauses = append(r.clauses, ldbuilders.SegmentMatchClause(segmentKeys...))
+	return r
+}
+
+// AndNotInSegment adds another clause, which matches any context that is not a member of any of the
+// specified segments.
+func (r *RuleBuilder) AndNotInSegment(segmentKeys ...string) *RuleBuilder {
+	r.clauses = append(r.clauses, ldbuilders.Negate(ldbuilders.SegmentMatchClause(segmentKeys...)))
 	return r
 }
 
@@ -492,10 +763,44 @@ func (r *RuleBuilder) ThenReturn(variation bool) *FlagBuilder {
 // is 0 for the first variation, 1 for the second, etc.
 func (r *RuleBuilder) ThenReturnIndex(variation int) *FlagBuilder {
 	r.variation = variation
+	r.rollout = nil
 	r.owner.rules = append(r.owner.rules, r)
 	return r.owner
 }
 
+// ThenRollout finishes defining the rule, specifying that it serves a percentage rollout. This is a
+// shortcut for calling [RuleBuilder.ThenRolloutWith] with [Rollout](weights...).
+//
+// Each weight is the share of matching contexts that should get the variation with the same index, in
+// thousandths of a percent; the weights should add up to [RolloutWeightTotal].
+func (r *RuleBuilder) ThenRollout(weights ...int) *FlagBuilder {
+	return r.ThenRolloutWith(Rollout(weights...))
+}
+
+// ThenRolloutWith finishes defining the rule, specifying that it serves a percentage rollout that is
+// configured with a [RolloutBuilder].
+//
+// For example, this creates a rule that runs an experiment among users whose country is "gb", with
+// half of them getting each variation:
+//
+//	testData.Flag("flag").
+//	    IfMatch("country", ldvalue.String("gb")).
+//	        ThenRolloutWith(ldtestdata.Rollout(50000, 50000).Experiment())
+//
+// Any subsequent changes to the RolloutBuilder do not affect this rule.
+func (r *RuleBuilder) ThenRolloutWith(rollout *RolloutBuilder) *FlagBuilder {
+	r.rollout = copyRolloutBuilder(rollout)
+	r.owner.rules = append(r.owner.rules, r)
+	return r.owner
+}
+
+// TrackEvents sets whether the SDK should send full analytics events for evaluations of this flag
+// that are matched by this rule, as it would for an experiment on the rule.
+func (r *RuleBuilder) TrackEvents(trackEvents bool) *RuleBuilder {
+	r.trackEvents = trackEvents
+	return r
+}
+
 func variationForBool(value bool) int {
 	if value {
 		return trueVariationForBool
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
 	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
 	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
 	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
@@ -282,4 +283,130 @@ func TestRuleConfig(t *testing.T) {
 			),
 		))
 	})
+	t.Run("segment match", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.IfInSegment("a", "b").AndNotInSegment("c").ThenReturn(true).
+				IfNotInSegment("d").ThenReturn(false)
+		}, basicBool().On(true).FallthroughVariation(0).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule0").Variation(trueVar).Clauses(
+				ldbuilders.SegmentMatchClause("a", "b"),
+				ldbuilders.Negate(ldbuilders.SegmentMatchClause("c")),
+			),
+		).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule1").Variation(falseVar).Clauses(
+				ldbuilders.Negate(ldbuilders.SegmentMatchClause("d")),
+			),
+		))
+	})
+}
+
+func TestRuleOperators(t *testing.T) {
+	t.Run("any operator", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.IfMatchOperator("name", ldmodel.OperatorStartsWith, ldvalue.String("P")).
+				AndMatchContextOperator("org", "version", ldmodel.OperatorSemVerLessThan, ldvalue.String("2.0.0")).
+				ThenReturn(true)
+		}, basicBool().On(true).FallthroughVariation(0).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule0").Variation(trueVar).Clauses(
+				ldbuilders.ClauseWithKind("user", "name", ldmodel.OperatorStartsWith, ldvalue.String("P")),
+				ldbuilders.ClauseWithKind("org", "version", ldmodel.OperatorSemVerLessThan, ldvalue.String("2.0.0")),
+			),
+		))
+
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.IfMatchContextOperator("org", "created", ldmodel.OperatorBefore, ldvalue.Int(1000)).ThenReturn(true)
+		}, basicBool().On(true).FallthroughVariation(0).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule0").Variation(trueVar).Clauses(
+				ldbuilders.ClauseWithKind("org", "created", ldmodel.OperatorBefore, ldvalue.Int(1000)),
+			),
+		))
+	})
+
+	t.Run("negated operator", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.IfNotMatchOperator("email", ldmodel.OperatorEndsWith, ldvalue.String("@example.com")).
+				AndNotMatchOperator("name", ldmodel.OperatorContains, ldvalue.String("x")).
+				ThenReturn(true).
+				IfNotMatchContextOperator("org", "name", ldmodel.OperatorMatches, ldvalue.String("^a")).
+				ThenReturn(false)
+		}, basicBool().On(true).FallthroughVariation(0).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule0").Variation(trueVar).Clauses(
+				ldbuilders.Negate(ldbuilders.ClauseWithKind("user", "email", ldmodel.OperatorEndsWith,
+					ldvalue.String("@example.com"))),
+				ldbuilders.Negate(ldbuilders.ClauseWithKind("user", "name", ldmodel.OperatorContains, ldvalue.String("x"))),
+			),
+		).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule1").Variation(falseVar).Clauses(
+				ldbuilders.Negate(ldbuilders.ClauseWithKind("org", "name", ldmodel.OperatorMatches, ldvalue.String("^a"))),
+			),
+		))
+	})
+}
+
+func TestRollouts(t *testing.T) {
+	t.Run("fallthrough rollout", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.FallthroughRollout(25000, 75000)
+		}, basicBool().On(true).Fallthrough(ldbuilders.Rollout(
+			ldbuilders.Bucket(0, 25000), ldbuilders.Bucket(1, 75000))))
+
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.FallthroughRollout(25000, 75000).FallthroughVariation(false)
+		}, basicBool().On(true).FallthroughVariation(falseVar))
+	})
+
+	t.Run("fallthrough rollout with bucketing options", func(t *testing.T) {
+		expectedRollout := ldbuilders.Rollout(ldbuilders.Bucket(0, 10000), ldbuilders.Bucket(1, 90000))
+		expectedRollout.Rollout.ContextKind = "org"
+		expectedRollout.Rollout.BucketBy = ldattr.NewLiteralRef("region")
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.FallthroughRolloutWith(Rollout(10000, 90000).ContextKind("org").BucketBy("region"))
+		}, basicBool().On(true).Fallthrough(expectedRollout))
+	})
+
+	t.Run("experiment", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.TrackEventsFallthrough(true).
+				FallthroughRolloutWith(Rollout(50000, 40000, 10000).Experiment().Seed(61).Untracked(2)).
+				Variations(threeStringValues...)
+		}, ldbuilders.NewFlagBuilder("flagkey").Version(1).On(true).Variations(threeStringValues...).
+			OffVariation(1).TrackEventsFallthrough(true).
+			Fallthrough(ldbuilders.Experiment(ldvalue.NewOptionalInt(61),
+				ldbuilders.Bucket(0, 50000), ldbuilders.Bucket(1, 40000), ldbuilders.BucketUntracked(2, 10000))))
+	})
+
+	t.Run("rule rollout", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.IfMatch("name", ldvalue.String("Lucy")).TrackEvents(true).ThenRollout(30000, 70000).
+				IfMatch("name", ldvalue.String("Mina")).ThenRolloutWith(Rollout(50000, 50000).Experiment())
+		}, basicBool().On(true).FallthroughVariation(0).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule0").TrackEvents(true).Clauses(
+				ldbuilders.ClauseWithKind("user", "name", ldmodel.OperatorIn, ldvalue.String("Lucy")),
+			).VariationOrRollout(ldbuilders.Rollout(ldbuilders.Bucket(0, 30000), ldbuilders.Bucket(1, 70000))),
+		).AddRule(
+			ldbuilders.NewRuleBuilder().ID("rule1").Clauses(
+				ldbuilders.ClauseWithKind("user", "name", ldmodel.OperatorIn, ldvalue.String("Mina")),
+			).VariationOrRollout(ldbuilders.Experiment(ldvalue.OptionalInt{},
+				ldbuilders.Bucket(0, 50000), ldbuilders.Bucket(1, 50000))),
+		))
+	})
+}
+
+func TestPrerequisitesAndTracking(t *testing.T) {
+	t.Run("prerequisites", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.Prerequisite("a", true).PrerequisiteIndex("b", 2).Prerequisite("a", false)
+		}, basicBool().On(true).FallthroughVariation(trueVar).
+			AddPrerequisite("a", falseVar).AddPrerequisite("b", 2))
+
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.Prerequisite("a", true).ClearPrerequisites()
+		}, basicBool().On(true).FallthroughVariation(trueVar))
+	})
+
+	t.Run("track events", func(t *testing.T) {
+		verifyFlag(t, func(f *FlagBuilder) {
+			f.TrackEvents(true)
+		}, basicBool().On(true).FallthroughVariation(trueVar).TrackEvents(true))
+	})
 }
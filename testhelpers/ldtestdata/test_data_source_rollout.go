@@ -0,0 +1,115 @@
+package ldtestdata
+
+import (
+	"github.com/launchdarkly/go-sdk-common/v3/ldattr"
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// RolloutWeightTotal is the sum of all weights in a percentage rollout that covers 100% of contexts.
+// Weights are expressed in thousandths of a percent, so a weight of 25000 means 25%.
+const RolloutWeightTotal = 100000
+
+// RolloutBuilder is a builder for percentage rollouts to be used with [FlagBuilder.FallthroughRolloutWith]
+// or [RuleBuilder.ThenRolloutWith].
+//
+// A percentage rollout assigns each context to a variation based on a hash of the flag key, the flag's
+// salt, and an attribute of the context (by default, its key). It is deterministic, so the same context
+// always gets the same variation as long as the rollout configuration does not change.
+type RolloutBuilder struct {
+	weights     []int
+	untracked   map[int]bool
+	bucketBy    string
+	contextKind ldcontext.Kind
+	experiment  bool
+	seed        ldvalue.OptionalInt
+}
+
+// Rollout creates a [RolloutBuilder] for a percentage rollout.
+//
+// Each weight is the share of contexts that should get the variation with the same index: the first
+// weight is for variation 0, the second for variation 1, etc. Weights are expressed in thousandths of
+// a percent, and should add up to [RolloutWeightTotal]. For instance, Rollout(25000, 75000) assigns 25%
+// of contexts to variation 0 and 75% to variation 1.
+func Rollout(weights ...int) *RolloutBuilder {
+	return &RolloutBuilder{weights: slices.Clone(weights)}
+}
+
+// BucketBy sets the context attribute that is used to assign contexts to variations. By default, the
+// context key is used. The attribute is assumed to be a simple attribute name rather than a path
+// reference.
+func (r *RolloutBuilder) BucketBy(attribute string) *RolloutBuilder {
+	r.bucketBy = attribute
+	return r
+}
+
+// ContextKind sets the kind of context whose attributes are used to assign contexts to variations.
+// By default, this is "user".
+func (r *RolloutBuilder) ContextKind(contextKind ldcontext.Kind) *RolloutBuilder {
+	r.contextKind = contextKind
+	return r
+}
+
+// Experiment makes this rollout an experiment. The SDK reports evaluations from an experiment as
+// being in the experiment (see [github.com/launchdarkly/go-sdk-common/v3/ldreason.EvaluationReason]),
+// and always sends full analytics events for them.
+//
+// Experiments always use the context key for bucketing, so a [RolloutBuilder.BucketBy] setting is
+// ignored by the SDK for an experiment.
+func (r *RolloutBuilder) Experiment() *RolloutBuilder {
+	r.experiment = true
+	return r
+}
+
+// Seed sets a fixed seed for the bucketing hash, instead of using the flag key and salt. This is
+// normally only used for experiments.
+func (r *RolloutBuilder) Seed(seed int) *RolloutBuilder {
+	r.seed = ldvalue.NewOptionalInt(seed)
+	return r
+}
+
+// Untracked marks the buckets for the specified variation indexes as not being part of an experiment.
+// Contexts that fall into these buckets are not reported as being in the experiment.
+func (r *RolloutBuilder) Untracked(variationIndexes ...int) *RolloutBuilder {
+	r.untracked = addKeys(r.untracked, variationIndexes)
+	return r
+}
+
+func copyRolloutBuilder(from *RolloutBuilder) *RolloutBuilder {
+	if from == nil {
+		return nil
+	}
+	r := new(RolloutBuilder)
+	*r = *from
+	r.weights = slices.Clone(from.weights)
+	r.untracked = maps.Clone(from.untracked)
+	return r
+}
+
+func (r *RolloutBuilder) build() ldmodel.VariationOrRollout {
+	buckets := make([]ldmodel.WeightedVariation, 0, len(r.weights))
+	for i, w := range r.weights {
+		if r.untracked[i] {
+			buckets = append(buckets, ldbuilders.BucketUntracked(i, w))
+		} else {
+			buckets = append(buckets, ldbuilders.Bucket(i, w))
+		}
+	}
+	var vr ldmodel.VariationOrRollout
+	if r.experiment {
+		vr = ldbuilders.Experiment(r.seed, buckets...)
+	} else {
+		vr = ldbuilders.Rollout(buckets...)
+		vr.Rollout.Seed = r.seed
+	}
+	vr.Rollout.ContextKind = r.contextKind
+	if r.bucketBy != "" {
+		vr.Rollout.BucketBy = ldattr.NewLiteralRef(r.bucketBy)
+	}
+	return vr
+}
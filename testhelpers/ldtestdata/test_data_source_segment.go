@@ -0,0 +1,392 @@
+package ldtestdata
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// SegmentBuilder is a builder for segment configurations to be used with [TestDataSource].
+//
+// A segment can include or exclude specific context keys, and can include any context that matches
+// one of its rules. It can also be configured as a Big Segment (also known as an unbounded segment),
+// in which case the membership of individual contexts is provided by a Big Segment store; see
+// [SegmentBuilder.Unbounded] and [TestDataSource.BigSegmentStore].
+type SegmentBuilder struct {
+	key                  string
+	included             map[ldcontext.Kind]map[string]bool
+	excluded             map[ldcontext.Kind]map[string]bool
+	rules                []*SegmentRuleBuilder
+	unbounded            bool
+	unboundedContextKind ldcontext.Kind
+	generation           ldvalue.OptionalInt
+	bigSegmentIncluded   map[string]bool
+	bigSegmentExcluded   map[string]bool
+}
+
+// SegmentRuleBuilder is a builder for segment rules to be used with [TestDataSource].
+//
+// A segment rule matches a context if all of the rule's clauses match the context; any context that
+// matches a rule is included in the segment, unless it has been explicitly excluded.
+//
+// To start defining a rule, use one of the segment builder's matching methods such as
+// [SegmentBuilder.IfMatch]. This defines the first clause for the rule. Optionally, you may add more
+// clauses with the rule builder's methods such as [SegmentRuleBuilder.AndMatch]. Finally, call
+// [SegmentRuleBuilder.ThenInclude] to finish defining the rule.
+type SegmentRuleBuilder struct {
+	owner   *SegmentBuilder
+	clauses []ldmodel.Clause
+}
+
+func newSegmentBuilder(key string) *SegmentBuilder {
+	return &SegmentBuilder{key: key}
+}
+
+func copySegmentBuilder(from *SegmentBuilder) *SegmentBuilder {
+	s := new(SegmentBuilder)
+	*s = *from
+	s.included = copyKeysByKind(from.included)
+	s.excluded = copyKeysByKind(from.excluded)
+	s.bigSegmentIncluded = maps.Clone(from.bigSegmentIncluded)
+	s.bigSegmentExcluded = maps.Clone(from.bigSegmentExcluded)
+	if s.rules != nil {
+		s.rules = make([]*SegmentRuleBuilder, 0, len(from.rules))
+		for _, r := range from.rules {
+			s.rules = append(s.rules, &SegmentRuleBuilder{owner: s, clauses: slices.Clone(r.clauses)})
+		}
+	}
+	return s
+}
+
+// Included adds user keys to the segment's list of included contexts (that is, contexts with these
+// keys whose context kind is "user"). This is a shortcut for calling [SegmentBuilder.IncludedContextKind]
+// with "user" as the context kind.
+//
+// If any of these keys were previously in the excluded list for the same context kind, they are
+// removed from it.
+func (s *SegmentBuilder) Included(keys ...string) *SegmentBuilder {
+	return s.IncludedContextKind(ldcontext.DefaultKind, keys...)
+}
+
+// IncludedContextKind adds context keys to the segment's list of included contexts for the specified
+// context kind.
+//
+// If any of these keys were previously in the excluded list for the same context kind, they are
+// removed from it.
+func (s *SegmentBuilder) IncludedContextKind(contextKind ldcontext.Kind, keys ...string) *SegmentBuilder {
+	s.included = addKeysForKind(s.included, contextKind, keys)
+	s.excluded = removeKeysForKind(s.excluded, contextKind, keys)
+	return s
+}
+
+// Excluded adds user keys to the segment's list of excluded contexts (that is, contexts with these
+// keys whose context kind is "user"). This is a shortcut for calling [SegmentBuilder.ExcludedContextKind]
+// with "user" as the context kind.
+//
+// An excluded context is not a member of the segment even if it matches one of the segment's rules.
+// If any of these keys were previously in the included list for the same context kind, they are
+// removed from it.
+func (s *SegmentBuilder) Excluded(keys ...string) *SegmentBuilder {
+	return s.ExcludedContextKind(ldcontext.DefaultKind, keys...)
+}
+
+// ExcludedContextKind adds context keys to the segment's list of excluded contexts for the specified
+// context kind.
+//
+// An excluded context is not a member of the segment even if it matches one of the segment's rules.
+// If any of these keys were previously in the included list for the same context kind, they are
+// removed from it.
+func (s *SegmentBuilder) ExcludedContextKind(contextKind ldcontext.Kind, keys ...string) *SegmentBuilder {
+	s.excluded = addKeysForKind(s.excluded, contextKind, keys)
+	s.included = removeKeysForKind(s.included, contextKind, keys)
+	return s
+}
+
+// ClearTargets removes all included and excluded context keys from the segment. This undoes the effect
+// of methods like [SegmentBuilder.Included]. It does not affect Big Segment membership.
+func (s *SegmentBuilder) ClearTargets() *SegmentBuilder {
+	s.included = nil
+	s.excluded = nil
+	return s
+}
+
+// IfMatch starts defining a segment rule, using the "is one of" operator. This is a shortcut for
+// calling [SegmentBuilder.IfMatchContext] with "user" as the context kind.
+//
+// The method returns a [SegmentRuleBuilder]. Call its [SegmentRuleBuilder.ThenInclude] method to finish
+// the rule, or add more tests with another method like [SegmentRuleBuilder.AndMatch].
+//
+// For example, this creates a rule that includes any user whose country attribute is "gb":
+//
+//	testData.Segment("segment").
+//	    IfMatch("country", ldvalue.String("gb")).
+//	        ThenInclude()
+func (s *SegmentBuilder) IfMatch(attribute string, values ...ldvalue.Value) *SegmentRuleBuilder {
+	return (&SegmentRuleBuilder{owner: s}).AndMatch(attribute, values...)
+}
+
+// IfMatchContext starts defining a segment rule, using the "is one of" operator. This matching
+// expression only applies to contexts of a specific kind, identified by the contextKind parameter.
+//
+// The method returns a [SegmentRuleBuilder]. Call its [SegmentRuleBuilder.ThenInclude] method to finish
+// the rule, or add more tests with another method like [SegmentRuleBuilder.AndMatch].
+func (s *SegmentBuilder) IfMatchContext(
+	contextKind ldcontext.Kind,
+	attribute string,
+	values ...ldvalue.Value,
+) *SegmentRuleBuilder {
+	return (&SegmentRuleBuilder{owner: s}).AndMatchContext(contextKind, attribute, values...)
+}
+
+// IfNotMatch starts defining a segment rule, using the "is not one of" operator. This is a shortcut for
+// calling [SegmentBuilder.IfNotMatchContext] with "user" as the context kind.
+//
+// The method returns a [SegmentRuleBuilder]. Call its [SegmentRuleBuilder.ThenInclude] method to finish
+// the rule, or add more tests with another method like [SegmentRuleBuilder.AndMatch].
+func (s *SegmentBuilder) IfNotMatch(attribute string, values ...ldvalue.Value) *SegmentRuleBuilder {
+	return (&SegmentRuleBuilder{owner: s}).AndNotMatch(attribute, values...)
+}
+
+// IfNotMatchContext starts defining a segment rule, using the "is not one of" operator. This matching
+// expression only applies to contexts of a specific kind, identified by the contextKind parameter.
+//
+// The method returns a [SegmentRuleBuilder]. Call its [SegmentRuleBuilder.ThenInclude] method to finish
+// the rule, or add more tests with another method like [SegmentRuleBuilder.AndMatch].
+func (s *SegmentBuilder) IfNotMatchContext(
+	contextKind ldcontext.Kind,
+	attribute string,
+	values ...ldvalue.Value,
+) *SegmentRuleBuilder {
+	return (&SegmentRuleBuilder{owner: s}).AndNotMatchContext(contextKind, attribute, values...)
+}
+
+// ClearRules removes any existing rules from the segment. This undoes the effect of methods like
+// [SegmentBuilder.IfMatch].
+func (s *SegmentBuilder) ClearRules() *SegmentBuilder {
+	s.rules = nil
+	return s
+}
+
+// Unbounded makes this segment a Big Segment (also known as an unbounded segment) for the specified
+// context kind. If contextKind is empty, it defaults to "user".
+//
+// The membership of a Big Segment is not part of the segment configuration; the SDK queries it from
+// the Big Segment store. To provide that membership in tests, use [SegmentBuilder.BigSegmentIncluded]
+// and [SegmentBuilder.BigSegmentExcluded], and configure the SDK to use the store returned by
+// [TestDataSource.BigSegmentStore].
+//
+// If no generation has been set with [SegmentBuilder.Generation], the generation is set to 1.
+func (s *SegmentBuilder) Unbounded(contextKind ldcontext.Kind) *SegmentBuilder {
+	if contextKind == "" {
+		contextKind = ldcontext.DefaultKind
+	}
+	s.unbounded = true
+	s.unboundedContextKind = contextKind
+	if !s.generation.IsDefined() {
+		s.generation = ldvalue.NewOptionalInt(1)
+	}
+	return s
+}
+
+// Generation sets the generation number of a Big Segment. Memberships in the Big Segment store are
+// associated with a specific generation, so changing this has the same effect as replacing the Big
+// Segment with a new one; the memberships defined with [SegmentBuilder.BigSegmentIncluded] and
+// [SegmentBuilder.BigSegmentExcluded] always apply to the current generation.
+func (s *SegmentBuilder) Generation(generation int) *SegmentBuilder {
+	s.generation = ldvalue.NewOptionalInt(generation)
+	return s
+}
+
+// BigSegmentIncluded adds context keys to the Big Segment membership that is reported by
+// [TestDataSource.BigSegmentStore]. The keys are for contexts of the kind that was specified in
+// [SegmentBuilder.Unbounded].
+//
+// This has no effect unless the segment is a Big Segment. If any of these keys were previously
+// excluded from the Big Segment, they are no longer excluded.
+func (s *SegmentBuilder) BigSegmentIncluded(keys ...string) *SegmentBuilder {
+	s.bigSegmentIncluded = addKeys(s.bigSegmentIncluded, keys)
+	removeKeys(s.bigSegmentExcluded, keys)
+	return s
+}
+
+// BigSegmentExcluded adds context keys to the list of contexts that are explicitly excluded from the
+// Big Segment, as reported by [TestDataSource.BigSegmentStore]. The keys are for contexts of the kind
+// that was specified in [SegmentBuilder.Unbounded].
+//
+// This has no effect unless the segment is a Big Segment. If any of these keys were previously
+// included in the Big Segment, they are no longer included.
+func (s *SegmentBuilder) BigSegmentExcluded(keys ...string) *SegmentBuilder {
+	s.bigSegmentExcluded = addKeys(s.bigSegmentExcluded, keys)
+	removeKeys(s.bigSegmentIncluded, keys)
+	return s
+}
+
+func (s *SegmentBuilder) createSegment(version int) ldmodel.Segment {
+	sb := ldbuilders.NewSegmentBuilder(s.key).Version(version)
+
+	// For the sake of test determinacy, we sort the context kinds and the context keys. As with flag
+	// targets, user keys go into the old-style Included/Excluded lists.
+	for _, kind := range sortedKinds(s.included) {
+		keys := sortedKeys(s.included[kind])
+		if kind == ldcontext.DefaultKind {
+			sb.Included(keys...)
+		} else {
+			sb.IncludedContextKind(kind, keys...)
+		}
+	}
+	for _, kind := range sortedKinds(s.excluded) {
+		keys := sortedKeys(s.excluded[kind])
+		if kind == ldcontext.DefaultKind {
+			sb.Excluded(keys...)
+		} else {
+			sb.ExcludedContextKind(kind, keys...)
+		}
+	}
+	for i, r := range s.rules {
+		sb.AddRule(ldbuilders.NewSegmentRuleBuilder().
+			ID(fmt.Sprintf("rule%d", i)).
+			Clauses(r.clauses...),
+		)
+	}
+	if s.unbounded {
+		sb.Unbounded(true).UnboundedContextKind(s.unboundedContextKind)
+	}
+	if s.generation.IsDefined() {
+		sb.Generation(s.generation.IntValue())
+	}
+	return sb.Build()
+}
+
+// bigSegmentRef returns the segment reference that a Big Segment store uses for this segment. The
+// definition of this has to be kept in sync with the equivalent function in go-server-sdk-evaluation.
+func (s *SegmentBuilder) bigSegmentRef() string {
+	return fmt.Sprintf("%s.g%d", s.key, s.generation.IntValue())
+}
+
+// AndMatch adds another clause, using the "is one of" operator. This is a shortcut for calling
+// [SegmentRuleBuilder.AndMatchContext] with "user" as the context kind.
+//
+// For example, this creates a rule that includes any user whose name attribute is "Patsy" and whose
+// country is "gb":
+//
+//	testData.Segment("segment").
+//	    IfMatch("name", ldvalue.String("Patsy")).
+//	        AndMatch("country", ldvalue.String("gb")).
+//	        ThenInclude()
+func (r *SegmentRuleBuilder) AndMatch(attribute string, values ...ldvalue.Value) *SegmentRuleBuilder {
+	return r.AndMatchContext(ldcontext.DefaultKind, attribute, values...)
+}
+
+// AndMatchContext adds another clause, using the "is one of" operator. This matching expression
+// only applies to contexts of a specific kind, identified by the contextKind parameter.
+func (r *SegmentRuleBuilder) AndMatchContext(
+	contextKind ldcontext.Kind,
+	attribute string,
+	values ...ldvalue.Value,
+) *SegmentRuleBuilder {
+	r.clauses = append(r.clauses, ldbuilders.ClauseWithKind(contextKind, attribute, ldmodel.OperatorIn, values...))
+	return r
+}
+
+// AndNotMatch adds another clause, using the "is not one of" operator. This is a shortcut for calling
+// [SegmentRuleBuilder.AndNotMatchContext] with "user" as the context kind.
+func (r *SegmentRuleBuilder) AndNotMatch(attribute string, values ...ldvalue.Value) *SegmentRuleBuilder {
+	return r.AndNotMatchContext(ldcontext.DefaultKind, attribute, values...)
+}
+
+// AndNotMatchContext adds another clause, using the "is not one of" operator. This matching expression
+// only applies to contexts of a specific kind, identified by the contextKind parameter.
+func (r *SegmentRuleBuilder) AndNotMatchContext(
+	contextKind ldcontext.Kind,
+	attribute string,
+	values ...ldvalue.Value,
+) *SegmentRuleBuilder {
+	r.clauses = append(r.clauses, ldbuilders.Negate(ldbuilders.ClauseWithKind(contextKind,
+		attribute, ldmodel.OperatorIn, values...)))
+	return r
+}
+
+// ThenInclude finishes defining the rule. Any context that matches all of the rule's clauses will be
+// included in the segment, unless it is explicitly excluded.
+func (r *SegmentRuleBuilder) ThenInclude() *SegmentBuilder {
+	r.owner.rules = append(r.owner.rules, r)
+	return r.owner
+}
+
+func copyKeysByKind(from map[ldcontext.Kind]map[string]bool) map[ldcontext.Kind]map[string]bool {
+	if from == nil {
+		return nil
+	}
+	ret := make(map[ldcontext.Kind]map[string]bool, len(from))
+	for kind, keys := range from {
+		ret[kind] = maps.Clone(keys)
+	}
+	return ret
+}
+
+func addKeysForKind(
+	keysByKind map[ldcontext.Kind]map[string]bool,
+	contextKind ldcontext.Kind,
+	keys []string,
+) map[ldcontext.Kind]map[string]bool {
+	if contextKind == "" {
+		contextKind = ldcontext.DefaultKind
+	}
+	if keysByKind == nil {
+		keysByKind = make(map[ldcontext.Kind]map[string]bool)
+	}
+	keysByKind[contextKind] = addKeys(keysByKind[contextKind], keys)
+	return keysByKind
+}
+
+func removeKeysForKind(
+	keysByKind map[ldcontext.Kind]map[string]bool,
+	contextKind ldcontext.Kind,
+	keys []string,
+) map[ldcontext.Kind]map[string]bool {
+	if contextKind == "" {
+		contextKind = ldcontext.DefaultKind
+	}
+	if existing, ok := keysByKind[contextKind]; ok {
+		removeKeys(existing, keys)
+		if len(existing) == 0 {
+			delete(keysByKind, contextKind)
+		}
+	}
+	return keysByKind
+}
+
+func addKeys[K comparable](keySet map[K]bool, keys []K) map[K]bool {
+	if keySet == nil {
+		keySet = make(map[K]bool, len(keys))
+	}
+	for _, key := range keys {
+		keySet[key] = true
+	}
+	return keySet
+}
+
+func removeKeys(keySet map[string]bool, keys []string) {
+	for _, key := range keys {
+		delete(keySet, key)
+	}
+}
+
+func sortedKinds(keysByKind map[ldcontext.Kind]map[string]bool) []ldcontext.Kind {
+	kinds := maps.Keys(keysByKind)
+	slices.Sort(kinds)
+	return kinds
+}
+
+func sortedKeys(keySet map[string]bool) []string {
+	keys := maps.Keys(keySet)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,149 @@
+package ldtestdata
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/bigsegments"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+
+	m "github.com/launchdarkly/go-test-helpers/v3/matchers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func verifySegment(t *testing.T, configureSegment func(*SegmentBuilder), expectedSegment *ldbuilders.SegmentBuilder) {
+	t.Helper()
+	expectedJSON, _ := json.Marshal(expectedSegment.Build())
+	testDataSourceTest(t, func(p testDataSourceTestParams) {
+		t.Helper()
+		p.withDataSource(t, func(subsystems.DataSource) {
+			t.Helper()
+			s := p.td.Segment("segmentkey")
+			configureSegment(s)
+			p.td.UpdateSegment(s)
+			up := p.updates.DataStore.WaitForUpsert(t, ldstoreimpl.Segments(), "segmentkey", 1, time.Millisecond)
+			upJSON := ldstoreimpl.Segments().Serialize(up.Item)
+			m.In(t).Assert(string(upJSON), m.JSONStrEqual(string(expectedJSON)))
+		})
+	})
+}
+
+func basicSegment() *ldbuilders.SegmentBuilder {
+	return ldbuilders.NewSegmentBuilder("segmentkey").Version(1)
+}
+
+func TestSegmentConfig(t *testing.T) {
+	t.Run("empty segment", func(t *testing.T) {
+		verifySegment(t, func(s *SegmentBuilder) {}, basicSegment())
+	})
+
+	t.Run("included and excluded users", func(t *testing.T) {
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.Included("b", "a").Excluded("c")
+		}, basicSegment().Included("a", "b").Excluded("c"))
+
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.Included("a", "b").Excluded("a")
+		}, basicSegment().Included("b").Excluded("a"))
+
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.Included("a").ClearTargets().Excluded("b")
+		}, basicSegment().Excluded("b"))
+	})
+
+	t.Run("included and excluded contexts", func(t *testing.T) {
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.IncludedContextKind("org", "a", "b").ExcludedContextKind("org", "c").
+				IncludedContextKind("other", "a")
+		}, basicSegment().
+			IncludedContextKind("org", "a", "b").
+			IncludedContextKind("other", "a").
+			ExcludedContextKind("org", "c"))
+
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.IncludedContextKind("", "a")
+		}, basicSegment().Included("a"))
+	})
+
+	t.Run("rules", func(t *testing.T) {
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.IfMatch("name", ldvalue.String("Lucy")).AndNotMatch("country", ldvalue.String("gb")).ThenInclude().
+				IfMatchContext("org", "name", ldvalue.String("Catco")).ThenInclude().
+				IfNotMatchContext("org", "name", ldvalue.String("Pendant")).ThenInclude()
+		}, basicSegment().
+			AddRule(ldbuilders.NewSegmentRuleBuilder().ID("rule0").Clauses(
+				ldbuilders.ClauseWithKind("user", "name", ldmodel.OperatorIn, ldvalue.String("Lucy")),
+				ldbuilders.Negate(ldbuilders.ClauseWithKind("user", "country", ldmodel.OperatorIn, ldvalue.String("gb"))),
+			)).
+			AddRule(ldbuilders.NewSegmentRuleBuilder().ID("rule1").Clauses(
+				ldbuilders.ClauseWithKind("org", "name", ldmodel.OperatorIn, ldvalue.String("Catco")),
+			)).
+			AddRule(ldbuilders.NewSegmentRuleBuilder().ID("rule2").Clauses(
+				ldbuilders.Negate(ldbuilders.ClauseWithKind("org", "name", ldmodel.OperatorIn, ldvalue.String("Pendant"))),
+			)))
+
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.IfMatch("name", ldvalue.String("Lucy")).ThenInclude().ClearRules()
+		}, basicSegment())
+	})
+
+	t.Run("unbounded", func(t *testing.T) {
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.Unbounded("")
+		}, basicSegment().Unbounded(true).UnboundedContextKind("user").Generation(1))
+
+		verifySegment(t, func(s *SegmentBuilder) {
+			s.Generation(3).Unbounded("org")
+		}, basicSegment().Unbounded(true).UnboundedContextKind("org").Generation(3))
+	})
+}
+
+func TestSegmentUpdates(t *testing.T) {
+	testDataSourceTest(t, func(p testDataSourceTestParams) {
+		p.td.UpdateSegment(p.td.Segment("segmentkey").Included("a"))
+
+		p.withDataSource(t, func(subsystems.DataSource) {
+			p.td.UpdateSegment(p.td.Segment("segmentkey").Included("b"))
+
+			up := p.updates.DataStore.WaitForUpsert(t, ldstoreimpl.Segments(), "segmentkey", 2, time.Millisecond)
+			assert.Equal(t, []string{"a", "b"}, up.Item.Item.(*ldmodel.Segment).Included)
+		})
+	})
+}
+
+func TestBigSegmentStore(t *testing.T) {
+	td := DataSource()
+	td.UpdateSegment(td.Segment("segment1").Unbounded("").BigSegmentIncluded("a", "b").BigSegmentExcluded("c"))
+	td.UpdateSegment(td.Segment("segment2").Generation(2).Unbounded("").BigSegmentIncluded("a"))
+	td.UpdateSegment(td.Segment("segment3").Included("a").BigSegmentIncluded("a")) // not a Big Segment
+
+	store, err := td.BigSegmentStore().Build(subsystems.BasicClientContext{})
+	require.NoError(t, err)
+	defer store.Close()
+
+	md, err := store.GetMetadata()
+	require.NoError(t, err)
+	assert.NotEqual(t, 0, md.LastUpToDate)
+
+	membershipA, err := store.GetMembership(bigsegments.HashForContextKey("a"))
+	require.NoError(t, err)
+	assert.Equal(t, ldvalue.NewOptionalBool(true), membershipA.CheckMembership("segment1.g1"))
+	assert.Equal(t, ldvalue.NewOptionalBool(true), membershipA.CheckMembership("segment2.g2"))
+	assert.Equal(t, ldvalue.OptionalBool{}, membershipA.CheckMembership("segment3.g0"))
+
+	membershipC, err := store.GetMembership(bigsegments.HashForContextKey("c"))
+	require.NoError(t, err)
+	assert.Equal(t, ldvalue.NewOptionalBool(false), membershipC.CheckMembership("segment1.g1"))
+
+	td.UpdateSegment(td.Segment("segment1").BigSegmentIncluded("c"))
+	membershipC, err = store.GetMembership(bigsegments.HashForContextKey("c"))
+	require.NoError(t, err)
+	assert.Equal(t, ldvalue.NewOptionalBool(true), membershipC.CheckMembership("segment1.g1"))
+}
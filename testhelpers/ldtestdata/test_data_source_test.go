@@ -14,6 +14,7 @@ import (
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
 	"github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldservices"
 
 	th "github.com/launchdarkly/go-test-helpers/v3"
@@ -165,4 +166,100 @@ func TestTestDataSource(t *testing.T) {
 			})
 		})
 	})
+
+	t.Run("deletes flag", func(t *testing.T) {
+		testDataSourceTest(t, func(p testDataSourceTestParams) {
+			p.td.Update(p.td.Flag("flag1").On(true))
+
+			p.withDataSource(t, func(subsystems.DataSource) {
+				p.td.Delete("flag1")
+
+				p.updates.DataStore.WaitForDelete(t, ldstoreimpl.Features(), "flag1", 2, time.Millisecond)
+
+				p.td.Update(p.td.Flag("flag1"))
+				up := p.updates.DataStore.WaitForUpsert(t, ldstoreimpl.Features(), "flag1", 3, time.Millisecond)
+				assert.True(t, up.Item.Item.(*ldmodel.FeatureFlag).On)
+			})
+		})
+	})
+
+	t.Run("deletes segment", func(t *testing.T) {
+		testDataSourceTest(t, func(p testDataSourceTestParams) {
+			p.td.UpdateSegment(p.td.Segment("segment1").Included("a"))
+
+			p.withDataSource(t, func(subsystems.DataSource) {
+				p.td.DeleteSegment("segment1")
+
+				p.updates.DataStore.WaitForDelete(t, ldstoreimpl.Segments(), "segment1", 2, time.Millisecond)
+			})
+		})
+	})
+
+	t.Run("deleted items are in initial data as tombstones", func(t *testing.T) {
+		testDataSourceTest(t, func(p testDataSourceTestParams) {
+			p.td.Update(p.td.Flag("flag1")).Update(p.td.Flag("flag2")).Delete("flag2")
+			p.td.UpdateSegment(p.td.Segment("segment1")).DeleteSegment("segment1")
+
+			p.withDataSource(t, func(subsystems.DataSource) {
+				initData := p.updates.DataStore.WaitForNextInit(t, time.Millisecond)
+				dataMap := sharedtest.DataSetToMap(initData)
+				flags, segments := dataMap[ldstoreimpl.Features()], dataMap[ldstoreimpl.Segments()]
+				require.Len(t, flags, 2)
+				assert.NotNil(t, flags["flag1"].Item)
+				assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 2}, flags["flag2"])
+				require.Len(t, segments, 1)
+				assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 2}, segments["segment1"])
+			})
+		})
+	})
+
+	t.Run("applies batch as a single init", func(t *testing.T) {
+		testDataSourceTest(t, func(p testDataSourceTestParams) {
+			p.td.Update(p.td.Flag("flag1").On(false)).Update(p.td.Flag("flag2")).Update(p.td.Flag("flag3"))
+
+			p.withDataSource(t, func(subsystems.DataSource) {
+				_ = p.updates.DataStore.WaitForNextInit(t, time.Millisecond)
+
+				p.td.Batch(func(tx *Transaction) {
+					tx.Update(p.td.Flag("flag1").On(true)).
+						Update(p.td.Flag("flag1").On(true).OffVariationIndex(0)).
+						Delete("flag2").
+						Update(p.td.Flag("flag4")).
+						UpdateSegment(p.td.Segment("segment1").Included("a"))
+				})
+
+				initData := p.updates.DataStore.WaitForNextInit(t, time.Millisecond)
+				dataMap := sharedtest.DataSetToMap(initData)
+				flags := dataMap[ldstoreimpl.Features()]
+				require.Len(t, flags, 4)
+				assert.Equal(t, ldstoretypes.ItemDescriptor{Version: 2}, flags["flag2"])
+				assert.Equal(t, 2, flags["flag1"].Version)
+				assert.True(t, flags["flag1"].Item.(*ldmodel.FeatureFlag).On)
+				assert.Equal(t, ldvalue.NewOptionalInt(0), flags["flag1"].Item.(*ldmodel.FeatureFlag).OffVariation)
+				assert.Equal(t, 1, flags["flag3"].Version)
+				assert.Equal(t, 1, flags["flag4"].Version)
+				assert.Equal(t, 1, dataMap[ldstoreimpl.Segments()]["segment1"].Version)
+
+				p.td.Update(p.td.Flag("flag2"))
+				p.updates.DataStore.WaitForUpsert(t, ldstoreimpl.Features(), "flag2", 3, time.Millisecond)
+			})
+		})
+	})
+
+	t.Run("batch with a single change is sent as upsert", func(t *testing.T) {
+		testDataSourceTest(t, func(p testDataSourceTestParams) {
+			p.withDataSource(t, func(subsystems.DataSource) {
+				_ = p.updates.DataStore.WaitForNextInit(t, time.Millisecond)
+
+				p.td.Batch(func(tx *Transaction) {
+					tx.UsePreconfiguredSegment(ldbuilders.NewSegmentBuilder("segment1").Build())
+				})
+				p.updates.DataStore.WaitForUpsert(t, ldstoreimpl.Segments(), "segment1", 1, time.Millisecond)
+
+				p.td.Batch(func(tx *Transaction) {})
+				p.td.UpdateSegment(p.td.Segment("segment1"))
+				p.updates.DataStore.WaitForUpsert(t, ldstoreimpl.Segments(), "segment1", 2, time.Millisecond)
+			})
+		})
+	})
 }
@@ -0,0 +1,110 @@
+package ldtestdata
+
+import (
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// Transaction accumulates flag and segment changes to be applied together by [TestDataSource.Batch].
+//
+// Its methods have the same meaning as the TestDataSource methods of the same names, except that the
+// changes are not visible to any LDClient until the batch is complete.
+type Transaction struct {
+	changes []testDataChange
+}
+
+// testDataChange describes a pending change to a single flag or segment. A nil makeItem means that
+// the item is being deleted.
+type testDataChange struct {
+	kind           ldstoretypes.DataKind
+	key            string
+	makeItem       func(version int) interface{}
+	flagBuilder    *FlagBuilder
+	segmentBuilder *SegmentBuilder
+}
+
+// Update adds a flag configuration to the batch. See [TestDataSource.Update].
+func (tx *Transaction) Update(flagBuilder *FlagBuilder) *Transaction {
+	clonedBuilder := copyFlagBuilder(flagBuilder)
+	tx.changes = append(tx.changes, makeFlagChange(flagBuilder.key, clonedBuilder.createFlag, clonedBuilder))
+	return tx
+}
+
+// UpdateSegment adds a segment configuration to the batch. See [TestDataSource.UpdateSegment].
+func (tx *Transaction) UpdateSegment(segmentBuilder *SegmentBuilder) *Transaction {
+	clonedBuilder := copySegmentBuilder(segmentBuilder)
+	tx.changes = append(tx.changes,
+		makeSegmentChange(segmentBuilder.key, clonedBuilder.createSegment, clonedBuilder))
+	return tx
+}
+
+// UsePreconfiguredFlag adds a full feature flag data model object to the batch. See
+// [TestDataSource.UsePreconfiguredFlag].
+func (tx *Transaction) UsePreconfiguredFlag(flag ldmodel.FeatureFlag) *Transaction {
+	tx.changes = append(tx.changes, makeFlagChange(flag.Key, preconfiguredFlag(flag), nil))
+	return tx
+}
+
+// UsePreconfiguredSegment adds a full segment data model object to the batch. See
+// [TestDataSource.UsePreconfiguredSegment].
+func (tx *Transaction) UsePreconfiguredSegment(segment ldmodel.Segment) *Transaction {
+	tx.changes = append(tx.changes, makeSegmentChange(segment.Key, preconfiguredSegment(segment), nil))
+	return tx
+}
+
+// Delete adds the deletion of a flag to the batch. See [TestDataSource.Delete].
+func (tx *Transaction) Delete(flagKey string) *Transaction {
+	tx.changes = append(tx.changes, makeFlagChange(flagKey, nil, nil))
+	return tx
+}
+
+// DeleteSegment adds the deletion of a segment to the batch. See [TestDataSource.DeleteSegment].
+func (tx *Transaction) DeleteSegment(segmentKey string) *Transaction {
+	tx.changes = append(tx.changes, makeSegmentChange(segmentKey, nil, nil))
+	return tx
+}
+
+func makeFlagChange(key string, makeFlag func(int) ldmodel.FeatureFlag, builder *FlagBuilder) testDataChange {
+	change := testDataChange{kind: ldstoreimpl.Features(), key: key, flagBuilder: builder}
+	if makeFlag != nil {
+		change.makeItem = func(version int) interface{} {
+			flag := makeFlag(version)
+			return &flag
+		}
+	}
+	return change
+}
+
+func makeSegmentChange(
+	key string,
+	makeSegment func(int) ldmodel.Segment,
+	builder *SegmentBuilder,
+) testDataChange {
+	change := testDataChange{kind: ldstoreimpl.Segments(), key: key, segmentBuilder: builder}
+	if makeSegment != nil {
+		change.makeItem = func(version int) interface{} {
+			segment := makeSegment(version)
+			return &segment
+		}
+	}
+	return change
+}
+
+func preconfiguredFlag(flag ldmodel.FeatureFlag) func(int) ldmodel.FeatureFlag {
+	return func(version int) ldmodel.FeatureFlag {
+		f := flag
+		if f.Version < version {
+			f.Version = version
+		}
+		return f
+	}
+}
+
+func preconfiguredSegment(segment ldmodel.Segment) func(int) ldmodel.Segment {
+	return func(version int) ldmodel.Segment {
+		s := segment
+		s.Version = version
+		return s
+	}
+}
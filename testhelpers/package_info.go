@@ -1,9 +1,11 @@
 // Package testhelpers contains types and functions that may be useful in testing SDK functionality or
 // custom integrations.
 //
-// It contains two subpackages:
+// It contains three subpackages:
 //   - [github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldtestdata], which provides a test fixture
 //     for setting flag values programmatically;
+//   - [github.com/launchdarkly/go-server-sdk/v6/testhelpers/ldreplay], which provides a way to record the
+//     updates received from a data source and replay them later;
 //   - [github.com/launchdarkly/go-server-sdk/v6/testhelpers/storetest], which provides a standard test
 //     suite for custom persistent data store implementations.
 //
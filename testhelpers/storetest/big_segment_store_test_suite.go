@@ -42,7 +42,12 @@ type BigSegmentStoreTestSuite struct {
 //
 // The setMetadataFn and setSegmentsFn parameters are functions for populating the database. The
 // string slices passed to setSegmentsFn are lists of segment references in the same format used
-// by BigSegmentMembership, and should be used as-is by the store.
+// by BigSegmentMembership, and should be used as-is by the store. The suite may call setSegmentsFn
+// for more than one context hash; each call should add data for that context without removing the
+// data for any other context.
+//
+// If the store also implements [subsystems.BigSegmentStoreBatch], the suite tests its GetMemberships
+// method as well.
 func NewBigSegmentStoreTestSuite(
 	storeFactoryFn func(prefix string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore],
 	clearDataFn func(prefix string) error,
@@ -65,6 +70,7 @@ func (s *BigSegmentStoreTestSuite) Run(t *testing.T) {
 func (s *BigSegmentStoreTestSuite) runInternal(t testbox.TestingT) {
 	t.Run("GetMetadata", s.runMetadataTests)
 	t.Run("GetMembership", s.runMembershipTests)
+	t.Run("GetMemberships", s.runBatchMembershipTests)
 }
 
 func (s *BigSegmentStoreTestSuite) runMetadataTests(t testbox.TestingT) {
@@ -132,6 +138,28 @@ func (s *BigSegmentStoreTestSuite) runMembershipTests(t testbox.TestingT) {
 	})
 }
 
+// runBatchMembershipTests is only applicable to stores that implement BigSegmentStoreBatch.
+func (s *BigSegmentStoreTestSuite) runBatchMembershipTests(t testbox.TestingT) {
+	s.withStoreAndEmptyData(t, func(store subsystems.BigSegmentStore) {
+		batchStore, ok := store.(subsystems.BigSegmentStoreBatch)
+		if !ok {
+			t.Skip("store does not implement BigSegmentStoreBatch")
+		}
+		require.NoError(t, s.setSegmentsFn("", fakeUserHash, []string{"key1"}, []string{"key2"}))
+		require.NoError(t, s.setSegmentsFn("", fakeUserHash2, nil, []string{"key1"}))
+
+		memberships, err := batchStore.GetMemberships([]string{fakeUserHash, fakeUserHash2, "unknown-hash"})
+		require.NoError(t, err)
+		require.NotNil(t, memberships[fakeUserHash])
+		require.NotNil(t, memberships[fakeUserHash2])
+		assertEqualMembership(t, []string{"key1"}, []string{"key2"}, memberships[fakeUserHash])
+		assertEqualMembership(t, nil, []string{"key1"}, memberships[fakeUserHash2])
+		if unknown := memberships["unknown-hash"]; unknown != nil {
+			assertEqualMembership(t, nil, nil, unknown)
+		}
+	})
+}
+
 func (s *BigSegmentStoreTestSuite) withStoreAndEmptyData(
 	t testbox.TestingT,
 	action func(subsystems.BigSegmentStore),
@@ -18,9 +18,11 @@ import (
 // implementations and flaws in the test logic.
 
 type mockSegmentStoreData struct {
-	storesByPrefix        map[string]*mockSegmentStore
-	overrideGetMetadata   func(*mockSegmentStore) (subsystems.BigSegmentStoreMetadata, error)
-	overrideGetMembership func(*mockSegmentStore, string) (subsystems.BigSegmentMembership, error)
+	storesByPrefix         map[string]*mockSegmentStore
+	overrideGetMetadata    func(*mockSegmentStore) (subsystems.BigSegmentStoreMetadata, error)
+	overrideGetMembership  func(*mockSegmentStore, string) (subsystems.BigSegmentMembership, error)
+	batch                  bool
+	overrideGetMemberships func([]string) (map[string]subsystems.BigSegmentMembership, error)
 }
 
 type mockSegmentStore struct {
@@ -55,6 +57,24 @@ func (s *mockSegmentStore) GetMembership(contextHashKey string) (subsystems.BigS
 	return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(keys.included, keys.excluded), nil
 }
 
+// mockBatchSegmentStore is a mockSegmentStore that also implements BigSegmentStoreBatch.
+type mockBatchSegmentStore struct {
+	*mockSegmentStore
+}
+
+func (s mockBatchSegmentStore) GetMemberships(
+	contextHashKeys []string,
+) (map[string]subsystems.BigSegmentMembership, error) {
+	if s.owner.overrideGetMemberships != nil {
+		return s.owner.overrideGetMemberships(contextHashKeys)
+	}
+	ret := make(map[string]subsystems.BigSegmentMembership)
+	for _, key := range contextHashKeys {
+		ret[key], _ = s.GetMembership(key)
+	}
+	return ret, nil
+}
+
 func (d *mockSegmentStoreData) factory(prefix string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
 	store := d.storesByPrefix[prefix]
 	if store == nil {
@@ -64,6 +84,9 @@ func (d *mockSegmentStoreData) factory(prefix string) subsystems.ComponentConfig
 		}
 		d.storesByPrefix[prefix] = store
 	}
+	if d.batch {
+		return mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: mockBatchSegmentStore{store}}
+	}
 	return mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: store}
 }
 
@@ -103,6 +126,11 @@ func TestBigSegmentStoreTestSuite(t *testing.T) {
 		s.Run(t)
 	})
 
+	t.Run("tests pass with valid mock batch store", func(t *testing.T) {
+		s := makeSuite(&mockSegmentStoreData{batch: true})
+		s.Run(t)
+	})
+
 	t.Run("tests fail with malfunctioning store", func(t *testing.T) {
 		shouldFail := func(t *testing.T, s *BigSegmentStoreTestSuite) {
 			r := testbox.SandboxTest(s.runInternal)
@@ -186,5 +214,25 @@ func TestBigSegmentStoreTestSuite(t *testing.T) {
 			})
 			shouldFail(t, s)
 		})
+
+		t.Run("GetMemberships returns error", func(t *testing.T) {
+			s := makeSuite(&mockSegmentStoreData{
+				batch: true,
+				overrideGetMemberships: func([]string) (map[string]subsystems.BigSegmentMembership, error) {
+					return nil, fakeError
+				},
+			})
+			shouldFail(t, s)
+		})
+
+		t.Run("GetMemberships returns no memberships", func(t *testing.T) {
+			s := makeSuite(&mockSegmentStoreData{
+				batch: true,
+				overrideGetMemberships: func([]string) (map[string]subsystems.BigSegmentMembership, error) {
+					return map[string]subsystems.BigSegmentMembership{}, nil
+				},
+			})
+			shouldFail(t, s)
+		})
 	})
 }
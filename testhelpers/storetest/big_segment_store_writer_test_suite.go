@@ -0,0 +1,241 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers"
+
+	"github.com/launchdarkly/go-test-helpers/v3/testbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeUserHash2 = "userhash2"
+
+// BigSegmentStoreWriterTestSuite provides a configurable test suite for implementations of
+// BigSegmentStore that also implement BigSegmentStoreWriter. It verifies the store's behavior by
+// writing data with the BigSegmentStoreWriter methods and then reading it with the BigSegmentStore
+// methods, so it is a complement to BigSegmentStoreTestSuite, which populates the database directly.
+type BigSegmentStoreWriterTestSuite struct {
+	storeFactoryFn func(string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore]
+	clearDataFn    func(string) error
+}
+
+// NewBigSegmentStoreWriterTestSuite creates a BigSegmentStoreWriterTestSuite for testing some
+// implementation of BigSegmentStoreWriter. The parameters have the same meaning as the corresponding
+// parameters of [NewBigSegmentStoreTestSuite].
+func NewBigSegmentStoreWriterTestSuite(
+	storeFactoryFn func(prefix string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore],
+	clearDataFn func(prefix string) error,
+) *BigSegmentStoreWriterTestSuite {
+	return &BigSegmentStoreWriterTestSuite{
+		storeFactoryFn: storeFactoryFn,
+		clearDataFn:    clearDataFn,
+	}
+}
+
+// Run runs the configured test suite.
+func (s *BigSegmentStoreWriterTestSuite) Run(t *testing.T) {
+	s.runInternal(testbox.RealTest(t))
+}
+
+func (s *BigSegmentStoreWriterTestSuite) runInternal(t testbox.TestingT) {
+	t.Run("ReplaceSegmentMembership", s.runReplaceTests)
+	t.Run("ApplyMembershipDelta", s.runDeltaTests)
+	t.Run("SetSyncedOn", s.runSyncedOnTests)
+	t.Run("sync cursor", s.runCursorTests)
+}
+
+func (s *BigSegmentStoreWriterTestSuite) runReplaceTests(t testbox.TestingT) {
+	t.Run("adds memberships", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, w.ReplaceSegmentMembership("key1", []string{fakeUserHash}, []string{fakeUserHash2}))
+			require.NoError(t, w.ReplaceSegmentMembership("key2", []string{fakeUserHash}, nil))
+
+			assertWrittenMembership(t, w, fakeUserHash, []string{"key1", "key2"}, nil)
+			assertWrittenMembership(t, w, fakeUserHash2, nil, []string{"key1"})
+		})
+	})
+
+	t.Run("replaces previous memberships of the same segment only", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, w.ReplaceSegmentMembership("key1", []string{fakeUserHash}, nil))
+			require.NoError(t, w.ReplaceSegmentMembership("key2", []string{fakeUserHash}, nil))
+			require.NoError(t, w.ReplaceSegmentMembership("key1", []string{fakeUserHash2}, []string{fakeUserHash}))
+
+			assertWrittenMembership(t, w, fakeUserHash, []string{"key2"}, []string{"key1"})
+			assertWrittenMembership(t, w, fakeUserHash2, []string{"key1"}, nil)
+		})
+	})
+
+	t.Run("empty lists remove all memberships", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, w.ReplaceSegmentMembership("key1", []string{fakeUserHash}, []string{fakeUserHash2}))
+			require.NoError(t, w.ReplaceSegmentMembership("key1", nil, nil))
+
+			assertWrittenMembership(t, w, fakeUserHash, nil, nil)
+			assertWrittenMembership(t, w, fakeUserHash2, nil, nil)
+		})
+	})
+
+	t.Run("is idempotent", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			for i := 0; i < 2; i++ {
+				require.NoError(t, w.ReplaceSegmentMembership("key1", []string{fakeUserHash}, nil))
+			}
+			assertWrittenMembership(t, w, fakeUserHash, []string{"key1"}, nil)
+		})
+	})
+}
+
+func (s *BigSegmentStoreWriterTestSuite) runDeltaTests(t testbox.TestingT) {
+	t.Run("adds and removes memberships", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, w.ApplyMembershipDelta(subsystems.BigSegmentMembershipDelta{
+				SegmentRef:  "key1",
+				AddIncluded: []string{fakeUserHash},
+				AddExcluded: []string{fakeUserHash2},
+			}))
+			assertWrittenMembership(t, w, fakeUserHash, []string{"key1"}, nil)
+			assertWrittenMembership(t, w, fakeUserHash2, nil, []string{"key1"})
+
+			require.NoError(t, w.ApplyMembershipDelta(subsystems.BigSegmentMembershipDelta{
+				SegmentRef:     "key1",
+				RemoveIncluded: []string{fakeUserHash},
+				AddIncluded:    []string{fakeUserHash2},
+				RemoveExcluded: []string{fakeUserHash2},
+			}))
+			assertWrittenMembership(t, w, fakeUserHash, nil, nil)
+			assertWrittenMembership(t, w, fakeUserHash2, []string{"key1"}, nil)
+		})
+	})
+
+	t.Run("does not affect other segments or contexts", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, w.ReplaceSegmentMembership("key1", []string{fakeUserHash, fakeUserHash2}, nil))
+			require.NoError(t, w.ApplyMembershipDelta(subsystems.BigSegmentMembershipDelta{
+				SegmentRef:     "key2",
+				AddIncluded:    []string{fakeUserHash},
+				RemoveIncluded: []string{fakeUserHash2},
+			}))
+			assertWrittenMembership(t, w, fakeUserHash, []string{"key1", "key2"}, nil)
+			assertWrittenMembership(t, w, fakeUserHash2, []string{"key1"}, nil)
+		})
+	})
+
+	t.Run("is idempotent", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			delta := subsystems.BigSegmentMembershipDelta{
+				SegmentRef:     "key1",
+				AddIncluded:    []string{fakeUserHash},
+				RemoveExcluded: []string{fakeUserHash2},
+			}
+			for i := 0; i < 2; i++ {
+				require.NoError(t, w.ApplyMembershipDelta(delta))
+			}
+			assertWrittenMembership(t, w, fakeUserHash, []string{"key1"}, nil)
+			assertWrittenMembership(t, w, fakeUserHash2, nil, nil)
+		})
+	})
+}
+
+func (s *BigSegmentStoreWriterTestSuite) runSyncedOnTests(t testbox.TestingT) {
+	s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+		for _, syncedOn := range []ldtime.UnixMillisecondTime{1234567890, 1234567891} {
+			require.NoError(t, w.SetSyncedOn(syncedOn))
+			meta, err := w.GetMetadata()
+			require.NoError(t, err)
+			assert.Equal(t, syncedOn, meta.LastUpToDate)
+		}
+	})
+}
+
+func (s *BigSegmentStoreWriterTestSuite) runCursorTests(t testbox.TestingT) {
+	t.Run("no value", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			cursor, err := w.GetSyncCursor()
+			require.NoError(t, err)
+			assert.Equal(t, "", cursor)
+		})
+	})
+
+	t.Run("value is retained by another store instance", func(t testbox.TestingT) {
+		s.withWriterAndEmptyData(t, func(w subsystems.BigSegmentStoreWriter) {
+			require.NoError(t, w.SetSyncCursor("cursor1"))
+			require.NoError(t, w.SetSyncCursor("cursor2"))
+			cursor, err := w.GetSyncCursor()
+			require.NoError(t, err)
+			assert.Equal(t, "cursor2", cursor)
+
+			s.withWriter(t, func(w2 subsystems.BigSegmentStoreWriter) {
+				cursor, err := w2.GetSyncCursor()
+				require.NoError(t, err)
+				assert.Equal(t, "cursor2", cursor)
+			})
+		})
+	})
+}
+
+func (s *BigSegmentStoreWriterTestSuite) withWriterAndEmptyData(
+	t testbox.TestingT,
+	action func(subsystems.BigSegmentStoreWriter),
+) {
+	require.NoError(t, s.clearDataFn(""))
+	s.withWriter(t, action)
+}
+
+func (s *BigSegmentStoreWriterTestSuite) withWriter(
+	t testbox.TestingT,
+	action func(subsystems.BigSegmentStoreWriter),
+) {
+	testhelpers.WithMockLoggingContext(t, func(context subsystems.ClientContext) {
+		store, err := s.storeFactoryFn("").Build(context)
+		require.NoError(t, err)
+		defer func() {
+			_ = store.Close()
+		}()
+
+		writer, ok := store.(subsystems.BigSegmentStoreWriter)
+		require.True(t, ok, "store does not implement BigSegmentStoreWriter")
+		action(writer)
+	})
+}
+
+// assertWrittenMembership checks the membership of a context in every segment that these tests use,
+// so that it can detect unwanted memberships regardless of how the store implements BigSegmentMembership.
+func assertWrittenMembership(
+	t testbox.TestingT,
+	store subsystems.BigSegmentStore,
+	contextHash string,
+	expectedIncludes []string,
+	expectedExcludes []string,
+) {
+	membership, err := store.GetMembership(contextHash)
+	require.NoError(t, err)
+	for _, segmentRef := range []string{"key1", "key2", "unused-key"} {
+		expected, actual := ldvalue.OptionalBool{}, ldvalue.OptionalBool{}
+		if membership != nil { // a nil value means the context is not in any segment
+			actual = membership.CheckMembership(segmentRef)
+		}
+		if stringSliceContains(expectedIncludes, segmentRef) {
+			expected = ldvalue.NewOptionalBool(true)
+		} else if stringSliceContains(expectedExcludes, segmentRef) {
+			expected = ldvalue.NewOptionalBool(false)
+		}
+		assert.Equal(t, expected, actual, "context %q, segment %q",
+			contextHash, segmentRef)
+	}
+}
+
+func stringSliceContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package storetest
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldtime"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoreimpl"
+
+	"github.com/launchdarkly/go-test-helpers/v3/testbox"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// This verifies that the BigSegmentStoreWriterTestSuite tests behave as expected as long as the
+// BigSegmentStoreWriter implementation behaves as expected.
+
+type mockSegmentWriterData struct {
+	syncedOn    ldtime.UnixMillisecondTime
+	cursor      string
+	memberships map[string]map[string]bool // context hash -> segment ref -> included
+	excluded    map[string]map[string]bool // context hash -> segment ref -> excluded
+	breakDelta  bool
+}
+
+type mockSegmentWriter struct {
+	data *mockSegmentWriterData
+}
+
+func (w mockSegmentWriter) Close() error { return nil }
+
+func (w mockSegmentWriter) GetMetadata() (subsystems.BigSegmentStoreMetadata, error) {
+	return subsystems.BigSegmentStoreMetadata{LastUpToDate: w.data.syncedOn}, nil
+}
+
+func (w mockSegmentWriter) GetMembership(contextHash string) (subsystems.BigSegmentMembership, error) {
+	keys := func(m map[string]bool) []string {
+		var ret []string
+		for k := range m {
+			ret = append(ret, k)
+		}
+		sort.Strings(ret)
+		return ret
+	}
+	return ldstoreimpl.NewBigSegmentMembershipFromSegmentRefs(
+		keys(w.data.memberships[contextHash]), keys(w.data.excluded[contextHash])), nil
+}
+
+func (w mockSegmentWriter) ApplyMembershipDelta(delta subsystems.BigSegmentMembershipDelta) error {
+	if w.data.breakDelta {
+		delta.RemoveIncluded = nil
+	}
+	for _, h := range delta.RemoveIncluded {
+		delete(w.data.memberships[h], delta.SegmentRef)
+	}
+	for _, h := range delta.RemoveExcluded {
+		delete(w.data.excluded[h], delta.SegmentRef)
+	}
+	w.add(w.data.memberships, delta.SegmentRef, delta.AddIncluded)
+	w.add(w.data.excluded, delta.SegmentRef, delta.AddExcluded)
+	return nil
+}
+
+func (w mockSegmentWriter) ReplaceSegmentMembership(segmentRef string, included, excluded []string) error {
+	for _, m := range []map[string]map[string]bool{w.data.memberships, w.data.excluded} {
+		for _, refs := range m {
+			delete(refs, segmentRef)
+		}
+	}
+	w.add(w.data.memberships, segmentRef, included)
+	w.add(w.data.excluded, segmentRef, excluded)
+	return nil
+}
+
+func (w mockSegmentWriter) add(m map[string]map[string]bool, segmentRef string, contextHashes []string) {
+	for _, h := range contextHashes {
+		if m[h] == nil {
+			m[h] = make(map[string]bool)
+		}
+		m[h][segmentRef] = true
+	}
+}
+
+func (w mockSegmentWriter) SetSyncedOn(syncedOn ldtime.UnixMillisecondTime) error {
+	w.data.syncedOn = syncedOn
+	return nil
+}
+
+func (w mockSegmentWriter) GetSyncCursor() (string, error) {
+	return w.data.cursor, nil
+}
+
+func (w mockSegmentWriter) SetSyncCursor(cursor string) error {
+	w.data.cursor = cursor
+	return nil
+}
+
+func (d *mockSegmentWriterData) factory(string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+	return mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{Instance: mockSegmentWriter{d}}
+}
+
+func (d *mockSegmentWriterData) clearData(string) error {
+	d.syncedOn, d.cursor = 0, ""
+	d.memberships = make(map[string]map[string]bool)
+	d.excluded = make(map[string]map[string]bool)
+	return nil
+}
+
+func TestBigSegmentStoreWriterTestSuite(t *testing.T) {
+	t.Run("tests pass with valid mock store", func(t *testing.T) {
+		d := &mockSegmentWriterData{}
+		NewBigSegmentStoreWriterTestSuite(d.factory, d.clearData).Run(t)
+	})
+
+	t.Run("tests fail with malfunctioning store", func(t *testing.T) {
+		shouldFail := func(t *testing.T, s *BigSegmentStoreWriterTestSuite) {
+			r := testbox.SandboxTest(s.runInternal)
+			assert.True(t, r.Failed, "test should have failed")
+		}
+
+		t.Run("store does not implement BigSegmentStoreWriter", func(t *testing.T) {
+			d := &mockSegmentWriterData{}
+			factory := func(string) subsystems.ComponentConfigurer[subsystems.BigSegmentStore] {
+				return mocks.SingleComponentConfigurer[subsystems.BigSegmentStore]{
+					Instance: &mocks.MockBigSegmentStore{},
+				}
+			}
+			shouldFail(t, NewBigSegmentStoreWriterTestSuite(factory, d.clearData))
+		})
+
+		t.Run("delta does not remove memberships", func(t *testing.T) {
+			d := &mockSegmentWriterData{breakDelta: true}
+			shouldFail(t, NewBigSegmentStoreWriterTestSuite(d.factory, d.clearData))
+		})
+
+		t.Run("clearing data fails", func(t *testing.T) {
+			d := &mockSegmentWriterData{}
+			clearData := func(string) error { return errors.New("sorry") }
+			shouldFail(t, NewBigSegmentStoreWriterTestSuite(d.factory, clearData))
+		})
+	})
+}
@@ -0,0 +1,261 @@
+package storetest
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	ssys "github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+)
+
+// ErrInjectedFault is the error returned by an operation that a [FaultInjector] has made fail at random.
+var ErrInjectedFault = errors.New("simulated data store error") //nolint:gochecknoglobals
+
+// ErrInjectedOutage is the error returned by every operation while a [FaultInjector] is simulating an
+// outage.
+var ErrInjectedOutage = errors.New("simulated data store outage") //nolint:gochecknoglobals
+
+// ErrInjectedPartialInit is the error returned by Init when a [FaultInjector] has made it write only
+// part of the data.
+var ErrInjectedPartialInit = errors.New("simulated partial data store initialization") //nolint:gochecknoglobals
+
+// FaultInjector simulates problems with a persistent data store, for testing how the SDK, or an
+// application, behaves when the database is slow or unreliable.
+//
+// Use [FaultInjector.DataStore] to wrap the configuration of a real or mock data store, or
+// [FaultInjector.Wrap] to wrap a data store instance. The store will behave normally until you change the
+// FaultInjector's settings, which can be done at any time, including while an SDK client is using it:
+//
+//	faults := storetest.NewFaultInjector()
+//	config := ld.Config{
+//	    DataStore: ldcomponents.PersistentDataStore(faults.DataStore(ldredis.DataStore())),
+//	}
+//	client, _ := ld.MakeCustomClient(sdkKey, config, 5*time.Second)
+//	faults.SetAvailable(false) // now every database operation fails
+//
+// All of the methods of FaultInjector are safe for concurrent use.
+type FaultInjector struct {
+	latency            time.Duration
+	errorRate          float64
+	available          bool
+	partialInitItems   int
+	random             *rand.Rand
+	injectedErrorCount int
+	initCount          int
+	lock               sync.Mutex
+}
+
+type faultInjectingPersistentDataStore struct {
+	core   ssys.PersistentDataStore
+	faults *FaultInjector
+}
+
+type faultInjectingDataStoreConfigurer struct {
+	factory ssys.ComponentConfigurer[ssys.PersistentDataStore]
+	faults  *FaultInjector
+}
+
+// NewFaultInjector creates a FaultInjector that does not inject any faults until it is configured to.
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{
+		available:        true,
+		partialInitItems: -1,
+		random:           rand.New(rand.NewSource(1)), //nolint:gosec // not used for security purposes
+	}
+}
+
+// SetLatency sets a delay that is added to every data store operation, other than IsStoreAvailable and
+// Close. The default is zero.
+func (f *FaultInjector) SetLatency(latency time.Duration) *FaultInjector {
+	f.lock.Lock()
+	f.latency = latency
+	f.lock.Unlock()
+	return f
+}
+
+// SetErrorRate sets the probability, from 0 to 1, that each Init, Get, GetAll, or Upsert operation fails
+// with [ErrInjectedFault] instead of being passed to the underlying store. These errors simulate
+// intermittent failures: IsStoreAvailable still reports that the store is available. The default is zero.
+func (f *FaultInjector) SetErrorRate(errorRate float64) *FaultInjector {
+	f.lock.Lock()
+	f.errorRate = errorRate
+	f.lock.Unlock()
+	return f
+}
+
+// SetAvailable simulates an outage if available is false: every operation fails with
+// [ErrInjectedOutage], IsInitialized returns false, and IsStoreAvailable returns false. Setting it back to
+// true ends the outage. The default is true.
+func (f *FaultInjector) SetAvailable(available bool) *FaultInjector {
+	f.lock.Lock()
+	f.available = available
+	f.lock.Unlock()
+	return f
+}
+
+// SetPartialInit makes every subsequent Init operation write only the first maxItems items to the
+// underlying store, as if the database had failed partway through, and then return
+// [ErrInjectedPartialInit]. A negative value, which is the default, turns this off.
+func (f *FaultInjector) SetPartialInit(maxItems int) *FaultInjector {
+	f.lock.Lock()
+	f.partialInitItems = maxItems
+	f.lock.Unlock()
+	return f
+}
+
+// Seed sets the seed for the random choice of which operations fail, so that a test can get the same
+// sequence of errors each time. The default seed is 1.
+func (f *FaultInjector) Seed(seed int64) *FaultInjector {
+	f.lock.Lock()
+	f.random.Seed(seed)
+	f.lock.Unlock()
+	return f
+}
+
+// Reset turns off all of the faults.
+func (f *FaultInjector) Reset() {
+	f.lock.Lock()
+	f.latency = 0
+	f.errorRate = 0
+	f.available = true
+	f.partialInitItems = -1
+	f.lock.Unlock()
+}
+
+// InjectedErrorCount returns the number of operations that have failed because of this FaultInjector.
+func (f *FaultInjector) InjectedErrorCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.injectedErrorCount
+}
+
+// InitCount returns the number of times that Init has been passed through to the underlying store,
+// including partial Init operations.
+func (f *FaultInjector) InitCount() int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.initCount
+}
+
+// Wrap returns a persistent data store that delegates to core, but with the faults that are configured
+// in this FaultInjector.
+func (f *FaultInjector) Wrap(core ssys.PersistentDataStore) ssys.PersistentDataStore {
+	return &faultInjectingPersistentDataStore{core: core, faults: f}
+}
+
+// DataStore returns a data store configuration that builds a store with the specified factory and then
+// wraps it as described for [FaultInjector.Wrap]. Pass it to
+// [github.com/launchdarkly/go-server-sdk/v6/ldcomponents.PersistentDataStore] to use it in an SDK client.
+func (f *FaultInjector) DataStore(
+	factory ssys.ComponentConfigurer[ssys.PersistentDataStore],
+) ssys.ComponentConfigurer[ssys.PersistentDataStore] {
+	return faultInjectingDataStoreConfigurer{factory: factory, faults: f}
+}
+
+// beforeOperation waits for the configured latency, if any, and then returns an error if the operation
+// should fail. Random errors only apply to operations that can return an error.
+func (f *FaultInjector) beforeOperation(canFailRandomly bool) error {
+	f.lock.Lock()
+	latency := f.latency
+	var err error
+	if !f.available {
+		err = ErrInjectedOutage
+	} else if canFailRandomly && f.errorRate > 0 && f.random.Float64() < f.errorRate {
+		err = ErrInjectedFault
+	}
+	if err != nil {
+		f.injectedErrorCount++
+	}
+	f.lock.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	return err
+}
+
+func (f *FaultInjector) beforeInit() (partialInitItems int) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.initCount++
+	if f.partialInitItems >= 0 {
+		f.injectedErrorCount++
+	}
+	return f.partialInitItems
+}
+
+func (f *FaultInjector) isAvailable() bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.available
+}
+
+func (c faultInjectingDataStoreConfigurer) Build(context ssys.ClientContext) (ssys.PersistentDataStore, error) {
+	core, err := c.factory.Build(context)
+	if err != nil {
+		return nil, err
+	}
+	return c.faults.Wrap(core), nil
+}
+
+func (s *faultInjectingPersistentDataStore) Init(allData []st.SerializedCollection) error {
+	if err := s.faults.beforeOperation(true); err != nil {
+		return err
+	}
+	maxItems := s.faults.beforeInit()
+	if maxItems < 0 {
+		return s.core.Init(allData)
+	}
+	partialData := make([]st.SerializedCollection, 0, len(allData))
+	for _, coll := range allData {
+		items := coll.Items
+		if len(items) > maxItems {
+			items = items[:maxItems]
+		}
+		maxItems -= len(items)
+		partialData = append(partialData, st.SerializedCollection{Kind: coll.Kind, Items: items})
+	}
+	_ = s.core.Init(partialData)
+	return ErrInjectedPartialInit
+}
+
+func (s *faultInjectingPersistentDataStore) Get(kind st.DataKind, key string) (st.SerializedItemDescriptor, error) {
+	if err := s.faults.beforeOperation(true); err != nil {
+		return st.SerializedItemDescriptor{}.NotFound(), err
+	}
+	return s.core.Get(kind, key)
+}
+
+func (s *faultInjectingPersistentDataStore) GetAll(kind st.DataKind) ([]st.KeyedSerializedItemDescriptor, error) {
+	if err := s.faults.beforeOperation(true); err != nil {
+		return nil, err
+	}
+	return s.core.GetAll(kind)
+}
+
+func (s *faultInjectingPersistentDataStore) Upsert(
+	kind st.DataKind,
+	key string,
+	item st.SerializedItemDescriptor,
+) (bool, error) {
+	if err := s.faults.beforeOperation(true); err != nil {
+		return false, err
+	}
+	return s.core.Upsert(kind, key, item)
+}
+
+func (s *faultInjectingPersistentDataStore) IsInitialized() bool {
+	if err := s.faults.beforeOperation(false); err != nil {
+		return false
+	}
+	return s.core.IsInitialized()
+}
+
+func (s *faultInjectingPersistentDataStore) IsStoreAvailable() bool {
+	return s.faults.isAvailable() && s.core.IsStoreAvailable()
+}
+
+func (s *faultInjectingPersistentDataStore) Close() error {
+	return s.core.Close()
+}
@@ -0,0 +1,184 @@
+package storetest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	faultTestItem1 = mocks.MockDataItem{Key: "item1", Version: 1}
+	faultTestItem2 = mocks.MockDataItem{Key: "item2", Version: 1}
+	faultTestItem3 = mocks.MockDataItem{Key: "item3", Version: 1, IsOtherKind: true}
+)
+
+func makeFaultInjectorTestStore() (*FaultInjector, *mocks.MockPersistentDataStore, subsystems.PersistentDataStore) {
+	faults := NewFaultInjector()
+	core := mocks.NewMockPersistentDataStore()
+	return faults, core, faults.Wrap(core)
+}
+
+func TestFaultInjectorPassesOperationsThroughByDefault(t *testing.T) {
+	faults, core, store := makeFaultInjectorTestStore()
+
+	require.NoError(t, store.Init(mocks.MakeSerializedMockDataSet(faultTestItem1)))
+	assert.True(t, store.IsInitialized())
+	assert.True(t, store.IsStoreAvailable())
+
+	updated, err := store.Upsert(mocks.MockData, faultTestItem2.Key, faultTestItem2.ToSerializedItemDescriptor())
+	require.NoError(t, err)
+	assert.True(t, updated)
+	assert.Equal(t, faultTestItem2.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, faultTestItem2.Key))
+
+	item, err := store.Get(mocks.MockData, faultTestItem1.Key)
+	require.NoError(t, err)
+	assert.Equal(t, faultTestItem1.ToSerializedItemDescriptor(), item)
+
+	items, err := store.GetAll(mocks.MockData)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	assert.Equal(t, 1, faults.InitCount())
+	assert.Equal(t, 0, faults.InjectedErrorCount())
+	assert.NoError(t, store.Close())
+}
+
+func TestFaultInjectorOutage(t *testing.T) {
+	faults, core, store := makeFaultInjectorTestStore()
+	require.NoError(t, store.Init(mocks.MakeSerializedMockDataSet(faultTestItem1)))
+
+	faults.SetAvailable(false)
+	assert.False(t, store.IsStoreAvailable())
+	assert.False(t, store.IsInitialized())
+	assert.Equal(t, ErrInjectedOutage, store.Init(mocks.MakeSerializedMockDataSet()))
+	_, err := store.Get(mocks.MockData, faultTestItem1.Key)
+	assert.Equal(t, ErrInjectedOutage, err)
+	_, err = store.GetAll(mocks.MockData)
+	assert.Equal(t, ErrInjectedOutage, err)
+	_, err = store.Upsert(mocks.MockData, faultTestItem2.Key, faultTestItem2.ToSerializedItemDescriptor())
+	assert.Equal(t, ErrInjectedOutage, err)
+	assert.Equal(t, 5, faults.InjectedErrorCount())
+	assert.Equal(t, 1, faults.InitCount())
+	assert.Equal(t, faultTestItem1.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, faultTestItem1.Key))
+
+	faults.SetAvailable(true)
+	assert.True(t, store.IsStoreAvailable())
+	assert.True(t, store.IsInitialized())
+}
+
+func TestFaultInjectorIsUnavailableIfUnderlyingStoreIsUnavailable(t *testing.T) {
+	_, core, store := makeFaultInjectorTestStore()
+	core.SetAvailable(false)
+	assert.False(t, store.IsStoreAvailable())
+}
+
+func TestFaultInjectorErrorRate(t *testing.T) {
+	t.Run("all operations fail at rate 1", func(t *testing.T) {
+		faults, _, store := makeFaultInjectorTestStore()
+		faults.SetErrorRate(1)
+		for i := 0; i < 10; i++ {
+			_, err := store.Get(mocks.MockData, faultTestItem1.Key)
+			assert.Equal(t, ErrInjectedFault, err)
+		}
+		assert.Equal(t, 10, faults.InjectedErrorCount())
+		assert.True(t, store.IsStoreAvailable())
+	})
+
+	t.Run("some operations fail at rate 0.5", func(t *testing.T) {
+		faults, _, store := makeFaultInjectorTestStore()
+		faults.SetErrorRate(0.5)
+		for i := 0; i < 100; i++ {
+			_, _ = store.Get(mocks.MockData, faultTestItem1.Key)
+		}
+		assert.Greater(t, faults.InjectedErrorCount(), 20)
+		assert.Less(t, faults.InjectedErrorCount(), 80)
+	})
+
+	t.Run("same seed produces same errors", func(t *testing.T) {
+		getErrors := func() []bool {
+			faults, _, store := makeFaultInjectorTestStore()
+			faults.SetErrorRate(0.5).Seed(42)
+			var result []bool
+			for i := 0; i < 20; i++ {
+				_, err := store.Get(mocks.MockData, faultTestItem1.Key)
+				result = append(result, err != nil)
+			}
+			return result
+		}
+		assert.Equal(t, getErrors(), getErrors())
+	})
+}
+
+func TestFaultInjectorLatency(t *testing.T) {
+	faults, _, store := makeFaultInjectorTestStore()
+	latency := time.Millisecond * 50
+	faults.SetLatency(latency)
+
+	start := time.Now()
+	_, err := store.Get(mocks.MockData, faultTestItem1.Key)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(latency))
+
+	start = time.Now()
+	assert.True(t, store.IsStoreAvailable())
+	assert.Less(t, int64(time.Since(start)), int64(latency))
+}
+
+func TestFaultInjectorPartialInit(t *testing.T) {
+	faults, core, store := makeFaultInjectorTestStore()
+	faults.SetPartialInit(2)
+
+	err := store.Init(mocks.MakeSerializedMockDataSet(faultTestItem1, faultTestItem2, faultTestItem3))
+	assert.Equal(t, ErrInjectedPartialInit, err)
+	assert.Equal(t, 1, faults.InitCount())
+	assert.Equal(t, 1, faults.InjectedErrorCount())
+	assert.Equal(t, faultTestItem1.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, faultTestItem1.Key))
+	assert.Equal(t, faultTestItem2.ToSerializedItemDescriptor(), core.ForceGet(mocks.MockData, faultTestItem2.Key))
+	assert.Nil(t, core.ForceGet(mocks.MockOtherData, faultTestItem3.Key).SerializedItem)
+
+	faults.SetPartialInit(-1)
+	require.NoError(t, store.Init(mocks.MakeSerializedMockDataSet(faultTestItem1, faultTestItem2, faultTestItem3)))
+	assert.Equal(t, 2, faults.InitCount())
+	assert.NotNil(t, core.ForceGet(mocks.MockOtherData, faultTestItem3.Key).SerializedItem)
+}
+
+func TestFaultInjectorReset(t *testing.T) {
+	faults, _, store := makeFaultInjectorTestStore()
+	faults.SetAvailable(false).SetErrorRate(1).SetLatency(time.Hour).SetPartialInit(0)
+
+	faults.Reset()
+	require.NoError(t, store.Init(mocks.MakeSerializedMockDataSet(faultTestItem1)))
+	assert.True(t, store.IsStoreAvailable())
+	_, err := store.Get(mocks.MockData, faultTestItem1.Key)
+	assert.NoError(t, err)
+}
+
+func TestFaultInjectorDataStoreConfigurer(t *testing.T) {
+	testhelpers.WithMockLoggingContext(t, func(context subsystems.ClientContext) {
+		faults := NewFaultInjector()
+		db := mocks.NewMockDatabaseInstance()
+		store, err := faults.DataStore(mockStoreFactory{db: db}).Build(context)
+		require.NoError(t, err)
+
+		faults.SetAvailable(false)
+		assert.False(t, store.IsStoreAvailable())
+		assert.Equal(t, ErrInjectedOutage, store.Init(mocks.MakeSerializedMockDataSet()))
+	})
+
+	t.Run("error from underlying factory", func(t *testing.T) {
+		testhelpers.WithMockLoggingContext(t, func(context subsystems.ClientContext) {
+			fakeError := errors.New("sorry")
+			factory := mocks.ComponentConfigurerThatReturnsError[subsystems.PersistentDataStore]{Err: fakeError}
+			store, err := NewFaultInjector().DataStore(factory).Build(context)
+			assert.Equal(t, fakeError, err)
+			assert.Nil(t, store)
+		})
+	})
+}
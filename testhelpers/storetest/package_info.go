@@ -3,5 +3,9 @@
 // If you are writing your own database integration, use this test suite to ensure that it is being
 // fully tested in the same way that all of the built-in ones are tested.
 //
+// It also contains FaultInjector, which simulates slow or failing database operations, and
+// PersistentDataStoreChaosTestSuite, which uses FaultInjector to verify how the SDK behaves when a
+// persistent data store has an outage.
+//
 // Due to its dependencies, this package can only be used when building with module support.
 package storetest
@@ -0,0 +1,377 @@
+package storetest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/launchdarkly/go-sdk-common/v3/ldcontext"
+	"github.com/launchdarkly/go-sdk-common/v3/ldlogtest"
+	"github.com/launchdarkly/go-sdk-common/v3/ldvalue"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldbuilders"
+	"github.com/launchdarkly/go-server-sdk-evaluation/v2/ldmodel"
+	ld "github.com/launchdarkly/go-server-sdk/v6"
+	"github.com/launchdarkly/go-server-sdk/v6/interfaces"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/datakinds"
+	sh "github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest"
+	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
+	ssys "github.com/launchdarkly/go-server-sdk/v6/subsystems"
+	st "github.com/launchdarkly/go-server-sdk/v6/subsystems/ldstoretypes"
+	"github.com/launchdarkly/go-server-sdk/v6/testhelpers"
+
+	th "github.com/launchdarkly/go-test-helpers/v3"
+	"github.com/launchdarkly/go-test-helpers/v3/testbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	chaosTestPrefix     = "chaos"
+	chaosTestFlagKey    = "flagkey"
+	chaosTestSegmentKey = "segmentkey"
+
+	// The SDK checks whether the store has recovered from an outage every 500 milliseconds.
+	chaosTestRecoveryTimeout = 3 * time.Second
+)
+
+// PersistentDataStoreChaosTestSuite runs an SDK client with a persistent data store whose operations are
+// made to fail in various ways by a [FaultInjector], and verifies that the SDK reports the data store
+// status correctly, serves cached data as expected during an outage, and brings the store back up to date
+// when it recovers.
+//
+// Unlike [PersistentDataStoreTestSuite], this mostly tests the SDK's own handling of persistent data stores
+// rather than the store implementation, but running it with a specific implementation verifies that the
+// implementation works correctly with that handling, for instance when Init is called again after an
+// outage. It takes several seconds to run, because it waits for the SDK to detect recovery.
+type PersistentDataStoreChaosTestSuite struct {
+	storeFactoryFn func(string) ssys.ComponentConfigurer[ssys.PersistentDataStore]
+	clearDataFn    func(string) error
+}
+
+// NewPersistentDataStoreChaosTestSuite creates a PersistentDataStoreChaosTestSuite for testing some
+// implementation of PersistentDataStore. The parameters have the same meaning as for
+// [NewPersistentDataStoreTestSuite].
+func NewPersistentDataStoreChaosTestSuite(
+	storeFactoryFn func(prefix string) ssys.ComponentConfigurer[ssys.PersistentDataStore],
+	clearDataFn func(prefix string) error,
+) *PersistentDataStoreChaosTestSuite {
+	return &PersistentDataStoreChaosTestSuite{
+		storeFactoryFn: storeFactoryFn,
+		clearDataFn:    clearDataFn,
+	}
+}
+
+// Run runs the configured test suite.
+func (s *PersistentDataStoreChaosTestSuite) Run(t *testing.T) {
+	s.runInternal(testbox.RealTest(t))
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runInternal(t testbox.TestingT) {
+	t.Run("outage and recovery are reported", s.runOutageStatusTests)
+	t.Run("cache forever", s.runCacheForeverTests)
+	t.Run("cache with TTL", s.runCacheTTLTests)
+	t.Run("no caching", s.runNoCachingTests)
+	t.Run("write-behind journal", s.runJournalTests)
+	t.Run("partial Init", s.runPartialInitTests)
+	t.Run("intermittent errors", s.runIntermittentErrorTests)
+	t.Run("latency", s.runLatencyTests)
+}
+
+// chaosTestEnv is the state of one SDK client that is used in the chaos tests.
+type chaosTestEnv struct {
+	t        testbox.TestingT
+	suite    *PersistentDataStoreChaosTestSuite
+	client   *ld.LDClient
+	faults   *FaultInjector
+	updates  ssys.DataSourceUpdateSink
+	statusCh <-chan interfaces.DataStoreStatus
+}
+
+func makeChaosTestFlag(version int, value string) ldmodel.FeatureFlag {
+	return ldbuilders.NewFlagBuilder(chaosTestFlagKey).Version(version).
+		On(true).Variations(ldvalue.String(value)).FallthroughVariation(0).
+		Build()
+}
+
+func makeChaosTestSegment(version int) ldmodel.Segment {
+	return ldbuilders.NewSegmentBuilder(chaosTestSegmentKey).Version(version).Included("a").Build()
+}
+
+// withChaosTestClient starts an SDK client whose data source provides version 1 of the test flag and
+// segment, and whose data store is the one being tested, wrapped in a FaultInjector. The configure
+// function sets the caching options; the faults function, if any, sets up faults before the client starts.
+func (s *PersistentDataStoreChaosTestSuite) withChaosTestClient(
+	t testbox.TestingT,
+	configure func(*ldcomponents.PersistentDataStoreBuilder),
+	setUpFaults func(*FaultInjector),
+	action func(*chaosTestEnv),
+) {
+	require.NoError(t, s.clearDataFn(chaosTestPrefix))
+	faults := NewFaultInjector()
+	if setUpFaults != nil {
+		setUpFaults(faults)
+	}
+	storeBuilder := ldcomponents.PersistentDataStore(faults.DataStore(s.storeFactoryFn(chaosTestPrefix)))
+	configure(storeBuilder)
+	data := []st.Collection{
+		{Kind: datakinds.Features, Items: []st.KeyedItemDescriptor{
+			{Key: chaosTestFlagKey, Item: sh.FlagDescriptor(makeChaosTestFlag(1, "value1"))},
+		}},
+		{Kind: datakinds.Segments, Items: []st.KeyedItemDescriptor{
+			{Key: chaosTestSegmentKey, Item: sh.SegmentDescriptor(makeChaosTestSegment(1))},
+		}},
+	}
+	dataSourceConfigurer := &mocks.ComponentConfigurerThatCapturesClientContext[ssys.DataSource]{
+		Configurer: &mocks.DataSourceFactoryWithData{Data: data},
+	}
+	mockLog := ldlogtest.NewMockLog()
+	config := ld.Config{
+		DataStore:  storeBuilder,
+		DataSource: dataSourceConfigurer,
+		Events:     ldcomponents.NoEvents(),
+		Logging:    ldcomponents.Logging().Loggers(mockLog.Loggers),
+	}
+	client, err := ld.MakeCustomClient("sdk-key", config, 5*time.Second)
+	require.NoError(t, err)
+	defer client.Close() //nolint:errcheck
+
+	env := &chaosTestEnv{
+		t:        t,
+		suite:    s,
+		client:   client,
+		faults:   faults,
+		updates:  dataSourceConfigurer.ReceivedClientContext.GetDataSourceUpdateSink(),
+		statusCh: client.GetDataStoreStatusProvider().AddStatusListener(),
+	}
+	action(env)
+	if t.Failed() {
+		for _, line := range mockLog.GetAllOutput() {
+			t.Errorf("log: %s: %s", line.Level, line.Message)
+		}
+	}
+}
+
+func (e *chaosTestEnv) flagValue() ldvalue.Value {
+	value, _ := e.client.JSONVariation(chaosTestFlagKey, ldcontext.New("a"), ldvalue.String("default"))
+	return value
+}
+
+func (e *chaosTestEnv) upsertFlag(version int, value string) {
+	e.updates.Upsert(datakinds.Features, chaosTestFlagKey, sh.FlagDescriptor(makeChaosTestFlag(version, value)))
+}
+
+// requireStatus waits for a status update with the expected availability and verifies that it has the
+// expected NeedsRefresh value. Updates that only change the journal depth are skipped.
+func (e *chaosTestEnv) requireStatus(available, needsRefresh bool) interfaces.DataStoreStatus {
+	deadline := time.Now().Add(chaosTestRecoveryTimeout)
+	var status interfaces.DataStoreStatus
+	for {
+		status = th.RequireValue(e.t, e.statusCh, time.Until(deadline), "timed out waiting for data store status")
+		if status.Available == available {
+			break
+		}
+	}
+	assert.Equal(e.t, needsRefresh, status.NeedsRefresh, "NeedsRefresh")
+	return status
+}
+
+// storedFlagVersion reads the test flag directly from the underlying store, without the FaultInjector
+// or the SDK's cache. It returns -1 if the flag is not in the store.
+func (e *chaosTestEnv) storedFlagVersion() int {
+	return e.storedItemVersion(datakinds.Features, chaosTestFlagKey)
+}
+
+func (e *chaosTestEnv) storedSegmentVersion() int {
+	return e.storedItemVersion(datakinds.Segments, chaosTestSegmentKey)
+}
+
+func (e *chaosTestEnv) storedItemVersion(kind st.DataKind, key string) int {
+	version := -1
+	testhelpers.WithMockLoggingContext(e.t, func(context ssys.ClientContext) {
+		store, err := e.suite.storeFactoryFn(chaosTestPrefix).Build(context)
+		require.NoError(e.t, err)
+		defer store.Close() //nolint:errcheck
+		item, err := store.Get(kind, key)
+		require.NoError(e.t, err)
+		if item.SerializedItem != nil {
+			// The store may not report the version separately, so we get it from the item
+			parsed, err := kind.Deserialize(item.SerializedItem)
+			require.NoError(e.t, err)
+			version = parsed.Version
+		}
+	})
+	return version
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runOutageStatusTests(t testbox.TestingT) {
+	s.withChaosTestClient(t, func(b *ldcomponents.PersistentDataStoreBuilder) {}, nil, func(e *chaosTestEnv) {
+		assert.True(t, e.client.GetDataStoreStatusProvider().GetStatus().Available)
+
+		e.faults.SetAvailable(false)
+		e.upsertFlag(2, "value2")
+		e.requireStatus(false, false)
+		assert.False(t, e.client.GetDataStoreStatusProvider().GetStatus().Available)
+
+		e.faults.SetAvailable(true)
+		e.requireStatus(true, true) // the update was lost, so the data source should refresh
+		assert.True(t, e.client.GetDataStoreStatusProvider().GetStatus().Available)
+	})
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runCacheForeverTests(t testbox.TestingT) {
+	cacheForever := func(b *ldcomponents.PersistentDataStoreBuilder) { b.CacheForever() }
+
+	t.Run("cached data and updates are used during outage", func(t testbox.TestingT) {
+		s.withChaosTestClient(t, cacheForever, nil, func(e *chaosTestEnv) {
+			e.faults.SetAvailable(false)
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+
+			e.upsertFlag(2, "value2")
+			e.requireStatus(false, false)
+			assert.Equal(t, ldvalue.String("value2"), e.flagValue())
+		})
+	})
+
+	t.Run("store is reinitialized from cache after outage", func(t testbox.TestingT) {
+		s.withChaosTestClient(t, cacheForever, nil, func(e *chaosTestEnv) {
+			assert.Equal(t, 1, e.faults.InitCount())
+
+			e.faults.SetAvailable(false)
+			e.upsertFlag(2, "value2")
+			e.requireStatus(false, false)
+			assert.Equal(t, 1, e.storedFlagVersion())
+
+			e.faults.SetAvailable(true)
+			e.requireStatus(true, false) // the cache had all of the data, so no refresh is needed
+			assert.Equal(t, 2, e.faults.InitCount())
+			assert.Equal(t, 2, e.storedFlagVersion())
+			assert.Equal(t, 1, e.storedSegmentVersion())
+			assert.Equal(t, ldvalue.String("value2"), e.flagValue())
+		})
+	})
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runCacheTTLTests(t testbox.TestingT) {
+	cacheWithTTL := func(b *ldcomponents.PersistentDataStoreBuilder) { b.CacheTime(time.Minute) }
+
+	t.Run("cached data is used during outage without querying store", func(t testbox.TestingT) {
+		s.withChaosTestClient(t, cacheWithTTL, nil, func(e *chaosTestEnv) {
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+
+			e.faults.SetAvailable(false)
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+			assert.Equal(t, 0, e.faults.InjectedErrorCount())
+			assert.True(t, e.client.GetDataStoreStatusProvider().GetStatus().Available)
+		})
+	})
+
+	t.Run("failed update is not cached", func(t testbox.TestingT) {
+		s.withChaosTestClient(t, cacheWithTTL, nil, func(e *chaosTestEnv) {
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+
+			e.faults.SetAvailable(false)
+			e.upsertFlag(2, "value2")
+			e.requireStatus(false, false)
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+
+			e.faults.SetAvailable(true)
+			e.requireStatus(true, true)
+			assert.Equal(t, 1, e.faults.InitCount()) // we can't reinitialize the store without a full data set
+			assert.Equal(t, 1, e.storedFlagVersion())
+		})
+	})
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runNoCachingTests(t testbox.TestingT) {
+	s.withChaosTestClient(t, func(b *ldcomponents.PersistentDataStoreBuilder) { b.NoCaching() }, nil,
+		func(e *chaosTestEnv) {
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+
+			e.faults.SetAvailable(false)
+			assert.Equal(t, ldvalue.String("default"), e.flagValue())
+			e.requireStatus(false, false)
+
+			e.faults.SetAvailable(true)
+			e.requireStatus(true, true)
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+		})
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runJournalTests(t testbox.TestingT) {
+	withJournal := func(b *ldcomponents.PersistentDataStoreBuilder) {
+		b.CacheTime(time.Minute).WriteBehindJournal(100)
+	}
+	s.withChaosTestClient(t, withJournal, nil, func(e *chaosTestEnv) {
+		e.faults.SetAvailable(false)
+		e.upsertFlag(2, "value2")
+		e.requireStatus(false, false)
+		assert.Eventually(t, func() bool {
+			return e.client.GetDataStoreStatusProvider().GetStatus().JournalDepth == 1
+		}, time.Second, time.Millisecond*10)
+
+		e.faults.SetAvailable(true)
+		e.requireStatus(true, false) // the journal had all of the updates, so no refresh is needed
+		assert.Equal(t, 0, e.client.GetDataStoreStatusProvider().GetStatus().JournalDepth)
+		assert.Equal(t, 2, e.storedFlagVersion())
+		assert.Equal(t, ldvalue.String("value2"), e.flagValue())
+	})
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runPartialInitTests(t testbox.TestingT) {
+	cacheForever := func(b *ldcomponents.PersistentDataStoreBuilder) { b.CacheForever() }
+	partialInit := func(f *FaultInjector) { f.SetPartialInit(1) }
+	s.withChaosTestClient(t, cacheForever, partialInit, func(e *chaosTestEnv) {
+		// The SDK writes segments before flags, so only the segment was written
+		assert.False(t, e.client.GetDataStoreStatusProvider().GetStatus().Available)
+		assert.Equal(t, 1, e.storedSegmentVersion())
+		assert.Equal(t, -1, e.storedFlagVersion())
+		assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+
+		e.faults.SetPartialInit(-1)
+		e.requireStatus(true, false)
+		assert.Equal(t, 2, e.faults.InitCount())
+		assert.Equal(t, 1, e.storedSegmentVersion())
+		assert.Equal(t, 1, e.storedFlagVersion())
+	})
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runIntermittentErrorTests(t testbox.TestingT) {
+	cacheForever := func(b *ldcomponents.PersistentDataStoreBuilder) { b.CacheForever() }
+	s.withChaosTestClient(t, cacheForever, nil, func(e *chaosTestEnv) {
+		e.faults.SetErrorRate(0.5)
+		lastVersion := 1
+		for version := 2; version <= 20; version++ {
+			e.upsertFlag(version, "value")
+			lastVersion = version
+			assert.Equal(t, ldvalue.String("value"), e.flagValue())
+		}
+		assert.Greater(t, e.faults.InjectedErrorCount(), 0)
+
+		e.faults.SetErrorRate(0)
+		assert.Eventually(t, func() bool {
+			return e.client.GetDataStoreStatusProvider().GetStatus().Available
+		}, chaosTestRecoveryTimeout, time.Millisecond*50)
+		assert.Equal(t, lastVersion, e.storedFlagVersion())
+	})
+}
+
+func (s *PersistentDataStoreChaosTestSuite) runLatencyTests(t testbox.TestingT) {
+	latency := time.Millisecond * 100
+	cacheForever := func(b *ldcomponents.PersistentDataStoreBuilder) { b.CacheForever() }
+	s.withChaosTestClient(t, cacheForever, nil, func(e *chaosTestEnv) {
+		e.faults.SetLatency(latency)
+
+		start := time.Now()
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, ldvalue.String("value1"), e.flagValue())
+		}
+		assert.Less(t, int64(time.Since(start)), int64(latency), "evaluations should not have waited for the store")
+
+		start = time.Now()
+		e.upsertFlag(2, "value2")
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(latency), "update should have waited for the store")
+		assert.Equal(t, ldvalue.String("value2"), e.flagValue())
+		assert.True(t, e.client.GetDataStoreStatusProvider().GetStatus().Available)
+	})
+}
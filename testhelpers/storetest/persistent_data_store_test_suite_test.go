@@ -6,6 +6,7 @@ import (
 
 	"github.com/launchdarkly/go-server-sdk/v6/internal/sharedtest/mocks"
 
+	"github.com/launchdarkly/go-server-sdk/v6/ldcomponents"
 	"github.com/launchdarkly/go-server-sdk/v6/subsystems"
 	"github.com/launchdarkly/go-test-helpers/v3/testbox"
 
@@ -66,6 +67,19 @@ func TestPersistentDataStoreTestSuite(t *testing.T) {
 		runTests(t, true)
 	})
 
+	t.Run("with encryption", func(t *testing.T) {
+		NewPersistentDataStoreTestSuite(
+			func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+				return ldcomponents.EncryptedPersistentDataStore(mockStoreFactory{db, prefix, false, nil}).
+					PrimaryKey("key1", []byte("0123456789abcdef"))
+			},
+			func(prefix string) error {
+				db.Clear(prefix)
+				return nil
+			},
+		).Run(t)
+	})
+
 	t.Run("causing deliberate errors makes tests fail", func(t *testing.T) {
 		fakeError := errors.New("sorry")
 		s := baseSuite(false, fakeError)
@@ -113,3 +127,33 @@ func TestPersistentDataStoreTestSuite(t *testing.T) {
 		assert.True(t, r.Failed, "test should have failed")
 	})
 }
+
+func TestPersistentDataStoreChaosTestSuite(t *testing.T) {
+	db := mocks.NewMockDatabaseInstance()
+
+	baseSuite := func(persistOnlyAsString bool, fakeError error) *PersistentDataStoreChaosTestSuite {
+		return NewPersistentDataStoreChaosTestSuite(
+			func(prefix string) subsystems.ComponentConfigurer[subsystems.PersistentDataStore] {
+				return mockStoreFactory{db, prefix, persistOnlyAsString, fakeError}
+			},
+			func(prefix string) error {
+				db.Clear(prefix)
+				return nil
+			},
+		)
+	}
+
+	t.Run("with metadata stored separately from serialized item", func(t *testing.T) {
+		baseSuite(false, nil).Run(t)
+	})
+
+	t.Run("with metadata stored only in serialized item", func(t *testing.T) {
+		baseSuite(true, nil).Run(t)
+	})
+
+	t.Run("causing deliberate errors makes tests fail", func(t *testing.T) {
+		s := baseSuite(false, errors.New("sorry"))
+		r := testbox.SandboxTest(s.runInternal)
+		assert.True(t, r.Failed, "test should have failed")
+	})
+}